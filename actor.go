@@ -0,0 +1,31 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// actorContextKey is the context key under which the actor triggering a migration is stored
+type actorContextKey struct{}
+
+// ContextWithInitiatedBy returns a context recording migrations run with it as initiated by
+// actor, such as a user email, a service account or the URL of a CI job
+func ContextWithInitiatedBy(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// InitiatedByFromContext returns the actor set with ContextWithInitiatedBy
+func InitiatedByFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	return actor, ok && actor != ""
+}
+
+// initiatedBy returns the actor a statement on db runs on behalf of, the one of the context
+// before the configured one
+func (p *AutoMigratePlugin) initiatedBy(db *gorm.DB) string {
+	if actor, ok := InitiatedByFromContext(contextFrom(db)); ok {
+		return actor
+	}
+	return p.InitiatedBy
+}
@@ -0,0 +1,31 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInitiatedBy(t *testing.T) {
+	db, _ := openTestDB(t, WithInitiatedBy("deploy-bot"))
+	migrateAs(t, db, "1", &pluginUser{})
+	ctx := ContextWithInitiatedBy(ContextWithVersion(context.Background(), "2"), "jane@example.com")
+	if err := db.WithContext(ctx).AutoMigrate(&pluginUserWithEmail{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	if actor := mustRecorded(t, db, "1").InitiatedBy; actor != "deploy-bot" {
+		t.Errorf("version 1 initiated by %q, want the configured deploy-bot", actor)
+	}
+	// The context takes precedence over the configured actor
+	if actor := mustRecorded(t, db, "2").InitiatedBy; actor != "jane@example.com" {
+		t.Errorf("version 2 initiated by %q, want jane@example.com from the context", actor)
+	}
+
+	page, err := QueryHistory(db, HistoryFilter{InitiatedBy: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("QueryHistory: %v", err)
+	}
+	if page.Total != 1 || page.Versions[0].Version != "2" {
+		t.Errorf("versions initiated by jane@example.com = %v, want version 2", page.Versions)
+	}
+}
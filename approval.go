@@ -0,0 +1,132 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrApprovalRequired is returned by AutoMigrate when RequireApproval is set and the planned
+// changes haven't been approved yet
+var ErrApprovalRequired = errors.New("migration requires approval")
+
+// checkApproval returns the approved record of the plan of AutoMigrate. Unapproved plans are
+// recorded awaiting approval and fail with ErrApprovalRequired.
+func (p *AutoMigratePlugin) checkApproval(db *gorm.DB, plan *Plan, models []interface{}) (*SchemaVersion, error) {
+	if plan.Empty() {
+		return nil, nil
+	}
+
+	checksum, err := modelsChecksum(db, models)
+	if err != nil {
+		return nil, err
+	}
+	planned := make([]string, len(plan.Statements))
+	for i, statement := range plan.Statements {
+		planned[i] = statement.SQL
+	}
+	statements := joinStatements(planned)
+
+	candidates, stored, err := p.storedHistory(db)
+	if stored {
+		candidates = filterVersions(candidates, func(schemaVersion *SchemaVersion) bool {
+			planned := schemaVersion.Status == StatusAwaitingApproval || schemaVersion.Status == StatusApproved
+			return planned && schemaVersion.Checksum == checksum
+		})
+		slices.Reverse(candidates)
+	} else {
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).
+			Where("status IN ? AND checksum = ?", []string{StatusAwaitingApproval, StatusApproved}, checksum).
+			Order("id desc").Find(&candidates).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve planned schema versions: %v", err)
+		return nil, fmt.Errorf("failed to retrieve planned schema versions: %w", err)
+	}
+
+	for i, candidate := range candidates {
+		if candidate.Statements != statements {
+			continue
+		}
+		if candidate.Status == StatusApproved {
+			p.Logger.Info("Applying approved schema version %s", candidate.Version)
+			return &candidates[i], nil
+		}
+		p.Logger.Warn("Schema version %s is awaiting approval", candidate.Version)
+		return nil, fmt.Errorf("%w: approve version %s", ErrApprovalRequired, candidate.Version)
+	}
+
+	// The plan changed since the recorded ones, they can no longer be applied
+	for i := range candidates {
+		p.Logger.Info("Discarding outdated plan %s", candidates[i].Version)
+		if err := p.deleteVersions(db, candidates[i].ID); err != nil {
+			p.Logger.Error("Failed to discard outdated plan %s: %v", candidates[i].Version, err)
+			return nil, fmt.Errorf("failed to discard outdated plan %s: %w", candidates[i].Version, err)
+		}
+	}
+
+	schemaVersion, err := p.plannedVersion(db, plan, checksum, statements)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.record(db, schemaVersion); err != nil {
+		return nil, err
+	}
+	p.Logger.Warn("Recorded schema version %s awaiting approval", schemaVersion.Version)
+	return nil, fmt.Errorf("%w: approve version %s", ErrApprovalRequired, schemaVersion.Version)
+}
+
+// plannedVersion builds the record of a plan awaiting approval
+func (p *AutoMigratePlugin) plannedVersion(db *gorm.DB, plan *Plan, checksum, statements string) (*SchemaVersion, error) {
+	startTime := p.now()
+	version, err := p.generateVersion(withPlan(db, plan), startTime)
+	if err != nil {
+		p.Logger.Error("Failed to generate version: %v", err)
+		return nil, fmt.Errorf("failed to generate version: %w", err)
+	}
+
+	changes, err := encodeChangeSet(&ChangeSet{
+		Destructive: plan.Destructive,
+		Notes:       []string{fmt.Sprintf("Planned %d statements affecting %s", len(plan.Statements), strings.Join(plan.Tables, ", "))},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaVersion{
+		Version:        version,
+		Kind:           KindMigration,
+		Status:         StatusAwaitingApproval,
+		AppliedAt:      startTime,
+		Changes:        changes,
+		Statements:     statements,
+		DownStatements: joinStatements(plan.Down),
+		Checksum:       checksum,
+		Severity:       plan.Severity,
+	}, nil
+}
+
+// Approve approves the planned changes recorded under version, the next AutoMigrate of the
+// same models applies them
+func Approve(db *gorm.DB, version string) error {
+	logger := loggerFrom(db)
+	logger.Debug("Approve function called for %s", version)
+
+	schemaVersion, err := findVersion(db, version)
+	if err != nil {
+		return err
+	}
+	if schemaVersion.Status != StatusAwaitingApproval {
+		return fmt.Errorf("schema version %s is %s, not awaiting approval", version, schemaVersion.Status)
+	}
+	schemaVersion.Status = StatusApproved
+	registeredPlugin(db).sign(schemaVersion)
+	if err := registeredPlugin(db).updateVersion(db, schemaVersion, "status", "signature"); err != nil {
+		logger.Error("Failed to approve schema version %s: %v", version, err)
+		return fmt.Errorf("failed to approve schema version %s: %w", version, err)
+	}
+	logger.Info("Approved schema version %s", version)
+	return nil
+}
@@ -0,0 +1,86 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApproval(t *testing.T) {
+	db, _ := openTestDB(t, WithApproval(true), WithVersionGenerator(NewSequenceVersionGenerator("V")), WithSigningKey(testSigningKey))
+
+	// The plan is recorded awaiting approval instead of being applied
+	for range 2 {
+		if err := db.AutoMigrate(&pluginUser{}); !errors.Is(err, ErrApprovalRequired) {
+			t.Fatalf("AutoMigrate of an unapproved plan = %v, want ErrApprovalRequired", err)
+		}
+	}
+	if db.Migrator().HasTable(&pluginUser{}) {
+		t.Fatal("AutoMigrate applied an unapproved plan")
+	}
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Status != StatusAwaitingApproval {
+		t.Fatalf("history = %v, want the plan recorded once awaiting approval", history)
+	}
+	planned := history[0]
+	if !strings.Contains(planned.Statements, "CREATE TABLE `users`") {
+		t.Errorf("planned statements = %q, want the users table created", planned.Statements)
+	}
+
+	if err := Approve(db, planned.Version); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if err := Approve(db, planned.Version); err == nil {
+		t.Error("Approve of an approved version succeeded")
+	}
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate of an approved plan: %v", err)
+	}
+	if !db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("AutoMigrate didn't apply the approved plan")
+	}
+
+	// The approved record is completed rather than a new one recorded
+	history = mustHistory(t, db)
+	if len(history) != 1 || history[0].Version != planned.Version || history[0].Status != StatusSuccess {
+		t.Errorf("history = %v, want version %s completed", history, planned.Version)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory: %v", err)
+	}
+}
+
+func TestApprovalOutdatedPlan(t *testing.T) {
+	db, _ := openTestDB(t, WithApproval(true), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	if err := db.AutoMigrate(&pluginUser{}); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("AutoMigrate = %v, want ErrApprovalRequired", err)
+	}
+	if err := Approve(db, "V1"); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	// The schema changed since the plan was approved, it's replaced by a new plan
+	if err := db.Exec("CREATE TABLE `users` (`id` integer PRIMARY KEY)").Error; err != nil {
+		t.Fatalf("failed to create the users table: %v", err)
+	}
+	if err := db.AutoMigrate(&pluginUser{}); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("AutoMigrate of a changed schema = %v, want ErrApprovalRequired", err)
+	}
+	if db.Migrator().HasColumn(&pluginUser{}, "name") {
+		t.Error("AutoMigrate applied an outdated plan")
+	}
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Status != StatusAwaitingApproval {
+		t.Fatalf("history = %v, want only the new plan awaiting approval", history)
+	}
+	if !strings.Contains(history[0].Statements, "ADD `name`") {
+		t.Errorf("planned statements = %q, want the name column added", history[0].Statements)
+	}
+}
+
+func TestApproveUnknownVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	if err := Approve(db, "1"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Approve of an unknown version = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,128 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// atlasType matches the column types Atlas accepts unquoted, such as varchar(255)
+var atlasType = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\([0-9, ]*\))?$`)
+
+// ExportAtlasHCL writes the schema the given models define as Atlas HCL, to be fed into
+// atlas schema diff and atlas schema apply
+func ExportAtlasHCL(db *gorm.DB, w io.Writer, models ...interface{}) error {
+	logger := loggerFrom(db)
+	logger.Debug("ExportAtlasHCL function called")
+
+	snapshot, err := ModelsSnapshot(db, models...)
+	if err != nil {
+		logger.Error("Failed to build models snapshot: %v", err)
+		return err
+	}
+	if _, err := io.WriteString(w, snapshot.AtlasHCL(atlasSchemaName(db))); err != nil {
+		return fmt.Errorf("failed to write Atlas HCL: %w", err)
+	}
+	return nil
+}
+
+// ExportVersionAtlasHCL writes the schema recorded with a version as Atlas HCL, or the live
+// schema when version is empty
+func ExportVersionAtlasHCL(db *gorm.DB, w io.Writer, version string) error {
+	loggerFrom(db).Debug("ExportVersionAtlasHCL function called for version %q", version)
+
+	snapshot, err := versionSnapshot(db, version)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, snapshot.AtlasHCL(atlasSchemaName(db))); err != nil {
+		return fmt.Errorf("failed to write Atlas HCL: %w", err)
+	}
+	return nil
+}
+
+// AtlasHCL renders the snapshot as Atlas HCL, with every table in the named schema
+func (s *SchemaSnapshot) AtlasHCL(schema string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schema %q {\n}\n", schema)
+	for _, table := range s.Tables {
+		fmt.Fprintf(&b, "\ntable %q {\n", table.Name)
+		fmt.Fprintf(&b, "  schema = schema.%s\n", schema)
+
+		var primaryKey []string
+		for _, column := range table.Columns {
+			columnType, unsigned, autoIncrement := atlasColumnType(column.Type)
+			fmt.Fprintf(&b, "  column %q {\n", column.Name)
+			fmt.Fprintf(&b, "    null = %t\n", column.Nullable && !column.PrimaryKey)
+			fmt.Fprintf(&b, "    type = %s\n", columnType)
+			if unsigned {
+				b.WriteString("    unsigned = true\n")
+			}
+			if autoIncrement {
+				b.WriteString("    auto_increment = true\n")
+			}
+			b.WriteString("  }\n")
+			if column.PrimaryKey {
+				primaryKey = append(primaryKey, "column."+column.Name)
+			}
+		}
+		if len(primaryKey) > 0 {
+			b.WriteString("  primary_key {\n")
+			fmt.Fprintf(&b, "    columns = [%s]\n", strings.Join(primaryKey, ", "))
+			b.WriteString("  }\n")
+		}
+		for _, index := range table.Indexes {
+			columns := make([]string, len(index.Columns))
+			for i, column := range index.Columns {
+				columns[i] = "column." + column
+			}
+			fmt.Fprintf(&b, "  index %q {\n", index.Name)
+			if index.Unique {
+				b.WriteString("    unique  = true\n")
+			}
+			fmt.Fprintf(&b, "    columns = [%s]\n", strings.Join(columns, ", "))
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// atlasColumnType renders a column type as an Atlas type expression, falling back to
+// sql("...") for types Atlas doesn't accept unquoted. The column options GORM appends to
+// the type, such as PRIMARY KEY AUTOINCREMENT, are reported separately.
+func atlasColumnType(columnType string) (expression string, unsigned, autoIncrement bool) {
+	normalized := strings.ToLower(strings.TrimSpace(columnType))
+	for _, option := range []string{" primary key", " autoincrement", " auto_increment", " identity", " not null", " default "} {
+		if i := strings.Index(normalized, option); i >= 0 {
+			autoIncrement = autoIncrement || strings.Contains(normalized[i:], "increment") || strings.Contains(normalized[i:], "identity")
+			normalized = normalized[:i]
+		}
+	}
+	if strings.HasSuffix(normalized, " unsigned") {
+		normalized, unsigned = strings.TrimSuffix(normalized, " unsigned"), true
+	}
+
+	normalized = strings.ReplaceAll(normalized, " ", "_")
+	if atlasType.MatchString(normalized) {
+		return normalized, unsigned, autoIncrement
+	}
+	return fmt.Sprintf("sql(%q)", columnType), false, false
+}
+
+// atlasSchemaName returns the schema Atlas names the tables of the connection in
+func atlasSchemaName(db *gorm.DB) string {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return "main"
+	case "postgres":
+		return "public"
+	case "sqlserver":
+		return "dbo"
+	default:
+		return db.Migrator().CurrentDatabase()
+	}
+}
@@ -0,0 +1,54 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportAtlasHCL(t *testing.T) {
+	db, _ := openTestDB(t)
+	var b strings.Builder
+	if err := ExportAtlasHCL(db, &b, &indexedUser{}); err != nil {
+		t.Fatalf("ExportAtlasHCL: %v", err)
+	}
+	hcl := b.String()
+	for _, want := range []string{
+		"schema \"main\" {\n}\n",
+		"table \"users\" {\n  schema = schema.main\n",
+		"  column \"id\" {\n    null = false\n    type = integer\n",
+		"  primary_key {\n    columns = [column.id]\n  }\n",
+		"  index \"idx_users_name\" {\n    unique  = true\n    columns = [column.name]\n  }\n",
+	} {
+		if !strings.Contains(hcl, want) {
+			t.Errorf("HCL = %s\nwant %q", hcl, want)
+		}
+	}
+
+	// The schema recorded with a version matches the one of its models
+	migrateAs(t, db, "1", &indexedUser{})
+	var recorded strings.Builder
+	if err := ExportVersionAtlasHCL(db, &recorded, "1"); err != nil {
+		t.Fatalf("ExportVersionAtlasHCL: %v", err)
+	}
+	if !strings.Contains(recorded.String(), "index \"idx_users_name\"") {
+		t.Errorf("HCL of version 1 = %s, want the unique index", recorded.String())
+	}
+}
+
+func TestAtlasColumnType(t *testing.T) {
+	for columnType, want := range map[string]struct {
+		expression              string
+		unsigned, autoIncrement bool
+	}{
+		"varchar(255)":                      {"varchar(255)", false, false},
+		"bigint unsigned":                   {"bigint", true, false},
+		"integer PRIMARY KEY AUTOINCREMENT": {"integer", false, true},
+		"double precision":                  {"double_precision", false, false},
+		`enum('a','b')`:                     {`sql("enum('a','b')")`, false, false},
+	} {
+		expression, unsigned, autoIncrement := atlasColumnType(columnType)
+		if expression != want.expression || unsigned != want.unsigned || autoIncrement != want.autoIncrement {
+			t.Errorf("atlasColumnType(%s) = %s, %t, %t, want %+v", columnType, expression, unsigned, autoIncrement, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Defaults used by NewAuditFile
+const (
+	DefaultAuditMaxBytes = 10 << 20
+	DefaultAuditMaxFiles = 5
+)
+
+// AuditFile is a Notifier appending every MigrationEvent as a JSON line to a local file, so
+// the audit trail survives the database being rolled back or restored from a backup
+type AuditFile struct {
+	Path string
+	// MaxBytes rotates the file to Path.1, Path.2 and so on once a line would grow it past
+	// the size, it is never rotated when zero
+	MaxBytes int64
+	// MaxFiles is the number of rotated files kept
+	MaxFiles int
+
+	mu sync.Mutex
+}
+
+// NewAuditFile creates an AuditFile appending to path with the default rotation
+func NewAuditFile(path string) *AuditFile {
+	return &AuditFile{
+		Path:     path,
+		MaxBytes: DefaultAuditMaxBytes,
+		MaxFiles: DefaultAuditMaxFiles,
+	}
+}
+
+// Notify appends the event to the file and syncs it to disk
+func (a *AuditFile) Notify(_ context.Context, event MigrationEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode migration event: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.rotate(int64(len(line))); err != nil {
+		return fmt.Errorf("failed to rotate audit file %s: %w", a.Path, err)
+	}
+	file, err := os.OpenFile(a.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file %s: %w", a.Path, err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit file %s: %w", a.Path, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync audit file %s: %w", a.Path, err)
+	}
+	return file.Close()
+}
+
+// rotate shifts the rotated files and moves the file aside when appending size bytes would
+// grow it past MaxBytes, the oldest rotated file is removed
+func (a *AuditFile) rotate(size int64) error {
+	if a.MaxBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(a.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+size <= a.MaxBytes {
+		return nil
+	}
+
+	if a.MaxFiles <= 0 {
+		return os.Remove(a.Path)
+	}
+	if err := os.Remove(a.rotated(a.MaxFiles)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := a.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(a.rotated(i), a.rotated(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(a.Path, a.rotated(1))
+}
+
+// rotated returns the path of the i-th rotated file
+func (a *AuditFile) rotated(i int) string {
+	return fmt.Sprintf("%s.%d", a.Path, i)
+}
+
+// auditUnrecorded appends a SchemaVersion the history failed to record to the AuditFile
+// notifiers, as a last resort so the migration it describes isn't lost
+func (p *AutoMigratePlugin) auditUnrecorded(db *gorm.DB, schemaVersion *SchemaVersion, recordErr error) {
+	if schemaVersion.Status == StatusPending {
+		return
+	}
+	// The failed insertion may have left the columns encrypted or compressed
+	decoded := *schemaVersion
+	if err := decoded.decode(db); err != nil {
+		p.Logger.Warn("Failed to decode schema version %s: %v", decoded.Version, err)
+	}
+	event := versionEvent(&decoded)
+	event.RecordError = recordErr.Error()
+
+	for _, notifier := range p.Notifiers {
+		audit, ok := notifier.(*AuditFile)
+		if !ok {
+			continue
+		}
+		if err := audit.Notify(contextFrom(db), event); err != nil {
+			p.Logger.Error("Failed to keep schema version %s in audit file %s: %v", event.Version, audit.Path, err)
+			continue
+		}
+		p.Logger.Warn("Kept schema version %s in audit file %s", event.Version, audit.Path)
+	}
+}
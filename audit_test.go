@@ -0,0 +1,82 @@
+package gorm_migrate_tracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// auditEvents decodes the events appended to the audit file at path
+func auditEvents(t *testing.T, path string) []MigrationEvent {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open the audit file: %v", err)
+	}
+	defer file.Close()
+
+	var events []MigrationEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event MigrationEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("failed to decode audit line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read the audit file: %v", err)
+	}
+	return events
+}
+
+func TestAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrations.jsonl")
+	db, _ := openTestDB(t, WithAuditFile(path))
+	migrateAs(t, db, "1", &pluginUser{})
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "2")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	events := auditEvents(t, path)
+	if len(events) != 2 {
+		t.Fatalf("audited %d events, want 2", len(events))
+	}
+	if events[0].Version != "1" || events[0].Status != StatusSuccess || len(events[0].Statements) == 0 {
+		t.Errorf("first event = %+v, want version 1 with its statements", events[0])
+	}
+	if events[1].Version != "2" || events[1].Status != StatusFailed || events[1].Error == "" {
+		t.Errorf("second event = %+v, want the failure of version 2", events[1])
+	}
+
+	// The trail survives the history being wiped
+	if err := historyDB(db).Where("1 = 1").Delete(&SchemaVersion{}).Error; err != nil {
+		t.Fatalf("failed to wipe the history: %v", err)
+	}
+	if events := auditEvents(t, path); len(events) != 2 {
+		t.Errorf("audited %d events after wiping the history, want 2", len(events))
+	}
+}
+
+func TestAuditFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrations.jsonl")
+	audit := &AuditFile{Path: path, MaxBytes: 1, MaxFiles: 2}
+	for _, version := range []string{"1", "2", "3", "4"} {
+		if err := audit.Notify(context.Background(), MigrationEvent{Version: version}); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+
+	// Every event outgrows the file, the oldest one is dropped with the third rotated file
+	for path, want := range map[string]string{path: "4", path + ".1": "3", path + ".2": "2"} {
+		if events := auditEvents(t, path); len(events) != 1 || events[0].Version != want {
+			t.Errorf("%s holds %v, want version %s", filepath.Base(path), events, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("a third rotated file was kept: %v", err)
+	}
+}
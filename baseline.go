@@ -0,0 +1,81 @@
+package gorm_migrate_tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrHistoryNotEmpty is returned when baselining a database that already has a schema version
+var ErrHistoryNotEmpty = errors.New("migration history is not empty")
+
+// Baseline records the current schema of an existing database as its initial SchemaVersion
+// without running any DDL. The given models are listed in the change set and checksummed as
+// if they had been migrated, without models every table of the database is listed.
+func Baseline(db *gorm.DB, version string, models ...interface{}) (*SchemaVersion, error) {
+	p := registeredPlugin(db)
+	p.Logger.Debug("Baseline function called for version %s", version)
+	p.migrating.Lock()
+	defer p.migrating.Unlock()
+
+	if _, err := currentVersion(db); err == nil {
+		p.Logger.Error("Refusing to baseline a database with recorded migrations")
+		return nil, ErrHistoryNotEmpty
+	} else if !errors.Is(err, ErrVersionNotFound) {
+		return nil, err
+	}
+
+	snapshot, err := takeSnapshot(db, p.historyTableName(db))
+	if err != nil {
+		p.Logger.Error("Failed to take schema snapshot: %v", err)
+		return nil, fmt.Errorf("failed to take schema snapshot: %w", err)
+	}
+
+	changeSet := &ChangeSet{Baseline: true}
+	if len(models) > 0 {
+		for _, model := range models {
+			change := ModelChange{Model: modelName(model)}
+			if table, err := inspectTable(db, model); err == nil {
+				change.Table = table.Name
+			}
+			changeSet.Models = append(changeSet.Models, change)
+		}
+	} else {
+		for _, table := range snapshot.Tables {
+			changeSet.Models = append(changeSet.Models, ModelChange{Model: table.Name, Table: table.Name})
+		}
+	}
+	changes, err := encodeChangeSet(changeSet)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaVersion := &SchemaVersion{
+		Version:   version,
+		Kind:      KindBaseline,
+		Status:    StatusSuccess,
+		AppliedAt: p.now(),
+		Changes:   changes,
+		Severity:  SeverityInfo,
+	}
+	if len(models) > 0 {
+		if schemaVersion.Checksum, err = modelsChecksum(db, models); err != nil {
+			return nil, err
+		}
+	}
+	if !p.SkipSnapshots {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema snapshot: %w", err)
+		}
+		schemaVersion.Snapshot = string(data)
+	}
+
+	if err := p.record(db, schemaVersion); err != nil {
+		return nil, err
+	}
+	p.Logger.Info("Baselined schema at version %s", version)
+	return schemaVersion, nil
+}
@@ -0,0 +1,69 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	db, _ := openTestDB(t, WithSkipUnchanged(true))
+	if err := db.Exec("CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text)").Error; err != nil {
+		t.Fatalf("failed to create the users table: %v", err)
+	}
+
+	baseline, err := Baseline(db, "1", &pluginUser{})
+	if err != nil {
+		t.Fatalf("Baseline: %v", err)
+	}
+	recorded := mustRecorded(t, db, "1")
+	if recorded.Kind != KindBaseline || recorded.Statements != "" || recorded.Checksum != baseline.Checksum {
+		t.Errorf("version 1 = %s %q, want a baseline without statements", recorded.Kind, recorded.Statements)
+	}
+	snapshot, err := recorded.ParseSnapshot()
+	if err != nil {
+		t.Fatalf("ParseSnapshot: %v", err)
+	}
+	if _, ok := snapshot.Table("users"); !ok {
+		t.Error("the baseline snapshot has no users table")
+	}
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if !changes.Baseline || len(changes.Models) != 1 || changes.Models[0].Table != "users" {
+		t.Errorf("changes = %+v, want the users model baselined", changes)
+	}
+
+	// The baselined models are unchanged
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("recorded %d versions, want the baseline alone", len(history))
+	}
+
+	if _, err := Baseline(db, "2"); !errors.Is(err, ErrHistoryNotEmpty) {
+		t.Errorf("Baseline of a tracked database = %v, want ErrHistoryNotEmpty", err)
+	}
+}
+
+func TestBaselineWithoutModels(t *testing.T) {
+	db, _ := openTestDB(t)
+	for _, model := range []interface{}{&pluginUser{}, &squashedOrder{}} {
+		if err := db.WithContext(SkipTracking(db.Statement.Context)).AutoMigrate(model); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+	}
+
+	if _, err := Baseline(db, "1"); err != nil {
+		t.Fatalf("Baseline: %v", err)
+	}
+	changes, err := mustRecorded(t, db, "1").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	// Every table of the database is listed
+	if len(changes.Models) != 2 || changes.Models[0].Table != "squashed_orders" || changes.Models[1].Table != "users" {
+		t.Errorf("changes = %+v, want the orders and users tables", changes.Models)
+	}
+}
@@ -0,0 +1,210 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ChangeSet is the structured change data stored in SchemaVersion.Changes
+type ChangeSet struct {
+	Models []ModelChange `json:"models,omitempty"`
+	// Operation is the explicit Migrator call recorded, such as DropColumn(email), empty for
+	// AutoMigrate
+	Operation string `json:"operation,omitempty"`
+	// Baseline marks the models as adopted as they were instead of migrated
+	Baseline bool         `json:"baseline,omitempty"`
+	Drift    *DriftReport `json:"drift,omitempty"`
+	// Views lists the views created or replaced by MigrateViews
+	Views []ViewChange `json:"views,omitempty"`
+	// Routines lists the functions and triggers applied after the models
+	Routines []RoutineChange `json:"routines,omitempty"`
+	// Destructive lists the applied column drops, type narrowing and index drops
+	Destructive []string `json:"destructive,omitempty"`
+	// Notes holds free text that doesn't fit the structured fields, such as change logs
+	// recorded before changes were stored as JSON
+	Notes []string `json:"notes,omitempty"`
+}
+
+// ModelChange lists the changes AutoMigrate applied to a single model
+type ModelChange struct {
+	Model       string           `json:"model"`
+	Table       string           `json:"table,omitempty"`
+	Columns     []ColumnDiff     `json:"columns,omitempty"`
+	Indexes     []IndexDiff      `json:"indexes,omitempty"`
+	Constraints []ConstraintDiff `json:"constraints,omitempty"`
+	Enums       []EnumDiff       `json:"enums,omitempty"`
+	Notes       []string         `json:"notes,omitempty"`
+	DurationMs  int64            `json:"duration_ms"`
+}
+
+// String renders the change set as a human readable change log
+func (c *ChangeSet) String() string {
+	var b strings.Builder
+	for _, model := range c.Models {
+		if c.Baseline {
+			fmt.Fprintf(&b, "Baselined %s\n", model.Model)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s in %dms\n", c.verb(), model.Model, model.DurationMs)
+		for _, column := range model.Columns {
+			fmt.Fprintf(&b, "  %s\n", column)
+		}
+		for _, index := range model.Indexes {
+			fmt.Fprintf(&b, "  %s\n", index)
+		}
+		for _, constraint := range model.Constraints {
+			fmt.Fprintf(&b, "  %s\n", constraint)
+		}
+		for _, enum := range model.Enums {
+			fmt.Fprintf(&b, "  %s\n", enum)
+		}
+		for _, note := range model.Notes {
+			fmt.Fprintf(&b, "  %s\n", note)
+		}
+	}
+	for _, view := range c.Views {
+		fmt.Fprintf(&b, "Migrated %s\n", view)
+	}
+	for _, routine := range c.Routines {
+		fmt.Fprintf(&b, "Applied %s\n", routine)
+	}
+	for _, change := range c.Destructive {
+		fmt.Fprintf(&b, "destructive: %s\n", change)
+	}
+	if c.Drift != nil {
+		b.WriteString(c.Drift.String())
+	}
+	for _, note := range c.Notes {
+		fmt.Fprintf(&b, "%s\n", note)
+	}
+	return b.String()
+}
+
+// verb describes how the models of the change set were migrated
+func (c *ChangeSet) verb() string {
+	if c.Operation != "" {
+		return "Migrator." + c.Operation + " on"
+	}
+	return "AutoMigrated"
+}
+
+// Summary returns a one line description of the change set
+func (c *ChangeSet) Summary() string {
+	switch {
+	case len(c.Models) > 0:
+		names := make([]string, len(c.Models))
+		for i, model := range c.Models {
+			names[i] = model.Model
+		}
+		if c.Baseline {
+			return "Baselined " + strings.Join(names, ", ")
+		}
+		if c.Drift != nil {
+			return "Repaired drift of " + strings.Join(names, ", ")
+		}
+		return c.verb() + " " + strings.Join(names, ", ")
+	case c.Operation != "":
+		return "Migrator." + c.Operation
+	case len(c.Views) > 0:
+		names := make([]string, len(c.Views))
+		for i, view := range c.Views {
+			names[i] = view.Name
+		}
+		return "Migrated views " + strings.Join(names, ", ")
+	case c.Drift != nil:
+		return "Schema drift detected"
+	case len(c.Notes) > 0:
+		return c.Notes[0]
+	default:
+		return ""
+	}
+}
+
+// encodeChangeSet serializes a change set for storage
+func encodeChangeSet(changeSet *ChangeSet) (string, error) {
+	data, err := json.Marshal(changeSet)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode change set: %w", err)
+	}
+	return string(data), nil
+}
+
+// isJSONChangeSet reports whether stored changes use the JSON format
+func isJSONChangeSet(changes string) bool {
+	return strings.HasPrefix(strings.TrimSpace(changes), "{")
+}
+
+// ParseChanges decodes the change set stored with a version, converting change logs
+// recorded in the legacy free text format
+func (v SchemaVersion) ParseChanges() (*ChangeSet, error) {
+	if isJSONChangeSet(v.Changes) {
+		changeSet := &ChangeSet{}
+		if err := json.Unmarshal([]byte(v.Changes), changeSet); err != nil {
+			return nil, fmt.Errorf("failed to decode changes of version %s: %w", v.Version, err)
+		}
+		return changeSet, nil
+	}
+	return parseLegacyChanges(v.Changes), nil
+}
+
+// parseLegacyChanges converts a free text change log into a change set
+func parseLegacyChanges(changes string) *ChangeSet {
+	changeSet := &ChangeSet{}
+	for _, line := range strings.Split(changes, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(line, "AutoMigrated "):
+			changeSet.Models = append(changeSet.Models, ModelChange{Model: strings.TrimPrefix(trimmed, "AutoMigrated ")})
+		case strings.HasPrefix(line, " ") && len(changeSet.Models) > 0:
+			last := &changeSet.Models[len(changeSet.Models)-1]
+			last.Notes = append(last.Notes, trimmed)
+		default:
+			changeSet.Notes = append(changeSet.Notes, trimmed)
+		}
+	}
+	return changeSet
+}
+
+// UpgradeChangesFormatContext is like UpgradeChangesFormat but stops when ctx is done
+func UpgradeChangesFormatContext(ctx context.Context, db *gorm.DB) (int, error) {
+	return UpgradeChangesFormat(db.WithContext(ctx))
+}
+
+// UpgradeChangesFormat rewrites history records whose changes were stored in the legacy
+// free text format as JSON change sets, returning the number of upgraded records
+func UpgradeChangesFormat(db *gorm.DB) (int, error) {
+	return upgradeChangesFormat(historyConn(db), historyTableName(db), loggerFrom(db))
+}
+
+// upgradeChangesFormat rewrites legacy change logs of the given history table
+func upgradeChangesFormat(db *gorm.DB, table string, logger Logger) (int, error) {
+	logger.Debug("UpgradeChangesFormat function called")
+
+	var history []SchemaVersion
+	if err := db.Table(table).Where("changes NOT LIKE ? AND changes NOT LIKE ? AND changes NOT LIKE ?", "{%", encryptedPrefix+"%", compressedPrefix+"%").Find(&history).Error; err != nil {
+		logger.Error("Failed to retrieve legacy change logs: %v", err)
+		return 0, fmt.Errorf("failed to retrieve legacy change logs: %w", err)
+	}
+
+	for _, schemaVersion := range history {
+		changes, err := encodeChangeSet(parseLegacyChanges(schemaVersion.Changes))
+		if err != nil {
+			return 0, err
+		}
+		if err := db.Table(table).Where("id = ?", schemaVersion.ID).Update("changes", changes).Error; err != nil {
+			logger.Error("Failed to upgrade changes of version %s: %v", schemaVersion.Version, err)
+			return 0, fmt.Errorf("failed to upgrade changes of version %s: %w", schemaVersion.Version, err)
+		}
+	}
+
+	if len(history) > 0 {
+		logger.Info("Upgraded %d change logs to the JSON format", len(history))
+	}
+	return len(history), nil
+}
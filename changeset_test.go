@@ -0,0 +1,85 @@
+package gorm_migrate_tracker
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// legacyChanges is a change log in the free text format recorded before changes were JSON
+const legacyChanges = "AutoMigrated main.User\n  Added column email\nAutoMigrated main.Order\nImported from Flyway\n"
+
+func TestRecordedChangeSet(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	changes, err := mustRecorded(t, db, "2").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 || changes.Models[0].Table != "users" {
+		t.Fatalf("changes = %+v, want the users model", changes)
+	}
+	want := []ColumnDiff{{Table: "users", Column: "email", Kind: ColumnAdded, NewType: "text", NewNullable: true}}
+	if !slices.Equal(changes.Models[0].Columns, want) {
+		t.Errorf("column changes = %v, want %v", changes.Models[0].Columns, want)
+	}
+}
+
+func TestParseLegacyChanges(t *testing.T) {
+	changes, err := SchemaVersion{Changes: legacyChanges}.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 2 || changes.Models[0].Model != "main.User" || changes.Models[1].Model != "main.Order" {
+		t.Fatalf("models = %+v, want main.User and main.Order", changes.Models)
+	}
+	if !slices.Equal(changes.Models[0].Notes, []string{"Added column email"}) {
+		t.Errorf("notes of main.User = %v, want the indented line", changes.Models[0].Notes)
+	}
+	if !slices.Equal(changes.Notes, []string{"Imported from Flyway"}) {
+		t.Errorf("notes = %v, want the unindented line", changes.Notes)
+	}
+
+	if _, err := (SchemaVersion{Changes: "{not json"}).ParseChanges(); err == nil {
+		t.Error("ParseChanges of malformed JSON succeeded")
+	}
+}
+
+func TestUpgradeChangesFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, _ := openTestDBAt(t, path)
+	seedVersions(t, db,
+		SchemaVersion{Version: "1", Changes: legacyChanges},
+		SchemaVersion{Version: "2"},
+	)
+
+	upgraded, err := UpgradeChangesFormat(db)
+	if err != nil {
+		t.Fatalf("UpgradeChangesFormat: %v", err)
+	}
+	if upgraded != 1 {
+		t.Errorf("upgraded %d records, want only the legacy one", upgraded)
+	}
+	stored := storedColumn(t, db, "1", "changes")
+	if !isJSONChangeSet(stored) {
+		t.Fatalf("changes stored as %q, want JSON", stored)
+	}
+	changes, err := mustRecorded(t, db, "1").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 2 || changes.Models[0].Model != "main.User" {
+		t.Errorf("upgraded changes = %+v, want the models of the change log", changes)
+	}
+
+	// History recorded by an earlier release is upgraded when the plugin initializes
+	if err := historyDB(db).Create(&SchemaVersion{Version: "3", Kind: KindMigration, Status: StatusSuccess, Changes: legacyChanges}).Error; err != nil {
+		t.Fatalf("failed to seed version 3: %v", err)
+	}
+	reopened, _ := openTestDBAt(t, path)
+	if stored := storedColumn(t, reopened, "3", "changes"); !isJSONChangeSet(stored) {
+		t.Errorf("changes stored as %q after initializing, want JSON", stored)
+	}
+}
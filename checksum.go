@@ -0,0 +1,79 @@
+package gorm_migrate_tracker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// modelsChecksum hashes the parsed schemas of the given models, it only changes when a
+// table, column or index definition AutoMigrate acts on changes
+func modelsChecksum(db *gorm.DB, models []interface{}) (string, error) {
+	definitions := make([]string, 0, len(models))
+	for _, model := range models {
+		definition, err := modelDefinition(db, model)
+		if err != nil {
+			return "", err
+		}
+		definitions = append(definitions, definition)
+	}
+	sort.Strings(definitions)
+
+	sum := sha256.Sum256([]byte(strings.Join(definitions, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// modelDefinition renders the parts of a model's schema that affect migrations
+func modelDefinition(db *gorm.DB, model interface{}) (string, error) {
+	if name, ok := model.(string); ok {
+		return fmt.Sprintf("table %s\n", name), nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", fmt.Errorf("failed to parse model %s: %w", modelName(model), err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "table %s\n", stmt.Table)
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		if field.IgnoreMigration {
+			continue
+		}
+		fmt.Fprintf(&b, "column %s %s primary=%t notnull=%t unique=%t default=%q comment=%q\n",
+			dbName, db.Dialector.DataTypeOf(field), field.PrimaryKey, field.NotNull, field.Unique, field.DefaultValue, field.Comment)
+	}
+
+	indexes := stmt.Schema.ParseIndexes()
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		index := indexes[name]
+		columns := make([]string, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			columns = append(columns, option.DBName)
+		}
+		fmt.Fprintf(&b, "index %s %s %s (%s) where=%q\n", name, index.Class, index.Type, strings.Join(columns, ","), index.Where)
+	}
+	return b.String(), nil
+}
+
+// unchangedSince reports whether the current version was recorded with checksum
+func (p *AutoMigratePlugin) unchangedSince(db *gorm.DB, checksum string) (bool, error) {
+	current, err := currentVersion(db.Session(&gorm.Session{NewDB: true}))
+	if errors.Is(err, ErrVersionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return current.Checksum == checksum, nil
+}
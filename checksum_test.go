@@ -0,0 +1,64 @@
+package gorm_migrate_tracker
+
+import "testing"
+
+func TestModelsChecksum(t *testing.T) {
+	db, _ := openTestDB(t)
+	checksum := func(models ...interface{}) string {
+		t.Helper()
+		sum, err := modelsChecksum(db, models)
+		if err != nil {
+			t.Fatalf("modelsChecksum: %v", err)
+		}
+		return sum
+	}
+
+	if checksum(&pluginUser{}, &squashedOrder{}) != checksum(&squashedOrder{}, &pluginUser{}) {
+		t.Error("the checksum depends on the order of the models")
+	}
+	if checksum(&pluginUser{}) == checksum(&pluginUserWithEmail{}) {
+		t.Error("models with different columns have the same checksum")
+	}
+}
+
+func TestSkipUnchanged(t *testing.T) {
+	db, _ := openTestDB(t, WithSkipUnchanged(true), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	for range 3 {
+		if err := db.AutoMigrate(&pluginUser{}); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+	}
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Checksum == "" {
+		t.Fatalf("history = %v, want a single record with its checksum", history)
+	}
+
+	// The email column is added, then the narrower model runs no DDL but isn't the same
+	// schema definition
+	if err := db.AutoMigrate(&pluginUserWithEmail{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	history = mustHistory(t, db)
+	if len(history) != 3 {
+		t.Fatalf("recorded %d versions, want the changed models recorded", len(history))
+	}
+	if current, err := GetCurrentVersion(db); err != nil || current.Statements != "" {
+		t.Errorf("GetCurrentVersion = %v, %v, want the models recorded without DDL", current, err)
+	}
+}
+
+func TestWithoutSkipUnchanged(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	for range 2 {
+		if err := db.AutoMigrate(&pluginUser{}); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+	}
+	history := mustHistory(t, db)
+	if len(history) != 2 || history[0].Checksum != history[1].Checksum {
+		t.Errorf("history = %v, want every run recorded with the same checksum", history)
+	}
+}
@@ -0,0 +1,43 @@
+package gorm_migrate_tracker
+
+import (
+	"sync"
+	"time"
+)
+
+// ManualClock is a Clock that only moves when told to, for tests and deterministic versions.
+// With a Step every reading advances it, so consecutive migrations get distinct timestamp
+// versions, while without one they collide on the same second like fast restarts do.
+type ManualClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewManualClock creates a ManualClock reading t, advanced by step after every reading
+func NewManualClock(t time.Time, step time.Duration) *ManualClock {
+	return &ManualClock{now: t, step: step}
+}
+
+// Now returns the current time of the clock and advances it by its step
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Set moves the clock to t
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,436 @@
+// Command migrate-tracer inspects and manages the migration history recorded by AutoMigratePlugin
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/leodahal4/go-migrate-tracer/schemaversion"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: migrate-tracer [flags] <command> [args]
+
+Commands:
+  history               list recorded schema versions
+  status                show the current schema version
+  diff <from> <to>      show the changes recorded between two versions
+  reconstruct <version> print the DDL recreating the schema as it was at version
+  diagram [version]     print the schema of version, or the live schema, as a diagram
+  atlas [version]       print the schema of version, or the live schema, as Atlas HCL
+  changelog             print the history as a Markdown changelog
+  snapshot              print the live schema as a JSON snapshot
+  check-snapshot <file> compare the live schema with a snapshot, printing the diff as JSON
+                        and failing when they differ
+  verify                check the signatures of the history, see -signing-key
+  approve <version>     approve the planned changes recorded under version
+  rollback <version>    revert every migration recorded after version
+  tag <version> key=value...
+                        label a version, an empty value removes the label
+  baseline <version>    record the existing schema as the initial version
+  import-golang-migrate [dir]
+                        import the history of golang-migrate, with its files from dir
+  embed-version [file]  write the current version and checksum to a Go file of -package,
+                        or print them as -ldflags, see the schemaversion package
+  tui                   browse the history interactively, expanding the diff of every
+                        version, and roll back or approve versions
+
+Flags:
+`
+
+func main() {
+	flags := flag.NewFlagSet("migrate-tracer", flag.ExitOnError)
+	driver := flags.String("driver", "", "database driver: postgres, mysql or sqlite (inferred from the DSN when empty)")
+	dsn := flags.String("dsn", os.Getenv("MIGRATE_TRACER_DSN"), "database DSN, defaults to $MIGRATE_TRACER_DSN")
+	table := flags.String("table", "", "history table name, defaults to schema_versions")
+	schema := flags.String("schema", "", "database schema of the history table")
+	format := flags.String("format", "mermaid", "diagram format: mermaid or dot")
+	pkg := flags.String("package", "main", "package of the file written by embed-version")
+	encryptionKey := flags.String("encryption-key", os.Getenv("MIGRATE_TRACER_ENCRYPTION_KEY"), "base64 AES key the history is encrypted with, defaults to $MIGRATE_TRACER_ENCRYPTION_KEY")
+	signingKey := flags.String("signing-key", os.Getenv("MIGRATE_TRACER_SIGNING_KEY"), "history signing key, defaults to $MIGRATE_TRACER_SIGNING_KEY")
+	initiatedBy := flags.String("initiated-by", os.Getenv("USER"), "actor recorded with the versions written by the command, defaults to $USER")
+	verbose := flags.Bool("v", false, "enable plugin debug logging")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 || *dsn == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	args := flags.Args()
+	db, err := open(*driver, *dsn, *table, *schema, *initiatedBy, *signingKey, *encryptionKey, *verbose, readOnlyCommands[args[0]])
+	if err != nil {
+		fatal(err)
+	}
+
+	// Cancel in-flight queries on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	db = db.WithContext(ctx)
+
+	switch args[0] {
+	case "history":
+		err = history(db, os.Stdout)
+	case "status":
+		err = status(db, os.Stdout)
+	case "diff":
+		if len(args) != 3 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = diff(db, os.Stdout, args[1], args[2])
+	case "reconstruct":
+		if len(args) != 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = reconstruct(db, os.Stdout, args[1])
+	case "diagram":
+		if len(args) > 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		var version string
+		if len(args) == 2 {
+			version = args[1]
+		}
+		var diagram string
+		diagram, err = tracker.SchemaDiagram(db, version, tracker.DiagramFormat(*format))
+		if err == nil {
+			fmt.Fprint(os.Stdout, diagram)
+		}
+	case "atlas":
+		if len(args) > 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		var version string
+		if len(args) == 2 {
+			version = args[1]
+		}
+		err = tracker.ExportVersionAtlasHCL(db, os.Stdout, version)
+	case "changelog":
+		err = tracker.ExportMarkdown(db, os.Stdout)
+	case "snapshot":
+		err = tracker.ExportSnapshot(db, os.Stdout)
+	case "check-snapshot":
+		if len(args) != 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = checkSnapshot(db, os.Stdout, args[1])
+	case "verify":
+		err = tracker.VerifyHistory(db)
+		if err == nil {
+			fmt.Fprintln(os.Stdout, "History verified")
+		}
+	case "approve":
+		if len(args) != 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = tracker.Approve(db, args[1])
+		if err == nil {
+			fmt.Fprintf(os.Stdout, "Approved %s\n", args[1])
+		}
+	case "rollback":
+		if len(args) != 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = tracker.RollbackTo(db, args[1])
+		if err == nil {
+			fmt.Fprintf(os.Stdout, "Rolled back to %s\n", args[1])
+		}
+	case "tag":
+		if len(args) < 3 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		labels := tracker.Labels{}
+		for _, arg := range args[2:] {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || key == "" {
+				flags.Usage()
+				os.Exit(2)
+			}
+			labels[key] = value
+		}
+		err = tracker.TagVersion(db, args[1], labels)
+		if err == nil {
+			fmt.Fprintf(os.Stdout, "Tagged %s\n", args[1])
+		}
+	case "baseline":
+		if len(args) != 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		_, err = tracker.Baseline(db, args[1])
+		if err == nil {
+			fmt.Fprintf(os.Stdout, "Baselined at %s\n", args[1])
+		}
+	case "import-golang-migrate":
+		if len(args) > 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		var migrations fs.FS
+		if len(args) == 2 {
+			migrations = os.DirFS(args[1])
+		}
+		var imported int
+		imported, err = tracker.ImportGolangMigrate(db, "", migrations)
+		if err == nil {
+			fmt.Fprintf(os.Stdout, "Imported %d versions\n", imported)
+		}
+	case "embed-version":
+		if len(args) > 2 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = embedVersion(db, os.Stdout, *pkg, args[1:])
+	case "tui":
+		if len(args) != 1 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = runTUI(db)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// readOnlyCommands only read the database, the plugin is registered without creating its
+// tables or upgrading the recorded history for them
+var readOnlyCommands = map[string]bool{
+	"history":        true,
+	"status":         true,
+	"diff":           true,
+	"reconstruct":    true,
+	"diagram":        true,
+	"atlas":          true,
+	"changelog":      true,
+	"snapshot":       true,
+	"check-snapshot": true,
+	"verify":         true,
+	"embed-version":  true,
+}
+
+// open connects to the database and registers the plugin so its configuration is honoured,
+// read-only when readOnly is set
+func open(driver, dsn, table, schema, initiatedBy, signingKey, encryptionKey string, verbose, readOnly bool) (*gorm.DB, error) {
+	if driver == "" {
+		driver = inferDriver(dsn)
+	}
+
+	var dialector gorm.Dialector
+	switch driver {
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	case "mysql":
+		dialector = mysql.Open(strings.TrimPrefix(dsn, "mysql://"))
+	case "sqlite":
+		dialector = sqlite.Open(strings.TrimPrefix(dsn, "sqlite://"))
+	default:
+		return nil, fmt.Errorf("unsupported driver %q", driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	output := io.Discard
+	if verbose {
+		output = os.Stderr
+	}
+	plugin := tracker.NewAutoMigratePlugin(
+		tracker.WithLogger(tracker.NewStdLogger(log.New(output, "[AutoMigratePlugin] ", log.LstdFlags))),
+		tracker.WithLogLevel(tracker.LogLevelDebug),
+		tracker.WithTableName(table),
+		tracker.WithTableSchema(schema),
+		tracker.WithInitiatedBy(initiatedBy),
+	)
+	if readOnly {
+		tracker.WithReadOnly()(plugin)
+	}
+	if signingKey != "" {
+		tracker.WithSigningKey([]byte(signingKey))(plugin)
+	}
+	if encryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+		}
+		encryptor, err := tracker.NewAESEncryptor(key)
+		if err != nil {
+			return nil, err
+		}
+		tracker.WithEncryptor(encryptor)(plugin)
+	}
+	if err := db.Use(plugin); err != nil {
+		return nil, fmt.Errorf("failed to register plugin: %w", err)
+	}
+	return db, nil
+}
+
+// inferDriver guesses the driver from the DSN
+func inferDriver(dsn string) string {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"), strings.Contains(dsn, "host="):
+		return "postgres"
+	case strings.HasPrefix(dsn, "mysql://"), strings.Contains(dsn, "@tcp("):
+		return "mysql"
+	default:
+		return "sqlite"
+	}
+}
+
+// history prints every recorded schema version, newest first
+func history(db *gorm.DB, w io.Writer) error {
+	versions, err := tracker.GetMigrationHistory(db)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tKIND\tSTATUS\tAPPLIED AT\tDURATION\tCHANGES")
+	for _, version := range versions {
+		changes, err := version.ParseChanges()
+		if err != nil {
+			return err
+		}
+		summary := changes.Summary()
+		if version.Error != "" {
+			summary = "error: " + version.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%dms\t%s\n", version.Version, version.Kind, version.Status, version.AppliedAt.Format("2006-01-02 15:04:05"), version.DurationMs, summary)
+	}
+	return tw.Flush()
+}
+
+// status prints the current schema version
+func status(db *gorm.DB, w io.Writer) error {
+	versions, err := tracker.GetMigrationHistory(db)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Fprintln(w, "No migrations recorded")
+		return nil
+	}
+
+	var failed int
+	for _, version := range versions {
+		if version.Status == tracker.StatusFailed {
+			failed++
+		}
+	}
+	current, err := tracker.GetCurrentVersion(db)
+	if err != nil && !errors.Is(err, tracker.ErrVersionNotFound) {
+		return err
+	}
+	if current == nil {
+		fmt.Fprintln(w, "No successful migrations recorded")
+	} else {
+		fmt.Fprintf(w, "Current version: %s\n", current.Version)
+		fmt.Fprintf(w, "Applied at:      %s\n", current.AppliedAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(w, "Recorded:        %d versions, %d failed\n", len(versions), failed)
+	if latest := versions[0]; latest.Status == tracker.StatusFailed {
+		fmt.Fprintf(w, "Last attempt %s failed: %s\n", latest.Version, latest.Error)
+	}
+	return nil
+}
+
+// diff prints the schema changes between two versions
+func diff(db *gorm.DB, w io.Writer, from, to string) error {
+	versionDiff, err := tracker.DiffVersions(db, from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, versionDiff)
+	return nil
+}
+
+// reconstruct prints the DDL recreating the schema as it was at version
+func reconstruct(db *gorm.DB, w io.Writer, version string) error {
+	snapshot, err := tracker.ReconstructSchema(db, version)
+	if err != nil {
+		return err
+	}
+	for _, statement := range snapshot.CreateStatements(db) {
+		fmt.Fprintf(w, "%s;\n", statement)
+	}
+	return nil
+}
+
+// checkSnapshot compares the live schema with the snapshot file at path, printing the diff as
+// JSON when they differ
+func checkSnapshot(db *gorm.DB, w io.Writer, path string) error {
+	committed, err := tracker.ReadSnapshotFile(path)
+	if err != nil {
+		return err
+	}
+	diff, err := tracker.CheckSnapshot(db, committed)
+	if errors.Is(err, tracker.ErrSnapshotMismatch) {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(diff); encodeErr != nil {
+			return encodeErr
+		}
+		return tracker.ErrSnapshotMismatch
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Schema matches the snapshot")
+	return nil
+}
+
+// embedVersion writes the expected schema to the file in args, or prints it as -ldflags
+func embedVersion(db *gorm.DB, w io.Writer, pkg string, args []string) error {
+	expected, err := schemaversion.FromDatabase(db)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(w, schemaversion.LDFlags(expected))
+		return nil
+	}
+	if err := schemaversion.WriteFile(args[0], pkg, expected); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Embedded version %s in %s\n", expected.Version, args[0])
+	return nil
+}
+
+// fatal prints err and exits
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "migrate-tracer: %v\n", err)
+	os.Exit(1)
+}
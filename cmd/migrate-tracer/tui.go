@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/gorm"
+)
+
+const tuiHelp = "↑/↓ select · enter details · ctrl+u/d scroll · r rollback · a approve · R reload · q quit"
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	failedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	pendingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	mutedStyle    = lipgloss.NewStyle().Faint(true)
+)
+
+// tuiAction is an action awaiting confirmation
+type tuiAction int
+
+const (
+	noAction tuiAction = iota
+	rollbackAction
+	approveAction
+)
+
+// historyMsg carries the reloaded history
+type historyMsg struct {
+	versions []tracker.SchemaVersion
+	err      error
+}
+
+// detailsMsg carries the details of a version
+type detailsMsg struct {
+	version string
+	lines   []string
+	err     error
+}
+
+// actionMsg carries the outcome of a rollback or an approval
+type actionMsg struct {
+	message string
+	err     error
+}
+
+// tuiModel is the state of the tui command
+type tuiModel struct {
+	db       *gorm.DB
+	versions []tracker.SchemaVersion
+	// cursor is the selected version and offset the first one shown
+	cursor int
+	offset int
+	// expanded shows the details of the selected version, scrolled by detailsOffset
+	expanded      bool
+	details       []string
+	detailsOffset int
+	confirm       tuiAction
+	message       string
+	err           error
+	width         int
+	height        int
+}
+
+// runTUI browses the history interactively until the user quits
+func runTUI(db *gorm.DB) error {
+	_, err := tea.NewProgram(&tuiModel{db: db}, tea.WithAltScreen()).Run()
+	return err
+}
+
+// Init loads the history
+func (m *tuiModel) Init() tea.Cmd {
+	return m.loadHistory
+}
+
+// loadHistory retrieves the history, newest first
+func (m *tuiModel) loadHistory() tea.Msg {
+	versions, err := tracker.GetMigrationHistory(m.db)
+	return historyMsg{versions: versions, err: err}
+}
+
+// loadDetails returns a command rendering the details of the selected version, with its diff
+// from the version recorded before
+func (m *tuiModel) loadDetails() tea.Cmd {
+	if len(m.versions) == 0 {
+		return nil
+	}
+	version := m.versions[m.cursor]
+	var previous string
+	if m.cursor+1 < len(m.versions) {
+		previous = m.versions[m.cursor+1].Version
+	}
+	return func() tea.Msg {
+		lines, err := versionDetails(m.db, version, previous)
+		return detailsMsg{version: version.Version, lines: lines, err: err}
+	}
+}
+
+// rollback returns a command reverting every migration recorded after the selected version
+func (m *tuiModel) rollback(version string) tea.Cmd {
+	return func() tea.Msg {
+		if err := tracker.RollbackTo(m.db, version); err != nil {
+			return actionMsg{err: err}
+		}
+		return actionMsg{message: fmt.Sprintf("Rolled back to %s", version)}
+	}
+}
+
+// approve returns a command approving the planned changes recorded under the selected version
+func (m *tuiModel) approve(version string) tea.Cmd {
+	return func() tea.Msg {
+		if err := tracker.Approve(m.db, version); err != nil {
+			return actionMsg{err: err}
+		}
+		return actionMsg{message: fmt.Sprintf("Approved %s", version)}
+	}
+}
+
+// Update handles keys and the results of the commands
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+	case historyMsg:
+		m.versions, m.err = msg.versions, msg.err
+		m.cursor = min(m.cursor, max(len(m.versions)-1, 0))
+		m.scroll()
+		if m.expanded {
+			return m, m.loadDetails()
+		}
+	case detailsMsg:
+		if len(m.versions) > 0 && msg.version == m.versions[m.cursor].Version {
+			m.details, m.detailsOffset = msg.lines, 0
+			if msg.err != nil {
+				m.details = []string{failedStyle.Render(msg.err.Error())}
+			}
+		}
+	case actionMsg:
+		m.message, m.err = msg.message, msg.err
+		return m, m.loadHistory
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey handles a key press
+func (m *tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirm != noAction {
+		action := m.confirm
+		m.confirm = noAction
+		if msg.String() != "y" || len(m.versions) == 0 {
+			m.message = "Cancelled"
+			return m, nil
+		}
+		version := m.versions[m.cursor].Version
+		m.message = "Working…"
+		if action == rollbackAction {
+			return m, m.rollback(version)
+		}
+		return m, m.approve(version)
+	}
+
+	m.message, m.err = "", nil
+	previous := m.cursor
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.versions) - 1
+	case "enter", " ":
+		m.expanded = !m.expanded
+		m.details = nil
+		m.scroll()
+		if m.expanded {
+			return m, m.loadDetails()
+		}
+	case "ctrl+u":
+		m.detailsOffset = max(m.detailsOffset-m.detailsHeight()/2, 0)
+	case "ctrl+d":
+		m.detailsOffset = max(min(m.detailsOffset+m.detailsHeight()/2, len(m.details)-m.detailsHeight()), 0)
+	case "r":
+		if len(m.versions) > 0 {
+			m.confirm = rollbackAction
+		}
+	case "a":
+		if len(m.versions) == 0 {
+			break
+		}
+		if m.versions[m.cursor].Status != tracker.StatusAwaitingApproval {
+			m.message = fmt.Sprintf("%s isn't awaiting approval", m.versions[m.cursor].Version)
+			break
+		}
+		m.confirm = approveAction
+	case "R":
+		return m, m.loadHistory
+	}
+
+	m.cursor = max(min(m.cursor, len(m.versions)-1), 0)
+	m.scroll()
+	if m.expanded && m.cursor != previous {
+		m.details = nil
+		return m, m.loadDetails()
+	}
+	return m, nil
+}
+
+// listHeight is the number of versions shown at once
+func (m *tuiModel) listHeight() int {
+	// The header, the status line and the help take a line each
+	height := m.height - 3
+	if m.expanded {
+		height = height / 2
+	}
+	return max(height, 1)
+}
+
+// detailsHeight is the number of detail lines shown at once, below their border
+func (m *tuiModel) detailsHeight() int {
+	return max(m.height-3-m.listHeight()-1, 1)
+}
+
+// scroll keeps the selected version visible
+func (m *tuiModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.listHeight() {
+		m.offset = m.cursor - m.listHeight() + 1
+	}
+}
+
+// View renders the timeline, the details of the selected version when expanded and the
+// status line
+func (m *tuiModel) View() string {
+	if m.height == 0 {
+		return "Loading…"
+	}
+	var b strings.Builder
+
+	width := len("VERSION")
+	for _, version := range m.versions {
+		width = max(width, len(version.Version))
+	}
+	b.WriteString(headerStyle.Render(ansi.Truncate(fmt.Sprintf("%-*s  %-12s  %-17s  %-19s  %8s  %s", width, "VERSION", "KIND", "STATUS", "APPLIED AT", "DURATION", "CHANGES"), m.width, "…")))
+	b.WriteString("\n")
+	rows := m.listHeight()
+	for i := m.offset; i < m.offset+rows; i++ {
+		if i < len(m.versions) {
+			b.WriteString(m.renderVersion(i, width))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.expanded {
+		b.WriteString(mutedStyle.Render(strings.Repeat("─", m.width)))
+		b.WriteString("\n")
+		lines := m.details
+		if lines == nil {
+			lines = []string{mutedStyle.Render("Loading…")}
+		}
+		for i := m.detailsOffset; i < m.detailsOffset+m.detailsHeight(); i++ {
+			if i < len(lines) {
+				b.WriteString(ansi.Truncate(lines[i], m.width, "…"))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	switch {
+	case m.confirm == rollbackAction:
+		b.WriteString(pendingStyle.Render(fmt.Sprintf("Roll back every migration recorded after %s? (y/n)", m.versions[m.cursor].Version)))
+	case m.confirm == approveAction:
+		b.WriteString(pendingStyle.Render(fmt.Sprintf("Approve the changes planned under %s? (y/n)", m.versions[m.cursor].Version)))
+	case m.err != nil:
+		b.WriteString(failedStyle.Render(ansi.Truncate(m.err.Error(), m.width, "…")))
+	case len(m.versions) == 0:
+		b.WriteString("No migrations recorded")
+	default:
+		b.WriteString(ansi.Truncate(m.message, m.width, "…"))
+	}
+	b.WriteString("\n")
+	b.WriteString(mutedStyle.Render(ansi.Truncate(tuiHelp, m.width, "…")))
+	return b.String()
+}
+
+// renderVersion renders the timeline row of the version at index i
+func (m *tuiModel) renderVersion(i, width int) string {
+	version := m.versions[i]
+	summary := ""
+	if changes, err := version.ParseChanges(); err == nil {
+		summary = changes.Summary()
+	}
+	if version.Error != "" {
+		summary = "error: " + version.Error
+	}
+	row := ansi.Truncate(fmt.Sprintf("%-*s  %-12s  %-17s  %-19s  %6dms  %s", width, version.Version, version.Kind, version.Status, version.AppliedAt.Format("2006-01-02 15:04:05"), version.DurationMs, summary), m.width, "…")
+
+	switch {
+	case i == m.cursor:
+		return selectedStyle.Render(row)
+	case version.Status == tracker.StatusFailed:
+		return failedStyle.Render(row)
+	case version.Status == tracker.StatusPending || version.Status == tracker.StatusAwaitingApproval || version.Status == tracker.StatusApproved:
+		return pendingStyle.Render(row)
+	default:
+		return row
+	}
+}
+
+// versionDetails renders the details of a version as lines, with its diff from previous when
+// a version was recorded before
+func versionDetails(db *gorm.DB, version tracker.SchemaVersion, previous string) ([]string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s, %s)\n", headerStyle.Render(version.Version), version.Kind, version.Status)
+	fmt.Fprintf(&b, "Applied at %s in %dms", version.AppliedAt.Format("2006-01-02 15:04:05"), version.DurationMs)
+	if version.InitiatedBy != "" {
+		fmt.Fprintf(&b, " by %s", version.InitiatedBy)
+	}
+	if version.Environment.Hostname != "" {
+		fmt.Fprintf(&b, " on %s", version.Environment.Hostname)
+	}
+	if version.Severity != "" {
+		fmt.Fprintf(&b, ", severity %s", version.Severity)
+	}
+	b.WriteString("\n")
+	if version.Error != "" {
+		fmt.Fprintf(&b, "%s\n", failedStyle.Render("Error: "+version.Error))
+	}
+
+	changes, err := version.ParseChanges()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "\n%s\n%s", headerStyle.Render("Changes"), changes)
+	if previous != "" {
+		// A diff that can't be computed is shown in place so the record stays readable
+		versionDiff, err := tracker.DiffVersions(db, previous, version.Version)
+		if err != nil {
+			fmt.Fprintf(&b, "\n%s\n%s\n", headerStyle.Render("Diff"), failedStyle.Render("Error: "+err.Error()))
+		} else {
+			fmt.Fprintf(&b, "\n%s\n%s", headerStyle.Render("Diff"), versionDiff)
+		}
+	}
+	if version.Statements != "" {
+		fmt.Fprintf(&b, "\n%s\n%s\n", headerStyle.Render("Statements"), version.Statements)
+	}
+	if version.DownStatements != "" {
+		fmt.Fprintf(&b, "\n%s\n%s\n", headerStyle.Render("Down statements"), version.DownStatements)
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n"), nil
+}
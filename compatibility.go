@@ -0,0 +1,162 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VersionRange is a range of schema versions, an empty bound leaves its side open
+type VersionRange struct {
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+}
+
+// Empty reports whether the range has no bounds
+func (r VersionRange) Empty() bool {
+	return r.Min == "" && r.Max == ""
+}
+
+// String renders the range in interval notation
+func (r VersionRange) String() string {
+	lower, upper := r.Min, r.Max
+	if lower == "" {
+		lower = "-inf"
+	}
+	if upper == "" {
+		upper = "+inf"
+	}
+	return fmt.Sprintf("[%s, %s]", lower, upper)
+}
+
+// SchemaCompatibility is the range of schema versions an application version declared it's
+// compatible with, recorded by Initialize with WithCompatibleVersions
+type SchemaCompatibility struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	AppVersion string    `gorm:"uniqueIndex;size:191" json:"app_version"`
+	MinVersion string    `json:"min_version,omitempty"`
+	MaxVersion string    `json:"max_version,omitempty"`
+	DeclaredAt time.Time `json:"declared_at"`
+}
+
+// compatibilityTableName returns the name of the compatibility table
+func compatibilityTableName(db *gorm.DB) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&SchemaCompatibility{}); err != nil {
+		return ""
+	}
+	return stmt.Table
+}
+
+// CompatibilityVerdict tells whether an application version can run against the current schema
+type CompatibilityVerdict struct {
+	AppVersion string `json:"app_version"`
+	// SchemaVersion is the version the database is at, empty when none is recorded
+	SchemaVersion string `json:"schema_version,omitempty"`
+	// AppliedBy is the application version that applied SchemaVersion
+	AppliedBy string `json:"applied_by,omitempty"`
+	// Range is the range of schema versions AppVersion declared, Declared is false when it
+	// declared none
+	Range      VersionRange `json:"range"`
+	Declared   bool         `json:"declared"`
+	Compatible bool         `json:"compatible"`
+	// Reason explains why the application version isn't compatible
+	Reason string `json:"reason,omitempty"`
+}
+
+// declareCompatibility records the compatible range of the application version of the plugin
+func (p *AutoMigratePlugin) declareCompatibility(db *gorm.DB) error {
+	p.Logger.Debug("Declaring schema versions %s compatible with %s", p.CompatibleVersions, p.AppVersion)
+	conn := p.historyConn(db)
+	if err := untrackedMigrator(conn).AutoMigrate(&SchemaCompatibility{}); err != nil {
+		p.Logger.Error("Failed to create compatibility table: %v", err)
+		return fmt.Errorf("failed to create compatibility table: %w", err)
+	}
+
+	declared := SchemaCompatibility{}
+	err := conn.Session(&gorm.Session{NewDB: true}).Where(SchemaCompatibility{AppVersion: p.AppVersion}).
+		Assign(SchemaCompatibility{MinVersion: p.CompatibleVersions.Min, MaxVersion: p.CompatibleVersions.Max, DeclaredAt: p.now()}).
+		FirstOrCreate(&declared).Error
+	if err != nil {
+		p.Logger.Error("Failed to declare schema compatibility: %v", err)
+		return fmt.Errorf("failed to declare schema compatibility: %w", err)
+	}
+	return nil
+}
+
+// compatibleRange returns the range declared by appVersion, that of the plugin when it's the
+// application version of the plugin
+func (p *AutoMigratePlugin) compatibleRange(db *gorm.DB, appVersion string) (VersionRange, bool, error) {
+	if appVersion == p.AppVersion && !p.CompatibleVersions.Empty() {
+		return p.CompatibleVersions, true, nil
+	}
+	conn := p.historyConn(db).Session(&gorm.Session{NewDB: true})
+	if !conn.Migrator().HasTable(&SchemaCompatibility{}) {
+		return VersionRange{}, false, nil
+	}
+	var declared []SchemaCompatibility
+	if err := conn.Where("app_version = ?", appVersion).Limit(1).Find(&declared).Error; err != nil {
+		return VersionRange{}, false, fmt.Errorf("failed to retrieve schema compatibility of %s: %w", appVersion, err)
+	}
+	if len(declared) == 0 {
+		return VersionRange{}, false, nil
+	}
+	return VersionRange{Min: declared[0].MinVersion, Max: declared[0].MaxVersion}, true, nil
+}
+
+// CheckCompatibilityContext is like CheckCompatibility but cancels the queries when ctx is done
+func CheckCompatibilityContext(ctx context.Context, db *gorm.DB, appVersion string) (*CompatibilityVerdict, error) {
+	return CheckCompatibility(db.WithContext(ctx), appVersion)
+}
+
+// CheckCompatibility tells whether an application version can run against the current schema,
+// given the range of schema versions it declared with WithCompatibleVersions, so a rolling
+// deploy can hold back a release, or keep older replicas running, across a schema change.
+// An application version that declared no range isn't considered compatible. An empty
+// appVersion checks the AppVersion of the plugin.
+func CheckCompatibility(db *gorm.DB, appVersion string) (*CompatibilityVerdict, error) {
+	p := registeredPlugin(db)
+	if appVersion == "" {
+		appVersion = p.AppVersion
+	}
+	p.Logger.Debug("CheckCompatibility function called for %s", appVersion)
+
+	verdict := &CompatibilityVerdict{AppVersion: appVersion}
+	current, err := currentVersion(db)
+	if err != nil && !errors.Is(err, ErrVersionNotFound) {
+		p.Logger.Error("Failed to retrieve the current schema version: %v", err)
+		return nil, err
+	}
+	if current != nil {
+		verdict.SchemaVersion, verdict.AppliedBy = current.Version, current.Environment.AppVersion
+	}
+
+	verdict.Range, verdict.Declared, err = p.compatibleRange(db, appVersion)
+	if err != nil {
+		p.Logger.Error("Failed to retrieve schema compatibility: %v", err)
+		return nil, err
+	}
+
+	switch {
+	case !verdict.Declared:
+		verdict.Reason = fmt.Sprintf("app version %s declared no compatible schema versions", appVersion)
+	case current == nil && verdict.Range.Min != "":
+		verdict.Reason = fmt.Sprintf("no schema version recorded, %s required", verdict.Range.Min)
+	case current != nil && verdict.Range.Min != "" && p.CompareVersions(current.Version, verdict.Range.Min) < 0:
+		verdict.Reason = fmt.Sprintf("schema version %s is older than %s", current.Version, verdict.Range.Min)
+	case current != nil && verdict.Range.Max != "" && p.CompareVersions(current.Version, verdict.Range.Max) > 0:
+		verdict.Reason = fmt.Sprintf("schema version %s is newer than %s", current.Version, verdict.Range.Max)
+	default:
+		verdict.Compatible = true
+	}
+
+	if verdict.Compatible {
+		p.Logger.Info("App version %s is compatible with schema version %s", appVersion, verdict.SchemaVersion)
+	} else {
+		p.Logger.Warn("App version %s isn't compatible with the schema: %s", appVersion, verdict.Reason)
+	}
+	return verdict, nil
+}
@@ -0,0 +1,41 @@
+package gorm_migrate_tracker
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	old, _ := openTestDBAt(t, path, WithAppVersion("1.0.0"), WithCompatibleVersions("1", "2"))
+	migrateAs(t, old, "1", &pluginUser{})
+	migrateAs(t, old, "2", &pluginUserWithEmail{})
+	current, _ := openTestDBAt(t, path, WithAppVersion("2.0.0"), WithCompatibleVersions("3", ""))
+
+	verdict, err := CheckCompatibility(old, "")
+	if err != nil {
+		t.Fatalf("CheckCompatibility: %v", err)
+	}
+	if !verdict.Compatible || verdict.SchemaVersion != "2" || verdict.AppliedBy != "1.0.0" {
+		t.Errorf("verdict of 1.0.0 = %+v, want compatible with version 2 it applied", verdict)
+	}
+	// The range declared by another instance is read from the compatibility table
+	verdict, err = CheckCompatibility(old, "2.0.0")
+	if err != nil {
+		t.Fatalf("CheckCompatibility: %v", err)
+	}
+	if verdict.Compatible || !verdict.Declared || verdict.Range != (VersionRange{Min: "3"}) {
+		t.Errorf("verdict of 2.0.0 = %+v, want the schema too old for its declared range", verdict)
+	}
+
+	migrateAs(t, current, "3", &squashedOrder{})
+	if verdict, err := CheckCompatibility(current, ""); err != nil || !verdict.Compatible || verdict.AppliedBy != "2.0.0" {
+		t.Errorf("verdict of 2.0.0 = %+v, %v, want compatible with version 3 it applied", verdict, err)
+	}
+	if verdict, err := CheckCompatibility(current, "1.0.0"); err != nil || verdict.Compatible || verdict.Reason != "schema version 3 is newer than 2" {
+		t.Errorf("verdict of 1.0.0 = %+v, %v, want the schema too new", verdict, err)
+	}
+	if verdict, err := CheckCompatibility(current, "0.9.0"); err != nil || verdict.Compatible || verdict.Declared {
+		t.Errorf("verdict of 0.9.0 = %+v, %v, want an undeclared version incompatible", verdict, err)
+	}
+}
@@ -0,0 +1,102 @@
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compressedPrefix marks column values compressed by the plugin, it is followed by the name
+// of the compressor and a colon
+const compressedPrefix = "z:"
+
+// CompressionThreshold is the size in bytes from which column values are compressed, smaller
+// values are stored as is
+const CompressionThreshold = 1024
+
+// Compressor compresses the changes, statements and snapshot of every SchemaVersion, see
+// GzipCompressor and the contrib/zstd module
+type Compressor interface {
+	// Name identifies the format in stored values, so they are decompressed with the same one
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor compresses with gzip at Level, gzip.DefaultCompression when zero. Values
+// compressed with gzip can always be read, whichever Compressor is configured.
+type GzipCompressor struct {
+	Level int
+}
+
+// Name returns "gzip"
+func (GzipCompressor) Name() string {
+	return "gzip"
+}
+
+// Compress gzips data
+func (c GzipCompressor) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress gunzips data
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// compress encodes value with the compressor under its format marker
+func compress(compressor Compressor, value string) (string, error) {
+	compressed, err := compressor.Compress([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return compressedPrefix + compressor.Name() + ":" + base64.StdEncoding.EncodeToString(compressed), nil
+}
+
+// decompress decodes a value encoded by compress, with the configured compressor or gzip
+func decompress(compressor Compressor, value string) (string, error) {
+	name, encoded, ok := strings.Cut(strings.TrimPrefix(value, compressedPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed compressed value")
+	}
+	switch {
+	case compressor != nil && compressor.Name() == name:
+	case name == (GzipCompressor{}).Name():
+		compressor = GzipCompressor{}
+	default:
+		return "", fmt.Errorf("no %s compressor configured", name)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	decompressed, err := compressor.Decompress(compressed)
+	if err != nil {
+		return "", err
+	}
+	return string(decompressed), nil
+}
@@ -0,0 +1,115 @@
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reversingCompressor is a Compressor with its own format, it only reverses the data
+type reversingCompressor struct{}
+
+func (reversingCompressor) Name() string { return "reverse" }
+
+func (reversingCompressor) Compress(data []byte) ([]byte, error) {
+	reversed := bytes.Clone(data)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return reversed, nil
+}
+
+func (c reversingCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.Compress(data)
+}
+
+// largeVersion returns a successful record whose statements exceed CompressionThreshold
+func largeVersion(version string) SchemaVersion {
+	return SchemaVersion{
+		Version:    version,
+		Kind:       KindMigration,
+		Status:     StatusSuccess,
+		AppliedAt:  time.Now(),
+		Changes:    "{}",
+		Statements: strings.Repeat("CREATE INDEX `idx_a` ON `a`(`b`);\n", 64),
+	}
+}
+
+func TestCompressedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, _ := openTestDBAt(t, path, WithCompression(GzipCompressor{}))
+	large := largeVersion("1")
+	if err := historyDB(db).Create(&large).Error; err != nil {
+		t.Fatalf("failed to record version 1: %v", err)
+	}
+
+	stored := storedColumn(t, db, "1", "statements")
+	if !strings.HasPrefix(stored, compressedPrefix+"gzip:") {
+		t.Fatalf("statements stored as %.40q, want them gzipped", stored)
+	}
+	if len(stored) >= len(large.Statements) {
+		t.Errorf("statements stored in %d bytes, want less than %d", len(stored), len(large.Statements))
+	}
+	// Values under the threshold are stored as is
+	if changes := storedColumn(t, db, "1", "changes"); changes != "{}" {
+		t.Errorf("changes stored as %q, want them left uncompressed", changes)
+	}
+
+	want := largeVersion("1").Statements
+	if large.Statements != want {
+		t.Error("the saved record wasn't decompressed after saving")
+	}
+	if recorded := mustRecorded(t, db, "1"); recorded.Statements != want {
+		t.Errorf("statements read back as %.40q, want them decompressed", recorded.Statements)
+	}
+
+	// Gzipped values can be read without a Compressor
+	reader, _ := openTestDBAt(t, path)
+	if recorded := mustRecorded(t, reader, "1"); recorded.Statements != want {
+		t.Errorf("statements read without a compressor as %.40q, want them decompressed", recorded.Statements)
+	}
+}
+
+func TestCustomCompressor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, _ := openTestDBAt(t, path, WithCompression(reversingCompressor{}))
+	large := largeVersion("1")
+	if err := historyDB(db).Create(&large).Error; err != nil {
+		t.Fatalf("failed to record version 1: %v", err)
+	}
+	if stored := storedColumn(t, db, "1", "statements"); !strings.HasPrefix(stored, compressedPrefix+"reverse:") {
+		t.Fatalf("statements stored as %.40q, want them in the reverse format", stored)
+	}
+	if recorded := mustRecorded(t, db, "1"); recorded.Statements != largeVersion("1").Statements {
+		t.Errorf("statements read back as %.40q, want them decompressed", recorded.Statements)
+	}
+
+	// Only gzip can be read without the compressor that wrote the values
+	reader, _ := openTestDBAt(t, path)
+	if _, err := GetMigrationHistory(reader); err == nil {
+		t.Error("GetMigrationHistory without the reverse compressor succeeded")
+	}
+}
+
+func TestCompressedAndEncryptedHistory(t *testing.T) {
+	encryptor, err := NewAESEncryptor(testEncryptionKey)
+	if err != nil {
+		t.Fatalf("NewAESEncryptor: %v", err)
+	}
+	db, _ := openTestDB(t, WithCompression(GzipCompressor{}), WithEncryptor(encryptor))
+	large := largeVersion("1")
+	if err := historyDB(db).Create(&large).Error; err != nil {
+		t.Fatalf("failed to record version 1: %v", err)
+	}
+
+	// Compressed first, as ciphertext doesn't compress
+	stored := storedColumn(t, db, "1", "statements")
+	if !strings.HasPrefix(stored, encryptedPrefix) || len(stored) >= len(large.Statements) {
+		t.Errorf("statements stored as %.40q in %d bytes, want them compressed then encrypted", stored, len(stored))
+	}
+	if recorded := mustRecorded(t, db, "1"); recorded.Statements != largeVersion("1").Statements {
+		t.Errorf("statements read back as %.40q, want them decrypted and decompressed", recorded.Statements)
+	}
+}
@@ -0,0 +1,411 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Types of table constraints
+const (
+	ConstraintForeignKey = "foreign_key"
+	ConstraintCheck      = "check"
+	ConstraintUnique     = "unique"
+)
+
+// ConstraintSchema is the definition of a foreign key, check or unique constraint
+type ConstraintSchema struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Columns []string `json:"columns,omitempty"`
+	// ReferencedTable, ReferencedColumns, OnDelete and OnUpdate describe foreign keys
+	ReferencedTable   string   `json:"referenced_table,omitempty"`
+	ReferencedColumns []string `json:"referenced_columns,omitempty"`
+	OnDelete          string   `json:"on_delete,omitempty"`
+	OnUpdate          string   `json:"on_update,omitempty"`
+	// Expression is the condition of a check constraint
+	Expression string `json:"expression,omitempty"`
+}
+
+// String renders the definition of the constraint
+func (c ConstraintSchema) String() string {
+	switch c.Type {
+	case ConstraintForeignKey:
+		definition := fmt.Sprintf("foreign key (%s) references %s (%s)", strings.Join(c.Columns, ", "), c.ReferencedTable, strings.Join(c.ReferencedColumns, ", "))
+		if c.OnDelete != "" {
+			definition += " on delete " + strings.ToLower(c.OnDelete)
+		}
+		if c.OnUpdate != "" {
+			definition += " on update " + strings.ToLower(c.OnUpdate)
+		}
+		return definition
+	case ConstraintCheck:
+		return "check " + c.Expression
+	default:
+		return fmt.Sprintf("%s (%s)", c.Type, strings.Join(c.Columns, ", "))
+	}
+}
+
+// equal reports whether two constraints have the same definition
+func (c ConstraintSchema) equal(other ConstraintSchema) bool {
+	return c.Type == other.Type && slices.Equal(c.Columns, other.Columns) &&
+		c.ReferencedTable == other.ReferencedTable && slices.Equal(c.ReferencedColumns, other.ReferencedColumns) &&
+		c.OnDelete == other.OnDelete && c.OnUpdate == other.OnUpdate && c.Expression == other.Expression
+}
+
+// ConstraintDiff is a foreign key, check or unique constraint added to or dropped from a table,
+// a changed constraint is reported as dropped and added again
+type ConstraintDiff struct {
+	Table      string     `json:"table"`
+	Constraint string     `json:"constraint"`
+	Kind       ChangeKind `json:"kind"`
+	Type       string     `json:"type"`
+	Columns    []string   `json:"columns,omitempty"`
+	// ReferencedTable, ReferencedColumns, OnDelete and OnUpdate describe foreign keys
+	ReferencedTable   string   `json:"referenced_table,omitempty"`
+	ReferencedColumns []string `json:"referenced_columns,omitempty"`
+	OnDelete          string   `json:"on_delete,omitempty"`
+	OnUpdate          string   `json:"on_update,omitempty"`
+	Expression        string   `json:"expression,omitempty"`
+}
+
+// newConstraintDiff describes the change of a constraint of the table
+func newConstraintDiff(table string, kind ChangeKind, constraint ConstraintSchema) ConstraintDiff {
+	return ConstraintDiff{
+		Table:             table,
+		Constraint:        constraint.Name,
+		Kind:              kind,
+		Type:              constraint.Type,
+		Columns:           constraint.Columns,
+		ReferencedTable:   constraint.ReferencedTable,
+		ReferencedColumns: constraint.ReferencedColumns,
+		OnDelete:          constraint.OnDelete,
+		OnUpdate:          constraint.OnUpdate,
+		Expression:        constraint.Expression,
+	}
+}
+
+// String renders the diff as a single human readable line
+func (d ConstraintDiff) String() string {
+	definition := ConstraintSchema{
+		Type:              d.Type,
+		Columns:           d.Columns,
+		ReferencedTable:   d.ReferencedTable,
+		ReferencedColumns: d.ReferencedColumns,
+		OnDelete:          d.OnDelete,
+		OnUpdate:          d.OnUpdate,
+		Expression:        d.Expression,
+	}
+	return fmt.Sprintf("%s.%s constraint %s (%s)", d.Table, d.Constraint, d.Kind, definition)
+}
+
+// constraint returns the named constraint of the table, if present
+func (t *TableSchema) constraint(name string) (ConstraintSchema, bool) {
+	for _, constraint := range t.Constraints {
+		if strings.EqualFold(constraint.Name, name) {
+			return constraint, true
+		}
+	}
+	return ConstraintSchema{}, false
+}
+
+// diffConstraints computes the constraints added and dropped between two table definitions
+func diffConstraints(old, new *TableSchema) []ConstraintDiff {
+	var diffs []ConstraintDiff
+	for _, constraint := range old.Constraints {
+		if current, ok := new.constraint(constraint.Name); !ok || !current.equal(constraint) {
+			diffs = append(diffs, newConstraintDiff(old.Name, ConstraintDropped, constraint))
+		}
+	}
+	for _, constraint := range new.Constraints {
+		if previous, ok := old.constraint(constraint.Name); !ok || !previous.equal(constraint) {
+			diffs = append(diffs, newConstraintDiff(new.Name, ConstraintAdded, constraint))
+		}
+	}
+	return diffs
+}
+
+// modelConstraints returns the foreign keys and checks the parsed model declares
+func modelConstraints(modelSchema *schema.Schema) []ConstraintSchema {
+	var constraints []ConstraintSchema
+	for _, relationship := range modelSchema.Relationships.Relations {
+		constraint := relationship.ParseConstraint()
+		if constraint == nil || constraint.Schema != modelSchema {
+			continue
+		}
+		foreignKey := ConstraintSchema{
+			Name:            constraint.Name,
+			Type:            ConstraintForeignKey,
+			ReferencedTable: constraint.ReferenceSchema.Table,
+			OnDelete:        strings.ToUpper(constraint.OnDelete),
+			OnUpdate:        strings.ToUpper(constraint.OnUpdate),
+		}
+		for _, field := range constraint.ForeignKeys {
+			foreignKey.Columns = append(foreignKey.Columns, field.DBName)
+		}
+		for _, field := range constraint.References {
+			foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, field.DBName)
+		}
+		constraints = append(constraints, foreignKey)
+	}
+	for _, check := range modelSchema.ParseCheckConstraints() {
+		constraints = append(constraints, ConstraintSchema{Name: check.Name, Type: ConstraintCheck, Expression: check.Constraint})
+	}
+	slices.SortFunc(constraints, func(a, b ConstraintSchema) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return constraints
+}
+
+// inspectConstraints introspects the constraints of a live table, returning nil for dialects
+// whose constraints can't be listed
+func inspectConstraints(db *gorm.DB, table string) ([]ConstraintSchema, error) {
+	var constraints []ConstraintSchema
+	var err error
+	switch db.Dialector.Name() {
+	case "postgres":
+		constraints, err = postgresConstraints(db, table)
+	case "mysql":
+		constraints, err = mysqlConstraints(db, table)
+	case "sqlite":
+		constraints, err = sqliteConstraints(db, table)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect constraints of %s: %w", table, err)
+	}
+	slices.SortFunc(constraints, func(a, b ConstraintSchema) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return constraints, nil
+}
+
+// constraintRow is a constraint as listed by the information schema queries
+type constraintRow struct {
+	Name              string
+	Type              string
+	Columns           string
+	ReferencedTable   string
+	ReferencedColumns string
+	OnDelete          string
+	OnUpdate          string
+	Expression        string
+}
+
+// schema converts the row, with comma separated column lists
+func (r constraintRow) schema() ConstraintSchema {
+	constraint := ConstraintSchema{
+		Name:            r.Name,
+		Type:            r.Type,
+		ReferencedTable: r.ReferencedTable,
+		OnDelete:        r.OnDelete,
+		OnUpdate:        r.OnUpdate,
+		Expression:      trimParentheses(r.Expression),
+	}
+	if r.Columns != "" {
+		constraint.Columns = strings.Split(r.Columns, ",")
+	}
+	if r.ReferencedColumns != "" {
+		constraint.ReferencedColumns = strings.Split(r.ReferencedColumns, ",")
+	}
+	return constraint
+}
+
+// postgresConstraintsQuery lists the constraints of a table of the current schema, or of the
+// given schema
+const postgresConstraintsQuery = `SELECT con.conname AS name,
+	CASE con.contype WHEN 'f' THEN 'foreign_key' WHEN 'c' THEN 'check' ELSE 'unique' END AS type,
+	COALESCE((SELECT string_agg(att.attname, ',' ORDER BY key.n) FROM unnest(con.conkey) WITH ORDINALITY AS key(attnum, n)
+		JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = key.attnum), '') AS columns,
+	COALESCE(ref.relname, '') AS referenced_table,
+	COALESCE((SELECT string_agg(att.attname, ',' ORDER BY key.n) FROM unnest(con.confkey) WITH ORDINALITY AS key(attnum, n)
+		JOIN pg_attribute att ON att.attrelid = con.confrelid AND att.attnum = key.attnum), '') AS referenced_columns,
+	CASE WHEN con.contype <> 'f' THEN '' WHEN con.confdeltype = 'c' THEN 'CASCADE' WHEN con.confdeltype = 'n' THEN 'SET NULL'
+		WHEN con.confdeltype = 'd' THEN 'SET DEFAULT' WHEN con.confdeltype = 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END AS on_delete,
+	CASE WHEN con.contype <> 'f' THEN '' WHEN con.confupdtype = 'c' THEN 'CASCADE' WHEN con.confupdtype = 'n' THEN 'SET NULL'
+		WHEN con.confupdtype = 'd' THEN 'SET DEFAULT' WHEN con.confupdtype = 'r' THEN 'RESTRICT' ELSE 'NO ACTION' END AS on_update,
+	CASE WHEN con.contype = 'c' THEN pg_get_expr(con.conbin, con.conrelid) ELSE '' END AS expression
+FROM pg_constraint con
+JOIN pg_class rel ON rel.oid = con.conrelid
+JOIN pg_namespace nsp ON nsp.oid = rel.relnamespace
+LEFT JOIN pg_class ref ON ref.oid = con.confrelid
+WHERE rel.relname = ? AND nsp.nspname = COALESCE(NULLIF(?, ''), current_schema()) AND con.contype IN ('f', 'c', 'u')`
+
+// postgresConstraints lists the constraints of a Postgres table
+func postgresConstraints(db *gorm.DB, table string) ([]ConstraintSchema, error) {
+	schemaName, tableName := "", table
+	if before, after, ok := strings.Cut(table, "."); ok {
+		schemaName, tableName = before, after
+	}
+	var rows []constraintRow
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw(postgresConstraintsQuery, tableName, schemaName).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	constraints := make([]ConstraintSchema, len(rows))
+	for i, row := range rows {
+		constraints[i] = row.schema()
+	}
+	return constraints, nil
+}
+
+// mysqlConstraintsQuery lists the constraints of a table of the current database, it needs
+// the CHECK_CONSTRAINTS table of MySQL 8.0.16 and MariaDB 10.2.22
+const mysqlConstraintsQuery = `SELECT tc.CONSTRAINT_NAME AS name,
+	CASE tc.CONSTRAINT_TYPE WHEN 'FOREIGN KEY' THEN 'foreign_key' WHEN 'CHECK' THEN 'check' ELSE 'unique' END AS type,
+	COALESCE(GROUP_CONCAT(kcu.COLUMN_NAME ORDER BY kcu.ORDINAL_POSITION), '') AS columns,
+	COALESCE(MAX(kcu.REFERENCED_TABLE_NAME), '') AS referenced_table,
+	COALESCE(GROUP_CONCAT(kcu.REFERENCED_COLUMN_NAME ORDER BY kcu.ORDINAL_POSITION), '') AS referenced_columns,
+	COALESCE(MAX(rc.DELETE_RULE), '') AS on_delete,
+	COALESCE(MAX(rc.UPDATE_RULE), '') AS on_update,
+	COALESCE(MAX(cc.CHECK_CLAUSE), '') AS expression
+FROM information_schema.TABLE_CONSTRAINTS tc
+LEFT JOIN information_schema.KEY_COLUMN_USAGE kcu ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+	AND kcu.TABLE_NAME = tc.TABLE_NAME AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+LEFT JOIN information_schema.REFERENTIAL_CONSTRAINTS rc ON rc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+	AND rc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+LEFT JOIN information_schema.CHECK_CONSTRAINTS cc ON cc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+	AND cc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+WHERE tc.TABLE_SCHEMA = DATABASE() AND tc.TABLE_NAME = ? AND tc.CONSTRAINT_TYPE IN ('FOREIGN KEY', 'CHECK', 'UNIQUE')
+GROUP BY tc.CONSTRAINT_NAME, tc.CONSTRAINT_TYPE`
+
+// mysqlConstraints lists the constraints of a MySQL table
+func mysqlConstraints(db *gorm.DB, table string) ([]ConstraintSchema, error) {
+	var rows []constraintRow
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw(mysqlConstraintsQuery, table).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	constraints := make([]ConstraintSchema, len(rows))
+	for i, row := range rows {
+		constraints[i] = row.schema()
+	}
+	return constraints, nil
+}
+
+var (
+	// sqliteConstraintPattern matches a table constraint of a CREATE TABLE statement
+	sqliteConstraintPattern = regexp.MustCompile(`(?is)^(?:CONSTRAINT\s+["` + "`" + `]?(\w+)["` + "`" + `]?\s+)?(FOREIGN\s+KEY|CHECK|UNIQUE)\b\s*(.*)$`)
+	// sqliteReferencesPattern matches the columns and actions of a foreign key
+	sqliteReferencesPattern = regexp.MustCompile(`(?is)^\(([^)]*)\)\s*REFERENCES\s+["` + "`" + `]?(\w+)["` + "`" + `]?\s*\(([^)]*)\)(.*)$`)
+	// sqliteActionPattern matches an ON DELETE or ON UPDATE action
+	sqliteActionPattern = regexp.MustCompile(`(?i)ON\s+(DELETE|UPDATE)\s+(SET\s+NULL|SET\s+DEFAULT|CASCADE|RESTRICT|NO\s+ACTION)`)
+)
+
+// sqliteConstraints parses the constraints of the CREATE TABLE statement of a SQLite table
+func sqliteConstraints(db *gorm.DB, table string) ([]ConstraintSchema, error) {
+	var ddl string
+	err := db.Session(&gorm.Session{NewDB: true}).
+		Raw("SELECT COALESCE(sql, '') FROM sqlite_master WHERE type = 'table' AND name = ?", table).Row().Scan(&ddl)
+	if err != nil {
+		return nil, err
+	}
+	start, end := strings.Index(ddl, "("), strings.LastIndex(ddl, ")")
+	if start < 0 || end < start {
+		return nil, nil
+	}
+
+	var constraints []ConstraintSchema
+	for _, definition := range splitDefinitions(ddl[start+1 : end]) {
+		match := sqliteConstraintPattern.FindStringSubmatch(definition)
+		if match == nil {
+			continue
+		}
+		constraint := ConstraintSchema{Name: match[1]}
+		body := strings.TrimSpace(match[3])
+		switch strings.ToUpper(strings.Join(strings.Fields(match[2]), " ")) {
+		case "FOREIGN KEY":
+			references := sqliteReferencesPattern.FindStringSubmatch(body)
+			if references == nil {
+				continue
+			}
+			constraint.Type = ConstraintForeignKey
+			constraint.Columns = identifierList(references[1])
+			constraint.ReferencedTable = references[2]
+			constraint.ReferencedColumns = identifierList(references[3])
+			for _, action := range sqliteActionPattern.FindAllStringSubmatch(references[4], -1) {
+				rule := strings.ToUpper(strings.Join(strings.Fields(action[2]), " "))
+				if strings.EqualFold(action[1], "DELETE") {
+					constraint.OnDelete = rule
+				} else {
+					constraint.OnUpdate = rule
+				}
+			}
+		case "CHECK":
+			constraint.Type = ConstraintCheck
+			constraint.Expression = trimParentheses(body)
+		default:
+			constraint.Type = ConstraintUnique
+			constraint.Columns = identifierList(trimParentheses(body))
+		}
+		if constraint.Name == "" {
+			// Unnamed constraints are told apart by their definition
+			constraint.Name = constraint.String()
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, nil
+}
+
+// splitDefinitions splits the body of a CREATE TABLE statement on the commas outside of
+// parentheses and quotes
+func splitDefinitions(body string) []string {
+	var definitions []string
+	var depth int
+	var quote rune
+	start := 0
+	for i, r := range body {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			definitions = append(definitions, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	return append(definitions, strings.TrimSpace(body[start:]))
+}
+
+// identifierList splits a comma separated list of possibly quoted identifiers
+func identifierList(list string) []string {
+	var identifiers []string
+	for _, identifier := range strings.Split(list, ",") {
+		if identifier = strings.Trim(strings.TrimSpace(identifier), "`\"[]"); identifier != "" {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	return identifiers
+}
+
+// trimParentheses removes the parentheses wrapping a whole expression
+func trimParentheses(expression string) string {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "(") || !strings.HasSuffix(expression, ")") {
+		return expression
+	}
+	depth := 0
+	for i, r := range expression {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(expression)-1 {
+				// The opening parenthesis closes before the end, as in (a) AND (b)
+				return expression
+			}
+		}
+	}
+	return strings.TrimSpace(expression[1 : len(expression)-1])
+}
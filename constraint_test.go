@@ -0,0 +1,71 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"testing"
+)
+
+type constraintCustomer struct {
+	ID   uint
+	Name string
+}
+
+func (constraintCustomer) TableName() string { return "customers" }
+
+type constraintOrder struct {
+	ID         uint
+	CustomerID uint
+	Total      int
+}
+
+func (constraintOrder) TableName() string { return "orders" }
+
+type constrainedOrder struct {
+	ID         uint
+	CustomerID uint
+	Customer   constraintCustomer `gorm:"constraint:OnDelete:CASCADE"`
+	Total      int                `gorm:"check:chk_orders_total,total >= 0"`
+}
+
+func (constrainedOrder) TableName() string { return "orders" }
+
+func TestSQLiteConstraints(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &constraintCustomer{}, &constrainedOrder{})
+
+	constraints, err := inspectConstraints(db, "orders")
+	if err != nil {
+		t.Fatalf("inspectConstraints: %v", err)
+	}
+	expected, err := modelTableSchema(db, &constrainedOrder{})
+	if err != nil {
+		t.Fatalf("modelTableSchema: %v", err)
+	}
+	// The live constraints match the ones the model declares
+	if want := expected.Constraints; !slices.EqualFunc(constraints, want, ConstraintSchema.equal) {
+		t.Errorf("constraints = %v, want %v", constraints, want)
+	}
+}
+
+func TestAutoMigrateRecordsConstraintChanges(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &constraintCustomer{}, &constraintOrder{})
+	migrateAs(t, db, "2", &constrainedOrder{})
+
+	changes, err := mustRecorded(t, db, "2").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 {
+		t.Fatalf("recorded changes of %d models, want 1", len(changes.Models))
+	}
+	want := []ConstraintDiff{
+		{Table: "orders", Constraint: "chk_orders_total", Kind: ConstraintAdded, Type: ConstraintCheck, Expression: "total >= 0"},
+		{Table: "orders", Constraint: "fk_orders_customer", Kind: ConstraintAdded, Type: ConstraintForeignKey, Columns: []string{"customer_id"},
+			ReferencedTable: "customers", ReferencedColumns: []string{"id"}, OnDelete: "CASCADE"},
+	}
+	got := changes.Models[0].Constraints
+	if !slices.EqualFunc(got, want, func(a, b ConstraintDiff) bool { return a.String() == b.String() && a.Kind == b.Kind }) {
+		t.Errorf("recorded constraint changes =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Package datadogtracker traces AutoMigratePlugin activity with Datadog APM and submits its
+// metrics to DogStatsD
+package datadogtracker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Names of the submitted metrics
+const (
+	MetricMigrations = "gorm.automigrate.migrations"
+	MetricFailures   = "gorm.automigrate.failures"
+	MetricDuration   = "gorm.automigrate.duration"
+	MetricStatements = "gorm.automigrate.statements"
+)
+
+// WithDatadog traces every AutoMigrate run as service and submits its metrics through client,
+// start the tracer with tracer.Start first
+func WithDatadog(service string, client statsd.ClientInterface) tracker.Option {
+	return func(p *tracker.AutoMigratePlugin) {
+		tracker.WithTracer(NewTracer(service))(p)
+		tracker.WithMetrics(NewMetrics(service, client))(p)
+	}
+}
+
+// Tracer adapts dd-trace-go to tracker.Tracer
+type Tracer struct {
+	service string
+}
+
+var _ tracker.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer reporting spans under service, the service of the global tracer
+// is used when empty
+func NewTracer(service string) *Tracer {
+	return &Tracer{service: service}
+}
+
+// StartMigration starts the span of an AutoMigrate invocation
+func (t *Tracer) StartMigration(ctx context.Context) (context.Context, tracker.Span) {
+	return t.start(ctx, "AutoMigrate")
+}
+
+// StartModel starts the span of a single migrated model
+func (t *Tracer) StartModel(ctx context.Context, model string) (context.Context, tracker.Span) {
+	return t.start(ctx, "AutoMigrate "+model)
+}
+
+// start starts a gorm.automigrate span for resource
+func (t *Tracer) start(ctx context.Context, resource string) (context.Context, tracker.Span) {
+	options := []ddtrace.StartSpanOption{tracer.ResourceName(resource), tracer.SpanType("db")}
+	if t.service != "" {
+		options = append(options, tracer.ServiceName(t.service))
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, "gorm.automigrate", options...)
+	return ctx, &Span{span: span}
+}
+
+// Span adapts a Datadog span to tracker.Span
+type Span struct {
+	span ddtrace.Span
+}
+
+// SetAttribute sets the value as a span tag
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if values, ok := value.([]string); ok {
+		value = fmt.Sprint(values)
+	}
+	s.span.SetTag(key, value)
+}
+
+// End finishes the span, flagging it with err, if any
+func (s *Span) End(err error) {
+	s.span.Finish(tracer.WithError(err))
+}
+
+// Metrics is a tracker.MetricsRecorder submitting the measurements of every migration to
+// DogStatsD, tagged with the service and the migration version and status
+type Metrics struct {
+	client statsd.ClientInterface
+	tags   []string
+}
+
+var _ tracker.MetricsRecorder = (*Metrics)(nil)
+
+// NewMetrics creates Metrics submitting through client with the given service tag and extra tags
+func NewMetrics(service string, client statsd.ClientInterface, tags ...string) *Metrics {
+	if service != "" {
+		tags = append([]string{"service:" + service}, tags...)
+	}
+	return &Metrics{client: client, tags: tags}
+}
+
+// ObserveMigration submits the measurements of an AutoMigrate run, submission errors are dropped
+// like DogStatsD drops undeliverable datagrams
+func (m *Metrics) ObserveMigration(metrics tracker.MigrationMetrics) {
+	status := tracker.StatusSuccess
+	if metrics.Err != nil {
+		status = tracker.StatusFailed
+	}
+	tags := append(append([]string(nil), m.tags...), "migration_version:"+metrics.Version, "status:"+status)
+
+	m.client.Incr(MetricMigrations, tags, 1)
+	if metrics.Err != nil {
+		m.client.Incr(MetricFailures, tags, 1)
+	}
+	m.client.Timing(MetricDuration, metrics.Duration, tags, 1)
+	m.client.Count(MetricStatements, int64(metrics.Statements), tags, 1)
+}
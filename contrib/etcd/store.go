@@ -0,0 +1,157 @@
+// Package etcdtracker keeps the history recorded by AutoMigratePlugin in etcd
+package etcdtracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultPrefix is the key prefix the history is kept under when none is configured
+const DefaultPrefix = "/gorm-migrate-tracer/"
+
+// WithEtcd keeps the history in etcd under prefix, DefaultPrefix when empty
+func WithEtcd(client *clientv3.Client, prefix string) tracker.Option {
+	return tracker.WithHistoryStore(NewStore(client, prefix))
+}
+
+// Store is a tracker.HistoryStore keeping every version as a JSON value under its own key.
+// IDs and versions are claimed in transactions, so instances sharing the store never record
+// the same one twice.
+type Store struct {
+	Client *clientv3.Client
+	Prefix string
+}
+
+var _ tracker.HistoryStore = (*Store)(nil)
+
+// NewStore creates a Store keeping the history under prefix, DefaultPrefix when empty
+func NewStore(client *clientv3.Client, prefix string) *Store {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Store{Client: client, Prefix: prefix}
+}
+
+// Init does nothing, keys are created as versions are recorded
+func (s *Store) Init(_ context.Context) error {
+	return nil
+}
+
+// Create stores schemaVersion under the ID following the highest stored one
+func (s *Store) Create(ctx context.Context, schemaVersion *tracker.SchemaVersion) error {
+	for {
+		last, err := s.Client.Get(ctx, s.idPrefix(), clientv3.WithLastKey()...)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the last schema version: %w", err)
+		}
+		var id uint
+		if len(last.Kvs) > 0 {
+			var stored tracker.SchemaVersion
+			if err := json.Unmarshal(last.Kvs[0].Value, &stored); err != nil {
+				return fmt.Errorf("failed to decode schema version: %w", err)
+			}
+			id = stored.ID
+		}
+		schemaVersion.ID = id + 1
+
+		value, err := json.Marshal(schemaVersion)
+		if err != nil {
+			return fmt.Errorf("failed to encode schema version %s: %w", schemaVersion.Version, err)
+		}
+		idKey, versionKey := s.idKey(schemaVersion.ID), s.versionKey(schemaVersion.Version)
+		response, err := s.Client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(idKey), "=", 0), clientv3.Compare(clientv3.CreateRevision(versionKey), "=", 0)).
+			Then(clientv3.OpPut(idKey, string(value)), clientv3.OpPut(versionKey, strconv.FormatUint(uint64(schemaVersion.ID), 10))).
+			Else(clientv3.OpGet(versionKey)).
+			Commit()
+		if err != nil {
+			return fmt.Errorf("failed to store schema version %s: %w", schemaVersion.Version, err)
+		}
+		if response.Succeeded {
+			return nil
+		}
+		if len(response.Responses[0].GetResponseRange().Kvs) > 0 {
+			return fmt.Errorf("schema version %s is already recorded", schemaVersion.Version)
+		}
+		// Another instance claimed the ID in the meantime
+	}
+}
+
+// Update replaces the version stored under the ID of schemaVersion
+func (s *Store) Update(ctx context.Context, schemaVersion *tracker.SchemaVersion) error {
+	value, err := json.Marshal(schemaVersion)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema version %s: %w", schemaVersion.Version, err)
+	}
+	idKey := s.idKey(schemaVersion.ID)
+	response, err := s.Client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(idKey), ">", 0)).
+		Then(clientv3.OpPut(idKey, string(value))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to store schema version %s: %w", schemaVersion.Version, err)
+	}
+	if !response.Succeeded {
+		return fmt.Errorf("%w: %s", tracker.ErrVersionNotFound, schemaVersion.Version)
+	}
+	return nil
+}
+
+// Delete removes the versions with the given IDs along with their version keys
+func (s *Store) Delete(ctx context.Context, ids ...uint) error {
+	for _, id := range ids {
+		response, err := s.Client.Get(ctx, s.idKey(id))
+		if err != nil {
+			return fmt.Errorf("failed to retrieve schema version %d: %w", id, err)
+		}
+		if len(response.Kvs) == 0 {
+			continue
+		}
+		var stored tracker.SchemaVersion
+		if err := json.Unmarshal(response.Kvs[0].Value, &stored); err != nil {
+			return fmt.Errorf("failed to decode schema version %d: %w", id, err)
+		}
+		_, err = s.Client.Txn(ctx).
+			Then(clientv3.OpDelete(s.idKey(id)), clientv3.OpDelete(s.versionKey(stored.Version))).
+			Commit()
+		if err != nil {
+			return fmt.Errorf("failed to delete schema version %s: %w", stored.Version, err)
+		}
+	}
+	return nil
+}
+
+// List returns every stored version, ordered by ID
+func (s *Store) List(ctx context.Context) ([]tracker.SchemaVersion, error) {
+	response, err := s.Client.Get(ctx, s.idPrefix(), clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve schema versions: %w", err)
+	}
+	history := make([]tracker.SchemaVersion, len(response.Kvs))
+	for i, kv := range response.Kvs {
+		if err := json.Unmarshal(kv.Value, &history[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode schema version %s: %w", kv.Key, err)
+		}
+	}
+	return history, nil
+}
+
+// idPrefix is the prefix of the keys holding the versions
+func (s *Store) idPrefix() string {
+	return s.Prefix + "versions/"
+}
+
+// idKey is the key holding the version with the given ID, zero padded so keys sort by ID
+func (s *Store) idKey(id uint) string {
+	return fmt.Sprintf("%s%020d", s.idPrefix(), id)
+}
+
+// versionKey is the key claiming a version string, holding the ID it is stored under
+func (s *Store) versionKey(version string) string {
+	return s.Prefix + "by-version/" + version
+}
@@ -0,0 +1,125 @@
+// Package gittracker appends the migrations applied by AutoMigratePlugin to a CHANGELOG file
+// of a git repository, optionally committing every entry, so the in-repo schema history
+// matches what production actually ran
+package gittracker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+// DefaultPath is the changelog file used when none is configured, relative to the worktree
+const DefaultPath = "SCHEMA_CHANGELOG.md"
+
+// WithChangelog appends every applied migration to the changelog at path, DefaultPath when
+// empty, of the repository containing dir, committing each entry when commit is set
+func WithChangelog(dir, path string, commit bool) (tracker.Option, error) {
+	repository, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository %s: %w", dir, err)
+	}
+	changelog := NewChangelog(repository, path)
+	changelog.Commit = commit
+	return tracker.WithNotifiers(changelog), nil
+}
+
+// Changelog is a tracker.Notifier appending the Markdown section of every successful
+// migration to a file of a git repository
+type Changelog struct {
+	Repository *git.Repository
+	// Path is the changelog file relative to the root of the worktree
+	Path string
+	// Commit commits the changelog after every appended migration
+	Commit bool
+	// Author signs the commits, the user configured for the repository when empty
+	Author *object.Signature
+
+	mu sync.Mutex
+}
+
+var _ tracker.Notifier = (*Changelog)(nil)
+
+// NewChangelog creates a Changelog appending to path, DefaultPath when empty, in the worktree
+// of repository without committing
+func NewChangelog(repository *git.Repository, path string) *Changelog {
+	if path == "" {
+		path = DefaultPath
+	}
+	return &Changelog{Repository: repository, Path: path}
+}
+
+// Notify appends successful migrations to the changelog and commits it when configured,
+// failed ones never changed the schema and are skipped
+func (c *Changelog) Notify(_ context.Context, event tracker.MigrationEvent) error {
+	if event.Status != tracker.StatusSuccess {
+		return nil
+	}
+
+	var section bytes.Buffer
+	if err := tracker.WriteMarkdownEvent(&section, event); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	worktree, err := c.Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open git worktree: %w", err)
+	}
+	if err := c.append(worktree, section.Bytes()); err != nil {
+		return fmt.Errorf("failed to append to changelog %s: %w", c.Path, err)
+	}
+	if !c.Commit {
+		return nil
+	}
+
+	if _, err := worktree.Add(c.Path); err != nil {
+		return fmt.Errorf("failed to stage changelog %s: %w", c.Path, err)
+	}
+	options := &git.CommitOptions{}
+	if c.Author != nil {
+		author := *c.Author
+		if author.When.IsZero() {
+			author.When = time.Now()
+		}
+		options.Author = &author
+	}
+	if _, err := worktree.Commit("Record schema version "+event.Version, options); err != nil {
+		return fmt.Errorf("failed to commit changelog %s: %w", c.Path, err)
+	}
+	return nil
+}
+
+// append writes section at the end of the changelog, creating it with a title
+func (c *Changelog) append(worktree *git.Worktree, section []byte) error {
+	filesystem := worktree.Filesystem
+	_, err := filesystem.Stat(c.Path)
+	created := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !created {
+		return err
+	}
+
+	file, err := filesystem.OpenFile(c.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if created {
+		section = append([]byte("# Schema changelog\n"), section...)
+	}
+	if _, err := file.Write(section); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,67 @@
+package gittracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+func TestChangelog(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to create git repository: %v", err)
+	}
+	changelog := NewChangelog(repository, "")
+	changelog.Commit = true
+	changelog.Author = &object.Signature{Name: "Deploy Bot", Email: "deploy@example.com"}
+
+	ctx := context.Background()
+	appliedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, event := range []tracker.MigrationEvent{
+		{Version: "1", Status: tracker.StatusSuccess, AppliedAt: appliedAt, Statements: []string{"CREATE TABLE users (id integer)"}},
+		{Version: "2", Status: tracker.StatusFailed, AppliedAt: appliedAt, Error: "syntax error"},
+		{Version: "3", Status: tracker.StatusSuccess, AppliedAt: appliedAt},
+	} {
+		if err := changelog.Notify(ctx, event); err != nil {
+			t.Fatalf("Notify %s: %v", event.Version, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, DefaultPath))
+	if err != nil {
+		t.Fatalf("failed to read the changelog: %v", err)
+	}
+	if strings.Count(string(content), "# Schema changelog") != 1 {
+		t.Errorf("changelog = %q, want a single title", content)
+	}
+	if !strings.Contains(string(content), "## 1\n") || !strings.Contains(string(content), "## 3\n") || strings.Contains(string(content), "## 2\n") {
+		t.Errorf("changelog = %q, want the successful versions alone", content)
+	}
+
+	commits, err := repository.Log(&git.LogOptions{})
+	if err != nil {
+		t.Fatalf("failed to read the git log: %v", err)
+	}
+	var messages []string
+	err = commits.ForEach(func(commit *object.Commit) error {
+		messages = append(messages, commit.Message)
+		if commit.Author.Email != "deploy@example.com" {
+			t.Errorf("commit author = %s, want the configured author", commit.Author.Email)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read the git log: %v", err)
+	}
+	if len(messages) != 2 || messages[0] != "Record schema version 3" || messages[1] != "Record schema version 1" {
+		t.Errorf("commits = %q, want one per successful version", messages)
+	}
+}
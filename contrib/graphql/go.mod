@@ -0,0 +1,17 @@
+module github.com/leodahal4/go-migrate-tracer/contrib/graphql
+
+go 1.23.1
+
+require (
+	github.com/graph-gophers/graphql-go v1.5.0
+	github.com/leodahal4/go-migrate-tracer v0.0.0
+	gorm.io/gorm v1.25.12
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/leodahal4/go-migrate-tracer => ../..
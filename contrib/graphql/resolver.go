@@ -0,0 +1,400 @@
+// Package graphqltracker exposes the migration history recorded by AutoMigratePlugin as a
+// GraphQL schema, with the history, version details and diffs, so developer portals that
+// already speak GraphQL can query it. Handler serves it over HTTP, as in
+//
+//	mux.Handle("/graphql", graphqltracker.Handler(db))
+//
+// while Schema and Resolver let it be served by another GraphQL server.
+package graphqltracker
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/gorm"
+)
+
+// Schema is the GraphQL schema Resolver implements
+//
+//go:embed schema.graphql
+var Schema string
+
+// Resolver is the root resolver of Schema over a database tracked by AutoMigratePlugin
+type Resolver struct {
+	db *gorm.DB
+}
+
+// NewResolver creates a Resolver over db
+func NewResolver(db *gorm.DB) *Resolver {
+	return &Resolver{db: db}
+}
+
+// NewSchema parses Schema with a Resolver over db
+func NewSchema(db *gorm.DB, opts ...graphql.SchemaOpt) (*graphql.Schema, error) {
+	return graphql.ParseSchema(Schema, NewResolver(db), opts...)
+}
+
+// Handler returns an http.Handler executing the GraphQL queries posted as JSON against
+// Schema. It's meant to be mounted under an admin router, it doesn't authenticate.
+func Handler(db *gorm.DB, opts ...graphql.SchemaOpt) http.Handler {
+	return &relay.Handler{Schema: graphql.MustParseSchema(Schema, NewResolver(db), opts...)}
+}
+
+// CurrentVersion resolves the highest successfully applied version, see tracker.GetCurrentVersion
+func (r *Resolver) CurrentVersion(ctx context.Context) (*versionResolver, error) {
+	current, err := tracker.GetCurrentVersion(r.db.WithContext(ctx))
+	if errors.Is(err, tracker.ErrVersionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &versionResolver{version: *current}, nil
+}
+
+// historyFilter is the HistoryFilter input
+type historyFilter struct {
+	Since       *graphql.Time
+	Until       *graphql.Time
+	Model       *string
+	Status      *string
+	InitiatedBy *string
+	Labels      *[]label
+}
+
+// label is the LabelInput input
+type label struct {
+	Key   string
+	Value string
+}
+
+// History resolves a page of the recorded versions matching the filter
+func (r *Resolver) History(ctx context.Context, args struct {
+	Filter *historyFilter
+	Limit  int32
+	Offset int32
+	Order  string
+}) (*historyPageResolver, error) {
+	if args.Limit < 0 || args.Offset < 0 {
+		return nil, errors.New("limit and offset can't be negative")
+	}
+	filter := tracker.HistoryFilter{Limit: int(args.Limit), Offset: int(args.Offset), Order: tracker.NewestFirst}
+	if args.Order == "OLDEST_FIRST" {
+		filter.Order = tracker.OldestFirst
+	}
+	if args.Filter != nil {
+		if args.Filter.Since != nil {
+			filter.Since = args.Filter.Since.Time
+		}
+		if args.Filter.Until != nil {
+			filter.Until = args.Filter.Until.Time
+		}
+		filter.Model = deref(args.Filter.Model)
+		filter.Status = deref(args.Filter.Status)
+		filter.InitiatedBy = deref(args.Filter.InitiatedBy)
+		if args.Filter.Labels != nil {
+			filter.Labels = tracker.Labels{}
+			for _, label := range *args.Filter.Labels {
+				filter.Labels[label.Key] = label.Value
+			}
+		}
+	}
+
+	page, err := tracker.QueryHistory(r.db.WithContext(ctx), filter)
+	if err != nil {
+		return nil, err
+	}
+	return &historyPageResolver{page: page}, nil
+}
+
+// Version resolves a recorded version, nil when it isn't recorded
+func (r *Resolver) Version(ctx context.Context, args struct{ Version string }) (*versionResolver, error) {
+	history, err := tracker.GetMigrationHistoryContext(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range history {
+		if version.Version == args.Version {
+			return &versionResolver{version: version}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Diff resolves the schema changes between two recorded versions
+func (r *Resolver) Diff(ctx context.Context, args struct{ From, To string }) (*diffResolver, error) {
+	diff, err := tracker.DiffVersionsContext(ctx, r.db, args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+	return &diffResolver{diff: diff}, nil
+}
+
+// historyPageResolver resolves the HistoryPage type
+type historyPageResolver struct {
+	page *tracker.HistoryPage
+}
+
+func (r *historyPageResolver) Versions() []*versionResolver {
+	versions := make([]*versionResolver, 0, len(r.page.Versions))
+	for _, version := range r.page.Versions {
+		versions = append(versions, &versionResolver{version: version})
+	}
+	return versions
+}
+
+func (r *historyPageResolver) Total() int32 {
+	return int32(r.page.Total)
+}
+
+func (r *historyPageResolver) HasMore() bool {
+	return r.page.HasMore()
+}
+
+// versionResolver resolves the SchemaVersion type
+type versionResolver struct {
+	version tracker.SchemaVersion
+}
+
+func (r *versionResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(r.version.ID), 10))
+}
+
+func (r *versionResolver) Version() string {
+	return r.version.Version
+}
+
+func (r *versionResolver) Kind() string {
+	return r.version.Kind
+}
+
+func (r *versionResolver) Status() string {
+	return r.version.Status
+}
+
+func (r *versionResolver) Error() *string {
+	return optional(r.version.Error)
+}
+
+func (r *versionResolver) DurationMs() float64 {
+	return float64(r.version.DurationMs)
+}
+
+func (r *versionResolver) AppliedAt() graphql.Time {
+	return graphql.Time{Time: r.version.AppliedAt}
+}
+
+func (r *versionResolver) Changes() string {
+	return r.version.Changes
+}
+
+// ChangeLog renders the change set, empty when it can't be decoded
+func (r *versionResolver) ChangeLog() string {
+	changes, err := r.version.ParseChanges()
+	if err != nil {
+		return ""
+	}
+	return changes.String()
+}
+
+func (r *versionResolver) Statements() string {
+	return r.version.Statements
+}
+
+func (r *versionResolver) DownStatements() string {
+	return r.version.DownStatements
+}
+
+func (r *versionResolver) Checksum() *string {
+	return optional(r.version.Checksum)
+}
+
+func (r *versionResolver) Severity() *string {
+	return optional(string(r.version.Severity))
+}
+
+func (r *versionResolver) Tenant() *string {
+	return optional(r.version.Tenant)
+}
+
+func (r *versionResolver) InitiatedBy() *string {
+	return optional(r.version.InitiatedBy)
+}
+
+// Labels resolves the labels sorted by key
+func (r *versionResolver) Labels() []*labelResolver {
+	labels := make([]*labelResolver, 0, len(r.version.Labels))
+	for key, value := range r.version.Labels {
+		labels = append(labels, &labelResolver{key: key, value: value})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].key < labels[j].key })
+	return labels
+}
+
+func (r *versionResolver) Environment() *environmentResolver {
+	return &environmentResolver{environment: r.version.Environment}
+}
+
+// labelResolver resolves the Label type
+type labelResolver struct {
+	key   string
+	value string
+}
+
+func (r *labelResolver) Key() string {
+	return r.key
+}
+
+func (r *labelResolver) Value() string {
+	return r.value
+}
+
+// environmentResolver resolves the Environment type
+type environmentResolver struct {
+	environment tracker.Environment
+}
+
+func (r *environmentResolver) Hostname() *string {
+	return optional(r.environment.Hostname)
+}
+
+func (r *environmentResolver) OS() *string {
+	return optional(r.environment.OS)
+}
+
+func (r *environmentResolver) GoVersion() *string {
+	return optional(r.environment.GoVersion)
+}
+
+func (r *environmentResolver) AppVersion() *string {
+	return optional(r.environment.AppVersion)
+}
+
+func (r *environmentResolver) GitCommit() *string {
+	return optional(r.environment.GitCommit)
+}
+
+func (r *environmentResolver) GormVersion() *string {
+	return optional(r.environment.GormVersion)
+}
+
+func (r *environmentResolver) PluginVersion() *string {
+	return optional(r.environment.PluginVersion)
+}
+
+func (r *environmentResolver) Dialect() *string {
+	return optional(r.environment.Dialect)
+}
+
+func (r *environmentResolver) DriverVersion() *string {
+	return optional(r.environment.DriverVersion)
+}
+
+// diffResolver resolves the VersionDiff type
+type diffResolver struct {
+	diff *tracker.VersionDiff
+}
+
+func (r *diffResolver) From() string {
+	return r.diff.From
+}
+
+func (r *diffResolver) To() string {
+	return r.diff.To
+}
+
+func (r *diffResolver) AddedTables() []string {
+	return nonNil(r.diff.AddedTables)
+}
+
+func (r *diffResolver) DroppedTables() []string {
+	return nonNil(r.diff.DroppedTables)
+}
+
+func (r *diffResolver) Columns() []*columnDiffResolver {
+	columns := make([]*columnDiffResolver, 0, len(r.diff.Columns))
+	for _, column := range r.diff.Columns {
+		columns = append(columns, &columnDiffResolver{column: column})
+	}
+	return columns
+}
+
+func (r *diffResolver) AddedIndexes() []string {
+	return nonNil(r.diff.AddedIndexes)
+}
+
+func (r *diffResolver) DroppedIndexes() []string {
+	return nonNil(r.diff.DroppedIndexes)
+}
+
+func (r *diffResolver) Text() string {
+	return r.diff.String()
+}
+
+// columnDiffResolver resolves the ColumnDiff type
+type columnDiffResolver struct {
+	column tracker.ColumnDiff
+}
+
+func (r *columnDiffResolver) Table() string {
+	return r.column.Table
+}
+
+func (r *columnDiffResolver) Column() string {
+	return r.column.Column
+}
+
+func (r *columnDiffResolver) Kind() string {
+	return string(r.column.Kind)
+}
+
+func (r *columnDiffResolver) OldName() *string {
+	return optional(r.column.OldName)
+}
+
+func (r *columnDiffResolver) OldType() *string {
+	return optional(r.column.OldType)
+}
+
+func (r *columnDiffResolver) NewType() *string {
+	return optional(r.column.NewType)
+}
+
+func (r *columnDiffResolver) OldNullable() bool {
+	return r.column.OldNullable
+}
+
+func (r *columnDiffResolver) NewNullable() bool {
+	return r.column.NewNullable
+}
+
+// optional resolves an empty string as null
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// deref returns the value of an optional argument, empty when it's omitted
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// nonNil resolves a nil list as an empty one
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
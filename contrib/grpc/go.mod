@@ -0,0 +1,21 @@
+module github.com/leodahal4/go-migrate-tracer/contrib/grpc
+
+go 1.23.1
+
+require (
+	github.com/leodahal4/go-migrate-tracer v0.0.0
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
+	gorm.io/gorm v1.25.12
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
+
+replace github.com/leodahal4/go-migrate-tracer => ../..
@@ -0,0 +1,219 @@
+// Package grpctracker serves the migration history recorded by AutoMigratePlugin over gRPC, with
+// the MigrationTracker service of trackerpb/tracker.proto, so tooling written in other languages
+// can query the schema state of a service. Register it on a grpc.Server, as in
+//
+//	grpctracker.Register(server, grpctracker.NewServer(db, &User{}))
+package grpctracker
+
+import (
+	"context"
+	"errors"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/leodahal4/go-migrate-tracer/contrib/grpc/trackerpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// Server implements the MigrationTracker service over a database tracked by AutoMigratePlugin
+type Server struct {
+	trackerpb.UnimplementedMigrationTrackerServer
+
+	// AllowRollback enables the Rollback RPC, refused with PERMISSION_DENIED otherwise. The
+	// server doesn't authenticate, callers should be restricted with an interceptor.
+	AllowRollback bool
+
+	db     *gorm.DB
+	models []interface{}
+}
+
+var _ trackerpb.MigrationTrackerServer = (*Server)(nil)
+
+// NewServer creates a Server over db, GetStatus covers the given models, or those configured
+// with WithDriftCheck
+func NewServer(db *gorm.DB, models ...interface{}) *Server {
+	return &Server{db: db, models: models}
+}
+
+// Register registers the MigrationTracker service of server on registrar
+func Register(registrar grpc.ServiceRegistrar, server *Server) {
+	trackerpb.RegisterMigrationTrackerServer(registrar, server)
+}
+
+// GetHistory lists the recorded schema versions matching the request
+func (s *Server) GetHistory(ctx context.Context, req *trackerpb.GetHistoryRequest) (*trackerpb.GetHistoryResponse, error) {
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset can't be negative")
+	}
+	filter := tracker.HistoryFilter{
+		Model:  req.GetModel(),
+		Status: req.GetStatus(),
+		Limit:  int(req.GetLimit()),
+		Offset: int(req.GetOffset()),
+	}
+	if req.GetOldestFirst() {
+		filter.Order = tracker.OldestFirst
+	}
+
+	db := s.db.WithContext(ctx)
+	page, err := tracker.QueryHistory(db, filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &trackerpb.GetHistoryResponse{Total: page.Total}
+	for _, version := range page.Versions {
+		response.Versions = append(response.Versions, schemaVersion(version))
+	}
+
+	current, err := tracker.GetCurrentVersion(db)
+	if err != nil && !errors.Is(err, tracker.ErrVersionNotFound) {
+		return nil, statusError(err)
+	}
+	if current != nil {
+		response.CurrentVersion = current.Version
+	}
+	return response, nil
+}
+
+// GetStatus reports which models are applied, which AutoMigrate would change and which tables
+// no model manages
+func (s *Server) GetStatus(ctx context.Context, _ *trackerpb.GetStatusRequest) (*trackerpb.GetStatusResponse, error) {
+	report, err := tracker.StatusContext(ctx, s.db, s.models...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &trackerpb.GetStatusResponse{
+		SchemaVersion:   report.SchemaVersion,
+		Routines:        plannedStatements(report.Routines),
+		UnmanagedTables: report.UnmanagedTables,
+		UpToDate:        report.UpToDate(),
+	}
+	for _, model := range report.Applied {
+		response.Applied = append(response.Applied, modelStatus(model))
+	}
+	for _, model := range report.Pending {
+		response.Pending = append(response.Pending, modelStatus(model))
+	}
+	return response, nil
+}
+
+// DiffVersions returns the schema changes between two recorded versions
+func (s *Server) DiffVersions(ctx context.Context, req *trackerpb.DiffVersionsRequest) (*trackerpb.DiffVersionsResponse, error) {
+	if req.GetFrom() == "" || req.GetTo() == "" {
+		return nil, status.Error(codes.InvalidArgument, "from and to are required")
+	}
+	diff, err := tracker.DiffVersionsContext(ctx, s.db, req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &trackerpb.DiffVersionsResponse{
+		From:           diff.From,
+		To:             diff.To,
+		AddedTables:    diff.AddedTables,
+		DroppedTables:  diff.DroppedTables,
+		AddedIndexes:   diff.AddedIndexes,
+		DroppedIndexes: diff.DroppedIndexes,
+		Text:           diff.String(),
+	}
+	for _, column := range diff.Columns {
+		response.Columns = append(response.Columns, &trackerpb.ColumnDiff{
+			Table:       column.Table,
+			Column:      column.Column,
+			Kind:        string(column.Kind),
+			OldName:     column.OldName,
+			OldType:     column.OldType,
+			NewType:     column.NewType,
+			OldNullable: column.OldNullable,
+			NewNullable: column.NewNullable,
+		})
+	}
+	return response, nil
+}
+
+// Rollback reverts every migration recorded after the requested version, when AllowRollback
+// is set
+func (s *Server) Rollback(ctx context.Context, req *trackerpb.RollbackRequest) (*trackerpb.RollbackResponse, error) {
+	if !s.AllowRollback {
+		return nil, status.Error(codes.PermissionDenied, "rollbacks are disabled on this server")
+	}
+	if req.GetVersion() == "" {
+		return nil, status.Error(codes.InvalidArgument, "version is required")
+	}
+	if err := tracker.RollbackToContext(ctx, s.db, req.GetVersion()); err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &trackerpb.RollbackResponse{}
+	current, err := tracker.GetCurrentVersion(s.db.WithContext(ctx))
+	if err != nil && !errors.Is(err, tracker.ErrVersionNotFound) {
+		return nil, statusError(err)
+	}
+	if current != nil {
+		response.CurrentVersion = current.Version
+	}
+	return response, nil
+}
+
+// statusError converts an error of the tracker to a gRPC status
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, tracker.ErrVersionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, tracker.ErrPluginNotRegistered):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// schemaVersion converts a recorded version to its message
+func schemaVersion(version tracker.SchemaVersion) *trackerpb.SchemaVersion {
+	return &trackerpb.SchemaVersion{
+		Id:             uint64(version.ID),
+		Version:        version.Version,
+		Kind:           version.Kind,
+		Status:         version.Status,
+		Error:          version.Error,
+		DurationMs:     version.DurationMs,
+		AppliedAt:      timestamppb.New(version.AppliedAt),
+		Changes:        version.Changes,
+		Statements:     version.Statements,
+		DownStatements: version.DownStatements,
+		Checksum:       version.Checksum,
+		Severity:       string(version.Severity),
+		Tenant:         version.Tenant,
+		InitiatedBy:    version.InitiatedBy,
+		Labels:         version.Labels,
+		Hostname:       version.Environment.Hostname,
+		AppVersion:     version.Environment.AppVersion,
+		GitCommit:      version.Environment.GitCommit,
+	}
+}
+
+// modelStatus converts the status of a model to its message
+func modelStatus(model tracker.ModelStatus) *trackerpb.ModelStatus {
+	return &trackerpb.ModelStatus{
+		Model:      model.Model,
+		Table:      model.Table,
+		Statements: plannedStatements(model.Statements),
+		Severity:   string(model.Severity),
+	}
+}
+
+// plannedStatements converts planned statements to their messages
+func plannedStatements(statements []tracker.PlannedStatement) []*trackerpb.PlannedStatement {
+	var messages []*trackerpb.PlannedStatement
+	for _, statement := range statements {
+		messages = append(messages, &trackerpb.PlannedStatement{
+			Sql:      statement.SQL,
+			Table:    statement.Table,
+			Severity: string(statement.Severity),
+		})
+	}
+	return messages
+}
@@ -0,0 +1,19 @@
+module github.com/leodahal4/go-migrate-tracer/contrib/kafka
+
+go 1.23.1
+
+require (
+	github.com/leodahal4/go-migrate-tracer v0.0.0
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gorm.io/gorm v1.25.12 // indirect
+)
+
+replace github.com/leodahal4/go-migrate-tracer => ../..
@@ -0,0 +1,73 @@
+// Package kafkatracker publishes the migrations recorded by AutoMigratePlugin to a Kafka topic
+package kafkatracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/segmentio/kafka-go"
+)
+
+// Header keys set on every published message
+const (
+	HeaderVersion = "migration-version"
+	HeaderStatus  = "migration-status"
+)
+
+// Publisher is a tracker.Notifier producing one message per applied or failed migration. The
+// message is keyed by version and holds the tracker.MigrationEvent, changes included, as JSON.
+type Publisher struct {
+	writer *kafka.Writer
+}
+
+var _ tracker.Notifier = (*Publisher)(nil)
+
+// WithKafka publishes every migration to topic on the given brokers
+func WithKafka(brokers []string, topic string) tracker.Option {
+	return tracker.WithNotifiers(NewPublisher(brokers, topic))
+}
+
+// NewPublisher creates a Publisher producing to topic on the given brokers, waiting for every
+// in-sync replica to acknowledge a message
+func NewPublisher(brokers []string, topic string) *Publisher {
+	return NewPublisherWithWriter(&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	})
+}
+
+// NewPublisherWithWriter creates a Publisher producing through a configured writer, e.g. one
+// with TLS or SASL transport
+func NewPublisherWithWriter(writer *kafka.Writer) *Publisher {
+	return &Publisher{writer: writer}
+}
+
+// Notify publishes the event
+func (p *Publisher) Notify(ctx context.Context, event tracker.MigrationEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode migration event: %w", err)
+	}
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Version),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: HeaderVersion, Value: []byte(event.Version)},
+			{Key: HeaderStatus, Value: []byte(event.Status)},
+		},
+		Time: event.AppliedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish migration %s: %w", event.Version, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the writer
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
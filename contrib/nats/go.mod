@@ -0,0 +1,22 @@
+module github.com/leodahal4/go-migrate-tracer/contrib/nats
+
+go 1.23.1
+
+require (
+	github.com/leodahal4/go-migrate-tracer v0.0.0
+	github.com/nats-io/nats.go v1.41.1
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/nats-io/nkeys v0.4.9 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	gorm.io/gorm v1.25.12 // indirect
+)
+
+replace github.com/leodahal4/go-migrate-tracer => ../..
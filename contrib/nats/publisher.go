@@ -0,0 +1,97 @@
+// Package natstracker publishes the migrations recorded by AutoMigratePlugin to NATS, optionally
+// persisted in a JetStream stream
+package natstracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultSubject is the subject migrations are published on when none is configured
+const DefaultSubject = "gorm.migrations"
+
+// Header keys set on every published message
+const (
+	HeaderVersion = "Migration-Version"
+	HeaderStatus  = "Migration-Status"
+)
+
+// Publisher is a tracker.Notifier publishing one message per applied or failed migration,
+// holding the tracker.MigrationEvent, changes included, as JSON
+type Publisher struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+var _ tracker.Notifier = (*Publisher)(nil)
+
+// WithNATS publishes every migration on subject with core NATS
+func WithNATS(conn *nats.Conn, subject string) tracker.Option {
+	return tracker.WithNotifiers(NewPublisher(conn, subject))
+}
+
+// NewPublisher creates a Publisher publishing on subject with core NATS, so only subscribers
+// connected at the time receive the event
+func NewPublisher(conn *nats.Conn, subject string) *Publisher {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	return &Publisher{conn: conn, subject: subject}
+}
+
+// NewJetStreamPublisher creates a Publisher publishing on subject through JetStream, waiting
+// for the stream to acknowledge every event. The subject has to be bound to a stream, see
+// EnsureStream. The message ID is set to the version and status, so retried notifications
+// are deduplicated by the stream.
+func NewJetStreamPublisher(conn *nats.Conn, subject string) (*Publisher, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	publisher := NewPublisher(conn, subject)
+	publisher.js = js
+	return publisher, nil
+}
+
+// EnsureStream creates or updates the stream named name capturing the publisher's subject
+func (p *Publisher) EnsureStream(ctx context.Context, name string) error {
+	if p.js == nil {
+		return fmt.Errorf("publisher on %s was not created with JetStream", p.subject)
+	}
+	_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: []string{p.subject},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ensure stream %s: %w", name, err)
+	}
+	return nil
+}
+
+// Notify publishes the event
+func (p *Publisher) Notify(ctx context.Context, event tracker.MigrationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode migration event: %w", err)
+	}
+	msg := nats.NewMsg(p.subject)
+	msg.Data = data
+	msg.Header.Set(HeaderVersion, event.Version)
+	msg.Header.Set(HeaderStatus, event.Status)
+
+	if p.js != nil {
+		_, err = p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.Version+"-"+event.Status))
+	} else {
+		err = p.conn.PublishMsg(msg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish migration %s: %w", event.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package opatracker evaluates the plans of AutoMigratePlugin with embedded Open Policy Agent Rego policies
+package opatracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// WithRego evaluates every AutoMigrate plan against the Rego module, querying the decision
+// at query, such as data.gorm.migrations
+func WithRego(ctx context.Context, query, module string) (tracker.Option, error) {
+	policy, err := NewPolicy(ctx, query, map[string]string{"policy.rego": module})
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WithPolicy(policy), nil
+}
+
+// Policy evaluates a prepared Rego query. The decision is either a boolean, or an object
+// with an optional allow boolean and a deny set of messages, like tracker.OPAPolicy expects.
+type Policy struct {
+	query rego.PreparedEvalQuery
+}
+
+var _ tracker.Policy = (*Policy)(nil)
+
+// NewPolicy compiles the Rego modules, keyed by file name, and prepares query for evaluation
+func NewPolicy(ctx context.Context, query string, modules map[string]string) (*Policy, error) {
+	options := []func(*rego.Rego){rego.Query(query)}
+	for name, module := range modules {
+		options = append(options, rego.Module(name, module))
+	}
+	prepared, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare policy: %w", err)
+	}
+	return &Policy{query: prepared}, nil
+}
+
+// Evaluate evaluates the query with input
+func (p *Policy) Evaluate(ctx context.Context, input tracker.PolicyInput) (tracker.PolicyDecision, error) {
+	// Rego sees the input as the JSON document OPAPolicy would send
+	data, err := json.Marshal(input)
+	if err != nil {
+		return tracker.PolicyDecision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return tracker.PolicyDecision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+
+	results, err := p.query.Eval(ctx, rego.EvalInput(document))
+	if err != nil {
+		return tracker.PolicyDecision{}, err
+	}
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return tracker.PolicyDecision{}, fmt.Errorf("policy decision is undefined")
+	}
+	result, err := json.Marshal(results[0].Expressions[0].Value)
+	if err != nil {
+		return tracker.PolicyDecision{}, fmt.Errorf("failed to encode policy decision: %w", err)
+	}
+	return tracker.DecodeOPAResult(result)
+}
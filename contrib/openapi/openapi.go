@@ -0,0 +1,28 @@
+// Package openapitracker publishes the OpenAPI 3 document of the JSON API served by the dashboard
+// package and HistoryHandler, with a Go client generated from it in the client package
+package openapitracker
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -config client/config.yaml openapi.yaml
+
+// Spec is the OpenAPI 3 document of the API, as YAML
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// Handler returns an http.Handler serving Spec, so it can be published next to the API
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(Spec)
+	})
+}
@@ -0,0 +1,77 @@
+// Package oteltracker traces AutoMigratePlugin activity with OpenTelemetry
+package oteltracker
+
+import (
+	"context"
+	"fmt"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans emitted by this package
+const instrumentationName = "github.com/leodahal4/go-migrate-tracer"
+
+// WithTracerProvider traces every AutoMigrate run, and every model it migrates, with
+// spans from the given TracerProvider
+func WithTracerProvider(provider trace.TracerProvider) tracker.Option {
+	return tracker.WithTracer(NewTracer(provider))
+}
+
+// Tracer adapts an OpenTelemetry TracerProvider to tracker.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ tracker.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer emitting spans from the given TracerProvider
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+// StartMigration starts the span of an AutoMigrate invocation
+func (t *Tracer) StartMigration(ctx context.Context) (context.Context, tracker.Span) {
+	ctx, span := t.tracer.Start(ctx, "AutoMigrate")
+	return ctx, &Span{span: span}
+}
+
+// StartModel starts the span of a single migrated model
+func (t *Tracer) StartModel(ctx context.Context, model string) (context.Context, tracker.Span) {
+	ctx, span := t.tracer.Start(ctx, "AutoMigrate "+model)
+	return ctx, &Span{span: span}
+}
+
+// Span adapts an OpenTelemetry span to tracker.Span
+type Span struct {
+	span trace.Span
+}
+
+// SetAttribute converts the value to an OpenTelemetry attribute and attaches it
+func (s *Span) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case []string:
+		s.span.SetAttributes(attribute.StringSlice(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// End records err on the span, if any, and ends it
+func (s *Span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,86 @@
+// Package prometheustracker exposes AutoMigratePlugin activity as Prometheus metrics
+package prometheustracker
+
+import (
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector and tracker.MetricsRecorder reporting migration activity
+type Collector struct {
+	migrations  prometheus.Counter
+	failures    prometheus.Counter
+	duration    prometheus.Histogram
+	statements  prometheus.Counter
+	lastApplied prometheus.Gauge
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+var _ tracker.MetricsRecorder = (*Collector)(nil)
+
+// NewCollector creates a Collector whose metrics are prefixed with the given namespace
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		migrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "automigrate",
+			Name:      "migrations_total",
+			Help:      "Number of AutoMigrate runs.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "automigrate",
+			Name:      "failures_total",
+			Help:      "Number of failed AutoMigrate runs.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "automigrate",
+			Name:      "duration_seconds",
+			Help:      "Duration of AutoMigrate runs.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		statements: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "automigrate",
+			Name:      "statements_total",
+			Help:      "Number of DDL statements executed by AutoMigrate.",
+		}),
+		lastApplied: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "automigrate",
+			Name:      "last_applied_timestamp_seconds",
+			Help:      "Unix time the last successful AutoMigrate run was applied.",
+		}),
+	}
+}
+
+// ObserveMigration records the measurements of an AutoMigrate run
+func (c *Collector) ObserveMigration(metrics tracker.MigrationMetrics) {
+	c.migrations.Inc()
+	c.duration.Observe(metrics.Duration.Seconds())
+	c.statements.Add(float64(metrics.Statements))
+	if metrics.Err != nil {
+		c.failures.Inc()
+		return
+	}
+	c.lastApplied.Set(float64(metrics.AppliedAt.Unix()))
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.migrations.Describe(ch)
+	c.failures.Describe(ch)
+	c.duration.Describe(ch)
+	c.statements.Describe(ch)
+	c.lastApplied.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.migrations.Collect(ch)
+	c.failures.Collect(ch)
+	c.duration.Collect(ch)
+	c.statements.Collect(ch)
+	c.lastApplied.Collect(ch)
+}
@@ -0,0 +1,63 @@
+// Package s3tracker keeps the history recorded by AutoMigratePlugin in an S3 object
+package s3tracker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+// WithS3 keeps the history as a JSON document in the object under key in bucket
+func WithS3(client *s3.Client, bucket, key string) tracker.Option {
+	return tracker.WithHistoryStore(NewStore(client, bucket, key))
+}
+
+// NewStore creates a tracker.DocumentStore keeping the history in the object under key in
+// bucket. Concurrent writers, such as several instances migrating at once, need a migration
+// lock so no write is lost.
+func NewStore(client *s3.Client, bucket, key string) *tracker.DocumentStore {
+	return tracker.NewDocumentStore(&Object{Client: client, Bucket: bucket, Key: key})
+}
+
+// Object is a tracker.Document stored in an S3 object
+type Object struct {
+	Client *s3.Client
+	Bucket string
+	Key    string
+}
+
+var _ tracker.Document = (*Object)(nil)
+
+// Read downloads the object, returning nil when it doesn't exist
+func (o *Object) Read(ctx context.Context) ([]byte, error) {
+	output, err := o.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(o.Key),
+	})
+	var notFound *types.NoSuchKey
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// Write uploads data as the object
+func (o *Object) Write(ctx context.Context, data []byte) error {
+	_, err := o.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(o.Bucket),
+		Key:         aws.String(o.Key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
@@ -0,0 +1,99 @@
+package s3tracker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+// fakeS3 serves the objects it was given, by path, like a path style S3 endpoint
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestObject(t *testing.T) (*Object, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	return &Object{Client: client, Bucket: "audit", Key: "schema_versions.json"}, fake
+}
+
+func TestObject(t *testing.T) {
+	object, fake := newTestObject(t)
+	ctx := context.Background()
+
+	// A missing object is an empty document
+	data, err := object.Read(ctx)
+	if err != nil || data != nil {
+		t.Fatalf("Read of a missing object = %q, %v, want nil", data, err)
+	}
+
+	if err := object.Write(ctx, []byte(`[]`)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if stored := string(fake.objects["/audit/schema_versions.json"]); stored != `[]` {
+		t.Errorf("object stored as %q, want []", stored)
+	}
+	if data, err := object.Read(ctx); err != nil || string(data) != `[]` {
+		t.Errorf("Read = %q, %v, want []", data, err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	object, _ := newTestObject(t)
+	store := tracker.NewDocumentStore(object)
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := store.Create(ctx, &tracker.SchemaVersion{Version: "1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	history, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(history) != 1 || history[0].Version != "1" || history[0].ID != 1 {
+		t.Errorf("history = %v, want version 1", history)
+	}
+}
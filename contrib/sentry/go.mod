@@ -0,0 +1,18 @@
+module github.com/leodahal4/go-migrate-tracer/contrib/sentry
+
+go 1.23.1
+
+require (
+	github.com/getsentry/sentry-go v0.31.1
+	github.com/leodahal4/go-migrate-tracer v0.0.0
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gorm.io/gorm v1.25.12 // indirect
+)
+
+replace github.com/leodahal4/go-migrate-tracer => ../..
@@ -0,0 +1,76 @@
+// Package sentrytracker reports the migrations AutoMigratePlugin fails to apply or record to Sentry
+package sentrytracker
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+// Reporter is a tracker.ErrorReporter capturing every failed migration as a Sentry event,
+// tagged with its version and with the plan and captured statements attached as context
+type Reporter struct {
+	hub *sentry.Hub
+}
+
+var _ tracker.ErrorReporter = (*Reporter)(nil)
+
+// WithSentry reports failed migrations through the current hub, initialize the SDK with
+// sentry.Init first
+func WithSentry() tracker.Option {
+	return tracker.WithErrorReporter(NewReporter(nil))
+}
+
+// NewReporter creates a Reporter capturing through hub, the hub of the migration context or
+// the current hub is used when nil
+func NewReporter(hub *sentry.Hub) *Reporter {
+	return &Reporter{hub: hub}
+}
+
+// ReportError captures the failed migration
+func (r *Reporter) ReportError(ctx context.Context, report tracker.ErrorReport) {
+	hub := r.hub
+	if hub == nil {
+		hub = sentry.GetHubFromContext(ctx)
+	}
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("migration.version", report.Event.Version)
+		scope.SetTag("migration.recorded", strconv.FormatBool(report.Recorded))
+		if report.Tenant != "" {
+			scope.SetTag("migration.tenant", report.Tenant)
+		}
+
+		migration := sentry.Context{
+			"status":      report.Event.Status,
+			"duration_ms": report.Event.DurationMs,
+			"statements":  report.Event.Statements,
+		}
+		if report.Event.Changes != nil {
+			migration["changes"] = report.Event.Changes.Summary()
+		}
+		if report.Plan != nil {
+			planned := make([]string, len(report.Plan.Statements))
+			for i, statement := range report.Plan.Statements {
+				planned[i] = statement.SQL
+			}
+			migration["planned_statements"] = planned
+			migration["planned_tables"] = report.Plan.Tables
+			migration["severity"] = string(report.Plan.Severity)
+		}
+		scope.SetContext("migration", migration)
+		scope.SetContext("environment", sentry.Context{
+			"hostname":    report.Environment.Hostname,
+			"os":          report.Environment.OS,
+			"go_version":  report.Environment.GoVersion,
+			"app_version": report.Environment.AppVersion,
+			"git_commit":  report.Environment.GitCommit,
+		})
+		hub.CaptureException(report.Err)
+	})
+}
@@ -0,0 +1,75 @@
+// Package sqlitehistory keeps the history recorded by AutoMigratePlugin in an in-memory or
+// temporary SQLite database instead of the migrated one, for tests and read-only replicas
+package sqlitehistory
+
+import (
+	"fmt"
+	"os"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// WithMemory keeps the history in a new in-memory SQLite database, lost when the process exits
+func WithMemory() (tracker.Option, error) {
+	conn, err := Memory()
+	if err != nil {
+		return nil, err
+	}
+	return tracker.WithHistoryConn(conn), nil
+}
+
+// WithTempFile keeps the history in a new SQLite file in the temporary directory and returns
+// its path, the caller removes it
+func WithTempFile() (tracker.Option, string, error) {
+	conn, path, err := TempFile()
+	if err != nil {
+		return nil, "", err
+	}
+	return tracker.WithHistoryConn(conn), path, nil
+}
+
+// Memory opens a new in-memory SQLite database. It is limited to a single connection as every
+// connection to ":memory:" opens a database of its own.
+func Memory() (*gorm.DB, error) {
+	conn, err := open(":memory:")
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure in-memory history database: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return conn, nil
+}
+
+// TempFile opens a new SQLite database in the temporary directory and returns its path
+func TempFile() (*gorm.DB, string, error) {
+	file, err := os.CreateTemp("", "schema_versions-*.db")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create history database file: %w", err)
+	}
+	path := file.Name()
+	if err := file.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to create history database file: %w", err)
+	}
+
+	conn, err := open(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, "", err
+	}
+	return conn, path, nil
+}
+
+// open connects to the SQLite database at dsn without logging its queries
+func open(dsn string) (*gorm.DB, error) {
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %w", dsn, err)
+	}
+	return conn, nil
+}
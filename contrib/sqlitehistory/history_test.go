@@ -0,0 +1,82 @@
+package sqlitehistory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID   uint
+	Name string
+}
+
+// openMigrated opens the database migrated by the tests, recording its history with option
+func openMigrated(t *testing.T, option tracker.Option) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.Use(tracker.NewAutoMigratePlugin(tracker.WithQuiet(true), option)); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	return db
+}
+
+func TestWithMemory(t *testing.T) {
+	option, err := WithMemory()
+	if err != nil {
+		t.Fatalf("WithMemory: %v", err)
+	}
+	db := openMigrated(t, option)
+	if err := db.AutoMigrate(&user{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	if db.Migrator().HasTable(&tracker.SchemaVersion{}) {
+		t.Error("the history table was created on the migrated database")
+	}
+	history, err := tracker.GetMigrationHistory(db)
+	if err != nil {
+		t.Fatalf("GetMigrationHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("recorded %d versions in memory, want 1", len(history))
+	}
+}
+
+func TestWithTempFile(t *testing.T) {
+	option, path, err := WithTempFile()
+	if err != nil {
+		t.Fatalf("WithTempFile: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+	db := openMigrated(t, option)
+	if err := db.AutoMigrate(&user{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	// The history outlives the connection in the file
+	conn, err := open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if sqlDB, err := conn.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var count int64
+	if err := conn.Model(&tracker.SchemaVersion{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("the history file holds %d records, %v, want 1", count, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package tracertest provides helpers to test applications using AutoMigratePlugin: installing
+// it against an in-memory SQLite database or sqlmock, seeding SchemaVersion fixtures and
+// asserting what was recorded
+package tracertest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// databases numbers the in-memory databases so every Open gets its own
+var databases atomic.Int64
+
+// Open creates a private in-memory SQLite database with the plugin registered, logging
+// quietly unless the options say otherwise. The database is closed when the test ends.
+func Open(t testing.TB, opts ...tracker.Option) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:tracertest%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("tracertest: failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	Install(t, db, opts...)
+	return db
+}
+
+// Install registers a plugin created with the options on db, failing the test when it can't
+func Install(t testing.TB, db *gorm.DB, opts ...tracker.Option) *tracker.AutoMigratePlugin {
+	t.Helper()
+	plugin := tracker.NewAutoMigratePlugin(append([]tracker.Option{tracker.WithQuiet(true)}, opts...)...)
+	if err := db.Use(plugin); err != nil {
+		t.Fatalf("tracertest: failed to register plugin: %v", err)
+	}
+	return plugin
+}
+
+// NewMock opens a Postgres dialect connection backed by sqlmock, without the plugin. Set
+// the expectations of its initialization before calling Install. The test fails when the
+// expectations weren't met by its end.
+func NewMock(t testing.TB) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("tracertest: failed to create sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("tracertest: failed to open mocked database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("tracertest: %v", err)
+		}
+		sqlDB.Close()
+	})
+	return db, mock
+}
+
+// ExpectHistoryInsert expects the insert of a SchemaVersion into the default history table
+// of a NewMock connection, returning id as its primary key
+func ExpectHistoryInsert(mock sqlmock.Sqlmock, id int64) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`INSERT INTO "schema_versions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+// Fixture returns a successful migration recorded under version, applied now
+func Fixture(version string) tracker.SchemaVersion {
+	return tracker.SchemaVersion{
+		Version:   version,
+		Kind:      tracker.KindMigration,
+		Status:    tracker.StatusSuccess,
+		AppliedAt: time.Now(),
+		Changes:   "{}",
+	}
+}
+
+// Seed inserts the versions into the history table of the plugin registered on db
+func Seed(t testing.TB, db *gorm.DB, versions ...tracker.SchemaVersion) {
+	t.Helper()
+	history := db.Session(&gorm.Session{NewDB: true}).Table(tracker.HistoryTableName(db))
+	for i := range versions {
+		if err := history.Create(&versions[i]).Error; err != nil {
+			t.Fatalf("tracertest: failed to seed version %s: %v", versions[i].Version, err)
+		}
+	}
+}
+
+// AssertRecorded fails the test unless version is recorded, returning its record
+func AssertRecorded(t testing.TB, db *gorm.DB, version string) tracker.SchemaVersion {
+	t.Helper()
+	history, err := tracker.GetMigrationHistory(db)
+	if err != nil {
+		t.Fatalf("tracertest: %v", err)
+	}
+	for _, schemaVersion := range history {
+		if schemaVersion.Version == version {
+			return schemaVersion
+		}
+	}
+	t.Fatalf("tracertest: version %s is not recorded, recorded are %v", version, versions(history))
+	return tracker.SchemaVersion{}
+}
+
+// AssertStatus fails the test unless version is recorded with status
+func AssertStatus(t testing.TB, db *gorm.DB, version, status string) {
+	t.Helper()
+	if recorded := AssertRecorded(t, db, version); recorded.Status != status {
+		t.Errorf("tracertest: version %s is %s, want %s", version, recorded.Status, status)
+	}
+}
+
+// AssertCurrentVersion fails the test unless version is the current one, see tracker.GetCurrentVersion
+func AssertCurrentVersion(t testing.TB, db *gorm.DB, version string) {
+	t.Helper()
+	latest, err := tracker.GetCurrentVersion(db)
+	if errors.Is(err, tracker.ErrVersionNotFound) {
+		t.Fatalf("tracertest: no version applied, want %s", version)
+	}
+	if err != nil {
+		t.Fatalf("tracertest: %v", err)
+	}
+	if latest.Version != version {
+		t.Errorf("tracertest: current version is %s, want %s", latest.Version, version)
+	}
+}
+
+// AssertChanged fails the test unless version recorded changes to table, by model or table name
+func AssertChanged(t testing.TB, db *gorm.DB, version, table string) {
+	t.Helper()
+	recorded := AssertRecorded(t, db, version)
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("tracertest: %v", err)
+	}
+	var changed []string
+	for _, model := range changes.Models {
+		if model.Table == table || model.Model == table {
+			return
+		}
+		changed = append(changed, model.Table)
+	}
+	t.Errorf("tracertest: version %s didn't change %s, it changed %v", version, table, changed)
+}
+
+// AssertMigrations fails the test unless exactly count versions are recorded
+func AssertMigrations(t testing.TB, db *gorm.DB, count int) {
+	t.Helper()
+	history, err := tracker.GetMigrationHistory(db)
+	if err != nil {
+		t.Fatalf("tracertest: %v", err)
+	}
+	if len(history) != count {
+		t.Errorf("tracertest: %d versions recorded, want %d: %v", len(history), count, versions(history))
+	}
+}
+
+// versions lists the recorded versions, oldest first
+func versions(history []tracker.SchemaVersion) []string {
+	recorded := make([]string, len(history))
+	for i, schemaVersion := range history {
+		recorded[i] = schemaVersion.Version
+	}
+	slices.Reverse(recorded)
+	return recorded
+}
@@ -0,0 +1,46 @@
+package tracertest
+
+import (
+	"context"
+	"testing"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+type user struct {
+	ID   uint
+	Name string
+}
+
+func TestOpen(t *testing.T) {
+	db := Open(t)
+	if err := db.WithContext(tracker.ContextWithVersion(context.Background(), "1")).AutoMigrate(&user{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	AssertMigrations(t, db, 1)
+	AssertStatus(t, db, "1", tracker.StatusSuccess)
+	AssertCurrentVersion(t, db, "1")
+	AssertChanged(t, db, "1", "users")
+
+	// Every database is private to its Open
+	AssertMigrations(t, Open(t), 0)
+}
+
+func TestSeed(t *testing.T) {
+	db := Open(t)
+	failed := Fixture("3")
+	failed.Status = tracker.StatusFailed
+	Seed(t, db, Fixture("1"), Fixture("2"), failed)
+
+	AssertMigrations(t, db, 3)
+	AssertStatus(t, db, "3", tracker.StatusFailed)
+	AssertCurrentVersion(t, db, "2")
+}
+
+func TestVersions(t *testing.T) {
+	history := []tracker.SchemaVersion{{Version: "2"}, {Version: "1"}}
+	if recorded := versions(history); len(recorded) != 2 || recorded[0] != "1" || recorded[1] != "2" {
+		t.Errorf("versions = %v, want oldest first", recorded)
+	}
+}
@@ -0,0 +1,45 @@
+// Package zstdtracker compresses the history recorded by AutoMigratePlugin with Zstandard
+package zstdtracker
+
+import (
+	"github.com/klauspost/compress/zstd"
+	tracker "github.com/leodahal4/go-migrate-tracer"
+)
+
+// WithZstd compresses the large changes, statements and snapshots of recorded versions with
+// Zstandard at the default level
+func WithZstd() tracker.Option {
+	return tracker.WithCompression(NewCompressor(zstd.SpeedDefault))
+}
+
+// Compressor is a tracker.Compressor using Zstandard, its encoder and decoder are safe for
+// concurrent use
+type Compressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+var _ tracker.Compressor = (*Compressor)(nil)
+
+// NewCompressor creates a Compressor encoding at the given level
+func NewCompressor(level zstd.EncoderLevel) *Compressor {
+	// Without a writer or reader neither constructor fails
+	encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+	decoder, _ := zstd.NewReader(nil)
+	return &Compressor{encoder: encoder, decoder: decoder}
+}
+
+// Name returns "zstd"
+func (c *Compressor) Name() string {
+	return "zstd"
+}
+
+// Compress encodes data
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress decodes data
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
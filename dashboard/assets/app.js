@@ -0,0 +1,146 @@
+"use strict";
+
+// The API is served relative to the page, wherever the dashboard is mounted
+async function fetchJSON(path) {
+  const response = await fetch(path);
+  const body = await response.json();
+  if (!response.ok) {
+    throw new Error(body.error || response.statusText);
+  }
+  return body;
+}
+
+// element creates an element with the given class and text
+function element(tag, className, text) {
+  const node = document.createElement(tag);
+  if (className) {
+    node.className = className;
+  }
+  if (text !== undefined) {
+    node.textContent = text;
+  }
+  return node;
+}
+
+function renderSummary(history) {
+  const failed = history.history.filter((version) => version.status === "failed").length;
+  const summary = document.getElementById("summary");
+  summary.replaceChildren(
+    element("span", "", "Current version "),
+    element("span", "version", history.current_version || "none"),
+    element("span", "muted", ` · ${history.history.length} recorded, ${failed} failed`),
+  );
+}
+
+function renderDrift(drift) {
+  const section = document.getElementById("drift");
+  if (!drift || !drift.detected) {
+    section.hidden = true;
+    return;
+  }
+  section.hidden = false;
+  section.replaceChildren(element("h2", "", "Schema drift detected"));
+  const list = element("ul");
+  for (const [kind, items] of Object.entries(drift.report)) {
+    for (const item of items || []) {
+      list.append(element("li", "", `${kind.replaceAll("_", " ")}: ${item}`));
+    }
+  }
+  section.append(list);
+}
+
+function renderTimeline(history) {
+  const timeline = document.getElementById("timeline");
+  timeline.replaceChildren();
+  for (const version of history.history) {
+    const item = element("li", version.status);
+    item.append(element("div", "version", version.version));
+    const meta = element("div", "muted", `${version.kind} · ${new Date(version.applied_at).toLocaleString()} · ${version.duration_ms} ms `);
+    if (version.severity && version.severity !== "info") {
+      meta.append(element("span", `badge ${version.severity}`, version.severity));
+    }
+    if (version.status !== "success") {
+      meta.append(element("span", `badge ${version.status}`, version.status));
+    }
+    item.append(meta);
+    item.addEventListener("click", () => {
+      for (const selected of timeline.querySelectorAll(".selected")) {
+        selected.classList.remove("selected");
+      }
+      item.classList.add("selected");
+      showVersion(version.version);
+    });
+    timeline.append(item);
+  }
+}
+
+function section(title, text, className) {
+  const fragment = document.createDocumentFragment();
+  fragment.append(element("h3", "", title), element("pre", className, text));
+  return fragment;
+}
+
+async function showVersion(name) {
+  const details = document.getElementById("details");
+  details.replaceChildren(element("p", "muted", "Loading…"));
+  try {
+    const { version, change_log: changeLog, previous } = await fetchJSON(`api/versions/${encodeURIComponent(name)}`);
+    details.replaceChildren(element("h2", "version", version.version));
+
+    const facts = element("dl");
+    const rows = [
+      ["Kind", version.kind],
+      ["Status", version.status],
+      ["Applied at", new Date(version.applied_at).toLocaleString()],
+      ["Duration", `${version.duration_ms} ms`],
+      ["Severity", version.severity],
+      ["Initiated by", version.initiated_by],
+      ["Host", version.environment && version.environment.hostname],
+      ["App version", version.environment && version.environment.app_version],
+      ["Checksum", version.checksum],
+    ];
+    for (const [label, value] of rows) {
+      if (value) {
+        facts.append(element("dt", "", label), element("dd", "", value));
+      }
+    }
+    details.append(facts);
+
+    if (version.error) {
+      details.append(section("Error", version.error, "error"));
+    }
+    details.append(section("Changes", changeLog || version.changes || "No changes recorded"));
+    if (version.statements) {
+      details.append(section("Statements", version.statements));
+    }
+    if (version.down_statements) {
+      details.append(section("Down statements", version.down_statements));
+    }
+
+    if (previous) {
+      const diff = element("div");
+      details.append(diff);
+      try {
+        const { text } = await fetchJSON(`api/diff?from=${encodeURIComponent(previous)}&to=${encodeURIComponent(version.version)}`);
+        diff.append(section(`Schema diff from ${previous}`, text));
+      } catch (error) {
+        diff.append(section(`Schema diff from ${previous}`, error.message, "error"));
+      }
+    }
+  } catch (error) {
+    details.replaceChildren(element("pre", "error", error.message));
+  }
+}
+
+async function load() {
+  try {
+    const history = await fetchJSON("api/history");
+    renderSummary(history);
+    renderDrift(history.drift);
+    renderTimeline(history);
+  } catch (error) {
+    document.getElementById("details").replaceChildren(element("pre", "error", error.message));
+  }
+}
+
+load();
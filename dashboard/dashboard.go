@@ -0,0 +1,116 @@
+// Package dashboard serves a small web UI over the migration history recorded by
+// AutoMigratePlugin: the timeline of versions, the schema diff of every version, the drift
+// status and the details of failed migrations. Its assets are embedded, so it only needs to
+// be mounted, as in
+//
+//	mux.Handle("/migrations/", http.StripPrefix("/migrations", dashboard.Handler(db, &User{})))
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/gorm"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// VersionResponse is the JSON document served for a single version
+type VersionResponse struct {
+	Version tracker.SchemaVersion `json:"version"`
+	// ChangeLog renders the change set of the version
+	ChangeLog string `json:"change_log"`
+	// Previous is the version recorded before, the one its diff is computed from
+	Previous string `json:"previous,omitempty"`
+}
+
+// DiffResponse is the JSON document served for the diff between two versions
+type DiffResponse struct {
+	Diff *tracker.VersionDiff `json:"diff"`
+	// Text renders the diff as a human readable report
+	Text string `json:"text"`
+}
+
+// dashboard serves the UI and the API it relies on
+type dashboard struct {
+	db *gorm.DB
+}
+
+// Handler returns an http.Handler serving the dashboard at its root, with the JSON API it
+// uses under api/. The drift status covers the given models, or those configured with
+// WithDriftCheck. It's meant to be mounted under an admin router, it doesn't authenticate.
+func Handler(db *gorm.DB, models ...interface{}) http.Handler {
+	d := &dashboard{db: db}
+	static, _ := fs.Sub(assets, "assets")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", http.FileServerFS(static))
+	mux.Handle("GET /assets/", http.StripPrefix("/assets", http.FileServerFS(static)))
+	mux.Handle("GET /api/history", tracker.HistoryHandler(db, models...))
+	mux.HandleFunc("GET /api/versions/{version}", d.version)
+	mux.HandleFunc("GET /api/diff", d.diff)
+	return mux
+}
+
+// version serves the details of a version
+func (d *dashboard) version(w http.ResponseWriter, r *http.Request) {
+	history, err := tracker.GetMigrationHistory(d.db.WithContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The history is ordered newest first
+	for i, schemaVersion := range history {
+		if schemaVersion.Version != r.PathValue("version") {
+			continue
+		}
+		response := VersionResponse{Version: schemaVersion}
+		if changes, err := schemaVersion.ParseChanges(); err == nil {
+			response.ChangeLog = changes.String()
+		}
+		if i+1 < len(history) {
+			response.Previous = history[i+1].Version
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	writeError(w, http.StatusNotFound, tracker.ErrVersionNotFound)
+}
+
+// diff serves the diff between the versions of the from and to query parameters
+func (d *dashboard) diff(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, errors.New("from and to are required"))
+		return
+	}
+
+	diff, err := tracker.DiffVersions(d.db.WithContext(r.Context()), from, to)
+	if errors.Is(err, tracker.ErrVersionNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, DiffResponse{Diff: diff, Text: diff.String()})
+}
+
+// writeJSON writes value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes err as a JSON response with the given status code
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,93 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+)
+
+// ddlKeywords are the leading keywords of statements treated as schema changes
+var ddlKeywords = []string{"CREATE", "ALTER", "DROP", "RENAME", "COMMENT", "TRUNCATE"}
+
+// isDDL reports whether the given SQL statement changes the schema
+func isDDL(sql string) bool {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return false
+	}
+
+	keyword := strings.ToUpper(fields[0])
+	for _, ddl := range ddlKeywords {
+		if keyword == ddl {
+			return true
+		}
+	}
+	return false
+}
+
+// joinStatements serializes captured statements for storage
+func joinStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n") + ";"
+}
+
+// statementTable extracts the table a DDL statement operates on
+func statementTable(sql string) string {
+	fields := strings.Fields(sql)
+	for i := 0; i < len(fields); i++ {
+		keyword := strings.ToUpper(fields[i])
+		switch keyword {
+		case "ON":
+			if i+1 < len(fields) {
+				return unquoteIdentifier(fields[i+1])
+			}
+		case "TABLE":
+			j := i + 1
+			for j < len(fields) && isIfExistsKeyword(fields[j]) {
+				j++
+			}
+			if j < len(fields) {
+				return unquoteIdentifier(fields[j])
+			}
+		}
+	}
+	return ""
+}
+
+// isIfExistsKeyword reports whether the token is part of an IF [NOT] EXISTS clause
+func isIfExistsKeyword(token string) bool {
+	switch strings.ToUpper(token) {
+	case "IF", "NOT", "EXISTS":
+		return true
+	}
+	return false
+}
+
+// unquoteIdentifier strips identifier quoting and trailing column lists from a token
+func unquoteIdentifier(token string) string {
+	if idx := strings.Index(token, "("); idx >= 0 {
+		token = token[:idx]
+	}
+	return strings.Trim(token, "`\"[]")
+}
+
+// classifyStatement rates the risk of a DDL statement
+func classifyStatement(sql string) Severity {
+	upper := strings.ToUpper(sql)
+	switch {
+	case strings.HasPrefix(upper, "DROP") && !isDestructiveStatement(sql):
+		return SeverityWarning
+	case strings.HasPrefix(upper, "DROP"), strings.HasPrefix(upper, "TRUNCATE"):
+		return SeverityDanger
+	case strings.HasPrefix(upper, "ALTER") && strings.Contains(upper, " DROP ") && isDestructiveStatement(sql):
+		return SeverityDanger
+	case strings.HasPrefix(upper, "ALTER") && strings.Contains(upper, " DROP "):
+		return SeverityWarning
+	case strings.HasPrefix(upper, "ALTER") && (strings.Contains(upper, " ALTER ") || strings.Contains(upper, " MODIFY ") || strings.Contains(upper, " RENAME ")):
+		return SeverityWarning
+	case strings.HasPrefix(upper, "RENAME"):
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
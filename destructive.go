@@ -0,0 +1,192 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrDestructiveChange is returned when AutoMigrate is stopped by the destructive change policy
+var ErrDestructiveChange = errors.New("destructive schema change")
+
+// DestructivePolicy decides what AutoMigrate does when it is about to apply destructive
+// changes such as column drops, type narrowing or index drops
+type DestructivePolicy int
+
+const (
+	// DestructiveAllow applies destructive changes without checking for them
+	DestructiveAllow DestructivePolicy = iota
+	// DestructiveWarn logs destructive changes, then applies them
+	DestructiveWarn
+	// DestructiveConfirm refuses destructive changes unless the context was marked with AllowDestructive
+	DestructiveConfirm
+	// DestructiveRefuse never applies destructive changes
+	DestructiveRefuse
+)
+
+// destructiveContextKey marks a context as confirming destructive changes
+type destructiveContextKey struct{}
+
+// AllowDestructive returns a context confirming destructive changes under the DestructiveConfirm
+// policy, e.g. db.WithContext(AllowDestructive(ctx)).AutoMigrate(&User{})
+func AllowDestructive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, destructiveContextKey{}, true)
+}
+
+// destructiveAllowed reports whether the context was marked with AllowDestructive
+func destructiveAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(destructiveContextKey{}).(bool)
+	return allowed
+}
+
+// checkDestructive applies the destructive change policy to the plan of AutoMigrate
+func (p *AutoMigratePlugin) checkDestructive(db *gorm.DB, plan *Plan) error {
+	if p.DestructivePolicy == DestructiveAllow || len(plan.Destructive) == 0 {
+		return nil
+	}
+
+	for _, change := range plan.Destructive {
+		p.Logger.Warn("Destructive change planned: %s", change)
+	}
+	switch {
+	case p.DestructivePolicy == DestructiveWarn:
+		return nil
+	case p.DestructivePolicy == DestructiveConfirm && destructiveAllowed(contextFrom(db)):
+		p.Logger.Info("Destructive changes confirmed, proceeding with AutoMigrate")
+		return nil
+	default:
+		p.Logger.Error("Refusing to apply %d destructive changes", len(plan.Destructive))
+		return fmt.Errorf("%w: %s", ErrDestructiveChange, strings.Join(plan.Destructive, "; "))
+	}
+}
+
+// destructiveChanges lists the statements that drop data or indexes and the column diffs
+// narrowing a type
+func destructiveChanges(statements []string, diffs []ColumnDiff) []string {
+	var changes []string
+	for i, statement := range statements {
+		if isDestructiveStatement(statement) && !isTableRebuild(statement, statements[i+1:]) {
+			changes = append(changes, statement)
+		}
+	}
+	for _, diff := range diffs {
+		if diff.Kind == ColumnTypeChanged && isNarrowing(diff.OldType, diff.NewType) {
+			changes = append(changes, fmt.Sprintf("%s.%s narrowed from %s to %s", diff.Table, diff.Column, diff.OldType, diff.NewType))
+		}
+	}
+	return changes
+}
+
+// classifyStatementAt rates the risk of statements[i], taking table rebuilds into account
+func classifyStatementAt(statements []string, i int) Severity {
+	severity := classifyStatement(statements[i])
+	if severity == SeverityDanger && isTableRebuild(statements[i], statements[i+1:]) {
+		return SeverityWarning
+	}
+	return severity
+}
+
+// isDestructiveStatement reports whether a statement drops a table, column, index or constraint
+func isDestructiveStatement(sql string) bool {
+	upper := strings.ToUpper(sql)
+	switch {
+	case strings.HasPrefix(upper, "DROP TRIGGER"), strings.HasPrefix(upper, "DROP FUNCTION"), strings.HasPrefix(upper, "DROP PROCEDURE"):
+		// Routines hold no data and are dropped to be created again
+		return false
+	case strings.HasPrefix(upper, "DROP"), strings.HasPrefix(upper, "TRUNCATE"):
+		return true
+	case strings.HasPrefix(upper, "ALTER") && strings.Contains(upper, " DROP "):
+		// Relaxing a column is not destructive
+		return !strings.Contains(upper, " DROP NOT NULL") && !strings.Contains(upper, " DROP DEFAULT")
+	default:
+		return false
+	}
+}
+
+// isTableRebuild reports whether a DROP TABLE is part of a table rebuild, as done by the
+// SQLite migrator, where a copy of the table is renamed to the dropped name afterwards
+func isTableRebuild(sql string, following []string) bool {
+	if !strings.HasPrefix(strings.ToUpper(sql), "DROP TABLE") {
+		return false
+	}
+	table := statementTable(sql)
+	for _, statement := range following {
+		upper := strings.ToUpper(statement)
+		if idx := strings.Index(upper, " RENAME TO "); idx >= 0 && unquoteIdentifier(strings.TrimSpace(statement[idx+len(" RENAME TO "):])) == table {
+			return true
+		}
+	}
+	return false
+}
+
+// typeFamilies groups column types by the kind of value they hold, ranked by width
+var typeFamilies = map[string]struct {
+	family string
+	width  int
+}{
+	"bool":              {"bool", 1},
+	"boolean":           {"bool", 1},
+	"tinyint":           {"integer", 1},
+	"smallint":          {"integer", 2},
+	"mediumint":         {"integer", 3},
+	"int":               {"integer", 4},
+	"integer":           {"integer", 4},
+	"bigint":            {"integer", 5},
+	"real":              {"float", 1},
+	"float":             {"float", 1},
+	"double":            {"float", 2},
+	"double precision":  {"float", 2},
+	"decimal":           {"float", 3},
+	"numeric":           {"float", 3},
+	"char":              {"string", 1},
+	"character":         {"string", 1},
+	"varchar":           {"string", 2},
+	"character varying": {"string", 2},
+	"nvarchar":          {"string", 2},
+	"tinytext":          {"string", 3},
+	"text":              {"string", 4},
+	"mediumtext":        {"string", 5},
+	"longtext":          {"string", 6},
+}
+
+// isNarrowing reports whether changing a column from oldType to newType may lose data
+func isNarrowing(oldType, newType string) bool {
+	oldBase, oldSize := splitType(oldType)
+	newBase, newSize := splitType(newType)
+	if oldBase == newBase {
+		oldWidth, oldOK := typeSize(oldSize)
+		newWidth, newOK := typeSize(newSize)
+		return oldOK && newOK && newWidth < oldWidth
+	}
+
+	from, fromOK := typeFamilies[oldBase]
+	to, toOK := typeFamilies[newBase]
+	if !fromOK || !toOK {
+		return false
+	}
+	switch {
+	case from.family == to.family:
+		return to.width < from.width
+	case to.family == "string":
+		// Anything can be represented as a string
+		return false
+	case from.family == "integer" && to.family == "float":
+		return false
+	default:
+		return true
+	}
+}
+
+// typeSize parses the leading size of a type suffix such as (255) or (10,2)
+func typeSize(size string) (int, bool) {
+	size = strings.Trim(size, "()")
+	if idx := strings.Index(size, ","); idx >= 0 {
+		size = size[:idx]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	return n, err == nil
+}
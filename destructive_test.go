@@ -0,0 +1,121 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// narrowedUser turns the name of the users into a number
+type narrowedUser struct {
+	ID   uint
+	Name int
+}
+
+func (narrowedUser) TableName() string { return "users" }
+
+func TestIsDestructiveStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"DROP TABLE `users`", true},
+		{"DROP INDEX `idx_users_name`", true},
+		{"TRUNCATE TABLE `users`", true},
+		{"ALTER TABLE `users` DROP COLUMN `email`", true},
+		{"ALTER TABLE users ALTER COLUMN email DROP NOT NULL", false},
+		{"ALTER TABLE users ALTER COLUMN email DROP DEFAULT", false},
+		{"DROP TRIGGER touch_updated_at", false},
+		{"ALTER TABLE `users` ADD `email` text", false},
+	}
+	for _, tt := range tests {
+		if got := isDestructiveStatement(tt.sql); got != tt.want {
+			t.Errorf("isDestructiveStatement(%q) = %t, want %t", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestIsNarrowing(t *testing.T) {
+	tests := []struct {
+		oldType, newType string
+		want             bool
+	}{
+		{"varchar(255)", "varchar(100)", true},
+		{"varchar(100)", "varchar(255)", false},
+		{"bigint", "int", true},
+		{"int", "bigint", false},
+		{"text", "varchar(255)", true},
+		{"integer", "text", false},
+		{"integer", "real", false},
+		{"text", "integer", true},
+		{"uuid", "text", false},
+	}
+	for _, tt := range tests {
+		if got := isNarrowing(tt.oldType, tt.newType); got != tt.want {
+			t.Errorf("isNarrowing(%s, %s) = %t, want %t", tt.oldType, tt.newType, got, tt.want)
+		}
+	}
+}
+
+func TestDestructiveChanges(t *testing.T) {
+	// The SQLite migrator rebuilds tables to alter them, which isn't a destructive drop
+	statements := []string{
+		"CREATE TABLE `users__temp` (`id` integer,`name` integer)",
+		"INSERT INTO `users__temp`(`id`,`name`) SELECT `id`,`name` FROM `users`",
+		"DROP TABLE `users`",
+		"ALTER TABLE `users__temp` RENAME TO `users`",
+		"DROP INDEX `idx_users_name`",
+	}
+	diffs := []ColumnDiff{{Table: "users", Column: "name", Kind: ColumnTypeChanged, OldType: "text", NewType: "integer"}}
+	changes := destructiveChanges(statements, diffs)
+	want := []string{"DROP INDEX `idx_users_name`", "users.name narrowed from text to integer"}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("destructiveChanges = %q, want %q", changes, want)
+	}
+}
+
+func TestDestructivePolicy(t *testing.T) {
+	tests := []struct {
+		policy  DestructivePolicy
+		confirm bool
+		wantErr bool
+	}{
+		{DestructiveAllow, false, false},
+		{DestructiveWarn, false, false},
+		{DestructiveConfirm, false, true},
+		{DestructiveConfirm, true, false},
+		{DestructiveRefuse, true, true},
+	}
+	for _, tt := range tests {
+		db, _ := openTestDB(t, WithDestructivePolicy(tt.policy))
+		migrateAs(t, db, "1", &pluginUser{})
+
+		ctx := ContextWithVersion(context.Background(), "2")
+		if tt.confirm {
+			ctx = AllowDestructive(ctx)
+		}
+		err := db.WithContext(ctx).AutoMigrate(&narrowedUser{})
+		if tt.wantErr {
+			if !errors.Is(err, ErrDestructiveChange) {
+				t.Errorf("policy %d, confirmed %t: AutoMigrate = %v, want ErrDestructiveChange", tt.policy, tt.confirm, err)
+			}
+			if len(mustHistory(t, db)) != 1 {
+				t.Errorf("policy %d, confirmed %t: the refused migration was recorded", tt.policy, tt.confirm)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("policy %d, confirmed %t: AutoMigrate: %v", tt.policy, tt.confirm, err)
+			continue
+		}
+		// The classification is recorded with the version
+		changes, err := mustRecorded(t, db, "2").ParseChanges()
+		if err != nil {
+			t.Fatalf("ParseChanges: %v", err)
+		}
+		if len(changes.Destructive) != 1 || !strings.Contains(changes.Destructive[0], "users.name narrowed") {
+			t.Errorf("policy %d, confirmed %t: destructive changes = %q, want the narrowed name", tt.policy, tt.confirm, changes.Destructive)
+		}
+	}
+}
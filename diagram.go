@@ -0,0 +1,155 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DiagramFormat selects the language SchemaDiagram renders
+type DiagramFormat string
+
+const (
+	// DiagramMermaid renders a Mermaid erDiagram
+	DiagramMermaid DiagramFormat = "mermaid"
+	// DiagramDOT renders a Graphviz digraph
+	DiagramDOT DiagramFormat = "dot"
+)
+
+// Relation is a reference from a column of one table to another table
+type Relation struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Target string `json:"target"`
+}
+
+// Relations infers the references between the tables of the snapshot from the GORM
+// naming convention, a users_id or user_id column referencing the users table
+func (s *SchemaSnapshot) Relations() []Relation {
+	var relations []Relation
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			name := strings.ToLower(column.Name)
+			if !strings.HasSuffix(name, "_id") {
+				continue
+			}
+			base := strings.TrimSuffix(name, "_id")
+			for _, candidate := range []string{base + "s", base + "es", strings.TrimSuffix(base, "y") + "ies", base} {
+				if _, ok := s.Table(candidate); ok && candidate != table.Name {
+					relations = append(relations, Relation{Table: table.Name, Column: column.Name, Target: candidate})
+					break
+				}
+			}
+		}
+	}
+	return relations
+}
+
+// Mermaid renders the snapshot as a Mermaid ER diagram
+func (s *SchemaSnapshot) Mermaid() string {
+	relations := s.Relations()
+
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, table := range s.Tables {
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(table.Name))
+		for _, column := range table.Columns {
+			fmt.Fprintf(&b, "        %s %s", mermaidName(column.Type), mermaidName(column.Name))
+			if keys := columnKeys(&table, column, relations); len(keys) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(keys, ","))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, relation := range relations {
+		fmt.Fprintf(&b, "    %s }o--|| %s : %q\n", mermaidName(relation.Table), mermaidName(relation.Target), relation.Column)
+	}
+	return b.String()
+}
+
+// DOT renders the snapshot as a Graphviz digraph
+func (s *SchemaSnapshot) DOT() string {
+	relations := s.Relations()
+
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=plaintext];\n")
+	for _, table := range s.Tables {
+		fmt.Fprintf(&b, "  %q [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", table.Name)
+		fmt.Fprintf(&b, "<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(table.Name))
+		for _, column := range table.Columns {
+			label := column.Name + " " + column.Type
+			if keys := columnKeys(&table, column, relations); len(keys) > 0 {
+				label += " " + strings.Join(keys, ",")
+			}
+			fmt.Fprintf(&b, "<tr><td align=\"left\" port=%q>%s</td></tr>", column.Name, html.EscapeString(label))
+		}
+		b.WriteString("</table>>];\n")
+	}
+	for _, relation := range relations {
+		fmt.Fprintf(&b, "  %q:%q -> %q;\n", relation.Table, relation.Column, relation.Target)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Diagram renders the snapshot in the given format
+func (s *SchemaSnapshot) Diagram(format DiagramFormat) (string, error) {
+	switch format {
+	case DiagramMermaid, "":
+		return s.Mermaid(), nil
+	case DiagramDOT:
+		return s.DOT(), nil
+	default:
+		return "", fmt.Errorf("unsupported diagram format %q", format)
+	}
+}
+
+// SchemaDiagram renders the schema recorded with a version as a diagram, or the live
+// schema when version is empty
+func SchemaDiagram(db *gorm.DB, version string, format DiagramFormat) (string, error) {
+	loggerFrom(db).Debug("SchemaDiagram function called for version %q", version)
+
+	snapshot, err := versionSnapshot(db, version)
+	if err != nil {
+		return "", err
+	}
+	return snapshot.Diagram(format)
+}
+
+// columnKeys lists the Mermaid key markers of a column
+func columnKeys(table *TableSchema, column ColumnSchema, relations []Relation) []string {
+	var keys []string
+	if column.PrimaryKey {
+		keys = append(keys, "PK")
+	}
+	for _, relation := range relations {
+		if relation.Table == table.Name && relation.Column == column.Name {
+			keys = append(keys, "FK")
+			break
+		}
+	}
+	for _, index := range table.Indexes {
+		if index.Unique && len(index.Columns) == 1 && index.Columns[0] == column.Name && !column.PrimaryKey {
+			keys = append(keys, "UK")
+			break
+		}
+	}
+	return keys
+}
+
+// mermaidName replaces the characters Mermaid doesn't accept in names and types
+func mermaidName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '(', r == ')':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
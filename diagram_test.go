@@ -0,0 +1,42 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDiagram(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &constraintCustomer{}, &constraintOrder{})
+
+	snapshot, err := mustRecorded(t, db, "1").ParseSnapshot()
+	if err != nil || snapshot == nil {
+		t.Fatalf("ParseSnapshot = %v, %v, want the snapshot of version 1", snapshot, err)
+	}
+	if relations := snapshot.Relations(); !slices.Equal(relations, []Relation{{Table: "orders", Column: "customer_id", Target: "customers"}}) {
+		t.Errorf("relations = %v, want orders referencing customers", relations)
+	}
+
+	mermaid, err := SchemaDiagram(db, "1", DiagramMermaid)
+	if err != nil {
+		t.Fatalf("SchemaDiagram: %v", err)
+	}
+	for _, want := range []string{"erDiagram\n", "    customers {\n", "        integer id PK\n", "        integer customer_id FK\n", `    orders }o--|| customers : "customer_id"`} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid diagram = %q, want %q", mermaid, want)
+		}
+	}
+
+	dot, err := SchemaDiagram(db, "", DiagramDOT)
+	if err != nil {
+		t.Fatalf("SchemaDiagram: %v", err)
+	}
+	if !strings.HasPrefix(dot, "digraph schema {\n") || !strings.Contains(dot, `"orders":"customer_id" -> "customers";`) {
+		t.Errorf("DOT diagram of the live schema = %q, want orders referencing customers", dot)
+	}
+
+	if _, err := SchemaDiagram(db, "1", "svg"); err == nil {
+		t.Error("SchemaDiagram in an unsupported format succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// trackingDialector wraps the configured dialector so AutoMigrate calls can be observed
+type trackingDialector struct {
+	gorm.Dialector
+	plugin *AutoMigratePlugin
+}
+
+// Migrator returns a migrator that routes AutoMigrate through the plugin
+func (d *trackingDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return &trackingMigrator{
+		Migrator: d.Dialector.Migrator(db),
+		db:       db,
+		dialect:  d,
+	}
+}
+
+// SavePoint forwards savepoint support to the wrapped dialector
+func (d *trackingDialector) SavePoint(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.SavePoint(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// RollbackTo forwards savepoint rollback support to the wrapped dialector
+func (d *trackingDialector) RollbackTo(tx *gorm.DB, name string) error {
+	if savePointer, ok := d.Dialector.(gorm.SavePointerDialectorInterface); ok {
+		return savePointer.RollbackTo(tx, name)
+	}
+	return gorm.ErrUnsupportedDriver
+}
+
+// Translate forwards error translation to the wrapped dialector
+func (d *trackingDialector) Translate(err error) error {
+	if translator, ok := d.Dialector.(gorm.ErrorTranslator); ok {
+		return translator.Translate(err)
+	}
+	return err
+}
+
+// trackingMigrator wraps a migrator and records every AutoMigrate it performs
+type trackingMigrator struct {
+	gorm.Migrator
+	db      *gorm.DB
+	dialect *trackingDialector
+}
+
+// AutoMigrate runs the wrapped AutoMigrate between the plugin's before and after hooks
+func (m *trackingMigrator) AutoMigrate(dst ...interface{}) error {
+	if _, ok := runFromDB(m.db); ok {
+		// Already inside a tracked run, don't record a nested one
+		return m.Migrator.AutoMigrate(dst...)
+	}
+
+	plugin := m.dialect.plugin
+	if skipsTracking(m.db) {
+		plugin.Logger.Debug("Tracking skipped for this AutoMigrate call")
+		return m.Migrator.AutoMigrate(dst...)
+	}
+	if tracked, ignored := plugin.trackedModels(m.db, dst); len(ignored) > 0 {
+		// Ignored models are migrated once the tracked ones are, without being recorded
+		plugin.Logger.Debug("Migrating %d ignored models without tracking them", len(ignored))
+		if len(tracked) > 0 {
+			if err := m.AutoMigrate(tracked...); err != nil {
+				return err
+			}
+		}
+		return m.Migrator.AutoMigrate(ignored...)
+	}
+
+	plugin.migrating.Lock()
+	defer plugin.migrating.Unlock()
+	var waitedForLock bool
+	if plugin.Locker != nil {
+		lock, waited, err := plugin.acquireLock(m.db)
+		if errors.Is(err, ErrLockNotAcquired) {
+			plugin.Logger.Info("Migration lock held by another instance, skipping AutoMigrate")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer plugin.releaseLock(m.db, lock)
+		waitedForLock = waited
+	}
+
+	plan, approved, err := plugin.checkPlan(m.db, dst)
+	if err != nil {
+		return err
+	}
+
+	tx := plugin.beforeAutoMigrate(m.db, dst)
+	run, _ := runFromDB(tx)
+	run.plan = plan
+	run.waitedForLock = waitedForLock
+	if approved != nil {
+		// Complete the approved record instead of recording a new version
+		run.pending, run.version = approved, approved.Version
+	}
+	ctx, span := plugin.tracer().StartMigration(tx.Statement.Context)
+	tx = tx.WithContext(ctx)
+
+	switch {
+	case !plugin.Atomic:
+		err = m.migrateModels(tx, run, dst)
+		plugin.afterAutoMigrate(tx, err)
+	case supportsTransactionalDDL(tx):
+		err = m.migrateInTransaction(tx, run, dst)
+	default:
+		err = m.migrateTwoPhase(tx, run, dst)
+	}
+	if err == nil {
+		err = tx.Error
+	}
+	if err == nil {
+		_, err = plugin.migrateViews(m.db)
+	}
+
+	plugin.observeMigration(tx, err)
+	plugin.notify(tx, err)
+	plugin.observeOutcome(tx, err)
+	plugin.reportError(tx, err)
+	plugin.endMigrationSpan(tx, span, err)
+	return err
+}
+
+// migrateModels migrates the models one at a time so each can be traced and timed, GORM
+// still adds their dependencies
+func (m *trackingMigrator) migrateModels(tx *gorm.DB, run *migrationRun, dst []interface{}) error {
+	plugin := m.dialect.plugin
+	ctx := contextFrom(tx)
+	for i, model := range dst {
+		run.beginModel(i)
+		plugin.reportModelStarted(tx, run, i)
+		modelCtx, modelSpan := plugin.tracer().StartModel(ctx, modelName(model))
+		modelSpan.SetAttribute(AttributeModel, modelName(model))
+		modelStart := plugin.now()
+		migrator := m.dialect.Dialector.Migrator(tx.WithContext(modelCtx))
+		var err error
+		if i < len(run.enums) {
+			err = plugin.migrateEnums(tx.WithContext(modelCtx), run.enums[i])
+		}
+		if err == nil && i < len(run.renames) {
+			err = plugin.renameColumns(migrator, model, run.renames[i])
+		}
+		if err == nil {
+			err = migrator.AutoMigrate(model)
+		}
+		run.addDuration(plugin.now().Sub(modelStart))
+		modelSpan.End(err)
+		plugin.reportModelDone(tx, run, i, err)
+		if err != nil {
+			return err
+		}
+	}
+	return plugin.applyRoutines(tx, run)
+}
+
+// migrateInTransaction applies the DDL and records the SchemaVersion in a single transaction.
+// When it is rolled back the failure is recorded outside of it.
+func (m *trackingMigrator) migrateInTransaction(tx *gorm.DB, run *migrationRun, dst []interface{}) error {
+	plugin := m.dialect.plugin
+	pending := run.pending
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := m.migrateModels(tx, run, dst); err != nil {
+			return err
+		}
+		plugin.afterAutoMigrate(tx, nil)
+		return tx.Error
+	})
+	if err != nil {
+		plugin.Logger.Warn("AutoMigrate transaction rolled back: %v", err)
+		run.historyRows = 0
+		run.recorded = nil
+		// None of the captured statements survived the rollback
+		run.mu.Lock()
+		run.statements, run.byModel = nil, nil
+		run.mu.Unlock()
+		run.routines, run.down = nil, nil
+		run.pending = pending
+		plugin.afterAutoMigrate(tx, err)
+	}
+	return err
+}
+
+// migrateTwoPhase records a pending SchemaVersion before applying the DDL and completes it
+// afterwards, for dialects that commit DDL implicitly
+func (m *trackingMigrator) migrateTwoPhase(tx *gorm.DB, run *migrationRun, dst []interface{}) error {
+	plugin := m.dialect.plugin
+	if run.pending == nil {
+		if err := plugin.recordPending(tx, run); err != nil {
+			return err
+		}
+	}
+	err := m.migrateModels(tx, run, dst)
+	plugin.afterAutoMigrate(tx, err)
+	return err
+}
+
+// supportsTransactionalDDL reports whether DDL can be rolled back on the connected dialect
+func supportsTransactionalDDL(db *gorm.DB) bool {
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite", "sqlserver":
+		return true
+	default:
+		return false
+	}
+}
+
+// untrackedMigrator returns a migrator that bypasses the plugin, for the plugin's own tables.
+// Its session skips tracking, as the wrapped AutoMigrate creates tables through db.Migrator().
+func untrackedMigrator(db *gorm.DB) gorm.Migrator {
+	if d, ok := db.Dialector.(*trackingDialector); ok {
+		return d.Dialector.Migrator(db.Session(&gorm.Session{Context: SkipTracking(contextFrom(db))}))
+	}
+	return db.Migrator()
+}
@@ -0,0 +1,138 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type concurrentOrder struct {
+	ID    uint
+	Total int
+}
+
+type concurrentInvoice struct {
+	ID     uint
+	Amount int
+}
+
+type concurrentShipment struct {
+	ID      uint
+	Carrier string
+}
+
+type concurrentRefund struct {
+	ID     uint
+	Reason string
+}
+
+func TestConcurrentAutoMigrate(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	models := map[string]interface{}{
+		"concurrent_orders":    &concurrentOrder{},
+		"concurrent_invoices":  &concurrentInvoice{},
+		"concurrent_shipments": &concurrentShipment{},
+		"concurrent_refunds":   &concurrentRefund{},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(models))
+	for table, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.AutoMigrate(model); err != nil {
+				errs <- fmt.Errorf("AutoMigrate %s: %w", table, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	history := mustHistory(t, db)
+	if len(history) != len(models) {
+		t.Fatalf("recorded %d versions, want one per AutoMigrate call (%d)", len(history), len(models))
+	}
+	versions := map[string]bool{}
+	for _, schemaVersion := range history {
+		versions[schemaVersion.Version] = true
+
+		// Every run records the statements of its own model only
+		var own []string
+		for table := range models {
+			if strings.Contains(schemaVersion.Statements, "`"+table+"`") {
+				own = append(own, table)
+			}
+		}
+		if len(own) != 1 {
+			t.Errorf("version %s records the statements of %v, want exactly one model: %s", schemaVersion.Version, own, schemaVersion.Statements)
+		}
+	}
+	for i := 1; i <= len(models); i++ {
+		if version := fmt.Sprintf("V%d", i); !versions[version] {
+			t.Errorf("version %s not recorded, the sequence was generated by overlapping runs: %v", version, versions)
+		}
+	}
+}
+
+func TestConcurrentAutoMigrateSameModel(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(NewSequenceVersionGenerator("V")), WithSkipNoop(true))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.AutoMigrate(&concurrentOrder{}); err != nil {
+				t.Errorf("AutoMigrate: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The runs are serialized, only the first one executes statements
+	history := mustHistory(t, db)
+	if len(history) != 1 {
+		t.Fatalf("recorded %d versions, want 1", len(history))
+	}
+	if !strings.Contains(history[0].Statements, "CREATE TABLE `concurrent_orders`") {
+		t.Errorf("statements = %q, want the table created once", history[0].Statements)
+	}
+}
+
+func TestAtomicAutoMigrate(t *testing.T) {
+	db, _ := openTestDB(t, WithAtomic(true))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	recorded := mustRecorded(t, db, "1")
+	if recorded.Status != StatusSuccess || !strings.Contains(recorded.Statements, "CREATE TABLE `users`") {
+		t.Errorf("version 1 = %s %q, want the users table created", recorded.Status, recorded.Statements)
+	}
+}
+
+func TestAtomicAutoMigrateRollsBack(t *testing.T) {
+	db, _ := openTestDB(t, WithAtomic(true))
+	migrateErr := db.WithContext(ContextWithVersion(db.Statement.Context, "1")).AutoMigrate(&pluginUser{}, &failingModel{})
+	if migrateErr == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	// The users table was created in the rolled back transaction
+	if db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("the DDL preceding the failure survived the rollback")
+	}
+	failed := mustRecorded(t, db, "1")
+	if failed.Status != StatusFailed || failed.Error != migrateErr.Error() {
+		t.Errorf("version 1 = %s %q, want the failure recorded", failed.Status, failed.Error)
+	}
+	if failed.Statements != "" {
+		t.Errorf("version 1 statements = %q, want none as they were rolled back", failed.Statements)
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("recorded %d versions, want only the failure", len(history))
+	}
+}
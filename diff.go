@@ -0,0 +1,455 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ChangeKind describes the kind of change applied to a column, an index, a constraint, an
+// enum type, a view or a routine
+type ChangeKind string
+
+const (
+	ColumnAdded           ChangeKind = "added"
+	ColumnDropped         ChangeKind = "dropped"
+	ColumnTypeChanged     ChangeKind = "type_changed"
+	ColumnNullableChanged ChangeKind = "nullable_changed"
+	ColumnRenamed         ChangeKind = "renamed"
+
+	IndexCreated       ChangeKind = "created"
+	IndexDropped       ChangeKind = "dropped"
+	IndexRenamed       ChangeKind = "renamed"
+	IndexUniqueChanged ChangeKind = "unique_changed"
+
+	ConstraintAdded   ChangeKind = "added"
+	ConstraintDropped ChangeKind = "dropped"
+
+	EnumCreated    ChangeKind = "created"
+	EnumValueAdded ChangeKind = "value_added"
+
+	ViewCreated  ChangeKind = "created"
+	ViewReplaced ChangeKind = "replaced"
+
+	RoutineCreated  ChangeKind = "created"
+	RoutineReplaced ChangeKind = "replaced"
+)
+
+// ColumnSchema is the introspected definition of a single column
+type ColumnSchema struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	// RenamedFrom is the column a model column was renamed from, set from the renamedFrom tag
+	// setting or by rename detection
+	RenamedFrom string `json:"-"`
+}
+
+// IndexSchema is the introspected definition of an index
+type IndexSchema struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+	// Type is the index method declared by the model, such as btree or gin, dialects don't
+	// report it for live indexes
+	Type string `json:"type,omitempty"`
+}
+
+// TableSchema is the introspected definition of a table
+type TableSchema struct {
+	Name    string         `json:"name"`
+	Exists  bool           `json:"-"`
+	Columns []ColumnSchema `json:"columns"`
+	Indexes []IndexSchema  `json:"indexes,omitempty"`
+	// Constraints lists the foreign key, check and unique constraints, sorted by name
+	Constraints []ConstraintSchema `json:"constraints,omitempty"`
+}
+
+// column returns the named column of the table, if present
+func (t *TableSchema) column(name string) (ColumnSchema, bool) {
+	for _, column := range t.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+// index returns the named index of the table, if present
+func (t *TableSchema) index(name string) (IndexSchema, bool) {
+	for _, index := range t.Indexes {
+		if strings.EqualFold(index.Name, name) {
+			return index, true
+		}
+	}
+	return IndexSchema{}, false
+}
+
+// ColumnDiff is a single column-level difference between two table definitions
+type ColumnDiff struct {
+	Table  string     `json:"table"`
+	Column string     `json:"column"`
+	Kind   ChangeKind `json:"kind"`
+	// OldName is the previous name of a renamed column
+	OldName     string `json:"old_name,omitempty"`
+	OldType     string `json:"old_type,omitempty"`
+	NewType     string `json:"new_type,omitempty"`
+	OldNullable bool   `json:"old_nullable"`
+	NewNullable bool   `json:"new_nullable"`
+}
+
+// String renders the diff as a single human readable line
+func (d ColumnDiff) String() string {
+	switch d.Kind {
+	case ColumnAdded:
+		return fmt.Sprintf("%s.%s added (%s, nullable=%t)", d.Table, d.Column, d.NewType, d.NewNullable)
+	case ColumnDropped:
+		return fmt.Sprintf("%s.%s dropped (was %s, nullable=%t)", d.Table, d.Column, d.OldType, d.OldNullable)
+	case ColumnTypeChanged:
+		return fmt.Sprintf("%s.%s type changed from %s to %s", d.Table, d.Column, d.OldType, d.NewType)
+	case ColumnNullableChanged:
+		return fmt.Sprintf("%s.%s nullable changed from %t to %t", d.Table, d.Column, d.OldNullable, d.NewNullable)
+	case ColumnRenamed:
+		return fmt.Sprintf("%s.%s renamed to %s", d.Table, d.OldName, d.Column)
+	default:
+		return fmt.Sprintf("%s.%s %s", d.Table, d.Column, d.Kind)
+	}
+}
+
+// IndexDiff is a single index-level difference between two table definitions
+type IndexDiff struct {
+	Table string     `json:"table"`
+	Index string     `json:"index"`
+	Kind  ChangeKind `json:"kind"`
+	// OldName is the previous name of a renamed index
+	OldName   string   `json:"old_name,omitempty"`
+	Columns   []string `json:"columns,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	OldUnique bool     `json:"old_unique"`
+	NewUnique bool     `json:"new_unique"`
+}
+
+// String renders the diff as a single human readable line
+func (d IndexDiff) String() string {
+	columns := strings.Join(d.Columns, ", ")
+	if d.Type != "" {
+		columns += " using " + d.Type
+	}
+	switch d.Kind {
+	case IndexCreated:
+		return fmt.Sprintf("%s.%s index created on (%s), unique=%t", d.Table, d.Index, columns, d.NewUnique)
+	case IndexDropped:
+		return fmt.Sprintf("%s.%s index dropped (was on %s, unique=%t)", d.Table, d.Index, columns, d.OldUnique)
+	case IndexRenamed:
+		return fmt.Sprintf("%s.%s index renamed to %s", d.Table, d.OldName, d.Index)
+	case IndexUniqueChanged:
+		return fmt.Sprintf("%s.%s index unique changed from %t to %t", d.Table, d.Index, d.OldUnique, d.NewUnique)
+	default:
+		return fmt.Sprintf("%s.%s index %s", d.Table, d.Index, d.Kind)
+	}
+}
+
+// DiffContext is like Diff but cancels the introspection queries when ctx is done
+func DiffContext(ctx context.Context, db *gorm.DB, model interface{}) ([]ColumnDiff, error) {
+	return Diff(db.WithContext(ctx), model)
+}
+
+// Diff compares the live table of a model against the model definition and
+// returns the column changes AutoMigrate would need to reconcile them
+func Diff(db *gorm.DB, model interface{}) ([]ColumnDiff, error) {
+	live, err := inspectTable(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := modelTableSchema(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffTables(db.Migrator(), live, expected), nil
+}
+
+// inspectTable introspects the live table backing a model, or the table of the given name
+func inspectTable(db *gorm.DB, model interface{}) (*TableSchema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if name, ok := model.(string); ok {
+		stmt.Table = name
+	} else if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model %s: %w", modelName(model), err)
+	}
+
+	table := &TableSchema{Name: stmt.Table}
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) {
+		return table, nil
+	}
+	table.Exists = true
+
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect columns of %s: %w", stmt.Table, err)
+	}
+
+	for _, columnType := range columnTypes {
+		column := ColumnSchema{Name: columnType.Name(), Type: columnType.DatabaseTypeName()}
+		if fullType, ok := columnType.ColumnType(); ok && fullType != "" {
+			column.Type = fullType
+		}
+		if nullable, ok := columnType.Nullable(); ok {
+			column.Nullable = nullable
+		}
+		if primaryKey, ok := columnType.PrimaryKey(); ok {
+			column.PrimaryKey = primaryKey
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	// Constraints of dialects that can't be inspected are unknown as well
+	if constraints, err := inspectConstraints(db, stmt.Table); err == nil {
+		table.Constraints = constraints
+	}
+
+	// Not every dialect can list indexes, treat that as a table without known indexes
+	indexes, err := migrator.GetIndexes(model)
+	if err != nil {
+		return table, nil
+	}
+	for _, index := range indexes {
+		if primaryKey, ok := index.PrimaryKey(); ok && primaryKey {
+			continue
+		}
+		unique, _ := index.Unique()
+		table.Indexes = append(table.Indexes, IndexSchema{
+			Name:    index.Name(),
+			Columns: index.Columns(),
+			Unique:  unique,
+		})
+	}
+	return table, nil
+}
+
+// modelTableSchema builds the table definition a model describes
+func modelTableSchema(db *gorm.DB, model interface{}) (*TableSchema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model %s: %w", modelName(model), err)
+	}
+
+	table := &TableSchema{Name: stmt.Table, Exists: true}
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		if field.IgnoreMigration {
+			continue
+		}
+		table.Columns = append(table.Columns, ColumnSchema{
+			Name:        dbName,
+			Type:        db.Dialector.DataTypeOf(field),
+			Nullable:    !field.NotNull && !field.PrimaryKey,
+			PrimaryKey:  field.PrimaryKey,
+			RenamedFrom: field.TagSettings[renamedFromTag],
+		})
+	}
+
+	for _, index := range stmt.Schema.ParseIndexes() {
+		columns := make([]string, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			if option.Field != nil {
+				columns = append(columns, option.DBName)
+			}
+		}
+		table.Indexes = append(table.Indexes, IndexSchema{
+			Name:    index.Name,
+			Columns: columns,
+			Unique:  index.Class == "UNIQUE",
+			Type:    strings.ToLower(index.Type),
+		})
+	}
+	sort.Slice(table.Indexes, func(i, j int) bool {
+		return table.Indexes[i].Name < table.Indexes[j].Name
+	})
+	table.Constraints = modelConstraints(stmt.Schema)
+	return table, nil
+}
+
+// diffTables computes the column changes needed to go from the old to the new table definition,
+// a new column renamed from an old one is reported as a rename rather than dropped and added
+func diffTables(migrator gorm.Migrator, old, new *TableSchema) []ColumnDiff {
+	var diffs []ColumnDiff
+	renamed := map[string]bool{}
+	for _, column := range new.Columns {
+		previous, ok := old.column(column.Name)
+		if !ok {
+			previous, ok = renamedColumn(old, new, column)
+			if !ok {
+				diffs = append(diffs, ColumnDiff{
+					Table:       new.Name,
+					Column:      column.Name,
+					Kind:        ColumnAdded,
+					NewType:     column.Type,
+					NewNullable: column.Nullable,
+				})
+				continue
+			}
+			renamed[strings.ToLower(previous.Name)] = true
+			diffs = append(diffs, ColumnDiff{
+				Table:       new.Name,
+				Column:      column.Name,
+				Kind:        ColumnRenamed,
+				OldName:     previous.Name,
+				OldType:     previous.Type,
+				NewType:     column.Type,
+				OldNullable: previous.Nullable,
+				NewNullable: column.Nullable,
+			})
+		}
+
+		if !sameType(migrator, previous.Type, column.Type) {
+			diffs = append(diffs, ColumnDiff{
+				Table:   new.Name,
+				Column:  column.Name,
+				Kind:    ColumnTypeChanged,
+				OldType: previous.Type,
+				NewType: column.Type,
+			})
+		}
+		// Primary keys are implicitly NOT NULL, but not every dialect reports them that way
+		if previous.Nullable != column.Nullable && !previous.PrimaryKey && !column.PrimaryKey {
+			diffs = append(diffs, ColumnDiff{
+				Table:       new.Name,
+				Column:      column.Name,
+				Kind:        ColumnNullableChanged,
+				OldNullable: previous.Nullable,
+				NewNullable: column.Nullable,
+			})
+		}
+	}
+
+	for _, column := range old.Columns {
+		if _, ok := new.column(column.Name); !ok && !renamed[strings.ToLower(column.Name)] {
+			diffs = append(diffs, ColumnDiff{
+				Table:       old.Name,
+				Column:      column.Name,
+				Kind:        ColumnDropped,
+				OldType:     column.Type,
+				OldNullable: column.Nullable,
+			})
+		}
+	}
+
+	return diffs
+}
+
+// diffIndexes computes the index changes needed to go from the old to the new table
+// definition. A dropped and a created index on the same columns are reported as a rename,
+// an index whose columns changed as dropped and created again.
+func diffIndexes(old, new *TableSchema) []IndexDiff {
+	var created, dropped, diffs []IndexDiff
+	for _, index := range new.Indexes {
+		previous, ok := old.index(index.Name)
+		if ok && slices.Equal(previous.Columns, index.Columns) {
+			if previous.Unique != index.Unique {
+				diffs = append(diffs, IndexDiff{
+					Table:     new.Name,
+					Index:     index.Name,
+					Kind:      IndexUniqueChanged,
+					Columns:   index.Columns,
+					Type:      index.Type,
+					OldUnique: previous.Unique,
+					NewUnique: index.Unique,
+				})
+			}
+			continue
+		}
+		if ok {
+			dropped = append(dropped, IndexDiff{
+				Table:     old.Name,
+				Index:     previous.Name,
+				Kind:      IndexDropped,
+				Columns:   previous.Columns,
+				Type:      previous.Type,
+				OldUnique: previous.Unique,
+			})
+		}
+		created = append(created, IndexDiff{
+			Table:     new.Name,
+			Index:     index.Name,
+			Kind:      IndexCreated,
+			Columns:   index.Columns,
+			Type:      index.Type,
+			NewUnique: index.Unique,
+		})
+	}
+	for _, index := range old.Indexes {
+		if _, ok := new.index(index.Name); !ok {
+			dropped = append(dropped, IndexDiff{
+				Table:     old.Name,
+				Index:     index.Name,
+				Kind:      IndexDropped,
+				Columns:   index.Columns,
+				Type:      index.Type,
+				OldUnique: index.Unique,
+			})
+		}
+	}
+
+	for _, drop := range dropped {
+		i := slices.IndexFunc(created, func(create IndexDiff) bool {
+			return create.Index != drop.Index && create.NewUnique == drop.OldUnique && slices.Equal(create.Columns, drop.Columns)
+		})
+		if i < 0 {
+			diffs = append(diffs, drop)
+			continue
+		}
+		created[i].Kind = IndexRenamed
+		created[i].OldName = drop.Index
+		created[i].OldUnique = drop.OldUnique
+	}
+	return append(diffs, created...)
+}
+
+// sameType reports whether two column types are equivalent, taking dialect aliases into account
+func sameType(migrator gorm.Migrator, a, b string) bool {
+	baseA, sizeA := splitType(a)
+	baseB, sizeB := splitType(b)
+	if sizeA != "" && sizeB != "" && sizeA != sizeB {
+		return false
+	}
+	if baseA == baseB {
+		return true
+	}
+
+	for _, alias := range migrator.GetTypeAliases(baseA) {
+		if strings.EqualFold(alias, baseB) {
+			return true
+		}
+	}
+	for _, alias := range migrator.GetTypeAliases(baseB) {
+		if strings.EqualFold(alias, baseA) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeConstraintMarkers start the constraint part some dialects append to a data type
+var typeConstraintMarkers = []string{" primary key", " autoincrement", " auto_increment", " not null", " default "}
+
+// splitType splits a column type such as varchar(255) into its lower-cased base name and size
+func splitType(columnType string) (string, string) {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	for _, marker := range typeConstraintMarkers {
+		if idx := strings.Index(columnType, marker); idx >= 0 {
+			columnType = strings.TrimSpace(columnType[:idx])
+		}
+	}
+	if idx := strings.Index(columnType, "("); idx >= 0 {
+		return strings.TrimSpace(columnType[:idx]), strings.TrimSpace(columnType[idx:])
+	}
+	return columnType, ""
+}
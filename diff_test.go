@@ -0,0 +1,137 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffTables(t *testing.T) {
+	db, _ := openTestDB(t)
+	old := &TableSchema{Name: "users", Exists: true, Columns: []ColumnSchema{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "name", Type: "varchar(100)", Nullable: true},
+		{Name: "age", Type: "integer", Nullable: true},
+		{Name: "legacy", Type: "text", Nullable: true},
+		{Name: "mail", Type: "text", Nullable: true},
+	}}
+	new := &TableSchema{Name: "users", Columns: []ColumnSchema{
+		{Name: "id", Type: "integer", PrimaryKey: true, Nullable: true},
+		{Name: "name", Type: "varchar(255)", Nullable: true},
+		{Name: "age", Type: "integer"},
+		{Name: "email", Type: "text", Nullable: true, RenamedFrom: "mail"},
+		{Name: "created_at", Type: "datetime", Nullable: true},
+	}}
+
+	want := []ColumnDiff{
+		{Table: "users", Column: "name", Kind: ColumnTypeChanged, OldType: "varchar(100)", NewType: "varchar(255)"},
+		{Table: "users", Column: "age", Kind: ColumnNullableChanged, OldNullable: true},
+		{Table: "users", Column: "email", Kind: ColumnRenamed, OldName: "mail", OldType: "text", NewType: "text", OldNullable: true, NewNullable: true},
+		{Table: "users", Column: "created_at", Kind: ColumnAdded, NewType: "datetime", NewNullable: true},
+		{Table: "users", Column: "legacy", Kind: ColumnDropped, OldType: "text", OldNullable: true},
+	}
+	if got := diffTables(db.Migrator(), old, new); !slices.Equal(got, want) {
+		t.Errorf("diffTables =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDiffTablesTypeAliases(t *testing.T) {
+	db, _ := openTestDB(t)
+	old := &TableSchema{Name: "users", Columns: []ColumnSchema{{Name: "id", Type: "INTEGER PRIMARY KEY AUTOINCREMENT"}}}
+	new := &TableSchema{Name: "users", Columns: []ColumnSchema{{Name: "id", Type: "integer"}}}
+	if diffs := diffTables(db.Migrator(), old, new); len(diffs) != 0 {
+		t.Errorf("diffTables = %v, want no changes between equivalent types", diffs)
+	}
+}
+
+func TestDiffIndexes(t *testing.T) {
+	old := &TableSchema{Name: "users", Indexes: []IndexSchema{
+		{Name: "idx_users_name", Columns: []string{"name"}},
+		{Name: "idx_users_email", Columns: []string{"email"}},
+		{Name: "idx_users_legacy", Columns: []string{"legacy"}},
+	}}
+	new := &TableSchema{Name: "users", Indexes: []IndexSchema{
+		{Name: "idx_users_name", Columns: []string{"name"}, Unique: true},
+		{Name: "idx_email", Columns: []string{"email"}},
+		{Name: "idx_users_age", Columns: []string{"age"}},
+	}}
+
+	want := []IndexDiff{
+		{Table: "users", Index: "idx_users_name", Kind: IndexUniqueChanged, Columns: []string{"name"}, NewUnique: true},
+		{Table: "users", Index: "idx_users_legacy", Kind: IndexDropped, Columns: []string{"legacy"}},
+		{Table: "users", Index: "idx_email", Kind: IndexRenamed, OldName: "idx_users_email", Columns: []string{"email"}},
+		{Table: "users", Index: "idx_users_age", Kind: IndexCreated, Columns: []string{"age"}},
+	}
+	got := diffIndexes(old, new)
+	if !slices.EqualFunc(got, want, func(a, b IndexDiff) bool { return a.String() == b.String() && a.Kind == b.Kind }) {
+		t.Errorf("diffIndexes =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+
+	diffs, err := Diff(db, &pluginUserWithEmail{})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Kind != ColumnAdded || diffs[0].Column != "email" {
+		t.Errorf("Diff = %v, want the email column added", diffs)
+	}
+
+	diffs, err = Diff(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Diff of a migrated model = %v, want no changes", diffs)
+	}
+}
+
+func TestAutoMigrateRecordsColumnChanges(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	changes, err := mustRecorded(t, db, "2").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 {
+		t.Fatalf("recorded changes of %d models, want 1", len(changes.Models))
+	}
+	columns := changes.Models[0].Columns
+	if len(columns) != 1 || columns[0].Kind != ColumnAdded || columns[0].Table != "users" || columns[0].Column != "email" {
+		t.Errorf("recorded column changes = %v, want users.email added", columns)
+	}
+}
+
+type indexedUser struct {
+	ID   uint
+	Name string `gorm:"uniqueIndex:idx_users_name"`
+}
+
+func (indexedUser) TableName() string { return "users" }
+
+func TestAutoMigrateRecordsIndexChanges(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &indexedUser{})
+
+	changes, err := mustRecorded(t, db, "2").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 {
+		t.Fatalf("recorded changes of %d models, want 1", len(changes.Models))
+	}
+	want := []IndexDiff{{Table: "users", Index: "idx_users_name", Kind: IndexCreated, Columns: []string{"name"}, NewUnique: true}}
+	indexes := changes.Models[0].Indexes
+	if !slices.EqualFunc(indexes, want, func(a, b IndexDiff) bool { return a.String() == b.String() && a.Kind == b.Kind }) {
+		t.Errorf("recorded index changes = %v, want %v", indexes, want)
+	}
+	if !strings.Contains(changes.String(), "users.idx_users_name index created on (name), unique=true") {
+		t.Errorf("change log = %q, want the index creation", changes.String())
+	}
+}
@@ -0,0 +1,232 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DriftReport lists the schema objects that exist only in the models or only in the database
+type DriftReport struct {
+	// MissingTables are model tables absent from the database
+	MissingTables []string `json:"missing_tables"`
+	// UnmanagedTables are database tables without a corresponding model
+	UnmanagedTables []string `json:"unmanaged_tables"`
+	// MissingColumns are model columns, as table.column, absent from the database
+	MissingColumns []string `json:"missing_columns"`
+	// UnmanagedColumns are database columns, as table.column, without a model field
+	UnmanagedColumns []string `json:"unmanaged_columns"`
+	// MissingIndexes are model indexes, as table.index, absent from the database
+	MissingIndexes []string `json:"missing_indexes"`
+	// UnmanagedIndexes are database indexes, as table.index, not declared by a model
+	UnmanagedIndexes []string `json:"unmanaged_indexes"`
+}
+
+// HasDrift reports whether any discrepancy was found
+func (r *DriftReport) HasDrift() bool {
+	return len(r.MissingTables) > 0 || len(r.UnmanagedTables) > 0 ||
+		len(r.MissingColumns) > 0 || len(r.UnmanagedColumns) > 0 ||
+		len(r.MissingIndexes) > 0 || len(r.UnmanagedIndexes) > 0
+}
+
+// String renders the report as one discrepancy per line
+func (r *DriftReport) String() string {
+	var b strings.Builder
+	sections := []struct {
+		label string
+		items []string
+	}{
+		{"missing table", r.MissingTables},
+		{"unmanaged table", r.UnmanagedTables},
+		{"missing column", r.MissingColumns},
+		{"unmanaged column", r.UnmanagedColumns},
+		{"missing index", r.MissingIndexes},
+		{"unmanaged index", r.UnmanagedIndexes},
+	}
+	for _, section := range sections {
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "%s %s\n", section.label, item)
+		}
+	}
+	return b.String()
+}
+
+// DetectDriftContext is like DetectDrift but cancels the introspection queries when ctx is done
+func DetectDriftContext(ctx context.Context, db *gorm.DB, models ...interface{}) (*DriftReport, error) {
+	return DetectDrift(db.WithContext(ctx), models...)
+}
+
+// DetectDrift compares the live database schema against the given models
+func DetectDrift(db *gorm.DB, models ...interface{}) (*DriftReport, error) {
+	return detectDrift(db, loggerFrom(db), historyTableName(db), models)
+}
+
+// detectDrift compares the live database schema against the given models, ignoring the history table
+func detectDrift(db *gorm.DB, logger Logger, historyTable string, models []interface{}) (*DriftReport, error) {
+	logger.Debug("DetectDrift function called for %d models", len(models))
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		logger.Error("Failed to list database tables: %v", err)
+		return nil, fmt.Errorf("failed to list database tables: %w", err)
+	}
+
+	report := &DriftReport{}
+	managed := map[string]bool{}
+	for _, model := range models {
+		expected, err := modelTableSchema(db, model)
+		if err != nil {
+			return nil, err
+		}
+		managed[expected.Name] = true
+		if isIgnoredTable(db, expected.Name) {
+			continue
+		}
+
+		live, err := inspectTable(db, model)
+		if err != nil {
+			return nil, err
+		}
+		if !live.Exists {
+			report.MissingTables = append(report.MissingTables, expected.Name)
+			continue
+		}
+
+		for _, column := range expected.Columns {
+			if _, ok := live.column(column.Name); !ok {
+				report.MissingColumns = append(report.MissingColumns, expected.Name+"."+column.Name)
+			}
+		}
+		for _, column := range live.Columns {
+			if _, ok := expected.column(column.Name); !ok {
+				report.UnmanagedColumns = append(report.UnmanagedColumns, expected.Name+"."+column.Name)
+			}
+		}
+		for _, index := range expected.Indexes {
+			if _, ok := live.index(index.Name); !ok {
+				report.MissingIndexes = append(report.MissingIndexes, expected.Name+"."+index.Name)
+			}
+		}
+		for _, index := range live.Indexes {
+			if _, ok := expected.index(index.Name); !ok {
+				report.UnmanagedIndexes = append(report.UnmanagedIndexes, expected.Name+"."+index.Name)
+			}
+		}
+	}
+
+	for _, table := range tables {
+		if !managed[table] && !isTrackerTable(db, historyTable, table) && !isInternalTable(table) && !isIgnoredTable(db, table) {
+			report.UnmanagedTables = append(report.UnmanagedTables, table)
+		}
+	}
+	sort.Strings(report.UnmanagedTables)
+
+	logger.Debug("Drift detection completed, drift found: %t", report.HasDrift())
+	return report, nil
+}
+
+// checkDrift runs drift detection for the configured models and records a drift entry when needed
+func (p *AutoMigratePlugin) checkDrift(db *gorm.DB) error {
+	p.Logger.Debug("Checking %d models for drift", len(p.DriftModels))
+	report, err := detectDrift(db, p.Logger, p.historyTableName(db), p.DriftModels)
+	if err != nil {
+		p.Logger.Error("Failed to detect drift: %v", err)
+		return fmt.Errorf("failed to detect drift: %w", err)
+	}
+	if !report.HasDrift() {
+		p.Logger.Info("No schema drift detected")
+		return nil
+	}
+	p.Logger.Warn("Schema drift detected:\n%s", report)
+	if p.AutoRepairDrift {
+		_, err := p.repairDrift(db, report, p.DriftModels)
+		return err
+	}
+
+	// The drift is observed, not applied, so the version is generated for an empty plan and
+	// doesn't bump a semantic version
+	startTime := p.now()
+	version, err := p.generateVersion(withPlan(db, &Plan{}), startTime)
+	if err != nil {
+		p.Logger.Error("Failed to generate version: %v", err)
+		return fmt.Errorf("failed to generate version: %w", err)
+	}
+
+	changes, err := encodeChangeSet(&ChangeSet{Drift: report})
+	if err != nil {
+		return err
+	}
+	return p.record(db, &SchemaVersion{
+		Version:   version,
+		Kind:      KindDrift,
+		Status:    StatusSuccess,
+		AppliedAt: startTime,
+		Changes:   changes,
+	})
+}
+
+// HistoryTableName returns the name of the history table of the plugin registered on db,
+// qualified with its schema when one is configured
+func HistoryTableName(db *gorm.DB) string {
+	return historyTableName(db)
+}
+
+// historyTableName returns the name of the history table used on db
+func historyTableName(db *gorm.DB) string {
+	if p, ok := pluginFrom(db); ok {
+		return p.historyTableName(db)
+	}
+	return (&AutoMigratePlugin{}).historyTableName(db)
+}
+
+// historyTableName returns the name of the configured history table
+func (p *AutoMigratePlugin) historyTableName(db *gorm.DB) string {
+	name := p.TableName
+	if name == "" {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(&SchemaVersion{}); err != nil {
+			return ""
+		}
+		name = stmt.Table
+	}
+	if p.TenantTables {
+		if tenant := p.tenant(db); tenant != "" {
+			return tenant + "." + name
+		}
+	}
+	if p.TableSchema != "" {
+		return p.TableSchema + "." + name
+	}
+	return name
+}
+
+// splitTableName splits an optionally schema qualified table name
+func splitTableName(table string) (schema, name string) {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+// isTrackerTable reports whether an unqualified table name, as listed by the migrator,
+// is one of the tables the plugin maintains for itself
+func isTrackerTable(db *gorm.DB, historyTable, table string) bool {
+	names := []string{historyTable, entriesTableFor(db, historyTable), lockTableName(db), leaseTableName(db), outboxTableName(db), compatibilityTableName(db)}
+	if p, ok := pluginFrom(db); ok && p.FlywayTable != "" {
+		names = append(names, p.FlywayTable)
+	}
+	for _, name := range names {
+		if _, name = splitTableName(name); name == table {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternalTable reports whether a table belongs to the database engine itself
+func isInternalTable(table string) bool {
+	return strings.HasPrefix(table, "sqlite_")
+}
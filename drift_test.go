@@ -0,0 +1,93 @@
+package gorm_migrate_tracker
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// driftedDatabase creates at path a database drifted from pluginUser and squashedOrder: the
+// orders table and the name of the users are missing, and legacy objects are left around
+func driftedDatabase(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+	db, _ := openTestDBAt(t, path)
+	statements := []string{
+		"CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`legacy` text)",
+		"CREATE TABLE `audit_log` (`id` integer PRIMARY KEY)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to create the drifted schema: %v", err)
+		}
+	}
+	return db
+}
+
+func TestDetectDrift(t *testing.T) {
+	db := driftedDatabase(t, filepath.Join(t.TempDir(), "test.db"))
+
+	report, err := DetectDrift(db, &pluginUser{}, &squashedOrder{})
+	if err != nil {
+		t.Fatalf("DetectDrift: %v", err)
+	}
+	want := &DriftReport{
+		MissingTables:    []string{"squashed_orders"},
+		UnmanagedTables:  []string{"audit_log"},
+		MissingColumns:   []string{"users.name"},
+		UnmanagedColumns: []string{"users.legacy"},
+	}
+	for _, check := range []struct {
+		label     string
+		got, want []string
+	}{
+		{"missing tables", report.MissingTables, want.MissingTables},
+		{"unmanaged tables", report.UnmanagedTables, want.UnmanagedTables},
+		{"missing columns", report.MissingColumns, want.MissingColumns},
+		{"unmanaged columns", report.UnmanagedColumns, want.UnmanagedColumns},
+	} {
+		if !slices.Equal(check.got, check.want) {
+			t.Errorf("%s = %v, want %v", check.label, check.got, check.want)
+		}
+	}
+
+	migrateAs(t, db, "1", &pluginUser{}, &squashedOrder{})
+	if err := db.Exec("DROP TABLE `audit_log`").Error; err != nil {
+		t.Fatalf("failed to drop audit_log: %v", err)
+	}
+	report, err = DetectDrift(db, &pluginUser{}, &squashedOrder{})
+	if err != nil {
+		t.Fatalf("DetectDrift: %v", err)
+	}
+	// Columns left behind by the models aren't dropped by AutoMigrate
+	if !slices.Equal(report.UnmanagedColumns, []string{"users.legacy"}) || len(report.MissingTables)+len(report.MissingColumns) > 0 {
+		t.Errorf("drift after migrating = %+v, want only the legacy column", report)
+	}
+}
+
+func TestDriftCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	driftedDatabase(t, path)
+
+	// The drift found on Initialize is recorded without repairing it
+	db, _ := openTestDBAt(t, path, WithDriftCheck(&pluginUser{}, &squashedOrder{}))
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Kind != KindDrift {
+		t.Fatalf("history = %v, want a drift record", history)
+	}
+	changes, err := history[0].ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if changes.Drift == nil || !slices.Equal(changes.Drift.MissingColumns, []string{"users.name"}) {
+		t.Errorf("recorded drift = %+v, want the missing name column", changes.Drift)
+	}
+	if db.Migrator().HasTable(&squashedOrder{}) {
+		t.Error("the drift check created the missing table")
+	}
+	// A drift record doesn't describe the schema
+	if _, err := GetCurrentVersion(db); err == nil {
+		t.Error("the drift record is the current version")
+	}
+}
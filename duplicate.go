@@ -0,0 +1,174 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateVersion is returned when a version is already recorded and DuplicateVersions
+// is DuplicateFail, or when no free version was found
+var ErrDuplicateVersion = errors.New("schema version is already recorded")
+
+// DuplicateVersionPolicy decides how a record whose version is already recorded is handled,
+// such as after a restart within the same second or when two replicas migrate at once
+type DuplicateVersionPolicy int
+
+const (
+	// DuplicateRegenerate records under a version generated again, suffixed with a counter
+	// when the generator returns the same version
+	DuplicateRegenerate DuplicateVersionPolicy = iota
+	// DuplicateMerge merges a successful record into the successful record of the same kind
+	// holding the version, other conflicts, such as failed runs, are regenerated
+	DuplicateMerge
+	// DuplicateFail fails the record with ErrDuplicateVersion
+	DuplicateFail
+)
+
+// String returns the name of the policy
+func (p DuplicateVersionPolicy) String() string {
+	switch p {
+	case DuplicateMerge:
+		return "merge"
+	case DuplicateFail:
+		return "fail"
+	default:
+		return "regenerate"
+	}
+}
+
+// maxVersionAttempts caps the versions tried for a single record
+const maxVersionAttempts = 10
+
+// insertVersion creates the record of schemaVersion, resolving a version that is already
+// recorded with the DuplicateVersions policy. The version is looked up before the insertion,
+// so a conflict doesn't abort the transaction the record is part of, and again when the
+// insertion failed in case another replica recorded it in the meantime.
+func (p *AutoMigratePlugin) insertVersion(db *gorm.DB, schemaVersion *SchemaVersion) error {
+	requested := schemaVersion.Version
+	for attempt := 1; ; attempt++ {
+		existing, err := p.recordedVersion(db, schemaVersion.Version)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			err := p.RecordRetry.retry(p.historyConn(db), p.Logger, func() error {
+				return p.withOutbox(db, schemaVersion, func(tx *gorm.DB) error {
+					return p.createVersion(tx, schemaVersion)
+				})
+			})
+			if err == nil || attempt == maxVersionAttempts || !p.duplicateVersion(db, schemaVersion.Version, err) {
+				return err
+			}
+			continue
+		}
+
+		switch {
+		case p.DuplicateVersions == DuplicateFail:
+			return fmt.Errorf("%w: %s", ErrDuplicateVersion, schemaVersion.Version)
+		case p.DuplicateVersions == DuplicateMerge && mergeable(existing, schemaVersion):
+			return p.mergeVersion(db, existing, schemaVersion)
+		case attempt == maxVersionAttempts:
+			return fmt.Errorf("%w: %s after %d attempts", ErrDuplicateVersion, requested, attempt)
+		}
+
+		version, err := p.generateVersion(db, p.now())
+		if err != nil {
+			return fmt.Errorf("failed to generate version: %w", err)
+		}
+		if version == requested || version == schemaVersion.Version {
+			version = fmt.Sprintf("%s_%d", requested, attempt)
+		}
+		p.Logger.Warn("Version %s is already recorded, recording as %s", schemaVersion.Version, version)
+		schemaVersion.Version = version
+		p.sign(schemaVersion)
+	}
+}
+
+// duplicateVersion reports whether the insertion of version failed because it's already recorded
+func (p *AutoMigratePlugin) duplicateVersion(db *gorm.DB, version string, err error) bool {
+	if p.DuplicateVersions == DuplicateFail {
+		return false
+	}
+	if errors.Is(translateError(p.historyConn(db), err), gorm.ErrDuplicatedKey) {
+		return true
+	}
+	existing, findErr := p.recordedVersion(db, version)
+	return findErr == nil && existing != nil
+}
+
+// recordedVersion returns the record holding version, nil when there's none
+func (p *AutoMigratePlugin) recordedVersion(db *gorm.DB, version string) (*SchemaVersion, error) {
+	history, stored, err := p.storedHistory(db)
+	if !stored && err == nil {
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("version = ?", version).Limit(1).Find(&history).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve schema version %s: %w", version, err)
+	}
+	for _, recorded := range history {
+		if recorded.Version == version {
+			return &recorded, nil
+		}
+	}
+	return nil, nil
+}
+
+// mergeable reports whether schemaVersion can be merged into the existing record of its version.
+// Only successful runs are merged, a failed run would otherwise overwrite the status of a version
+// that was applied.
+func mergeable(existing, schemaVersion *SchemaVersion) bool {
+	return existing.Status == StatusSuccess && schemaVersion.Status == StatusSuccess && existing.Kind == schemaVersion.Kind
+}
+
+// mergeVersion merges schemaVersion into the existing record holding its version: the changes
+// and statements are appended, the down statements prepended and the durations added up
+func (p *AutoMigratePlugin) mergeVersion(db *gorm.DB, existing, schemaVersion *SchemaVersion) error {
+	merged, err := existing.ParseChanges()
+	if err != nil {
+		return err
+	}
+	changes, err := schemaVersion.ParseChanges()
+	if err != nil {
+		return err
+	}
+	merged.Models = append(merged.Models, changes.Models...)
+	merged.Views = append(merged.Views, changes.Views...)
+	merged.Routines = append(merged.Routines, changes.Routines...)
+	merged.Destructive = append(merged.Destructive, changes.Destructive...)
+	merged.Notes = append(merged.Notes, changes.Notes...)
+	if merged.Operation == "" {
+		merged.Operation = changes.Operation
+	}
+	if merged.Drift == nil {
+		merged.Drift = changes.Drift
+	}
+	encoded, err := encodeChangeSet(merged)
+	if err != nil {
+		return err
+	}
+
+	schemaVersion.ID = existing.ID
+	schemaVersion.Changes = encoded
+	schemaVersion.Statements = joinStatements(slices.Concat(splitStatements(existing.Statements), splitStatements(schemaVersion.Statements)))
+	schemaVersion.DownStatements = joinStatements(slices.Concat(splitStatements(schemaVersion.DownStatements), splitStatements(existing.DownStatements)))
+	schemaVersion.DurationMs += existing.DurationMs
+	schemaVersion.Severity = maxSeverity(existing.Severity, schemaVersion.Severity)
+	if schemaVersion.Snapshot == "" {
+		schemaVersion.Snapshot = existing.Snapshot
+	}
+	if len(existing.Labels) > 0 {
+		labels := maps.Clone(existing.Labels)
+		maps.Copy(labels, schemaVersion.Labels)
+		schemaVersion.Labels = labels
+	}
+	p.sign(schemaVersion)
+
+	p.Logger.Warn("Version %s is already recorded, merging the changes into it", schemaVersion.Version)
+	return p.withOutbox(db, schemaVersion, func(tx *gorm.DB) error {
+		return p.updateVersion(tx, schemaVersion)
+	})
+}
@@ -0,0 +1,70 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateRegenerate(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "1", &squashedOrder{})
+
+	// The context keeps generating the taken version, it's suffixed instead
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, []string{"1", "1_1"}) {
+		t.Errorf("versions = %v, want [1 1_1]", versions)
+	}
+}
+
+func TestDuplicateMerge(t *testing.T) {
+	db, _ := openTestDB(t, WithDuplicateVersions(DuplicateMerge), WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "1", &squashedOrder{})
+
+	history := mustHistory(t, db)
+	if len(history) != 1 {
+		t.Fatalf("recorded %d versions, want the runs merged", len(history))
+	}
+	merged := history[0]
+	users, orders := strings.Index(merged.Statements, "CREATE TABLE `users`"), strings.Index(merged.Statements, "CREATE TABLE `squashed_orders`")
+	if users < 0 || orders < users {
+		t.Errorf("merged statements = %q, want both tables created in order", merged.Statements)
+	}
+	// Reverting the merged record drops the orders first
+	if !strings.HasPrefix(merged.DownStatements, "DROP TABLE `squashed_orders`") {
+		t.Errorf("merged down statements = %q, want the orders dropped first", merged.DownStatements)
+	}
+	changes, err := merged.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 2 {
+		t.Errorf("merged changes = %+v, want both models", changes.Models)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory: %v", err)
+	}
+
+	// A failed run isn't merged into an applied version
+	if err := db.WithContext(ContextWithVersion(context.Background(), "1")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+	if recorded := mustRecorded(t, db, "1_1"); recorded.Status != StatusFailed {
+		t.Errorf("version 1_1 status = %s, want the failure recorded apart", recorded.Status)
+	}
+}
+
+func TestDuplicateFail(t *testing.T) {
+	db, _ := openTestDB(t, WithDuplicateVersions(DuplicateFail))
+	migrateAs(t, db, "1", &pluginUser{})
+	err := db.WithContext(ContextWithVersion(context.Background(), "1")).AutoMigrate(&squashedOrder{})
+	if !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("AutoMigrate under a taken version = %v, want ErrDuplicateVersion", err)
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("recorded %d versions, want the duplicate refused", len(history))
+	}
+}
@@ -0,0 +1,142 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// encryptedPrefix marks column values encrypted by the plugin
+const encryptedPrefix = "enc:"
+
+// ErrNoEncryptor is returned when reading encrypted history without a configured Encryptor
+var ErrNoEncryptor = errors.New("migration history is encrypted but no encryptor is configured")
+
+// Encryptor encrypts the changes, statements and snapshot of every SchemaVersion before they
+// are stored, implement it to delegate to a KMS or use NewAESEncryptor with a local key
+type Encryptor interface {
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// aesEncryptor encrypts with AES-GCM, prefixing the ciphertext with its random nonce
+type aesEncryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESEncryptor creates an Encryptor using AES-GCM with a 16, 24 or 32 byte key
+func NewAESEncryptor(key []byte) (Encryptor, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &aesEncryptor{aead: aead}, nil
+}
+
+// Encrypt seals plaintext under a random nonce
+func (e *aesEncryptor) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens ciphertext sealed by Encrypt
+func (e *aesEncryptor) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < e.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:e.aead.NonceSize()], ciphertext[e.aead.NonceSize():]
+	return e.aead.Open(nil, nonce, sealed, nil)
+}
+
+// BeforeSave compresses and encrypts the columns holding DDL and schema details when the
+// plugin has a Compressor or an Encryptor
+func (v *SchemaVersion) BeforeSave(tx *gorm.DB) error {
+	p, ok := pluginFrom(tx)
+	if !ok || (p.Encryptor == nil && p.Compressor == nil) {
+		return nil
+	}
+	for _, field := range v.encodedFields() {
+		if *field == "" || strings.HasPrefix(*field, encryptedPrefix) || strings.HasPrefix(*field, compressedPrefix) {
+			continue
+		}
+		value := *field
+		if p.Compressor != nil && len(value) >= CompressionThreshold {
+			compressed, err := compress(p.Compressor, value)
+			if err != nil {
+				return fmt.Errorf("failed to compress schema version %s: %w", v.Version, err)
+			}
+			value = compressed
+		}
+		if p.Encryptor != nil {
+			ciphertext, err := p.Encryptor.Encrypt(contextFrom(tx), []byte(value))
+			if err != nil {
+				return fmt.Errorf("failed to encrypt schema version %s: %w", v.Version, err)
+			}
+			value = encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+		}
+		*field = value
+	}
+	return nil
+}
+
+// AfterSave decodes the columns encoded by BeforeSave, so the saved value stays usable
+func (v *SchemaVersion) AfterSave(tx *gorm.DB) error {
+	return v.decode(tx)
+}
+
+// AfterFind decodes the columns of a retrieved SchemaVersion
+func (v *SchemaVersion) AfterFind(tx *gorm.DB) error {
+	return v.decode(tx)
+}
+
+// decode decrypts and decompresses the encoded columns, plain ones are left as is
+func (v *SchemaVersion) decode(tx *gorm.DB) error {
+	for _, field := range v.encodedFields() {
+		if encoded, ok := strings.CutPrefix(*field, encryptedPrefix); ok {
+			p, ok := pluginFrom(tx)
+			if !ok || p.Encryptor == nil {
+				return ErrNoEncryptor
+			}
+			ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("failed to decode encrypted schema version %s: %w", v.Version, err)
+			}
+			plaintext, err := p.Encryptor.Decrypt(contextFrom(tx), ciphertext)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt schema version %s: %w", v.Version, err)
+			}
+			*field = string(plaintext)
+		}
+		if strings.HasPrefix(*field, compressedPrefix) {
+			var compressor Compressor
+			if p, ok := pluginFrom(tx); ok {
+				compressor = p.Compressor
+			}
+			decompressed, err := decompress(compressor, *field)
+			if err != nil {
+				return fmt.Errorf("failed to decompress schema version %s: %w", v.Version, err)
+			}
+			*field = decompressed
+		}
+	}
+	return nil
+}
+
+// encodedFields returns the columns holding DDL and schema details
+func (v *SchemaVersion) encodedFields() []*string {
+	return []*string{&v.Changes, &v.Statements, &v.DownStatements, &v.Snapshot}
+}
@@ -0,0 +1,117 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+// storedColumn returns the raw value of a history column of version, as stored in the table
+func storedColumn(t *testing.T, db *gorm.DB, version, column string) string {
+	t.Helper()
+	var values []string
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", version).Pluck(column, &values).Error; err != nil {
+		t.Fatalf("failed to read the %s of version %s: %v", column, version, err)
+	}
+	if len(values) != 1 {
+		t.Fatalf("version %s is recorded %d times", version, len(values))
+	}
+	return values[0]
+}
+
+// countingEncryptor wraps an Encryptor, counting its calls
+type countingEncryptor struct {
+	Encryptor
+	encrypted, decrypted int
+}
+
+func (e *countingEncryptor) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	e.encrypted++
+	return e.Encryptor.Encrypt(ctx, plaintext)
+}
+
+func (e *countingEncryptor) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	e.decrypted++
+	return e.Encryptor.Decrypt(ctx, ciphertext)
+}
+
+func TestEncryptedHistory(t *testing.T) {
+	aes, err := NewAESEncryptor(testEncryptionKey)
+	if err != nil {
+		t.Fatalf("NewAESEncryptor: %v", err)
+	}
+	encryptor := &countingEncryptor{Encryptor: aes}
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, _ := openTestDBAt(t, path, WithEncryptor(encryptor), WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	for _, column := range []string{"changes", "statements", "down_statements", "snapshot"} {
+		if stored := storedColumn(t, db, "1", column); !strings.HasPrefix(stored, encryptedPrefix) {
+			t.Errorf("%s stored as %q, want it encrypted", column, stored)
+		}
+	}
+	if encryptor.encrypted == 0 {
+		t.Error("the configured encryptor wasn't called")
+	}
+
+	// The history reads and verifies as plaintext
+	recorded := mustRecorded(t, db, "1")
+	if !strings.Contains(recorded.Statements, "CREATE TABLE `users`") {
+		t.Errorf("statements = %q, want them decrypted", recorded.Statements)
+	}
+	if encryptor.decrypted == 0 {
+		t.Error("the configured encryptor didn't decrypt the history")
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory of an encrypted history: %v", err)
+	}
+
+	// Another instance without the key can't read it
+	reader, _ := openTestDBAt(t, path)
+	if _, err := GetMigrationHistory(reader); !errors.Is(err, ErrNoEncryptor) {
+		t.Errorf("GetMigrationHistory without an encryptor = %v, want ErrNoEncryptor", err)
+	}
+}
+
+func TestAESEncryptor(t *testing.T) {
+	encryptor, err := NewAESEncryptor(testEncryptionKey)
+	if err != nil {
+		t.Fatalf("NewAESEncryptor: %v", err)
+	}
+	ctx := context.Background()
+	first, err := encryptor.Encrypt(ctx, []byte("CREATE TABLE users"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	second, err := encryptor.Encrypt(ctx, []byte("CREATE TABLE users"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if string(first) == string(second) {
+		t.Error("the same plaintext encrypted twice gives the same ciphertext")
+	}
+	plaintext, err := encryptor.Decrypt(ctx, first)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if string(plaintext) != "CREATE TABLE users" {
+		t.Errorf("Decrypt = %q, want the plaintext", plaintext)
+	}
+
+	first[len(first)-1] ^= 0xff
+	if _, err := encryptor.Decrypt(ctx, first); err == nil {
+		t.Error("Decrypt of a modified ciphertext succeeded")
+	}
+	if _, err := encryptor.Decrypt(ctx, []byte("short")); err == nil {
+		t.Error("Decrypt of a truncated ciphertext succeeded")
+	}
+	if _, err := NewAESEncryptor([]byte("too short")); err == nil {
+		t.Error("NewAESEncryptor with an invalid key size succeeded")
+	}
+}
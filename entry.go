@@ -0,0 +1,128 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersionEntry records the changes applied to a single model as part of a SchemaVersion
+type SchemaVersionEntry struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	SchemaVersionID uint      `gorm:"index" json:"schema_version_id"`
+	Version         string    `gorm:"index" json:"version"`
+	Model           string    `json:"model"`
+	TableName       string    `gorm:"index" json:"table_name"`
+	Statements      string    `json:"statements"`
+	DurationMs      int64     `json:"duration_ms"`
+	AppliedAt       time.Time `gorm:"index" json:"applied_at"`
+}
+
+// entriesTableFor resolves the entry table that belongs to historyTable, in the same schema
+func entriesTableFor(db *gorm.DB, historyTable string) string {
+	schema, name := splitTableName(historyTable)
+	if name != (&AutoMigratePlugin{}).historyTableName(db) {
+		name += "_entries"
+	} else {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(&SchemaVersionEntry{}); err != nil {
+			return ""
+		}
+		name = stmt.Table
+	}
+	if schema != "" {
+		return schema + "." + name
+	}
+	return name
+}
+
+// entriesDB scopes db to the configured entry table
+func (p *AutoMigratePlugin) entriesDB(db *gorm.DB) *gorm.DB {
+	conn := p.historyConn(db)
+	if p.TableName == "" && p.TableSchema == "" {
+		return conn
+	}
+	return conn.Table(entriesTableFor(db, p.historyTableName(db)))
+}
+
+// entriesDB scopes db to the entry table of the plugin registered on db
+func entriesDB(db *gorm.DB) *gorm.DB {
+	if p, ok := pluginFrom(db); ok {
+		return p.entriesDB(db)
+	}
+	return db
+}
+
+// recordEntries inserts one entry per migrated model of the run, in batches within a single
+// transaction so migrating many models doesn't cost a round trip each
+func (p *AutoMigratePlugin) recordEntries(db *gorm.DB, schemaVersion *SchemaVersion, run *migrationRun, after []*TableSchema) error {
+	p.Logger.Debug("Recording %d SchemaVersionEntry records", len(run.models))
+	if len(run.models) == 0 {
+		return nil
+	}
+	entries := make([]SchemaVersionEntry, 0, len(run.models))
+	for i, model := range run.models {
+		entry := SchemaVersionEntry{
+			SchemaVersionID: schemaVersion.ID,
+			Version:         schemaVersion.Version,
+			Model:           modelName(model),
+			Statements:      joinStatements(run.byModel[i]),
+			AppliedAt:       schemaVersion.AppliedAt,
+		}
+		if i < len(after) && after[i] != nil {
+			entry.TableName = after[i].Name
+		}
+		if i < len(run.durations) {
+			entry.DurationMs = run.durations[i].Milliseconds()
+		}
+		entries = append(entries, entry)
+	}
+
+	err := p.historyConn(db.Session(&gorm.Session{NewDB: true})).Transaction(func(tx *gorm.DB) error {
+		return p.entriesDB(tx.Session(&gorm.Session{NewDB: true})).CreateInBatches(&entries, 100).Error
+	})
+	if err != nil {
+		p.Logger.Error("Failed to record schema version entries of %s: %v", schemaVersion.Version, err)
+		return fmt.Errorf("failed to record schema version entries of %s: %w", schemaVersion.Version, err)
+	}
+	return nil
+}
+
+// GetTableHistoryContext is like GetTableHistory but cancels the query when ctx is done
+func GetTableHistoryContext(ctx context.Context, db *gorm.DB, table string) ([]SchemaVersionEntry, error) {
+	return GetTableHistory(db.WithContext(ctx), table)
+}
+
+// GetTableHistory retrieves the per-model entries recorded for a table, newest first
+func GetTableHistory(db *gorm.DB, table string) ([]SchemaVersionEntry, error) {
+	logger := loggerFrom(db)
+	logger.Debug("GetTableHistory function called for %s", table)
+
+	var entries []SchemaVersionEntry
+	if err := entriesDB(db).Where("table_name = ?", table).Order("applied_at desc").Find(&entries).Error; err != nil {
+		logger.Error("Failed to retrieve history of table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to retrieve history of table %s: %w", table, err)
+	}
+	return entries, nil
+}
+
+// LastTableChangeContext is like LastTableChange but cancels the query when ctx is done
+func LastTableChangeContext(ctx context.Context, db *gorm.DB, table string) (*SchemaVersionEntry, error) {
+	return LastTableChange(db.WithContext(ctx), table)
+}
+
+// LastTableChange returns the most recent entry that applied statements to a table, or
+// ErrVersionNotFound when the table never changed
+func LastTableChange(db *gorm.DB, table string) (*SchemaVersionEntry, error) {
+	var entry SchemaVersionEntry
+	err := entriesDB(db).Where("table_name = ? AND statements <> ?", table, "").Order("applied_at desc").Limit(1).Find(&entry).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve last change of table %s: %w", table, err)
+	}
+	if entry.ID == 0 {
+		return nil, fmt.Errorf("%w: no change recorded for table %s", ErrVersionNotFound, table)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,81 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecordEntries(t *testing.T) {
+	db, _ := openTestDB(t, WithEntries(true))
+	migrateAs(t, db, "1", &pluginUser{}, &squashedOrder{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{}, &squashedOrder{})
+
+	history, err := GetTableHistory(db, "users")
+	if err != nil {
+		t.Fatalf("GetTableHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("recorded %d entries of users, want 2", len(history))
+	}
+	for _, entry := range history {
+		if entry.SchemaVersionID != mustRecorded(t, db, entry.Version).ID {
+			t.Errorf("entry of version %s doesn't reference its SchemaVersion", entry.Version)
+		}
+	}
+
+	// Every model gets its own statements
+	orders, err := GetTableHistory(db, "squashed_orders")
+	if err != nil {
+		t.Fatalf("GetTableHistory: %v", err)
+	}
+	for _, entry := range orders {
+		if strings.Contains(entry.Statements, "`users`") {
+			t.Errorf("entry of squashed_orders in version %s holds the statements of users: %s", entry.Version, entry.Statements)
+		}
+	}
+
+	// The orders were left untouched by version 2
+	last, err := LastTableChange(db, "squashed_orders")
+	if err != nil {
+		t.Fatalf("LastTableChange: %v", err)
+	}
+	if last.Version != "1" {
+		t.Errorf("last change of squashed_orders = %s, want 1", last.Version)
+	}
+	last, err = LastTableChange(db, "users")
+	if err != nil {
+		t.Fatalf("LastTableChange: %v", err)
+	}
+	if last.Version != "2" || !strings.Contains(last.Statements, "ADD `email`") {
+		t.Errorf("last change of users = %s %q, want the email column added by 2", last.Version, last.Statements)
+	}
+
+	if _, err := LastTableChange(db, "missing"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("LastTableChange of an unknown table = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestRecordEntriesInBatches(t *testing.T) {
+	db, plugin := openTestDB(t, WithEntries(true))
+	migrateAs(t, db, "1", &pluginUser{})
+	schemaVersion := mustRecorded(t, db, "1")
+
+	// More models than a batch holds are all recorded
+	run := &migrationRun{byModel: map[int][]string{}}
+	for i := range 250 {
+		run.models = append(run.models, &pluginUser{})
+		run.byModel[i] = []string{fmt.Sprintf("SELECT %d", i)}
+	}
+	if err := plugin.recordEntries(db, &schemaVersion, run, nil); err != nil {
+		t.Fatalf("recordEntries: %v", err)
+	}
+	var count int64
+	if err := entriesDB(db).Model(&SchemaVersionEntry{}).Where("schema_version_id = ?", schemaVersion.ID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count the entries: %v", err)
+	}
+	if count != 1+250 {
+		t.Errorf("recorded %d entries, want %d", count, 1+250)
+	}
+}
@@ -0,0 +1,181 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// EnumType is implemented by the Go types of model fields backed by a Postgres enum type,
+// named after the data type of the field, as in
+//
+//	type Mood string
+//
+//	func (Mood) GormDataType() string { return "mood" }
+//	func (Mood) EnumValues() []string  { return []string{"happy", "sad"} }
+//
+// AutoMigrate creates the type and adds new values before migrating the model, which plain
+// GORM leaves to the application. Values can't be removed from a Postgres enum, values
+// dropped from EnumValues are left in place.
+type EnumType interface {
+	EnumValues() []string
+}
+
+// EnumDiff is the creation of a Postgres enum type or the addition of values to it
+type EnumDiff struct {
+	Type   string     `json:"type"`
+	Kind   ChangeKind `json:"kind"`
+	Values []string   `json:"values"`
+	// Statements creates the type or adds the values
+	Statements []string `json:"-"`
+}
+
+// String renders the diff as a single human readable line
+func (d EnumDiff) String() string {
+	switch d.Kind {
+	case EnumCreated:
+		return fmt.Sprintf("enum %s created with (%s)", d.Type, strings.Join(d.Values, ", "))
+	default:
+		return fmt.Sprintf("enum %s values added (%s)", d.Type, strings.Join(d.Values, ", "))
+	}
+}
+
+// modelEnums returns the values of the enum types the fields of a model are backed by, in
+// the order of the fields
+func modelEnums(db *gorm.DB, model interface{}) ([]string, map[string][]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse model %s: %w", modelName(model), err)
+	}
+
+	var names []string
+	values := map[string][]string{}
+	for _, field := range stmt.Schema.Fields {
+		if field.IgnoreMigration || field.DBName == "" || field.DataType == "" {
+			continue
+		}
+		enum, ok := reflect.New(field.IndirectFieldType).Interface().(EnumType)
+		if !ok {
+			continue
+		}
+		name := string(field.DataType)
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = enum.EnumValues()
+	}
+	return names, values, nil
+}
+
+// liveEnumValues lists the values of a Postgres enum type in their sort order, it reports
+// whether the type exists
+func liveEnumValues(db *gorm.DB, name string) ([]string, bool, error) {
+	schemaName, typeName := "", name
+	if before, after, ok := strings.Cut(name, "."); ok {
+		schemaName, typeName = before, after
+	}
+	var exists bool
+	err := db.Session(&gorm.Session{NewDB: true}).Raw(
+		"SELECT EXISTS (SELECT 1 FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace WHERE t.typname = ? AND t.typtype = 'e' AND n.nspname = COALESCE(NULLIF(?, ''), current_schema()))",
+		typeName, schemaName,
+	).Row().Scan(&exists)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	var values []string
+	err = db.Session(&gorm.Session{NewDB: true}).Raw(
+		"SELECT e.enumlabel FROM pg_enum e JOIN pg_type t ON t.oid = e.enumtypid JOIN pg_namespace n ON n.oid = t.typnamespace WHERE t.typname = ? AND n.nspname = COALESCE(NULLIF(?, ''), current_schema()) ORDER BY e.enumsortorder",
+		typeName, schemaName,
+	).Scan(&values).Error
+	return values, true, err
+}
+
+// diffModelEnums compares the enum types backing the models with the live ones, every type
+// is reported once for the first model using it. It returns nothing unless connected to
+// Postgres.
+func (p *AutoMigratePlugin) diffModelEnums(db *gorm.DB, models []interface{}) [][]EnumDiff {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	diffs := make([][]EnumDiff, len(models))
+	seen := map[string]bool{}
+	for i, model := range models {
+		names, values, err := modelEnums(db, model)
+		if err != nil {
+			p.Logger.Warn("Failed to inspect enum types of %s: %v", modelName(model), err)
+			continue
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			live, exists, err := liveEnumValues(db, name)
+			if err != nil {
+				p.Logger.Warn("Failed to inspect enum type %s: %v", name, err)
+				continue
+			}
+			if diff, ok := diffEnum(db, name, live, exists, values[name]); ok {
+				diffs[i] = append(diffs[i], diff)
+			}
+		}
+	}
+	return diffs
+}
+
+// diffEnum computes the statements bringing a live enum type to the declared values, new
+// values are added after the declared value preceding them
+func diffEnum(db *gorm.DB, name string, live []string, exists bool, declared []string) (EnumDiff, bool) {
+	if !exists {
+		quoted := make([]string, len(declared))
+		for i, value := range declared {
+			quoted[i] = quoteEnumValue(value)
+		}
+		return EnumDiff{
+			Type:       name,
+			Kind:       EnumCreated,
+			Values:     declared,
+			Statements: []string{fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", quote(db, name), strings.Join(quoted, ", "))},
+		}, true
+	}
+
+	diff := EnumDiff{Type: name, Kind: EnumValueAdded}
+	for i, value := range declared {
+		if slices.Contains(live, value) {
+			continue
+		}
+		statement := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s", quote(db, name), quoteEnumValue(value))
+		if i > 0 {
+			statement += " AFTER " + quoteEnumValue(declared[i-1])
+		}
+		diff.Values = append(diff.Values, value)
+		diff.Statements = append(diff.Statements, statement)
+		live = append(live, value)
+	}
+	return diff, len(diff.Values) > 0
+}
+
+// quoteEnumValue quotes an enum value as a string literal
+func quoteEnumValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// migrateEnums creates the enum types of a model and adds their new values before AutoMigrate
+// adds the columns using them
+func (p *AutoMigratePlugin) migrateEnums(db *gorm.DB, diffs []EnumDiff) error {
+	for _, diff := range diffs {
+		p.Logger.Info("Migrating %s", diff)
+		for _, statement := range diff.Statements {
+			if err := db.Exec(statement).Error; err != nil {
+				p.Logger.Error("Failed to migrate enum type %s: %v", diff.Type, err)
+				return fmt.Errorf("failed to migrate enum type %s: %w", diff.Type, err)
+			}
+		}
+	}
+	return nil
+}
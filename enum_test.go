@@ -0,0 +1,48 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"testing"
+)
+
+type mood string
+
+func (mood) GormDataType() string { return "mood" }
+func (mood) EnumValues() []string { return []string{"happy", "ok", "sad"} }
+
+type moodyUser struct {
+	ID   uint
+	Mood mood
+}
+
+func TestModelEnums(t *testing.T) {
+	db, _ := openTestDB(t)
+	names, values, err := modelEnums(db, &moodyUser{})
+	if err != nil {
+		t.Fatalf("modelEnums: %v", err)
+	}
+	if !slices.Equal(names, []string{"mood"}) || !slices.Equal(values["mood"], []string{"happy", "ok", "sad"}) {
+		t.Errorf("modelEnums = %v, %v, want the values of mood", names, values)
+	}
+}
+
+func TestDiffEnum(t *testing.T) {
+	db, _ := openTestDB(t)
+	declared := []string{"happy", "ok", "sad"}
+
+	created, ok := diffEnum(db, "mood", nil, false, declared)
+	if !ok || created.Kind != EnumCreated || !slices.Equal(created.Statements, []string{"CREATE TYPE `mood` AS ENUM ('happy', 'ok', 'sad')"}) {
+		t.Errorf("diffEnum of a missing type = %+v, want the type created", created)
+	}
+
+	added, ok := diffEnum(db, "mood", []string{"happy", "sad"}, true, declared)
+	want := []string{"ALTER TYPE `mood` ADD VALUE IF NOT EXISTS 'ok' AFTER 'happy'"}
+	if !ok || added.Kind != EnumValueAdded || !slices.Equal(added.Values, []string{"ok"}) || !slices.Equal(added.Statements, want) {
+		t.Errorf("diffEnum of a type missing a value = %+v, want %v", added, want)
+	}
+
+	// Values dropped from the declaration are left in place
+	if diff, ok := diffEnum(db, "mood", []string{"happy", "ok", "sad", "angry"}, true, declared); ok {
+		t.Errorf("diffEnum of an up to date type = %+v, want no change", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package gorm_migrate_tracker
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// modulePath is the module path of the plugin, looked up in the build info for its version
+const modulePath = "github.com/leodahal4/go-migrate-tracer"
+
+// Environment describes the host, binary and libraries that recorded a SchemaVersion
+type Environment struct {
+	Hostname   string `json:"hostname,omitempty"`
+	OS         string `json:"os,omitempty"`
+	GoVersion  string `json:"go_version,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+	GitCommit  string `json:"git_commit,omitempty"`
+	// GormVersion and PluginVersion are the versions of gorm.io/gorm and of this plugin the
+	// binary was built with, empty when the build info isn't available
+	GormVersion   string `json:"gorm_version,omitempty"`
+	PluginVersion string `json:"plugin_version,omitempty"`
+	// Dialect is the name of the dialector and DriverVersion the version of the module it
+	// comes from, such as gorm.io/driver/postgres
+	Dialect       string `json:"dialect,omitempty"`
+	DriverVersion string `json:"driver_version,omitempty"`
+}
+
+var (
+	environmentOnce sync.Once
+	environment     Environment
+)
+
+// currentEnvironment collects the environment of the running process, once
+func currentEnvironment() Environment {
+	environmentOnce.Do(func() {
+		environment.Hostname, _ = os.Hostname()
+		environment.OS = runtime.GOOS + "/" + runtime.GOARCH
+		environment.GoVersion = runtime.Version()
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		environment.AppVersion = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				environment.GitCommit = setting.Value
+			}
+		}
+		environment.GormVersion = moduleVersion(info, "gorm.io/gorm")
+		environment.PluginVersion = moduleVersion(info, modulePath)
+	})
+	return environment
+}
+
+// environment returns the environment of the running process along with the dialect of db
+func (p *AutoMigratePlugin) environment(db *gorm.DB) Environment {
+	env := currentEnvironment()
+	if p.AppVersion != "" {
+		env.AppVersion = p.AppVersion
+	}
+	dialector := db.Dialector
+	if tracking, ok := dialector.(*trackingDialector); ok {
+		dialector = tracking.Dialector
+	}
+	if dialector == nil {
+		return env
+	}
+	env.Dialect = dialector.Name()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		dialectorType := reflect.TypeOf(dialector)
+		if dialectorType.Kind() == reflect.Pointer {
+			dialectorType = dialectorType.Elem()
+		}
+		env.DriverVersion = moduleVersion(info, dialectorType.PkgPath())
+	}
+	return env
+}
+
+// moduleVersion returns the version of the module providing the package at path, the
+// module itself or one of the dependencies of the binary
+func moduleVersion(info *debug.BuildInfo, path string) string {
+	if path == "" {
+		return ""
+	}
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	var version string
+	var longest int
+	for _, module := range modules {
+		if module.Replace != nil && module.Replace.Version != "" {
+			module = &debug.Module{Path: module.Path, Version: module.Replace.Version}
+		}
+		if (path == module.Path || strings.HasPrefix(path, module.Path+"/")) && len(module.Path) > longest {
+			version, longest = module.Version, len(module.Path)
+		}
+	}
+	return version
+}
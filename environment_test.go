@@ -0,0 +1,70 @@
+package gorm_migrate_tracker
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestRecordedEnvironment(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+
+	env := mustRecorded(t, db, "1").Environment
+	hostname, _ := os.Hostname()
+	if env.Hostname != hostname {
+		t.Errorf("hostname = %q, want %q", env.Hostname, hostname)
+	}
+	if env.OS != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("OS = %q, want %s/%s", env.OS, runtime.GOOS, runtime.GOARCH)
+	}
+	if env.GoVersion != runtime.Version() {
+		t.Errorf("Go version = %q, want %q", env.GoVersion, runtime.Version())
+	}
+}
+
+func TestRecordedLibraryVersions(t *testing.T) {
+	db, _ := openTestDB(t, WithAppVersion("v2.3.0"))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	env := mustRecorded(t, db, "1").Environment
+	if env.Dialect != "sqlite" {
+		t.Errorf("dialect = %q, want sqlite", env.Dialect)
+	}
+	if env.AppVersion != "v2.3.0" {
+		t.Errorf("app version = %q, want the configured v2.3.0", env.AppVersion)
+	}
+	// The test binary is built with the versions of go.mod
+	if env.GormVersion != "v1.25.12" || env.DriverVersion != "v1.5.6" {
+		t.Errorf("gorm and driver versions = %q, %q, want v1.25.12 and v1.5.6", env.GormVersion, env.DriverVersion)
+	}
+}
+
+func TestModuleVersion(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "v1.0.0"},
+		Deps: []*debug.Module{
+			{Path: "gorm.io/gorm", Version: "v1.25.12"},
+			{Path: "gorm.io/driver/postgres", Version: "v1.5.0", Replace: &debug.Module{Path: "example.com/postgres", Version: "v1.5.1"}},
+			{Path: "example.com/app/plugins", Version: "v0.2.0"},
+		},
+	}
+	tests := []struct {
+		path, want string
+	}{
+		{"gorm.io/gorm", "v1.25.12"},
+		{"gorm.io/gorm/schema", "v1.25.12"},
+		{"gorm.io/driver/postgres", "v1.5.1"},
+		{"example.com/app/internal", "v1.0.0"},
+		// The longest module path provides the package
+		{"example.com/app/plugins/audit", "v0.2.0"},
+		{"gorm.io/gormx", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := moduleVersion(info, tt.path); got != tt.want {
+			t.Errorf("moduleVersion(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
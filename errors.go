@@ -0,0 +1,61 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// ErrorReport describes a failed AutoMigrate to an ErrorReporter
+type ErrorReport struct {
+	Err error
+	// Event describes the failed migration, with the changes applied before it failed
+	Event MigrationEvent
+	// Recorded reports whether the failure was recorded in the history table, it is false
+	// when recording the migration is what failed
+	Recorded bool
+	// Plan holds the changes AutoMigrate was about to apply
+	Plan        *Plan
+	Tenant      string
+	Environment Environment
+}
+
+// ErrorReporter receives the details of failed migrations, see the contrib/sentry module
+type ErrorReporter interface {
+	ReportError(ctx context.Context, report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to the ErrorReporter interface
+type ErrorReporterFunc func(ctx context.Context, report ErrorReport)
+
+// ReportError calls f(ctx, report)
+func (f ErrorReporterFunc) ReportError(ctx context.Context, report ErrorReport) {
+	f(ctx, report)
+}
+
+// reportError passes the failure of the run attached to db to the error reporter
+func (p *AutoMigratePlugin) reportError(db *gorm.DB, err error) {
+	if p.ErrorReporter == nil || err == nil {
+		return
+	}
+
+	run, ok := runFromDB(db)
+	if !ok {
+		return
+	}
+
+	event, _ := p.migrationEvent(run, err)
+	run.mu.Lock()
+	report := ErrorReport{
+		Err:         err,
+		Event:       event,
+		Recorded:    run.recorded != nil,
+		Plan:        run.plan,
+		Tenant:      p.tenant(db),
+		Environment: p.environment(db),
+	}
+	run.mu.Unlock()
+
+	p.Logger.Debug("Reporting failed migration %s to %T", event.Version, p.ErrorReporter)
+	p.ErrorReporter.ReportError(contextFrom(db), report)
+}
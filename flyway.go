@@ -0,0 +1,109 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultFlywayTable is the history table name used by Flyway
+const DefaultFlywayTable = "flyway_schema_history"
+
+// FlywayHistory is a row of a Flyway shaped history table
+type FlywayHistory struct {
+	InstalledRank int       `gorm:"column:installed_rank;primaryKey;autoIncrement:false" json:"installed_rank"`
+	Version       string    `gorm:"column:version;size:50" json:"version"`
+	Description   string    `gorm:"column:description;size:200;not null" json:"description"`
+	Type          string    `gorm:"column:type;size:20;not null" json:"type"`
+	Script        string    `gorm:"column:script;size:1000;not null" json:"script"`
+	Checksum      *int32    `gorm:"column:checksum" json:"checksum"`
+	InstalledBy   string    `gorm:"column:installed_by;size:100;not null" json:"installed_by"`
+	InstalledOn   time.Time `gorm:"column:installed_on;not null" json:"installed_on"`
+	ExecutionTime int64     `gorm:"column:execution_time;not null" json:"execution_time"`
+	Success       bool      `gorm:"column:success;not null;index" json:"success"`
+}
+
+// flywayDB scopes db to the Flyway history table
+func (p *AutoMigratePlugin) flywayDB(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Table(p.FlywayTable)
+}
+
+// recordFlyway mirrors a completed SchemaVersion into the Flyway history table
+func (p *AutoMigratePlugin) recordFlyway(db *gorm.DB, schemaVersion *SchemaVersion) error {
+	finished := schemaVersion.Status == StatusSuccess || schemaVersion.Status == StatusFailed
+	if p.FlywayTable == "" || !finished || schemaVersion.Kind == KindDrift {
+		return nil
+	}
+
+	row := flywayRow(schemaVersion)
+	err := p.historyConn(db).Transaction(func(tx *gorm.DB) error {
+		var rank int
+		if err := p.flywayDB(tx).Select("COALESCE(MAX(installed_rank), 0)").Scan(&rank).Error; err != nil {
+			return err
+		}
+		row.InstalledRank = rank + 1
+		return p.flywayDB(tx).Create(&row).Error
+	})
+	if err != nil {
+		p.Logger.Error("Failed to record Flyway history: %v", err)
+		return fmt.Errorf("failed to record Flyway history: %w", err)
+	}
+	p.Logger.Debug("Recorded Flyway history rank %d", row.InstalledRank)
+	return nil
+}
+
+// flywayRow converts a SchemaVersion to its Flyway representation
+func flywayRow(schemaVersion *SchemaVersion) FlywayHistory {
+	row := FlywayHistory{
+		Version:       schemaVersion.Version,
+		Type:          "SQL",
+		Script:        "AutoMigrate",
+		InstalledBy:   schemaVersion.Server.User,
+		InstalledOn:   schemaVersion.AppliedAt,
+		ExecutionTime: schemaVersion.DurationMs,
+		Success:       schemaVersion.Status == StatusSuccess,
+	}
+	if schemaVersion.Kind == KindBaseline {
+		row.Type = "BASELINE"
+		row.Script = "<< Flyway Baseline >>"
+	}
+	if row.InstalledBy == "" {
+		row.InstalledBy = schemaVersion.Environment.Hostname
+	}
+
+	row.Description = schemaVersion.Version
+	if changes, err := schemaVersion.ParseChanges(); err == nil {
+		if summary := changes.Summary(); summary != "" {
+			row.Description = summary
+		}
+	}
+	if len(row.Description) > 200 {
+		row.Description = row.Description[:200]
+	}
+
+	if schemaVersion.Statements != "" {
+		checksum := int32(crc32.ChecksumIEEE([]byte(schemaVersion.Statements)))
+		row.Checksum = &checksum
+	}
+	return row
+}
+
+// GetFlywayHistory retrieves the Flyway shaped history, ordered by installed rank
+func GetFlywayHistory(db *gorm.DB) ([]FlywayHistory, error) {
+	logger := loggerFrom(db)
+	logger.Debug("GetFlywayHistory function called")
+
+	table := DefaultFlywayTable
+	if p, ok := pluginFrom(db); ok && p.FlywayTable != "" {
+		table = p.FlywayTable
+	}
+
+	var history []FlywayHistory
+	if err := historyConn(db).Table(table).Order("installed_rank").Find(&history).Error; err != nil {
+		logger.Error("Failed to retrieve Flyway history: %v", err)
+		return nil, fmt.Errorf("failed to retrieve Flyway history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,45 @@
+package gorm_migrate_tracker
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func TestFlywayHistory(t *testing.T) {
+	db, _ := openTestDB(t, WithFlywayHistory(""))
+	if _, err := Baseline(db, "1"); err != nil {
+		t.Fatalf("Baseline: %v", err)
+	}
+	migrateAs(t, db, "2", &pluginUser{})
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "3")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	history, err := GetFlywayHistory(db)
+	if err != nil {
+		t.Fatalf("GetFlywayHistory: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Flyway history holds %d rows, want 3", len(history))
+	}
+	for i, row := range history {
+		if row.InstalledRank != i+1 {
+			t.Errorf("rank of version %s = %d, want %d", row.Version, row.InstalledRank, i+1)
+		}
+	}
+
+	baseline, migrated, failed := history[0], history[1], history[2]
+	if baseline.Type != "BASELINE" || baseline.Checksum != nil {
+		t.Errorf("baseline row = %+v, want a Flyway baseline without checksum", baseline)
+	}
+	recorded := mustRecorded(t, db, "2")
+	if migrated.Type != "SQL" || !migrated.Success || migrated.Description != "AutoMigrated "+modelName(&pluginUser{}) {
+		t.Errorf("migration row = %+v, want a successful SQL migration described by its models", migrated)
+	}
+	if migrated.Checksum == nil || *migrated.Checksum != int32(crc32.ChecksumIEEE([]byte(recorded.Statements))) {
+		t.Errorf("checksum of version 2 = %v, want the CRC32 of its statements", migrated.Checksum)
+	}
+	if failed.Version != "3" || failed.Success {
+		t.Errorf("failed row = %+v, want version 3 unsuccessful", failed)
+	}
+}
@@ -2,10 +2,14 @@ module github.com/leodahal4/go-migrate-tracer
 
 go 1.23.1
 
-require gorm.io/gorm v1.25.12
+require (
+	gorm.io/driver/sqlite v1.5.6
+	gorm.io/gorm v1.25.12
+)
 
 require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/mattn/go-sqlite3 v1.14.22 // indirect
 	golang.org/x/text v0.14.0 // indirect
 )
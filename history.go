@@ -0,0 +1,166 @@
+package gorm_migrate_tracker
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryOrder sorts the results of QueryHistory
+type HistoryOrder string
+
+const (
+	// NewestFirst sorts the most recently applied versions first
+	NewestFirst HistoryOrder = "desc"
+	// OldestFirst sorts the history in the order it was applied
+	OldestFirst HistoryOrder = "asc"
+)
+
+// HistoryFilter selects the schema versions returned by QueryHistory, zero fields don't filter
+type HistoryFilter struct {
+	// Since and Until bound the applied at time, inclusively
+	Since time.Time
+	Until time.Time
+	// Model matches versions that changed the model, by model or table name
+	Model string
+	// Status matches versions with the given status
+	Status string
+	// Labels matches versions carrying every given label
+	Labels Labels
+	// InitiatedBy matches versions triggered by the given actor
+	InitiatedBy string
+	// Limit caps the number of returned versions, every match is returned when zero
+	Limit int
+	// Offset skips the first matching versions
+	Offset int
+	// Order sorts the results, NewestFirst when empty
+	Order HistoryOrder
+}
+
+// HistoryPage is a page of QueryHistory results
+type HistoryPage struct {
+	Versions []SchemaVersion `json:"versions"`
+	// Total is the number of versions matching the filter, regardless of Limit and Offset
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit,omitempty"`
+	Offset int   `json:"offset,omitempty"`
+}
+
+// HasMore reports whether versions matching the filter follow the page
+func (p *HistoryPage) HasMore() bool {
+	return int64(p.Offset+len(p.Versions)) < p.Total
+}
+
+// QueryHistory retrieves a page of the schema versions matching filter along with the total
+// number of matches, so large histories don't have to be loaded at once
+func QueryHistory(db *gorm.DB, filter HistoryFilter) (*HistoryPage, error) {
+	logger := loggerFrom(db)
+	logger.Debug("QueryHistory function called")
+
+	page := &HistoryPage{Limit: filter.Limit, Offset: filter.Offset}
+	history, stored, err := registeredPlugin(db).storedHistory(db)
+	if err != nil {
+		logger.Error("Failed to query migration history: %v", err)
+		return nil, err
+	}
+	if stored {
+		filter.page(page, history)
+		logger.Debug("Retrieved %d of %d matching migration history records", len(page.Versions), page.Total)
+		return page, nil
+	}
+
+	query := filter.apply(historyDB(db))
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		logger.Error("Failed to count migration history: %v", err)
+		return nil, fmt.Errorf("failed to count migration history: %w", err)
+	}
+
+	order := filter.Order
+	if order != OldestFirst {
+		order = NewestFirst
+	}
+	query = query.Order(fmt.Sprintf("applied_at %s, id %s", order, order))
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if err := query.Find(&page.Versions).Error; err != nil {
+		logger.Error("Failed to query migration history: %v", err)
+		return nil, fmt.Errorf("failed to query migration history: %w", err)
+	}
+
+	logger.Debug("Retrieved %d of %d matching migration history records", len(page.Versions), page.Total)
+	return page, nil
+}
+
+// page fills page with the versions of a stored history matching the filter
+func (f HistoryFilter) page(page *HistoryPage, history []SchemaVersion) {
+	history = filterVersions(history, f.match)
+	page.Total = int64(len(history))
+	slices.SortStableFunc(history, func(a, b SchemaVersion) int {
+		if f.Order == OldestFirst {
+			return cmp.Or(a.AppliedAt.Compare(b.AppliedAt), cmp.Compare(a.ID, b.ID))
+		}
+		return cmp.Or(b.AppliedAt.Compare(a.AppliedAt), cmp.Compare(b.ID, a.ID))
+	})
+	history = history[min(f.Offset, len(history)):]
+	if f.Limit > 0 {
+		history = history[:min(f.Limit, len(history))]
+	}
+	page.Versions = history
+}
+
+// match reports whether a version satisfies the conditions of the filter
+func (f HistoryFilter) match(schemaVersion *SchemaVersion) bool {
+	switch {
+	case !f.Since.IsZero() && schemaVersion.AppliedAt.Before(f.Since),
+		!f.Until.IsZero() && schemaVersion.AppliedAt.After(f.Until),
+		f.Status != "" && schemaVersion.Status != f.Status,
+		f.InitiatedBy != "" && schemaVersion.InitiatedBy != f.InitiatedBy:
+		return false
+	}
+	for key, value := range f.Labels {
+		if schemaVersion.Labels[key] != value {
+			return false
+		}
+	}
+	if f.Model == "" {
+		return true
+	}
+	changes, err := schemaVersion.ParseChanges()
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(changes.Models, func(model ModelChange) bool {
+		return model.Model == f.Model || model.Table == f.Model
+	})
+}
+
+// apply adds the conditions of the filter to a history query
+func (f HistoryFilter) apply(query *gorm.DB) *gorm.DB {
+	query = query.Model(&SchemaVersion{})
+	if !f.Since.IsZero() {
+		query = query.Where("applied_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		query = query.Where("applied_at <= ?", f.Until)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.InitiatedBy != "" {
+		query = query.Where("initiated_by = ?", f.InitiatedBy)
+	}
+	if f.Model != "" {
+		query = query.Where("changes LIKE ? OR changes LIKE ?", `%"model":"`+f.Model+`"%`, `%"table":"`+f.Model+`"%`)
+	}
+	for key, value := range f.Labels {
+		query = query.Where("labels LIKE ?", labelCondition(key, value))
+	}
+	return query
+}
@@ -0,0 +1,62 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// versionsOf returns the versions of a page in its order
+func versionsOf(page *HistoryPage) []string {
+	versions := make([]string, len(page.Versions))
+	for i, schemaVersion := range page.Versions {
+		versions[i] = schemaVersion.Version
+	}
+	return versions
+}
+
+func TestQueryHistory(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := openTestDB(t, WithClock(&tickingClock{now: start, step: time.Hour}))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &squashedOrder{})
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "4")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	page, err := QueryHistory(db, HistoryFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryHistory: %v", err)
+	}
+	if !slices.Equal(versionsOf(page), []string{"4", "3"}) || page.Total != 4 || !page.HasMore() {
+		t.Errorf("first page = %v of %d, want 4 and 3 of 4", versionsOf(page), page.Total)
+	}
+	page, err = QueryHistory(db, HistoryFilter{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("QueryHistory: %v", err)
+	}
+	if !slices.Equal(versionsOf(page), []string{"2", "1"}) || page.HasMore() {
+		t.Errorf("second page = %v, want 2 and 1 and no more", versionsOf(page))
+	}
+
+	for _, test := range []struct {
+		name   string
+		filter HistoryFilter
+		want   []string
+	}{
+		{"oldest first", HistoryFilter{Order: OldestFirst}, []string{"1", "2", "3", "4"}},
+		{"status", HistoryFilter{Status: StatusFailed}, []string{"4"}},
+		{"table", HistoryFilter{Model: "users", Order: OldestFirst}, []string{"1", "3"}},
+		{"since", HistoryFilter{Since: mustRecorded(t, db, "3").AppliedAt}, []string{"4", "3"}},
+		{"until", HistoryFilter{Until: mustRecorded(t, db, "2").AppliedAt}, []string{"2", "1"}},
+	} {
+		page, err := QueryHistory(db, test.filter)
+		if err != nil {
+			t.Fatalf("QueryHistory by %s: %v", test.name, err)
+		}
+		if !slices.Equal(versionsOf(page), test.want) || page.Total != int64(len(test.want)) {
+			t.Errorf("QueryHistory by %s = %v of %d, want %v", test.name, versionsOf(page), page.Total, test.want)
+		}
+	}
+}
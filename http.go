@@ -0,0 +1,130 @@
+package gorm_migrate_tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// HistoryResponse is the JSON document served by HistoryHandler
+type HistoryResponse struct {
+	CurrentVersion string          `json:"current_version"`
+	History        []SchemaVersion `json:"history"`
+	// Total is the number of recorded versions, regardless of the limit and offset
+	Total int64        `json:"total"`
+	Drift *DriftStatus `json:"drift,omitempty"`
+}
+
+// DriftStatus reports whether the live schema drifted from the models
+type DriftStatus struct {
+	Detected bool         `json:"detected"`
+	Report   *DriftReport `json:"report"`
+}
+
+// historyHandler serves the migration history of a connection
+type historyHandler struct {
+	db     *gorm.DB
+	models []interface{}
+}
+
+// HistoryHandler returns an http.Handler serving the migration history, the current
+// version and, when models are given or configured with WithDriftCheck, the drift status
+// as JSON. The limit and offset query parameters page the history, newest first, and the
+// snapshots of the versions are left out unless snapshots=true. It is meant to be mounted
+// under a debug or admin router.
+func HistoryHandler(db *gorm.DB, models ...interface{}) http.Handler {
+	return &historyHandler{db: db, models: models}
+}
+
+// ServeHTTP implements http.Handler
+func (h *historyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := queryInt(query.Get("limit"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid limit: "+err.Error())
+		return
+	}
+	offset, err := queryInt(query.Get("offset"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid offset: "+err.Error())
+		return
+	}
+
+	db := h.db.WithContext(r.Context())
+	page, err := QueryHistory(db, HistoryFilter{Limit: limit, Offset: offset})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if query.Get("snapshots") != "true" {
+		for i := range page.Versions {
+			page.Versions[i].Snapshot = ""
+		}
+	}
+
+	response := HistoryResponse{History: page.Versions, Total: page.Total}
+	if response.History == nil {
+		response.History = []SchemaVersion{}
+	}
+	current, err := GetCurrentVersion(db)
+	if err != nil && !errors.Is(err, ErrVersionNotFound) {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if current != nil {
+		response.CurrentVersion = current.Version
+	}
+
+	models := h.models
+	if len(models) == 0 {
+		if p, ok := pluginFrom(db); ok {
+			models = p.DriftModels
+		}
+	}
+	if len(models) > 0 {
+		report, err := DetectDrift(db, models...)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response.Drift = &DriftStatus{Detected: report.HasDrift(), Report: report}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// queryInt parses a non-negative integer query parameter, zero when it's empty
+func queryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("can't be negative")
+	}
+	return n, nil
+}
+
+// writeJSON writes value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeJSONError writes an error message as a JSON response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,80 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// SkipTrackingKey is the setting skipping the tracking of the AutoMigrate and Migrator calls
+// made with it, e.g. db.Set(SkipTrackingKey, true).AutoMigrate(&Fixture{}) for test fixtures
+// or temporary tables
+const SkipTrackingKey = "migrate_tracker:skip"
+
+// skipTrackingContextKey marks a context as skipping tracking
+type skipTrackingContextKey struct{}
+
+// SkipTracking returns a context skipping the tracking of the migrations run with it, like
+// SkipTrackingKey, e.g. db.WithContext(SkipTracking(ctx)).AutoMigrate(&Fixture{})
+func SkipTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTrackingContextKey{}, true)
+}
+
+// skipsTracking reports whether db was set with SkipTrackingKey or its context with SkipTracking
+func skipsTracking(db *gorm.DB) bool {
+	if value, ok := db.Get(SkipTrackingKey); ok {
+		if skip, _ := value.(bool); skip {
+			return true
+		}
+	}
+	skip, _ := contextFrom(db).Value(skipTrackingContextKey{}).(bool)
+	return skip
+}
+
+// ignoresTable reports whether a table, qualified or not, is excluded from tracking with
+// IgnoreTables or IgnoreModels, or by not being one of OnlyModels
+func (p *AutoMigratePlugin) ignoresTable(db *gorm.DB, table string) bool {
+	if len(p.IgnoreTables) == 0 && len(p.IgnoreModels) == 0 && len(p.OnlyModels) == 0 {
+		return false
+	}
+	_, unqualified := splitTableName(table)
+	matches := func(name string) bool {
+		_, ignored := splitTableName(name)
+		return name == table || ignored == unqualified
+	}
+	if len(p.OnlyModels) > 0 && !slices.ContainsFunc(p.OnlyModels, func(model interface{}) bool {
+		return matches(tableOf(db, model))
+	}) {
+		return true
+	}
+	for _, name := range p.IgnoreTables {
+		if matches(name) {
+			return true
+		}
+	}
+	for _, model := range p.IgnoreModels {
+		if matches(tableOf(db, model)) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackedModels returns the models whose table isn't ignored, and the ignored ones
+func (p *AutoMigratePlugin) trackedModels(db *gorm.DB, models []interface{}) (tracked, ignored []interface{}) {
+	for _, model := range models {
+		if p.ignoresTable(db, tableOf(db, model)) {
+			ignored = append(ignored, model)
+		} else {
+			tracked = append(tracked, model)
+		}
+	}
+	return tracked, ignored
+}
+
+// isIgnoredTable reports whether the plugin registered on db ignores a table
+func isIgnoredTable(db *gorm.DB, table string) bool {
+	p, ok := pluginFrom(db)
+	return ok && p.ignoresTable(db, table)
+}
@@ -0,0 +1,113 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+type jobQueue struct {
+	ID      uint
+	Payload string
+}
+
+func TestIgnoreModels(t *testing.T) {
+	db, _ := openTestDB(t, WithIgnoreModels(&jobQueue{}), WithIgnoreTables("sessions"))
+	migrateAs(t, db, "1", &pluginUser{}, &jobQueue{})
+	if err := db.Exec("CREATE TABLE sessions (id integer)").Error; err != nil {
+		t.Fatalf("failed to create the sessions table: %v", err)
+	}
+
+	// The ignored model is migrated without being recorded
+	if !db.Migrator().HasTable(&jobQueue{}) {
+		t.Fatal("the ignored model wasn't migrated")
+	}
+	recorded := mustRecorded(t, db, "1")
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 || changes.Models[0].Table != "users" || strings.Contains(recorded.Statements, "job_queues") {
+		t.Errorf("version 1 = %v, %q, want the users alone recorded", changes.Models, recorded.Statements)
+	}
+
+	snapshot, err := TakeSnapshot(db)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	if len(snapshot.Tables) != 1 || snapshot.Tables[0].Name != "users" {
+		t.Errorf("snapshot = %v, want the users table alone", snapshot.Tables)
+	}
+	report, err := DetectDrift(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("DetectDrift: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("drift = %s, want the ignored tables left out", report)
+	}
+
+	// A run of ignored models alone isn't recorded
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "2")).AutoMigrate(&jobQueue{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("recorded %d versions, want the run of ignored models not recorded", len(history))
+	}
+}
+
+func TestTrackedModels(t *testing.T) {
+	db, _ := openTestDB(t)
+	plugin := &AutoMigratePlugin{IgnoreTables: []string{"public.job_queues"}}
+	tracked, ignored := plugin.trackedModels(db, []interface{}{&pluginUser{}, &jobQueue{}})
+	if len(ignored) != 1 || len(tracked) != 1 {
+		t.Fatalf("trackedModels = %v, %v, want a single model ignored", tracked, ignored)
+	}
+	if _, ok := ignored[0].(*jobQueue); !ok {
+		t.Errorf("trackedModels = %v, %v, want the qualified job_queues table ignored", tracked, ignored)
+	}
+}
+
+func TestOnlyModels(t *testing.T) {
+	db, _ := openTestDB(t, WithOnlyModels(&pluginUser{}))
+	migrateAs(t, db, "1", &pluginUser{}, &jobQueue{})
+
+	if !db.Migrator().HasTable(&jobQueue{}) {
+		t.Fatal("the model left out wasn't migrated")
+	}
+	changes, err := mustRecorded(t, db, "1").ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 1 || changes.Models[0].Table != "users" {
+		t.Errorf("recorded changes = %v, want the users alone", changes.Models)
+	}
+
+	// Tables of other services sharing the database aren't unmanaged drift
+	report, err := DetectDrift(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("DetectDrift: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("drift = %s, want the tables left out ignored", report)
+	}
+}
+
+func TestSkipTracking(t *testing.T) {
+	db, _ := openTestDB(t)
+	if err := db.Set(SkipTrackingKey, true).AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := db.WithContext(SkipTracking(db.Statement.Context)).AutoMigrate(&jobQueue{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	if !db.Migrator().HasTable(&pluginUser{}) || !db.Migrator().HasTable(&jobQueue{}) {
+		t.Fatal("the untracked models weren't migrated")
+	}
+	if history := mustHistory(t, db); len(history) != 0 {
+		t.Errorf("history = %v, want the untracked calls not recorded", history)
+	}
+
+	// The setting doesn't leak to later calls
+	migrateAs(t, db, "1", &pluginUserWithEmail{})
+	mustRecorded(t, db, "1")
+}
@@ -0,0 +1,177 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultGolangMigrateTable is the table golang-migrate stores its version in
+const DefaultGolangMigrateTable = "schema_migrations"
+
+// golangMigrateFile matches golang-migrate file names such as 3_add_users.up.sql
+var golangMigrateFile = regexp.MustCompile(`^([0-9]+)_(.*)\.(up|down)\.[^.]+$`)
+
+// golangMigration is a migration found in a golang-migrate files directory
+type golangMigration struct {
+	version uint64
+	title   string
+	up      string
+	down    string
+	modTime time.Time
+}
+
+// ImportGolangMigrate converts the history of golang-migrate into SchemaVersion records and
+// returns the number of imported versions. golang-migrate only stores its current version in
+// table, DefaultGolangMigrateTable when empty. When migrations holds the migration files, every
+// migration up to the current version is imported with its statements, otherwise only the current
+// version is. Versions already recorded are skipped, so importing again is harmless.
+func ImportGolangMigrate(db *gorm.DB, table string, migrations fs.FS) (int, error) {
+	p := registeredPlugin(db)
+	p.Logger.Debug("ImportGolangMigrate function called")
+	if table == "" {
+		table = DefaultGolangMigrateTable
+	}
+
+	var state struct {
+		Version int64
+		Dirty   bool
+	}
+	result := db.Session(&gorm.Session{NewDB: true}).Table(table).Select("version", "dirty").Limit(1).Find(&state)
+	if result.Error != nil {
+		p.Logger.Error("Failed to read golang-migrate version from %s: %v", table, result.Error)
+		return 0, fmt.Errorf("failed to read golang-migrate version from %s: %w", table, result.Error)
+	}
+	if result.RowsAffected == 0 || state.Version < 0 {
+		p.Logger.Info("No golang-migrate version recorded in %s", table)
+		return 0, nil
+	}
+	current := uint64(state.Version)
+
+	var found []golangMigration
+	if migrations != nil {
+		var err error
+		if found, err = readGolangMigrations(migrations); err != nil {
+			return 0, err
+		}
+	}
+
+	// Import the migrations applied up to the current version, or just the current version
+	byVersion := map[uint64]golangMigration{current: {version: current}}
+	for _, migration := range found {
+		if migration.version <= current {
+			byVersion[migration.version] = migration
+		}
+	}
+	versions := make([]uint64, 0, len(byVersion))
+	for version := range byVersion {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	imported := 0
+	for _, version := range versions {
+		migration := byVersion[version]
+		name := strconv.FormatUint(version, 10)
+		if _, err := findVersion(db, name); err == nil {
+			p.Logger.Debug("golang-migrate version %s already recorded, skipping", name)
+			continue
+		} else if !errors.Is(err, ErrVersionNotFound) {
+			return imported, err
+		}
+
+		note := "Imported from golang-migrate"
+		if migration.title != "" {
+			note += ": " + migration.title
+		}
+		changes, err := encodeChangeSet(&ChangeSet{Notes: []string{note}})
+		if err != nil {
+			return imported, err
+		}
+
+		schemaVersion := &SchemaVersion{
+			Version:        name,
+			Kind:           KindMigration,
+			Status:         StatusSuccess,
+			AppliedAt:      golangMigrationTime(migration, p.now()),
+			Changes:        changes,
+			Statements:     migration.up,
+			DownStatements: migration.down,
+			Severity:       SeverityInfo,
+		}
+		if version == current && state.Dirty {
+			schemaVersion.Status = StatusFailed
+			schemaVersion.Error = "golang-migrate marked this version dirty"
+		}
+		if err := p.record(db, schemaVersion); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	p.Logger.Info("Imported %d golang-migrate versions", imported)
+	return imported, nil
+}
+
+// readGolangMigrations reads the up and down files of a golang-migrate files directory
+func readGolangMigrations(migrations fs.FS) ([]golangMigration, error) {
+	entries, err := fs.ReadDir(migrations, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golang-migrate migrations: %w", err)
+	}
+
+	byVersion := map[uint64]*golangMigration{}
+	for _, entry := range entries {
+		match := golangMigrateFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(migrations, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read golang-migrate migration %s: %w", entry.Name(), err)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &golangMigration{version: version, title: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.up = strings.TrimSpace(string(content))
+			if info, err := entry.Info(); err == nil {
+				migration.modTime = info.ModTime()
+			}
+		} else {
+			migration.down = strings.TrimSpace(string(content))
+		}
+	}
+
+	found := make([]golangMigration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		found = append(found, *migration)
+	}
+	return found, nil
+}
+
+// golangMigrationTime estimates when a migration was applied: versions created by
+// golang-migrate's timestamp format are the best guess, then the time the file was written
+func golangMigrationTime(migration golangMigration, fallback time.Time) time.Time {
+	if t, err := time.Parse(DefaultVersionLayout, strconv.FormatUint(migration.version, 10)); err == nil {
+		return t
+	}
+	if !migration.modTime.IsZero() {
+		return migration.modTime
+	}
+	return fallback
+}
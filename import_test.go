@@ -0,0 +1,94 @@
+package gorm_migrate_tracker
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// golangMigrateState records version as the current golang-migrate version of db
+func golangMigrateState(t *testing.T, db *gorm.DB, version int64, dirty bool) {
+	t.Helper()
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)",
+		"DELETE FROM schema_migrations",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to prepare schema_migrations: %v", err)
+		}
+	}
+	if err := db.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", version, dirty).Error; err != nil {
+		t.Fatalf("failed to record the golang-migrate version: %v", err)
+	}
+}
+
+func TestImportGolangMigrate(t *testing.T) {
+	db, _ := openTestDB(t)
+	golangMigrateState(t, db, 2, false)
+	migrations := fstest.MapFS{
+		"1_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id integer);\n")},
+		"1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"2_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email text;")},
+		"3_add_orders.up.sql":     {Data: []byte("CREATE TABLE orders (id integer);")},
+		"README.md":               {Data: []byte("migrations")},
+	}
+
+	imported, err := ImportGolangMigrate(db, "", migrations)
+	if err != nil {
+		t.Fatalf("ImportGolangMigrate: %v", err)
+	}
+	// The migration beyond the current version wasn't applied
+	if imported != 2 {
+		t.Errorf("imported %d versions, want 2", imported)
+	}
+	first := mustRecorded(t, db, "1")
+	if first.Statements != "CREATE TABLE users (id integer);" || first.DownStatements != "DROP TABLE users;" {
+		t.Errorf("version 1 statements = %q, down %q, want the migration files", first.Statements, first.DownStatements)
+	}
+	changes, err := first.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Notes) != 1 || changes.Notes[0] != "Imported from golang-migrate: create_users" {
+		t.Errorf("notes = %q, want the import noted with the title", changes.Notes)
+	}
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != "2" {
+		t.Errorf("GetCurrentVersion = %v, %v, want version 2", current, err)
+	}
+
+	// Importing again is harmless
+	if imported, err := ImportGolangMigrate(db, "", migrations); err != nil || imported != 0 {
+		t.Errorf("importing again = %d, %v, want nothing imported", imported, err)
+	}
+}
+
+func TestImportGolangMigrateDirty(t *testing.T) {
+	db, _ := openTestDB(t)
+	golangMigrateState(t, db, 20240102150405, true)
+
+	if imported, err := ImportGolangMigrate(db, "", nil); err != nil || imported != 1 {
+		t.Fatalf("ImportGolangMigrate = %d, %v, want the current version imported", imported, err)
+	}
+	recorded := mustRecorded(t, db, "20240102150405")
+	if recorded.Status != StatusFailed {
+		t.Errorf("status of a dirty version = %s, want %s", recorded.Status, StatusFailed)
+	}
+	// Timestamp versions tell when they were applied
+	if want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC); !recorded.AppliedAt.Equal(want) {
+		t.Errorf("applied at %v, want %v", recorded.AppliedAt, want)
+	}
+}
+
+func TestImportGolangMigrateWithoutVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	golangMigrateState(t, db, -1, false)
+	if imported, err := ImportGolangMigrate(db, "", nil); err != nil || imported != 0 {
+		t.Errorf("ImportGolangMigrate = %d, %v, want nothing imported", imported, err)
+	}
+	if _, err := ImportGolangMigrate(db, "missing_migrations", nil); err == nil {
+		t.Error("ImportGolangMigrate from a missing table succeeded")
+	}
+}
@@ -0,0 +1,118 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Labels are free form key value pairs attached to a SchemaVersion, such as a release
+// name, ticket ID or environment
+type Labels map[string]string
+
+// GormDataType stores labels like the other text columns of the history table
+func (Labels) GormDataType() string {
+	return string(schema.String)
+}
+
+// Value encodes the labels as a JSON object, NULL when empty
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(l))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return string(data), nil
+}
+
+// Scan decodes labels stored as a JSON object
+func (l *Labels) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported labels value %T", value)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	labels := Labels{}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return fmt.Errorf("failed to decode labels: %w", err)
+	}
+	*l = labels
+	return nil
+}
+
+// labelsContextKey is the context key under which labels for the next recorded version are stored
+type labelsContextKey struct{}
+
+// ContextWithLabels returns a context attaching labels to the versions recorded with it,
+// merged with the labels already set on ctx
+func ContextWithLabels(ctx context.Context, labels Labels) context.Context {
+	merged := Labels{}
+	maps.Copy(merged, LabelsFromContext(ctx))
+	maps.Copy(merged, labels)
+	return context.WithValue(ctx, labelsContextKey{}, merged)
+}
+
+// LabelsFromContext returns the labels set with ContextWithLabels
+func LabelsFromContext(ctx context.Context) Labels {
+	labels, _ := ctx.Value(labelsContextKey{}).(Labels)
+	return labels
+}
+
+// TagVersion merges labels into the labels of a recorded version, a label with an empty
+// value is removed
+func TagVersion(db *gorm.DB, version string, labels Labels) error {
+	logger := loggerFrom(db)
+	logger.Debug("TagVersion function called for %s", version)
+
+	schemaVersion, err := findVersion(db, version)
+	if err != nil {
+		return err
+	}
+
+	merged := Labels{}
+	maps.Copy(merged, schemaVersion.Labels)
+	for key, value := range labels {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	// Records failing verification aren't signed again, that would hide the tampering
+	p := registeredPlugin(db)
+	verified := len(p.SigningKey) > 0 && verifySignature(p.SigningKey, schemaVersion)
+	schemaVersion.Labels = merged
+	if verified {
+		p.sign(schemaVersion)
+	}
+	if err := p.updateVersion(db, schemaVersion, "labels", "signature"); err != nil {
+		logger.Error("Failed to tag schema version %s: %v", version, err)
+		return fmt.Errorf("failed to tag schema version %s: %w", version, err)
+	}
+	logger.Info("Tagged schema version %s with %d labels", version, len(labels))
+	return nil
+}
+
+// labelCondition returns the LIKE pattern matching versions labelled key=value
+func labelCondition(key, value string) string {
+	pair, _ := json.Marshal(map[string]string{key: value})
+	return "%" + string(pair[1:len(pair)-1]) + "%"
+}
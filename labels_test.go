@@ -0,0 +1,51 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"maps"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	db, _ := openTestDB(t, WithSigningKey(testSigningKey))
+	ctx := ContextWithLabels(context.Background(), Labels{"release": "2024.1", "ticket": "OPS-1"})
+	ctx = ContextWithLabels(ctx, Labels{"ticket": "OPS-2"})
+	if err := db.WithContext(ContextWithVersion(ctx, "1")).AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	want := Labels{"release": "2024.1", "ticket": "OPS-2"}
+	if labels := mustRecorded(t, db, "1").Labels; !maps.Equal(labels, want) {
+		t.Errorf("labels of version 1 = %v, want %v", labels, want)
+	}
+	if labels := mustRecorded(t, db, "2").Labels; labels != nil {
+		t.Errorf("labels of version 2 = %v, want none", labels)
+	}
+	if stored := storedColumn(t, db, "1", "labels"); stored != `{"release":"2024.1","ticket":"OPS-2"}` {
+		t.Errorf("labels stored as %q, want a JSON object", stored)
+	}
+
+	if err := TagVersion(db, "1", Labels{"env": "production", "ticket": ""}); err != nil {
+		t.Fatalf("TagVersion: %v", err)
+	}
+	want = Labels{"release": "2024.1", "env": "production"}
+	if labels := mustRecorded(t, db, "1").Labels; !maps.Equal(labels, want) {
+		t.Errorf("labels after tagging = %v, want %v", labels, want)
+	}
+	// Tagging signs the record again
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory after tagging: %v", err)
+	}
+
+	page, err := QueryHistory(db, HistoryFilter{Labels: Labels{"env": "production"}})
+	if err != nil {
+		t.Fatalf("QueryHistory: %v", err)
+	}
+	if page.Total != 1 || page.Versions[0].Version != "1" {
+		t.Errorf("versions labelled env=production = %v, want version 1", page.Versions)
+	}
+	if page, err := QueryHistory(db, HistoryFilter{Labels: Labels{"env": "production", "release": "2023.4"}}); err != nil || page.Total != 0 {
+		t.Errorf("versions carrying a missing label = %v, %v, want none", page, err)
+	}
+}
@@ -0,0 +1,297 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotLeader is returned by Campaign when another instance holds a live lease
+var ErrNotLeader = errors.New("migration leadership is held by another instance")
+
+// ErrLeadershipLost is returned when the lease could not be renewed before it expired
+var ErrLeadershipLost = errors.New("migration leadership lost")
+
+// Defaults of LeaderElector
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultPollInterval  = 2 * time.Second
+)
+
+// LeaderLease is a row of the leader election table
+type LeaderLease struct {
+	Name      string `gorm:"primaryKey"`
+	Holder    string
+	RenewedAt time.Time
+	ExpiresAt time.Time
+}
+
+// leaseTableName returns the name of the leader election table
+func leaseTableName(db *gorm.DB) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&LeaderLease{}); err != nil {
+		return ""
+	}
+	return stmt.Table
+}
+
+// LeaderElector elects a single instance, such as one pod of a Kubernetes deployment, to run
+// migrations. The leader holds a lease row it renews with a heartbeat, when it dies the lease
+// expires and another instance takes over. Unlike a Locker it doesn't rely on a session
+// staying open, so it survives connection pool churn and works with every dialect.
+type LeaderElector struct {
+	// Name identifies the election, instances migrating the same database share it
+	Name string
+	// Identity identifies this instance in the lease, defaults to hostname:pid
+	Identity string
+	// LeaseDuration is how long a lease lasts without being renewed, it must exceed the
+	// clock skew between instances
+	LeaseDuration time.Duration
+	// RenewInterval is how often the leader renews its lease, defaults to a third of LeaseDuration
+	RenewInterval time.Duration
+	// PollInterval is how often followers check for the target version or an expired lease
+	PollInterval time.Duration
+	// Logger receives election events, the logger of the registered plugin when nil
+	Logger Logger
+	// Clock times the leases, the clock of the registered plugin when nil
+	Clock Clock
+}
+
+// NewLeaderElector creates a LeaderElector for the named election with default timings
+func NewLeaderElector(name string) *LeaderElector {
+	return &LeaderElector{Name: name}
+}
+
+// Leadership is a lease held by the elected leader
+type Leadership struct {
+	elector   *LeaderElector
+	db        *gorm.DB
+	expiresAt time.Time
+	lost      chan struct{}
+	stop      chan struct{}
+	once      sync.Once
+	done      sync.WaitGroup
+}
+
+// Lost is closed when the lease expired without being renewed or was taken over, and another
+// instance may take over
+func (l *Leadership) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Resign stops renewing the lease and releases it so a follower can take over immediately
+func (l *Leadership) Resign(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	l.done.Wait()
+	err := l.db.WithContext(ctx).Where("name = ? AND holder = ?", l.elector.Name, l.elector.identity()).Delete(&LeaderLease{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release leadership %s: %w", l.elector.Name, err)
+	}
+	l.elector.logger(l.db).Debug("Resigned leadership %s", l.elector.Name)
+	return nil
+}
+
+// Campaign tries to become the leader once, returning ErrNotLeader when another instance
+// holds a live lease. The returned leadership is renewed in the background until Resign.
+func (e *LeaderElector) Campaign(ctx context.Context, db *gorm.DB) (*Leadership, error) {
+	logger := e.logger(db)
+	tx := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx)
+	if err := untrackedMigrator(tx).AutoMigrate(&LeaderLease{}); err != nil {
+		return nil, fmt.Errorf("failed to create leader election table: %w", err)
+	}
+
+	now := e.now(db)
+	lease := LeaderLease{Name: e.Name, Holder: e.identity(), RenewedAt: now, ExpiresAt: now.Add(e.leaseDuration())}
+	if err := tx.Create(&lease).Error; err != nil {
+		// The row exists, take it over when its lease expired or is already ours
+		result := tx.Model(&LeaderLease{}).
+			Where("name = ? AND (expires_at < ? OR holder = ?)", e.Name, now, e.identity()).
+			Updates(map[string]interface{}{"holder": lease.Holder, "renewed_at": lease.RenewedAt, "expires_at": lease.ExpiresAt})
+		if result.Error != nil {
+			logger.Error("Failed to campaign for leadership %s: %v", e.Name, result.Error)
+			return nil, fmt.Errorf("failed to campaign for leadership %s: %w", e.Name, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrNotLeader
+		}
+	}
+	logger.Info("Elected leader of %s as %s", e.Name, e.identity())
+
+	leadership := &Leadership{elector: e, db: db.Session(&gorm.Session{NewDB: true}), expiresAt: lease.ExpiresAt, lost: make(chan struct{}), stop: make(chan struct{})}
+	leadership.done.Add(1)
+	go leadership.heartbeat()
+	return leadership, nil
+}
+
+// heartbeat renews the lease until the leader resigns or leadership is lost. A failed renewal
+// is retried on the next tick while the lease is still valid, so a transient database error
+// doesn't force a failover.
+func (l *Leadership) heartbeat() {
+	defer l.done.Done()
+	e := l.elector
+	ticker := time.NewTicker(e.renewInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		now := e.now(l.db)
+		expiresAt := now.Add(e.leaseDuration())
+		result := l.db.Model(&LeaderLease{}).Where("name = ? AND holder = ?", e.Name, e.identity()).
+			Updates(map[string]interface{}{"renewed_at": now, "expires_at": expiresAt})
+		switch {
+		case result.Error == nil && result.RowsAffected == 1:
+			l.expiresAt = expiresAt
+			continue
+		case result.Error == nil:
+			// The lease row is gone or held by another instance
+			e.logger(l.db).Error("Leadership %s was taken over", e.Name)
+		case now.Before(l.expiresAt):
+			e.logger(l.db).Warn("Failed to renew leadership %s, retrying until the lease expires at %s: %v", e.Name, l.expiresAt, result.Error)
+			continue
+		default:
+			e.logger(l.db).Error("Failed to renew leadership %s before the lease expired: %v", e.Name, result.Error)
+		}
+		close(l.lost)
+		return
+	}
+}
+
+// RunAsLeader runs migrate on the elected instance only. Followers poll until version is
+// recorded as applied and return, or take over when the leader's lease expires first. When
+// version is empty followers wait for the leader to finish and then run migrate themselves,
+// which AutoMigratePlugin records as a no-op unless SkipNoop or SkipUnchanged is set.
+func (e *LeaderElector) RunAsLeader(ctx context.Context, db *gorm.DB, version string, migrate func(db *gorm.DB) error) error {
+	logger := e.logger(db)
+	logger.Debug("RunAsLeader method called for %s", e.Name)
+
+	for {
+		if version != "" {
+			reached, err := IsApplied(db.WithContext(ctx), version)
+			if err != nil {
+				return err
+			}
+			if reached {
+				logger.Info("Schema version %s applied, continuing startup", version)
+				return nil
+			}
+		}
+
+		leadership, err := e.Campaign(ctx, db)
+		if err == nil {
+			return leadership.runUnlessApplied(ctx, version, migrate)
+		}
+		if !errors.Is(err, ErrNotLeader) {
+			return err
+		}
+
+		logger.Debug("Waiting for the leader of %s", e.Name)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.pollInterval()):
+		}
+	}
+}
+
+// runUnlessApplied calls migrate while the lease is held, unless version was applied by the
+// previous leader between the follower's last poll and its election
+func (l *Leadership) runUnlessApplied(ctx context.Context, version string, migrate func(db *gorm.DB) error) error {
+	if version != "" {
+		reached, err := IsApplied(l.db.WithContext(ctx), version)
+		if err != nil || reached {
+			if resignErr := l.Resign(context.WithoutCancel(ctx)); resignErr != nil && err == nil {
+				err = resignErr
+			}
+			if reached {
+				l.elector.logger(l.db).Info("Schema version %s applied, continuing startup", version)
+			}
+			return err
+		}
+	}
+	return l.run(ctx, migrate)
+}
+
+// run calls migrate while the lease is held, cancelling it when leadership is lost
+func (l *Leadership) run(ctx context.Context, migrate func(db *gorm.DB) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := migrate(l.db.WithContext(ctx))
+	select {
+	case <-l.lost:
+		if err == nil {
+			err = ErrLeadershipLost
+		}
+	default:
+	}
+	if resignErr := l.Resign(context.WithoutCancel(ctx)); resignErr != nil && err == nil {
+		err = resignErr
+	}
+	return err
+}
+
+// identity returns the holder name of this instance
+func (e *LeaderElector) identity() string {
+	if e.Identity != "" {
+		return e.Identity
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// leaseDuration returns the configured lease duration or its default
+func (e *LeaderElector) leaseDuration() time.Duration {
+	if e.LeaseDuration > 0 {
+		return e.LeaseDuration
+	}
+	return DefaultLeaseDuration
+}
+
+// renewInterval returns the configured renew interval or its default
+func (e *LeaderElector) renewInterval() time.Duration {
+	if e.RenewInterval > 0 {
+		return e.RenewInterval
+	}
+	return e.leaseDuration() / 3
+}
+
+// pollInterval returns the configured poll interval or its default
+func (e *LeaderElector) pollInterval() time.Duration {
+	if e.PollInterval > 0 {
+		return e.PollInterval
+	}
+	return DefaultPollInterval
+}
+
+// logger returns the configured logger or the one of the registered plugin
+func (e *LeaderElector) logger(db *gorm.DB) Logger {
+	if e.Logger != nil {
+		return e.Logger
+	}
+	return loggerFrom(db)
+}
+
+// now returns the current time according to the configured clock
+func (e *LeaderElector) now(db *gorm.DB) time.Time {
+	if e.Clock != nil {
+		return e.Clock.Now()
+	}
+	return registeredPlugin(db).now()
+}
@@ -0,0 +1,108 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestCampaign(t *testing.T) {
+	db, _ := openTestDB(t)
+	leader := &LeaderElector{Name: "migrations", Identity: "pod-a"}
+	follower := &LeaderElector{Name: "migrations", Identity: "pod-b"}
+
+	leadership, err := leader.Campaign(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Campaign: %v", err)
+	}
+	if _, err := follower.Campaign(context.Background(), db); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Campaign against a live lease = %v, want ErrNotLeader", err)
+	}
+
+	if err := leadership.Resign(context.Background()); err != nil {
+		t.Fatalf("Resign: %v", err)
+	}
+	leadership, err = follower.Campaign(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Campaign once the leader resigned: %v", err)
+	}
+	leadership.Resign(context.Background())
+}
+
+func TestCampaignTakesOverExpiredLease(t *testing.T) {
+	db, _ := openTestDB(t)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// The leader stops renewing once the follower took over
+	leader := &LeaderElector{Name: "migrations", Identity: "pod-a", LeaseDuration: time.Second, RenewInterval: 5 * time.Millisecond, Clock: &tickingClock{now: start}}
+	follower := &LeaderElector{Name: "migrations", Identity: "pod-b", LeaseDuration: time.Second, Clock: &tickingClock{now: start.Add(time.Hour)}}
+
+	leadership, err := leader.Campaign(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Campaign: %v", err)
+	}
+	defer leadership.Resign(context.Background())
+	taken, err := follower.Campaign(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Campaign against an expired lease: %v", err)
+	}
+	defer taken.Resign(context.Background())
+
+	select {
+	case <-leadership.Lost():
+	case <-time.After(5 * time.Second):
+		t.Error("the leader wasn't told its lease was taken over")
+	}
+	select {
+	case <-taken.Lost():
+		t.Error("the new leader lost its lease")
+	default:
+	}
+}
+
+func TestRunAsLeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	const replicas = 4
+	dbs := make([]*gorm.DB, replicas)
+	for i := range dbs {
+		dbs[i], _ = openTestDBAt(t, path)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		migrated int
+	)
+	errs := make([]error, replicas)
+	for i, db := range dbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			elector := &LeaderElector{Name: "migrations", Identity: string(rune('a' + i)), PollInterval: 5 * time.Millisecond}
+			errs[i] = elector.RunAsLeader(context.Background(), db, "1", func(db *gorm.DB) error {
+				mu.Lock()
+				migrated++
+				mu.Unlock()
+				return db.WithContext(ContextWithVersion(db.Statement.Context, "1")).AutoMigrate(&pluginUser{})
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("RunAsLeader of replica %d: %v", i, err)
+		}
+	}
+	// The followers continue once the leader applied the version
+	if migrated != 1 {
+		t.Errorf("%d replicas migrated, want the leader alone", migrated)
+	}
+	if history := mustHistory(t, dbs[0]); len(history) != 1 {
+		t.Errorf("recorded %d versions, want 1", len(history))
+	}
+}
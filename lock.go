@@ -0,0 +1,290 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrLockNotAcquired is returned when the migration lock is held by another instance
+var ErrLockNotAcquired = errors.New("migration lock is held by another instance")
+
+// DefaultLockName is the name of the lock coordinating migrations across instances
+const DefaultLockName = "gorm_migrate_tracker"
+
+// LockPolicy decides what an instance does when another instance holds the migration lock
+type LockPolicy int
+
+const (
+	// LockWait blocks until the lock is released, then migrates. A run executing no statements
+	// for the models of the current version, which the holder applied meanwhile, isn't recorded
+	// a second time.
+	LockWait LockPolicy = iota
+	// LockSkip skips AutoMigrate and recording altogether
+	LockSkip
+)
+
+// Lock is a held migration lock
+type Lock interface {
+	Release(ctx context.Context) error
+}
+
+// Locker acquires the lock coordinating migrations across instances. When wait is false
+// Acquire returns ErrLockNotAcquired instead of blocking if the lock is held elsewhere.
+type Locker interface {
+	Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error)
+}
+
+// DefaultLocker returns the locker best suited to the connected dialect: pg_advisory_lock
+// for Postgres, GET_LOCK for MySQL and a lock table everywhere else
+func DefaultLocker(db *gorm.DB, name string) Locker {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return &PostgresLocker{Name: name}
+	case "mysql":
+		return &MySQLLocker{Name: name}
+	default:
+		return &TableLocker{Name: name}
+	}
+}
+
+// dialectLocker picks the DefaultLocker for the connection it is asked to lock
+type dialectLocker struct{}
+
+// Acquire obtains the lock using the default locker of the connected dialect
+func (dialectLocker) Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error) {
+	return DefaultLocker(db, DefaultLockName).Acquire(ctx, db, wait)
+}
+
+// sqlConnLock is a lock held on a dedicated connection
+type sqlConnLock struct {
+	conn    *sql.Conn
+	release func(ctx context.Context, conn *sql.Conn) error
+}
+
+// Release releases the lock and returns the connection to the pool
+func (l *sqlConnLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	return l.release(ctx, l.conn)
+}
+
+// conn pins a connection so session level locks are acquired and released on the same session
+func conn(ctx context.Context, db *gorm.DB) (*sql.Conn, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to access database handle: %w", err)
+	}
+	return sqlDB.Conn(ctx)
+}
+
+// PostgresLocker coordinates migrations with pg_advisory_lock
+type PostgresLocker struct {
+	Name string
+}
+
+// key derives the advisory lock key from the lock name
+func (l *PostgresLocker) key() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(l.Name))
+	return int64(h.Sum64())
+}
+
+// Acquire obtains the advisory lock on a dedicated connection
+func (l *PostgresLocker) Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error) {
+	c, err := conn(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if wait {
+		_, err = c.ExecContext(ctx, "SELECT pg_advisory_lock($1)", l.key())
+	} else {
+		var acquired bool
+		err = c.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key()).Scan(&acquired)
+		if err == nil && !acquired {
+			err = ErrLockNotAcquired
+		}
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return &sqlConnLock{conn: c, release: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key())
+		return err
+	}}, nil
+}
+
+// MySQLLocker coordinates migrations with GET_LOCK
+type MySQLLocker struct {
+	Name string
+}
+
+// Acquire obtains the named lock on a dedicated connection
+func (l *MySQLLocker) Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error) {
+	c, err := conn(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 0
+	if wait {
+		timeout = -1
+	}
+	var acquired sql.NullInt64
+	err = c.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", l.Name, timeout).Scan(&acquired)
+	if err == nil && acquired.Int64 != 1 {
+		err = ErrLockNotAcquired
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return &sqlConnLock{conn: c, release: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.Name)
+		return err
+	}}, nil
+}
+
+// MigrationLock is a row of the fallback lock table
+type MigrationLock struct {
+	Name     string `gorm:"primaryKey"`
+	Owner    string
+	LockedAt time.Time
+}
+
+// lockTableName returns the name of the fallback lock table
+func lockTableName(db *gorm.DB) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&MigrationLock{}); err != nil {
+		return ""
+	}
+	return stmt.Table
+}
+
+// TableLocker coordinates migrations by inserting a row into a lock table, for
+// dialects without advisory locks
+type TableLocker struct {
+	Name string
+	// PollInterval is how often a waiting instance retries, defaults to one second
+	PollInterval time.Duration
+	// StaleAfter is how long a lock row is honoured before it's considered left behind by a
+	// crashed instance and taken over, defaults to fifteen minutes. It must exceed the
+	// longest migration, the row isn't refreshed while it's held.
+	StaleAfter time.Duration
+}
+
+// tableLock is a held TableLocker lock
+type tableLock struct {
+	db    *gorm.DB
+	name  string
+	owner string
+}
+
+// Release deletes the lock row, unless it was taken over by another instance since
+func (l *tableLock) Release(ctx context.Context) error {
+	return l.db.WithContext(ctx).Delete(&MigrationLock{}, "name = ? AND owner = ?", l.name, l.owner).Error
+}
+
+// lockOwner identifies an Acquire call across instances and within the process
+func lockOwner() string {
+	hostname, _ := os.Hostname()
+	token := make([]byte, 4)
+	rand.Read(token)
+	return fmt.Sprintf("%s:%d:%x", hostname, os.Getpid(), token)
+}
+
+// Acquire inserts the lock row, polling until it succeeds when wait is true. A row older than
+// StaleAfter is deleted so the lock can be taken over.
+func (l *TableLocker) Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error) {
+	tx := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx)
+	// Instances starting together race to create the table, only the ones it's missing for fail
+	if err := untrackedMigrator(tx).AutoMigrate(&MigrationLock{}); err != nil && !untrackedMigrator(tx).HasTable(&MigrationLock{}) {
+		return nil, fmt.Errorf("failed to create lock table: %w", err)
+	}
+
+	interval := l.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	staleAfter := l.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = 15 * time.Minute
+	}
+	plugin := registeredPlugin(db)
+	owner := lockOwner()
+
+	for {
+		err := tx.Create(&MigrationLock{Name: l.Name, Owner: owner, LockedAt: plugin.now()}).Error
+		if err == nil {
+			return &tableLock{db: tx, name: l.Name, owner: owner}, nil
+		}
+
+		var held MigrationLock
+		if heldErr := tx.Where("name = ?", l.Name).Limit(1).Find(&held).Error; heldErr != nil || held.Name == "" {
+			return nil, fmt.Errorf("failed to acquire lock %s: %w", l.Name, err)
+		}
+		if plugin.now().Sub(held.LockedAt) > staleAfter {
+			// Owners are unique, only one of the instances racing for the stale row deletes it
+			takeover := tx.Delete(&MigrationLock{}, "name = ? AND owner = ?", l.Name, held.Owner)
+			if takeover.Error != nil {
+				return nil, fmt.Errorf("failed to take over stale lock %s: %w", l.Name, takeover.Error)
+			}
+			if takeover.RowsAffected > 0 {
+				plugin.Logger.Warn("Took over migration lock %s held by %s since %s", l.Name, held.Owner, held.LockedAt.Format(time.RFC3339))
+			}
+			continue
+		}
+		if !wait {
+			return nil, ErrLockNotAcquired
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// acquireLock obtains the migration lock according to the configured policy, reporting whether
+// it blocked because another instance held it. The lock is tried without waiting first, so a
+// run that didn't wait isn't mistaken for one following another instance's migration.
+func (p *AutoMigratePlugin) acquireLock(db *gorm.DB) (Lock, bool, error) {
+	wait := p.LockPolicy == LockWait
+	p.Logger.Debug("Acquiring migration lock (wait=%t)", wait)
+	lock, err := p.Locker.Acquire(db.Statement.Context, db, false)
+	waited := false
+	if errors.Is(err, ErrLockNotAcquired) && wait {
+		p.Logger.Debug("Migration lock held by another instance, waiting for it")
+		lock, err = p.Locker.Acquire(db.Statement.Context, db, true)
+		waited = true
+	}
+	if err != nil {
+		if !errors.Is(err, ErrLockNotAcquired) {
+			p.Logger.Error("Failed to acquire migration lock: %v", err)
+			return nil, false, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return nil, false, err
+	}
+	p.Logger.Debug("Acquired migration lock")
+	return lock, waited, nil
+}
+
+// releaseLock releases the migration lock, logging failures
+func (p *AutoMigratePlugin) releaseLock(db *gorm.DB, lock Lock) {
+	if err := lock.Release(db.Statement.Context); err != nil {
+		p.Logger.Error("Failed to release migration lock: %v", err)
+		return
+	}
+	p.Logger.Debug("Released migration lock")
+}
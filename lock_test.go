@@ -0,0 +1,188 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// contendedLocker wraps a Locker, signalling every attempt that found the lock held
+type contendedLocker struct {
+	Locker
+	contended chan struct{}
+}
+
+func (l *contendedLocker) Acquire(ctx context.Context, db *gorm.DB, wait bool) (Lock, error) {
+	if !wait {
+		lock, err := l.Locker.Acquire(ctx, db, false)
+		if errors.Is(err, ErrLockNotAcquired) {
+			l.contended <- struct{}{}
+		}
+		return lock, err
+	}
+	return l.Locker.Acquire(ctx, db, true)
+}
+
+type lockedAccount struct {
+	ID      uint
+	Balance int
+}
+
+func TestTableLockerExclusive(t *testing.T) {
+	db, _ := openTestDB(t)
+	locker := &TableLocker{Name: "test", PollInterval: 10 * time.Millisecond}
+	ctx := context.Background()
+
+	lock, err := locker.Acquire(ctx, db, false)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := locker.Acquire(ctx, db, false); !errors.Is(err, ErrLockNotAcquired) {
+		t.Fatalf("Acquire of a held lock = %v, want ErrLockNotAcquired", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	lock, err = locker.Acquire(ctx, db, false)
+	if err != nil {
+		t.Fatalf("Acquire of a released lock: %v", err)
+	}
+	lock.Release(ctx)
+}
+
+func TestTableLockerConcurrentAcquire(t *testing.T) {
+	db, _ := openTestDB(t)
+	locker := &TableLocker{Name: "test", PollInterval: time.Millisecond}
+	ctx := context.Background()
+
+	var held, maxHeld atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := locker.Acquire(ctx, db, true)
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			n := held.Add(1)
+			for {
+				highest := maxHeld.Load()
+				if n <= highest || maxHeld.CompareAndSwap(highest, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			held.Add(-1)
+			if err := lock.Release(ctx); err != nil {
+				t.Errorf("Release: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxHeld.Load() != 1 {
+		t.Errorf("lock held by %d goroutines at once, want 1", maxHeld.Load())
+	}
+}
+
+func TestTableLockerTakesOverStaleLock(t *testing.T) {
+	db, _ := openTestDB(t)
+	locker := &TableLocker{Name: "test", StaleAfter: time.Minute}
+	ctx := context.Background()
+
+	if _, err := locker.Acquire(ctx, db, false); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	// The holder crashed long ago without releasing it
+	if err := db.Model(&MigrationLock{}).Where("name = ?", "test").Update("locked_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("failed to age the lock: %v", err)
+	}
+	lock, err := locker.Acquire(ctx, db, false)
+	if err != nil {
+		t.Fatalf("Acquire of a stale lock: %v", err)
+	}
+	lock.Release(ctx)
+}
+
+func TestAutoMigrateLockSkip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	holder, _ := openTestDBAt(t, path)
+	db, _ := openTestDBAt(t, path, WithLocker(&TableLocker{Name: DefaultLockName}, LockSkip))
+
+	lock, err := (&TableLocker{Name: DefaultLockName}).Acquire(context.Background(), holder, false)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer lock.Release(context.Background())
+
+	if err := db.AutoMigrate(&lockedAccount{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if db.Migrator().HasTable(&lockedAccount{}) {
+		t.Error("AutoMigrate migrated while another instance held the lock")
+	}
+	if history := mustHistory(t, db); len(history) != 0 {
+		t.Errorf("recorded %d versions while another instance held the lock, want none", len(history))
+	}
+}
+
+func TestAutoMigrateLockWaitSkipsAppliedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	holder, _ := openTestDBAt(t, path)
+	first := &contendedLocker{Locker: &TableLocker{Name: DefaultLockName, PollInterval: 5 * time.Millisecond}, contended: make(chan struct{}, 1)}
+	second := &contendedLocker{Locker: &TableLocker{Name: DefaultLockName, PollInterval: 5 * time.Millisecond}, contended: make(chan struct{}, 1)}
+	replicas := []*gorm.DB{}
+	for _, locker := range []*contendedLocker{first, second} {
+		db, _ := openTestDBAt(t, path, WithLocker(locker, LockWait), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+		replicas = append(replicas, db)
+	}
+
+	lock, err := (&TableLocker{Name: DefaultLockName}).Acquire(context.Background(), holder, false)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	// Both replicas start while the lock is held, whichever gets it first migrates and the
+	// other one finds the models applied
+	var wg sync.WaitGroup
+	for _, db := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.AutoMigrate(&lockedAccount{}); err != nil {
+				t.Errorf("AutoMigrate: %v", err)
+			}
+		}()
+	}
+	<-first.contended
+	<-second.contended
+	if err := lock.Release(context.Background()); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	wg.Wait()
+
+	if history := mustHistory(t, holder); len(history) != 1 {
+		t.Errorf("recorded %d versions, want the migration recorded once", len(history))
+	}
+}
+
+func TestAutoMigrateLockRecordsUncontendedRuns(t *testing.T) {
+	db, _ := openTestDB(t, WithLocker(&TableLocker{Name: DefaultLockName}, LockWait), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+
+	// Neither run waited for the lock, the no-op one is recorded like without a locker
+	for range 2 {
+		if err := db.AutoMigrate(&lockedAccount{}); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+	}
+	if history := mustHistory(t, db); len(history) != 2 {
+		t.Errorf("recorded %d versions, want 2", len(history))
+	}
+}
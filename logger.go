@@ -0,0 +1,200 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// Logger is the leveled logger used by the plugin
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
+// LogLevel is the minimum level of the messages the plugin logs, ordered like slog levels
+type LogLevel int
+
+// Log levels, the zero value is LogLevelInfo
+const (
+	LogLevelDebug LogLevel = -4
+	LogLevelInfo  LogLevel = 0
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 8
+	// LogLevelSilent disables logging
+	LogLevelSilent LogLevel = 12
+)
+
+// leveledLogger drops the messages of a Logger below a level
+type leveledLogger struct {
+	logger Logger
+	level  LogLevel
+}
+
+// NewLeveledLogger returns a Logger passing the messages at or above level to logger
+func NewLeveledLogger(logger Logger, level LogLevel) Logger {
+	if leveled, ok := logger.(*leveledLogger); ok {
+		logger = leveled.logger
+	}
+	return &leveledLogger{logger: logger, level: level}
+}
+
+// Debug logs a debug level message
+func (l *leveledLogger) Debug(format string, args ...interface{}) {
+	if l.level <= LogLevelDebug {
+		l.logger.Debug(format, args...)
+	}
+}
+
+// Info logs a info level message
+func (l *leveledLogger) Info(format string, args ...interface{}) {
+	if l.level <= LogLevelInfo {
+		l.logger.Info(format, args...)
+	}
+}
+
+// Warn logs a warn level message
+func (l *leveledLogger) Warn(format string, args ...interface{}) {
+	if l.level <= LogLevelWarn {
+		l.logger.Warn(format, args...)
+	}
+}
+
+// Error logs a error level message
+func (l *leveledLogger) Error(format string, args ...interface{}) {
+	if l.level <= LogLevelError {
+		l.logger.Error(format, args...)
+	}
+}
+
+// stdLogger adapts a *log.Logger to the Logger interface
+type stdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger returns a Logger writing to a standard library *log.Logger
+func NewStdLogger(logger *log.Logger) Logger {
+	return &stdLogger{logger: logger}
+}
+
+// defaultLogger returns the logger used when none is configured, logging from LogLevelInfo
+func defaultLogger() Logger {
+	return NewLeveledLogger(NewStdLogger(log.New(os.Stdout, "[AutoMigratePlugin] ", log.LstdFlags)), LogLevelInfo)
+}
+
+// Debug logs a debug level message
+func (l *stdLogger) Debug(format string, args ...interface{}) {
+	l.logger.Printf("DEBUG "+format, args...)
+}
+
+// Info logs a info level message
+func (l *stdLogger) Info(format string, args ...interface{}) {
+	l.logger.Printf("INFO "+format, args...)
+}
+
+// Warn logs a warn level message
+func (l *stdLogger) Warn(format string, args ...interface{}) {
+	l.logger.Printf("WARN "+format, args...)
+}
+
+// Error logs a error level message
+func (l *stdLogger) Error(format string, args ...interface{}) {
+	l.logger.Printf("ERROR "+format, args...)
+}
+
+// slogLogger adapts a *slog.Logger to the Logger interface
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger writing to a log/slog logger
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+// log emits the formatted message if the level is enabled
+func (l *slogLogger) log(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.logger.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+// Debug logs a debug level message
+func (l *slogLogger) Debug(format string, args ...interface{}) {
+	l.log(slog.LevelDebug, format, args...)
+}
+
+// Info logs a info level message
+func (l *slogLogger) Info(format string, args ...interface{}) {
+	l.log(slog.LevelInfo, format, args...)
+}
+
+// Warn logs a warn level message
+func (l *slogLogger) Warn(format string, args ...interface{}) {
+	l.log(slog.LevelWarn, format, args...)
+}
+
+// Error logs a error level message
+func (l *slogLogger) Error(format string, args ...interface{}) {
+	l.log(slog.LevelError, format, args...)
+}
+
+// FormatLogger is implemented by printf-style leveled loggers such as
+// *zap.SugaredLogger, *logrus.Logger and *logrus.Entry
+type FormatLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// formatLogger adapts a FormatLogger to the Logger interface
+type formatLogger struct {
+	logger FormatLogger
+}
+
+// NewZapLogger returns a Logger writing to a zap logger, pass zapLogger.Sugar()
+func NewZapLogger(logger FormatLogger) Logger {
+	return &formatLogger{logger: logger}
+}
+
+// NewLogrusLogger returns a Logger writing to a *logrus.Logger or *logrus.Entry
+func NewLogrusLogger(logger FormatLogger) Logger {
+	return &formatLogger{logger: logger}
+}
+
+// Debug logs a debug level message
+func (l *formatLogger) Debug(format string, args ...interface{}) {
+	l.logger.Debugf(format, args...)
+}
+
+// Info logs a info level message
+func (l *formatLogger) Info(format string, args ...interface{}) {
+	l.logger.Infof(format, args...)
+}
+
+// Warn logs a warn level message
+func (l *formatLogger) Warn(format string, args ...interface{}) {
+	l.logger.Warnf(format, args...)
+}
+
+// Error logs a error level message
+func (l *formatLogger) Error(format string, args ...interface{}) {
+	l.logger.Errorf(format, args...)
+}
+
+// loggerFrom returns the logger of the plugin registered on db, or the default logger
+func loggerFrom(db *gorm.DB) Logger {
+	if p, ok := pluginFrom(db); ok && p.Logger != nil {
+		return p.Logger
+	}
+	return defaultLogger()
+}
@@ -0,0 +1,47 @@
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLeveledLogger(t *testing.T) {
+	var b bytes.Buffer
+	std := NewStdLogger(log.New(&b, "", 0))
+	logAll := func(logger Logger) int {
+		b.Reset()
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		return strings.Count(b.String(), "\n")
+	}
+
+	for level, want := range map[LogLevel]int{LogLevelDebug: 4, LogLevelInfo: 3, LogLevelWarn: 2, LogLevelError: 1, LogLevelSilent: 0} {
+		if logged := logAll(NewLeveledLogger(std, level)); logged != want {
+			t.Errorf("level %d logged %d messages, want %d", level, logged, want)
+		}
+	}
+	// Leveling a leveled logger replaces its level rather than stacking both
+	if logged := logAll(NewLeveledLogger(NewLeveledLogger(std, LogLevelError), LogLevelDebug)); logged != 4 {
+		t.Errorf("releveled logger logged %d messages, want all 4", logged)
+	}
+}
+
+func TestWithQuiet(t *testing.T) {
+	var b bytes.Buffer
+	db, _ := openTestDB(t, WithLogger(NewStdLogger(log.New(&b, "", 0))), WithQuiet(false))
+	migrateAs(t, db, "1", &pluginUser{})
+	if strings.Contains(b.String(), "INFO") || strings.Contains(b.String(), "DEBUG") {
+		t.Errorf("quiet plugin logged %q, want warnings and errors alone", b.String())
+	}
+
+	b.Reset()
+	db, _ = openTestDB(t, WithLogger(NewStdLogger(log.New(&b, "", 0))), WithLogLevel(LogLevelDebug))
+	migrateAs(t, db, "1", &pluginUser{})
+	if !strings.Contains(b.String(), "DEBUG") {
+		t.Errorf("plugin logging from LogLevelDebug logged %q, want debug messages", b.String())
+	}
+}
@@ -0,0 +1,163 @@
+package gorm_migrate_tracker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ExportMarkdown writes the migration history as a CHANGELOG style Markdown document, one
+// section per version, newest first, with the changes grouped by table
+func ExportMarkdown(db *gorm.DB, w io.Writer) error {
+	logger := loggerFrom(db)
+	logger.Debug("ExportMarkdown function called")
+
+	history, err := GetMigrationHistory(db)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "# Schema changelog")
+	for _, schemaVersion := range history {
+		if schemaVersion.Status == StatusPending {
+			continue
+		}
+		if err := writeMarkdownVersion(b, schemaVersion); err != nil {
+			return err
+		}
+	}
+	if err := b.Flush(); err != nil {
+		logger.Error("Failed to write Markdown changelog: %v", err)
+		return fmt.Errorf("failed to write Markdown changelog: %w", err)
+	}
+	logger.Debug("Exported %d versions to Markdown", len(history))
+	return nil
+}
+
+// WriteMarkdownEvent writes the section of a migration event the way ExportMarkdown writes the
+// section of its version, e.g. to append it to a changelog
+func WriteMarkdownEvent(w io.Writer, event MigrationEvent) error {
+	changeSet := event.Changes
+	if changeSet == nil {
+		changeSet = &ChangeSet{}
+	}
+	changes, err := encodeChangeSet(changeSet)
+	if err != nil {
+		return err
+	}
+	return writeMarkdownVersion(w, SchemaVersion{
+		Version:    event.Version,
+		Kind:       KindMigration,
+		Status:     event.Status,
+		Error:      event.Error,
+		DurationMs: event.DurationMs,
+		AppliedAt:  event.AppliedAt,
+		Changes:    changes,
+		Statements: joinStatements(event.Statements),
+	})
+}
+
+// writeMarkdownVersion writes the section of a single version
+func writeMarkdownVersion(w io.Writer, schemaVersion SchemaVersion) error {
+	changes, err := schemaVersion.ParseChanges()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n## %s\n\n", schemaVersion.Version)
+	if schemaVersion.Kind != KindMigration {
+		fmt.Fprintf(w, "- Kind: %s\n", schemaVersion.Kind)
+	}
+	fmt.Fprintf(w, "- Status: %s\n", schemaVersion.Status)
+	fmt.Fprintf(w, "- Applied at: %s\n", schemaVersion.AppliedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Fprintf(w, "- Duration: %dms\n", schemaVersion.DurationMs)
+	if schemaVersion.InitiatedBy != "" {
+		fmt.Fprintf(w, "- Initiated by: %s\n", schemaVersion.InitiatedBy)
+	}
+	if schemaVersion.Severity != "" && schemaVersion.Severity != SeverityInfo {
+		fmt.Fprintf(w, "- Severity: %s\n", schemaVersion.Severity)
+	}
+	if len(schemaVersion.Labels) > 0 {
+		labels := make([]string, 0, len(schemaVersion.Labels))
+		for _, key := range slices.Sorted(maps.Keys(schemaVersion.Labels)) {
+			labels = append(labels, key+"="+schemaVersion.Labels[key])
+		}
+		fmt.Fprintf(w, "- Labels: %s\n", strings.Join(labels, ", "))
+	}
+	if schemaVersion.Error != "" {
+		fmt.Fprintf(w, "- Error: `%s`\n", schemaVersion.Error)
+	}
+
+	for _, model := range changes.Models {
+		table := model.Table
+		if table == "" {
+			table = model.Model
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", table)
+		switch {
+		case changes.Baseline:
+			fmt.Fprintf(w, "- Baselined %s\n", model.Model)
+		case len(model.Columns) == 0 && len(model.Indexes) == 0 && len(model.Constraints) == 0 && len(model.Enums) == 0 && len(model.Notes) == 0:
+			fmt.Fprintf(w, "- %s %s in %dms, no column changes\n", changes.verb(), model.Model, model.DurationMs)
+		default:
+			fmt.Fprintf(w, "- %s %s in %dms\n", changes.verb(), model.Model, model.DurationMs)
+		}
+		for _, column := range model.Columns {
+			fmt.Fprintf(w, "- %s\n", column)
+		}
+		for _, index := range model.Indexes {
+			fmt.Fprintf(w, "- %s\n", index)
+		}
+		for _, constraint := range model.Constraints {
+			fmt.Fprintf(w, "- %s\n", constraint)
+		}
+		for _, enum := range model.Enums {
+			fmt.Fprintf(w, "- %s\n", enum)
+		}
+		for _, note := range model.Notes {
+			fmt.Fprintf(w, "- %s\n", note)
+		}
+	}
+
+	if len(changes.Views) > 0 {
+		fmt.Fprint(w, "\n### Views\n\n")
+		for _, view := range changes.Views {
+			fmt.Fprintf(w, "- %s\n", view)
+		}
+	}
+
+	if len(changes.Routines) > 0 {
+		fmt.Fprint(w, "\n### Routines\n\n")
+		for _, routine := range changes.Routines {
+			fmt.Fprintf(w, "- %s\n", routine)
+		}
+	}
+
+	if len(changes.Destructive) > 0 {
+		fmt.Fprint(w, "\n**Destructive changes**\n\n")
+		for _, change := range changes.Destructive {
+			fmt.Fprintf(w, "- %s\n", change)
+		}
+	}
+	if changes.Drift != nil {
+		fmt.Fprintf(w, "\n```\n%s```\n", changes.Drift)
+	}
+	for _, note := range changes.Notes {
+		fmt.Fprintf(w, "\n%s\n", note)
+	}
+
+	if statements := splitStatements(schemaVersion.Statements); len(statements) > 0 {
+		fmt.Fprint(w, "\n```sql\n")
+		for _, statement := range statements {
+			fmt.Fprintf(w, "%s;\n", statement)
+		}
+		fmt.Fprint(w, "```\n")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "3")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	var b strings.Builder
+	if err := ExportMarkdown(db, &b); err != nil {
+		t.Fatalf("ExportMarkdown: %v", err)
+	}
+	markdown := b.String()
+	if !strings.HasPrefix(markdown, "# Schema changelog\n") {
+		t.Errorf("changelog = %q, want its title first", markdown)
+	}
+	for _, want := range []string{"\n## 2\n", "\n### users\n", "- users.email added (text, nullable=true)\n", "- Status: failed\n", "- Error: `"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("changelog = %q, want %q", markdown, want)
+		}
+	}
+	// Newest first
+	if strings.Index(markdown, "## 3") > strings.Index(markdown, "## 1") {
+		t.Errorf("changelog = %q, want the newest version first", markdown)
+	}
+}
@@ -0,0 +1,48 @@
+package gorm_migrate_tracker
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationMetrics are the measurements of a single AutoMigrate run
+type MigrationMetrics struct {
+	Version    string
+	Duration   time.Duration
+	Statements int
+	AppliedAt  time.Time
+	Err        error
+}
+
+// MetricsRecorder receives measurements of migration activity, see StatsDRecorder and the
+// contrib/prometheus module for a Prometheus collector
+type MetricsRecorder interface {
+	ObserveMigration(metrics MigrationMetrics)
+}
+
+// observeMigration reports the measurements of the run attached to db
+func (p *AutoMigratePlugin) observeMigration(db *gorm.DB, err error) {
+	if p.Metrics == nil {
+		return
+	}
+
+	run, ok := runFromDB(db)
+	if !ok {
+		return
+	}
+
+	now := p.now()
+	run.mu.Lock()
+	metrics := MigrationMetrics{
+		Version:    run.version,
+		Duration:   now.Sub(run.startTime),
+		Statements: len(run.statements),
+		AppliedAt:  now,
+		Err:        err,
+	}
+	run.mu.Unlock()
+
+	p.Logger.Debug("Reporting metrics for migration %s", metrics.Version)
+	p.Metrics.ObserveMigration(metrics)
+}
@@ -0,0 +1,117 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationEvent describes a completed AutoMigrate run to notifiers
+type MigrationEvent struct {
+	Version    string     `json:"version"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Changes    *ChangeSet `json:"changes,omitempty"`
+	Statements []string   `json:"statements,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	AppliedAt  time.Time  `json:"applied_at"`
+	// RecordError is the error the SchemaVersion of the event failed to be recorded with,
+	// set on the events an AuditFile keeps in place of the history record
+	RecordError string `json:"record_error,omitempty"`
+}
+
+// Notifier is informed after every recorded migration, see WebhookNotifier
+type Notifier interface {
+	Notify(ctx context.Context, event MigrationEvent) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(ctx context.Context, event MigrationEvent) error
+
+// Notify calls f(ctx, event)
+func (f NotifierFunc) Notify(ctx context.Context, event MigrationEvent) error {
+	return f(ctx, event)
+}
+
+// notify sends the run attached to db to every configured notifier, failures are logged
+// and never fail the migration
+func (p *AutoMigratePlugin) notify(db *gorm.DB, err error) {
+	if len(p.Notifiers) == 0 {
+		return
+	}
+
+	run, ok := runFromDB(db)
+	if !ok {
+		return
+	}
+
+	if p.outboxEnabled() && run.recorded != nil {
+		// The event was enqueued with the record, deliver it along with the ones left behind
+		if _, err := p.dispatchOutbox(db); err != nil {
+			p.Logger.Warn("Migration events left in the outbox: %v", err)
+		}
+		return
+	}
+
+	event, ok := p.migrationEvent(run, err)
+	if !ok {
+		return
+	}
+
+	for _, notifier := range p.Notifiers {
+		p.Logger.Debug("Notifying %T of migration %s", notifier, event.Version)
+		if notifyErr := notifier.Notify(db.Statement.Context, event); notifyErr != nil {
+			p.Logger.Warn("Failed to notify %T of migration %s: %v", notifier, event.Version, notifyErr)
+		}
+	}
+}
+
+// versionEvent builds the event announcing a recorded SchemaVersion
+func versionEvent(schemaVersion *SchemaVersion) MigrationEvent {
+	event := MigrationEvent{
+		Version:    schemaVersion.Version,
+		Status:     schemaVersion.Status,
+		Error:      schemaVersion.Error,
+		Statements: splitStatements(schemaVersion.Statements),
+		DurationMs: schemaVersion.DurationMs,
+		AppliedAt:  schemaVersion.AppliedAt,
+	}
+	if changes, err := schemaVersion.ParseChanges(); err == nil {
+		event.Changes = changes
+	}
+	return event
+}
+
+// migrationEvent builds the event for a run, reporting false when there is nothing to announce
+func (p *AutoMigratePlugin) migrationEvent(run *migrationRun, err error) (MigrationEvent, bool) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	event := MigrationEvent{
+		Version:    run.version,
+		Status:     StatusSuccess,
+		Statements: append([]string(nil), run.statements...),
+	}
+	if recorded := run.recorded; recorded != nil {
+		event.Status = recorded.Status
+		event.Error = recorded.Error
+		event.DurationMs = recorded.DurationMs
+		event.AppliedAt = recorded.AppliedAt
+		if changes, parseErr := recorded.ParseChanges(); parseErr == nil {
+			event.Changes = changes
+		}
+	} else if err == nil {
+		// Nothing was recorded, e.g. a skipped no-op migration
+		return event, false
+	} else {
+		event.AppliedAt = p.now()
+		event.DurationMs = event.AppliedAt.Sub(run.startTime).Milliseconds()
+	}
+
+	if err != nil {
+		event.Status = StatusFailed
+		event.Error = err.Error()
+	}
+	return event, true
+}
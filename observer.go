@@ -0,0 +1,100 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Observer is informed of the lifecycle of every tracked migration, for integrations that
+// need more than the completed event a Notifier receives. Embed ObserverFuncs to implement
+// only some of the methods.
+type Observer interface {
+	// OnPlanned is called with the plan of an AutoMigrate before any DDL is applied
+	OnPlanned(ctx context.Context, plan *Plan)
+	// OnApplied is called after an AutoMigrate succeeded
+	OnApplied(ctx context.Context, event MigrationEvent)
+	// OnFailed is called after an AutoMigrate failed
+	OnFailed(ctx context.Context, event MigrationEvent)
+	// OnRecorded is called after a SchemaVersion was written to the history table. In an
+	// atomic migration the write is rolled back when the migration fails.
+	OnRecorded(ctx context.Context, schemaVersion SchemaVersion)
+}
+
+// ObserverFuncs implements Observer with optional functions, nil functions are skipped
+type ObserverFuncs struct {
+	Planned  func(ctx context.Context, plan *Plan)
+	Applied  func(ctx context.Context, event MigrationEvent)
+	Failed   func(ctx context.Context, event MigrationEvent)
+	Recorded func(ctx context.Context, schemaVersion SchemaVersion)
+}
+
+// OnPlanned calls f.Planned
+func (f ObserverFuncs) OnPlanned(ctx context.Context, plan *Plan) {
+	if f.Planned != nil {
+		f.Planned(ctx, plan)
+	}
+}
+
+// OnApplied calls f.Applied
+func (f ObserverFuncs) OnApplied(ctx context.Context, event MigrationEvent) {
+	if f.Applied != nil {
+		f.Applied(ctx, event)
+	}
+}
+
+// OnFailed calls f.Failed
+func (f ObserverFuncs) OnFailed(ctx context.Context, event MigrationEvent) {
+	if f.Failed != nil {
+		f.Failed(ctx, event)
+	}
+}
+
+// OnRecorded calls f.Recorded
+func (f ObserverFuncs) OnRecorded(ctx context.Context, schemaVersion SchemaVersion) {
+	if f.Recorded != nil {
+		f.Recorded(ctx, schemaVersion)
+	}
+}
+
+// observePlanned passes the plan of an AutoMigrate to every observer
+func (p *AutoMigratePlugin) observePlanned(db *gorm.DB, plan *Plan) {
+	for _, observer := range p.Observers {
+		p.Logger.Debug("Informing %T of the AutoMigrate plan", observer)
+		observer.OnPlanned(contextFrom(db), plan)
+	}
+}
+
+// observeOutcome passes the outcome of the run attached to db to every observer
+func (p *AutoMigratePlugin) observeOutcome(db *gorm.DB, err error) {
+	if len(p.Observers) == 0 {
+		return
+	}
+
+	run, ok := runFromDB(db)
+	if !ok {
+		return
+	}
+
+	event, ok := p.migrationEvent(run, err)
+	if !ok {
+		return
+	}
+
+	for _, observer := range p.Observers {
+		p.Logger.Debug("Informing %T of migration %s", observer, event.Version)
+		if event.Status == StatusFailed {
+			observer.OnFailed(contextFrom(db), event)
+		} else {
+			observer.OnApplied(contextFrom(db), event)
+		}
+	}
+}
+
+// observeRecorded passes a SchemaVersion written to the history table to every observer
+func (p *AutoMigratePlugin) observeRecorded(db *gorm.DB, schemaVersion *SchemaVersion) {
+	for _, observer := range p.Observers {
+		p.Logger.Debug("Informing %T of recorded version %s", observer, schemaVersion.Version)
+		observer.OnRecorded(contextFrom(db), *schemaVersion)
+	}
+}
@@ -0,0 +1,39 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestObservers(t *testing.T) {
+	var calls []string
+	observer := ObserverFuncs{
+		Planned: func(_ context.Context, plan *Plan) {
+			calls = append(calls, "planned "+plan.Tables[0])
+		},
+		Applied: func(_ context.Context, event MigrationEvent) {
+			calls = append(calls, "applied "+event.Version)
+		},
+		Failed: func(_ context.Context, event MigrationEvent) {
+			calls = append(calls, "failed "+event.Version+": "+event.Error)
+		},
+		Recorded: func(_ context.Context, schemaVersion SchemaVersion) {
+			calls = append(calls, "recorded "+schemaVersion.Version+" "+schemaVersion.Status)
+		},
+	}
+	db, _ := openTestDB(t, WithObservers(observer))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateErr := db.WithContext(ContextWithVersion(db.Statement.Context, "2")).AutoMigrate(&failingModel{})
+	if migrateErr == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	want := []string{
+		"planned users", "recorded 1 success", "applied 1",
+		"planned failing_models", "recorded 2 failed", "failed 2: " + migrateErr.Error(),
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("observer calls =\n%q\nwant\n%q", calls, want)
+	}
+}
@@ -0,0 +1,245 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// track applies an explicit Migrator call and, with TrackMigratorCalls, records it as a
+// SchemaVersion the way AutoMigrate is recorded. The call holds the migration lock and is
+// planned and checked like AutoMigrate, so the destructive change policy, the policy and
+// approvals apply to it. Calls made while a run is in flight, such as the ones AutoMigrate
+// makes itself, are passed through.
+func (m *trackingMigrator) track(operation string, models []interface{}, apply func(gorm.Migrator) error, down ...string) error {
+	return m.trackRun(&migrationRun{models: models, operation: operation, down: down}, apply)
+}
+
+// trackRun is like track with the run recording the call prepared by the caller
+func (m *trackingMigrator) trackRun(run *migrationRun, apply func(gorm.Migrator) error) error {
+	plugin := m.dialect.plugin
+	operation, models := run.operation, run.models
+	if _, ok := runFromDB(m.db); ok || !plugin.TrackMigratorCalls || skipsTracking(m.db) {
+		return apply(m.Migrator)
+	}
+	if tracked, _ := plugin.trackedModels(m.db, models); len(models) > 0 && len(tracked) == 0 {
+		return apply(m.Migrator)
+	}
+	plugin.Logger.Debug("Tracking Migrator.%s", operation)
+	plugin.migrating.Lock()
+	defer plugin.migrating.Unlock()
+	if plugin.Locker != nil {
+		lock, _, err := plugin.acquireLock(m.db)
+		if errors.Is(err, ErrLockNotAcquired) {
+			plugin.Logger.Info("Migration lock held by another instance, skipping Migrator.%s", operation)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer plugin.releaseLock(m.db, lock)
+	}
+	if plugin.checksPlans() {
+		plan, err := m.planOperation(run, apply)
+		if err != nil {
+			return err
+		}
+		_, approved, err := plugin.checkPlanned(m.db, plan, models)
+		if err != nil {
+			return err
+		}
+		run.plan = plan
+		if approved != nil {
+			// Complete the approved record instead of recording a new version
+			run.pending, run.version = approved, approved.Version
+		}
+	}
+
+	run.startTime = plugin.now()
+	run.before = plugin.inspectModels(m.db, models)
+	tx := m.db.WithContext(context.WithValue(contextFrom(m.db), runContextKey{}, run))
+	ctx, span := plugin.tracer().StartMigration(tx.Statement.Context)
+	tx = tx.WithContext(ctx)
+
+	err := apply(m.dialect.Dialector.Migrator(tx))
+	run.addDuration(plugin.now().Sub(run.startTime))
+	plugin.afterAutoMigrate(tx, err)
+	if err == nil {
+		err = tx.Error
+	}
+
+	plugin.observeMigration(tx, err)
+	plugin.notify(tx, err)
+	plugin.observeOutcome(tx, err)
+	plugin.reportError(tx, err)
+	plugin.endMigrationSpan(tx, span, err)
+	return err
+}
+
+// destructiveOperations are the Migrator calls dropping data or indexes, whatever statements
+// the dialect executes for them, such as the table rebuild of SQLite
+var destructiveOperations = []string{"DropTable", "DropColumn", "DropConstraint", "DropIndex"}
+
+// planOperation computes the statements an explicit Migrator call would execute, intercepting
+// them the way Plan does for AutoMigrate
+func (m *trackingMigrator) planOperation(run *migrationRun, apply func(gorm.Migrator) error) (*Plan, error) {
+	plugin := m.dialect.plugin
+	planning := &migrationRun{startTime: plugin.now(), models: run.models, operation: run.operation}
+	tx := m.db.Session(&gorm.Session{Context: context.WithValue(contextFrom(m.db), runContextKey{}, planning)})
+	tx.Statement.ConnPool = &planConnPool{ConnPool: tx.Statement.ConnPool}
+	if err := apply(m.dialect.Dialector.Migrator(tx)); err != nil {
+		plugin.Logger.Error("Failed to plan Migrator.%s: %v", run.operation, err)
+		return nil, fmt.Errorf("failed to plan Migrator.%s: %w", run.operation, err)
+	}
+
+	plan := newPlan(planning.statements, nil)
+	if name, _, _ := strings.Cut(run.operation, "("); slices.Contains(destructiveOperations, name) && len(plan.Destructive) == 0 {
+		plan.Destructive = []string{run.operation}
+		plan.Severity = SeverityDanger
+	}
+	if !plan.Empty() {
+		plan.Down = run.down
+	}
+	plugin.Logger.Info("Planned %d statements affecting %d tables", len(plan.Statements), len(plan.Tables))
+	return plan, nil
+}
+
+// CreateTable creates the tables of the models
+func (m *trackingMigrator) CreateTable(dst ...interface{}) error {
+	return m.track("CreateTable", dst, func(migrator gorm.Migrator) error {
+		return migrator.CreateTable(dst...)
+	})
+}
+
+// DropTable drops the tables of the models
+func (m *trackingMigrator) DropTable(dst ...interface{}) error {
+	return m.track("DropTable", dst, func(migrator gorm.Migrator) error {
+		return migrator.DropTable(dst...)
+	})
+}
+
+// RenameTable renames a table
+func (m *trackingMigrator) RenameTable(oldName, newName interface{}) error {
+	oldTable, newTable := tableOf(m.db, oldName), tableOf(m.db, newName)
+	if plugin := m.dialect.plugin; plugin.ignoresTable(m.db, oldTable) || plugin.ignoresTable(m.db, newTable) {
+		return m.Migrator.RenameTable(oldName, newName)
+	}
+	down := fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quote(m.db, newTable), quote(m.db, oldTable))
+	return m.track(fmt.Sprintf("RenameTable(%s, %s)", oldTable, newTable), nil, func(migrator gorm.Migrator) error {
+		return migrator.RenameTable(oldName, newName)
+	}, down)
+}
+
+// AddColumn adds a column of the model
+func (m *trackingMigrator) AddColumn(dst interface{}, field string) error {
+	return m.track(fmt.Sprintf("AddColumn(%s)", field), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.AddColumn(dst, field)
+	})
+}
+
+// DropColumn drops a column of the model
+func (m *trackingMigrator) DropColumn(dst interface{}, field string) error {
+	return m.track(fmt.Sprintf("DropColumn(%s)", field), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.DropColumn(dst, field)
+	})
+}
+
+// AlterColumn alters a column of the model to its declared type
+func (m *trackingMigrator) AlterColumn(dst interface{}, field string) error {
+	return m.track(fmt.Sprintf("AlterColumn(%s)", field), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.AlterColumn(dst, field)
+	})
+}
+
+// MigrateColumn migrates a column of the model to its declared definition
+func (m *trackingMigrator) MigrateColumn(dst interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	return m.track(fmt.Sprintf("MigrateColumn(%s)", field.DBName), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.MigrateColumn(dst, field, columnType)
+	})
+}
+
+// RenameColumn renames a column of the model. The rename is recorded as such, so rolling it
+// back renames the column again instead of dropping it with its data.
+func (m *trackingMigrator) RenameColumn(dst interface{}, oldName, field string) error {
+	// Migrator.RenameColumn resolves the new name as a field of the model
+	rename := columnRename{from: oldName, to: field}
+	if stmt := (&gorm.Statement{DB: m.db}); stmt.Parse(dst) == nil {
+		if f := stmt.Schema.LookUpField(field); f != nil {
+			rename.to = f.DBName
+		}
+	}
+	run := &migrationRun{
+		models:    []interface{}{dst},
+		operation: fmt.Sprintf("RenameColumn(%s, %s)", oldName, field),
+		renames:   [][]columnRename{{rename}},
+	}
+	return m.trackRun(run, func(migrator gorm.Migrator) error {
+		return migrator.RenameColumn(dst, oldName, field)
+	})
+}
+
+// CreateView creates a view
+func (m *trackingMigrator) CreateView(name string, option gorm.ViewOption) error {
+	return m.track(fmt.Sprintf("CreateView(%s)", name), nil, func(migrator gorm.Migrator) error {
+		return migrator.CreateView(name, option)
+	})
+}
+
+// DropView drops a view
+func (m *trackingMigrator) DropView(name string) error {
+	return m.track(fmt.Sprintf("DropView(%s)", name), nil, func(migrator gorm.Migrator) error {
+		return migrator.DropView(name)
+	})
+}
+
+// CreateConstraint creates a constraint of the model
+func (m *trackingMigrator) CreateConstraint(dst interface{}, name string) error {
+	return m.track(fmt.Sprintf("CreateConstraint(%s)", name), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.CreateConstraint(dst, name)
+	})
+}
+
+// DropConstraint drops a constraint of the model
+func (m *trackingMigrator) DropConstraint(dst interface{}, name string) error {
+	return m.track(fmt.Sprintf("DropConstraint(%s)", name), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.DropConstraint(dst, name)
+	})
+}
+
+// CreateIndex creates an index of the model
+func (m *trackingMigrator) CreateIndex(dst interface{}, name string) error {
+	return m.track(fmt.Sprintf("CreateIndex(%s)", name), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.CreateIndex(dst, name)
+	})
+}
+
+// DropIndex drops an index of the model
+func (m *trackingMigrator) DropIndex(dst interface{}, name string) error {
+	return m.track(fmt.Sprintf("DropIndex(%s)", name), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.DropIndex(dst, name)
+	})
+}
+
+// RenameIndex renames an index of the model
+func (m *trackingMigrator) RenameIndex(dst interface{}, oldName, newName string) error {
+	return m.track(fmt.Sprintf("RenameIndex(%s, %s)", oldName, newName), []interface{}{dst}, func(migrator gorm.Migrator) error {
+		return migrator.RenameIndex(dst, oldName, newName)
+	})
+}
+
+// tableOf returns the table of a model, or the given table name
+func tableOf(db *gorm.DB, model interface{}) string {
+	if name, ok := model.(string); ok {
+		return name
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return modelName(model)
+	}
+	return stmt.Table
+}
@@ -0,0 +1,69 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+// renamedUser calls the email of the users mail
+type renamedUser struct {
+	ID   uint
+	Name string
+	Mail string
+}
+
+func (renamedUser) TableName() string { return "users" }
+
+func TestMigratorTracking(t *testing.T) {
+	db, _ := openTestDB(t, WithMigratorTracking(), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := db.Migrator().AddColumn(&pluginUserWithEmail{}, "Email"); err != nil {
+		t.Fatalf("AddColumn: %v", err)
+	}
+	if err := db.Exec("INSERT INTO `users` (`name`, `email`) VALUES (?, ?)", "jane", "jane@example.com").Error; err != nil {
+		t.Fatalf("failed to insert a user: %v", err)
+	}
+	if err := db.Migrator().RenameColumn(&renamedUser{}, "email", "Mail"); err != nil {
+		t.Fatalf("RenameColumn: %v", err)
+	}
+
+	// AutoMigrate records its own calls to the Migrator once
+	history := mustHistory(t, db)
+	if len(history) != 3 {
+		t.Fatalf("recorded %d versions, want 3", len(history))
+	}
+	added := mustRecorded(t, db, "V2")
+	changes, err := added.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if changes.Operation != "AddColumn(Email)" || !strings.Contains(added.Statements, "ADD `email`") {
+		t.Errorf("V2 = %q %q, want the email column added", changes.Operation, added.Statements)
+	}
+	renamed := mustRecorded(t, db, "V3")
+	if !strings.Contains(renamed.DownStatements, "RENAME COLUMN `mail` TO `email`") {
+		t.Errorf("V3 down statements = %q, want the column renamed back", renamed.DownStatements)
+	}
+
+	// Rolling the rename back keeps the data of the column
+	if err := RollbackTo(db, "V2"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	var email string
+	if err := db.Raw("SELECT `email` FROM `users`").Scan(&email).Error; err != nil || email != "jane@example.com" {
+		t.Errorf("email after rolling back the rename = %q, %v, want jane@example.com", email, err)
+	}
+}
+
+func TestMigratorCallsUntracked(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	if err := db.Migrator().AddColumn(&pluginUserWithEmail{}, "Email"); err != nil {
+		t.Fatalf("AddColumn: %v", err)
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("recorded %d versions, want the Migrator call left untracked", len(history))
+	}
+}
@@ -0,0 +1,442 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Option configures an AutoMigratePlugin
+type Option func(*AutoMigratePlugin)
+
+// Clock provides the current time to the plugin
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now calls f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock is the default Clock backed by time.Now
+type systemClock struct{}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithLogger sets the logger used by the plugin, see NewStdLogger, NewSlogLogger,
+// NewZapLogger and NewLogrusLogger for adapters
+func WithLogger(logger Logger) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Logger = logger
+	}
+}
+
+// WithTableName sets the name of the table migration history is recorded in, a schema
+// qualified name such as "ops.migration_history" is accepted as well
+func WithTableName(name string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.TableName = name
+	}
+}
+
+// WithTableSchema places the history tables in the given database schema
+func WithTableSchema(schema string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.TableSchema = schema
+	}
+}
+
+// WithVersionGenerator sets the generator used to produce version strings
+func WithVersionGenerator(generator VersionGenerator) Option {
+	return func(p *AutoMigratePlugin) {
+		p.VersionGenerator = generator
+	}
+}
+
+// WithVersionTemplate generates versions from a template, see TemplateVersionGenerator. When
+// format doesn't parse, Initialize returns the error and db.Use fails.
+func WithVersionTemplate(format string) Option {
+	return func(p *AutoMigratePlugin) {
+		generator, err := NewTemplateVersionGenerator(format)
+		if err != nil {
+			p.optionErr = errors.Join(p.optionErr, err)
+			return
+		}
+		p.VersionGenerator = generator
+	}
+}
+
+// WithClock sets the clock used for start times, versions and AppliedAt
+func WithClock(clock Clock) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Clock = clock
+	}
+}
+
+// WithSkipNoop skips recording migrations that executed no DDL statements
+func WithSkipNoop(skip bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.SkipNoop = skip
+	}
+}
+
+// WithDriftCheck detects drift between the given models and the live database during
+// Initialize, recording a drift entry in the history when discrepancies are found
+func WithDriftCheck(models ...interface{}) Option {
+	return func(p *AutoMigratePlugin) {
+		p.DriftModels = models
+	}
+}
+
+// WithIgnoreModels excludes the given models from the change logs, drift detection and
+// snapshots, AutoMigrate still migrates them
+func WithIgnoreModels(models ...interface{}) Option {
+	return func(p *AutoMigratePlugin) {
+		p.IgnoreModels = append(p.IgnoreModels, models...)
+	}
+}
+
+// WithIgnoreTables excludes the named tables from the change logs, drift detection and
+// snapshots
+func WithIgnoreTables(tables ...string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.IgnoreTables = append(p.IgnoreTables, tables...)
+	}
+}
+
+// WithOnlyModels records the changes of the given models only, every other model and table
+// is ignored as with WithIgnoreModels
+func WithOnlyModels(models ...interface{}) Option {
+	return func(p *AutoMigratePlugin) {
+		p.OnlyModels = append(p.OnlyModels, models...)
+	}
+}
+
+// WithLocking coordinates AutoMigrate across instances using the default locker of the
+// connected dialect, see DefaultLocker
+func WithLocking(policy LockPolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Locker = dialectLocker{}
+		p.LockPolicy = policy
+	}
+}
+
+// WithLocker coordinates AutoMigrate across instances using the given locker
+func WithLocker(locker Locker, policy LockPolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Locker = locker
+		p.LockPolicy = policy
+	}
+}
+
+// WithMetrics reports measurements of every AutoMigrate run to the given recorder
+func WithMetrics(metrics MetricsRecorder) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Metrics = metrics
+	}
+}
+
+// WithTracer traces AutoMigrate runs with the given tracer
+func WithTracer(tracer Tracer) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Tracer = tracer
+	}
+}
+
+// WithSnapshots enables or disables storing a full schema snapshot with every version,
+// snapshots are enabled by default
+func WithSnapshots(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.SkipSnapshots = !enabled
+	}
+}
+
+// WithEntries records one SchemaVersionEntry per migrated model alongside every version
+func WithEntries(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.RecordEntries = enabled
+	}
+}
+
+// WithNotifiers informs the given notifiers of every recorded migration
+func WithNotifiers(notifiers ...Notifier) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Notifiers = append(p.Notifiers, notifiers...)
+	}
+}
+
+// WithAtomic applies the DDL of every migration and its SchemaVersion record together
+func WithAtomic(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Atomic = enabled
+	}
+}
+
+// WithRetention prunes old history records after every recorded migration
+func WithRetention(policy RetentionPolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Retention = policy
+	}
+}
+
+// WithSkipUnchanged skips recording migrations whose models are identical to the ones of
+// the current schema version and that executed no DDL statements
+func WithSkipUnchanged(skip bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.SkipUnchanged = skip
+	}
+}
+
+// WithDestructivePolicy sets what AutoMigrate does when it is about to apply destructive changes
+func WithDestructivePolicy(policy DestructivePolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.DestructivePolicy = policy
+	}
+}
+
+// WithTenantResolver sets how the tenant of a migration is determined when the context
+// doesn't name one, see CurrentSchemaTenant and TablePrefixTenant
+func WithTenantResolver(resolver TenantResolver) Option {
+	return func(p *AutoMigratePlugin) {
+		p.TenantResolver = resolver
+	}
+}
+
+// WithTenantTables keeps one history table per tenant, in the schema named after the tenant
+func WithTenantTables(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.TenantTables = enabled
+	}
+}
+
+// WithServerInspector sets how the database user and server version are queried, for
+// dialects DefaultServerInspector doesn't know
+func WithServerInspector(inspector ServerInspector) Option {
+	return func(p *AutoMigratePlugin) {
+		p.ServerInspector = inspector
+	}
+}
+
+// WithFlywayHistory mirrors every recorded version into a flyway_schema_history shaped table,
+// DefaultFlywayTable is used when table is empty
+func WithFlywayHistory(table string) Option {
+	return func(p *AutoMigratePlugin) {
+		if table == "" {
+			table = DefaultFlywayTable
+		}
+		p.FlywayTable = table
+	}
+}
+
+// WithDriftRepair repairs the drift detected on Initialize for the WithDriftCheck models
+func WithDriftRepair(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.AutoRepairDrift = enabled
+	}
+}
+
+// WithRequiredVersion makes Initialize fail with ErrSchemaTooOld when the database is older
+// than version, for applications whose migrations are run by a separate job
+func WithRequiredVersion(version string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.RequiredVersion = version
+	}
+}
+
+// WithApproval defers the DDL of AutoMigrate until its plan is approved with Approve
+func WithApproval(enabled bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.RequireApproval = enabled
+	}
+}
+
+// WithPolicy evaluates policy against the plan of every AutoMigrate before applying it
+func WithPolicy(policy Policy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Policy = policy
+	}
+}
+
+// WithPreflight adds checks run before AutoMigrate applies any DDL, such as
+// DefaultPreflightChecks()
+func WithPreflight(checks ...PreflightCheck) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Preflight = append(p.Preflight, checks...)
+	}
+}
+
+// WithObservers informs the given observers of the lifecycle of every migration
+func WithObservers(observers ...Observer) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Observers = append(p.Observers, observers...)
+	}
+}
+
+// WithErrorReporter reports every failed migration, such as to an error tracker
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(p *AutoMigratePlugin) {
+		p.ErrorReporter = reporter
+	}
+}
+
+// WithStatsD reports measurements of every AutoMigrate run to the StatsD server at addr
+func WithStatsD(addr, prefix string) Option {
+	return WithMetrics(NewStatsDRecorder(addr, prefix))
+}
+
+// WithInitiatedBy records actor as the initiator of migrations whose context doesn't name one
+func WithInitiatedBy(actor string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.InitiatedBy = actor
+	}
+}
+
+// WithSigningKey signs every recorded SchemaVersion with key, see VerifyHistory
+func WithSigningKey(key []byte) Option {
+	return func(p *AutoMigratePlugin) {
+		p.SigningKey = key
+	}
+}
+
+// WithEncryptor encrypts the changes, statements and snapshot of every recorded version
+func WithEncryptor(encryptor Encryptor) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Encryptor = encryptor
+	}
+}
+
+// WithCompression compresses the large changes, statements and snapshots of recorded versions
+func WithCompression(compressor Compressor) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Compressor = compressor
+	}
+}
+
+// WithLogLevel logs the messages at or above level only, e.g. LogLevelDebug to trace every call
+func WithLogLevel(level LogLevel) Option {
+	return func(p *AutoMigratePlugin) {
+		p.LogLevel = level
+	}
+}
+
+// WithQuiet only logs warnings and errors, or nothing at all when silent is set
+func WithQuiet(silent bool) Option {
+	return func(p *AutoMigratePlugin) {
+		p.LogLevel = LogLevelWarn
+		if silent {
+			p.LogLevel = LogLevelSilent
+		}
+	}
+}
+
+// WithHistoryConn stores the history on conn instead of the migrated database
+func WithHistoryConn(conn *gorm.DB) Option {
+	return func(p *AutoMigratePlugin) {
+		p.HistoryConn = conn
+	}
+}
+
+// WithHistoryStore keeps the history in store instead of the history table
+func WithHistoryStore(store HistoryStore) Option {
+	return func(p *AutoMigratePlugin) {
+		p.HistoryStore = store
+	}
+}
+
+// WithRenameDetection renames columns that look renamed instead of adding new ones, see
+// AutoMigratePlugin.DetectRenames
+func WithRenameDetection() Option {
+	return func(p *AutoMigratePlugin) {
+		p.DetectRenames = true
+	}
+}
+
+// WithViews registers views kept up to date by AutoMigrate, see MigrateViews
+func WithViews(views ...View) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Views = append(p.Views, views...)
+	}
+}
+
+// WithRoutines registers functions and triggers AutoMigrate applies when they change
+func WithRoutines(routines ...Routine) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Routines = append(p.Routines, routines...)
+	}
+}
+
+// WithMigratorTracking records explicit Migrator calls made outside of AutoMigrate, such as
+// db.Migrator().DropColumn(&User{}, "email"), as schema versions
+func WithMigratorTracking() Option {
+	return func(p *AutoMigratePlugin) {
+		p.TrackMigratorCalls = true
+	}
+}
+
+// WithRecordRetry retries the insertion of history records that failed with exponential
+// backoff, see RetryPolicy
+func WithRecordRetry(policy RetryPolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.RecordRetry = policy
+	}
+}
+
+// WithOutbox delivers the migration events to the notifiers through an outbox table, so
+// they reach them at least once even when they are down, see Outbox
+func WithOutbox() Option {
+	return func(p *AutoMigratePlugin) {
+		p.Outbox = true
+	}
+}
+
+// WithDuplicateVersions sets how a record whose version is already recorded is handled, see
+// DuplicateVersionPolicy
+func WithDuplicateVersions(policy DuplicateVersionPolicy) Option {
+	return func(p *AutoMigratePlugin) {
+		p.DuplicateVersions = policy
+	}
+}
+
+// WithAppVersion records version as the application version of every migration, see AppVersion
+func WithAppVersion(version string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.AppVersion = version
+	}
+}
+
+// WithCompatibleVersions declares the range of schema versions the application runs against,
+// an empty bound leaves its side open, see CheckCompatibility. It needs WithAppVersion.
+func WithCompatibleVersions(minVersion, maxVersion string) Option {
+	return func(p *AutoMigratePlugin) {
+		p.CompatibleVersions = VersionRange{Min: minVersion, Max: maxVersion}
+	}
+}
+
+// WithReadOnly leaves the tables and the recorded history untouched during Initialize, to
+// inspect a database without issuing DDL, see ReadOnly
+func WithReadOnly() Option {
+	return func(p *AutoMigratePlugin) {
+		p.ReadOnly = true
+	}
+}
+
+// WithProgress informs the given reporters of the progress of every AutoMigrate run, such as
+// a ProgressLogger
+func WithProgress(reporters ...ProgressReporter) Option {
+	return func(p *AutoMigratePlugin) {
+		p.Progress = append(p.Progress, reporters...)
+	}
+}
+
+// WithAuditFile appends every migration event as a JSON line to the file at path, see AuditFile
+func WithAuditFile(path string) Option {
+	return WithNotifiers(NewAuditFile(path))
+}
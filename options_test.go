@@ -0,0 +1,39 @@
+package gorm_migrate_tracker
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestOptions(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	generator := VersionGeneratorFunc(func(_ *gorm.DB, startTime time.Time) (string, error) {
+		return startTime.Format("v20060102"), nil
+	})
+	db, plugin := openTestDB(t, WithClock(ClockFunc(func() time.Time { return now })), WithVersionGenerator(generator))
+	if plugin.Logger == nil {
+		t.Error("the plugin was created without a logger")
+	}
+
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if recorded := mustRecorded(t, db, "v20240301"); !recorded.AppliedAt.Equal(now) {
+		t.Errorf("version applied at %s, want the time of the clock", recorded.AppliedAt)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	if err := db.Use(NewAutoMigratePlugin(WithQuiet(true), WithVersionTemplate("{{.Seq"))); err == nil {
+		t.Error("registering a plugin with an invalid version template succeeded")
+	}
+}
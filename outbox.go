@@ -0,0 +1,153 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultOutboxInterval is how often RunOutboxDispatcher delivers pending events by default
+const DefaultOutboxInterval = 30 * time.Second
+
+// MigrationOutboxEvent is a MigrationEvent waiting in the outbox to be delivered to the notifiers
+type MigrationOutboxEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Version   string    `gorm:"index" json:"version"`
+	Event     string    `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// outboxTableName returns the name of the outbox table
+func outboxTableName(db *gorm.DB) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&MigrationOutboxEvent{}); err != nil {
+		return ""
+	}
+	return stmt.Table
+}
+
+// outboxEnabled reports whether events go through the outbox, which needs the history table
+func (p *AutoMigratePlugin) outboxEnabled() bool {
+	return p.Outbox && p.HistoryStore == nil
+}
+
+// withOutbox writes the SchemaVersion of a run and, when the outbox is enabled, enqueues its
+// event in the same transaction
+func (p *AutoMigratePlugin) withOutbox(db *gorm.DB, schemaVersion *SchemaVersion, write func(*gorm.DB) error) error {
+	_, ok := runFromDB(db)
+	finished := schemaVersion.Status == StatusSuccess || schemaVersion.Status == StatusFailed
+	if !p.outboxEnabled() || !ok || !finished {
+		return write(db)
+	}
+
+	return p.historyConn(db).Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		event, err := json.Marshal(versionEvent(schemaVersion))
+		if err != nil {
+			return fmt.Errorf("failed to encode migration event: %w", err)
+		}
+		entry := MigrationOutboxEvent{Version: schemaVersion.Version, Event: string(event), CreatedAt: p.now()}
+		if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entry).Error; err != nil {
+			return fmt.Errorf("failed to enqueue migration event: %w", err)
+		}
+		p.Logger.Debug("Enqueued migration event %d of version %s", entry.ID, entry.Version)
+		return nil
+	})
+}
+
+// DispatchOutboxContext is like DispatchOutbox but cancels the queries and deliveries when ctx is done
+func DispatchOutboxContext(ctx context.Context, db *gorm.DB) (int, error) {
+	return DispatchOutbox(db.WithContext(ctx))
+}
+
+// DispatchOutbox delivers the events waiting in the outbox to the notifiers of the plugin,
+// oldest first, and returns how many were delivered. An event is removed once every notifier
+// accepted it, the first one that can't be delivered stops the dispatch and is attempted
+// again, with every notifier, on the next one. Events are delivered at least once.
+func DispatchOutbox(db *gorm.DB) (int, error) {
+	p, ok := pluginFrom(db)
+	if !ok {
+		return 0, ErrPluginNotRegistered
+	}
+	p.Logger.Debug("DispatchOutbox function called")
+	return p.dispatchOutbox(db)
+}
+
+// RunOutboxDispatcher dispatches the outbox every interval, DefaultOutboxInterval when zero,
+// until ctx is done. It's meant to run in its own goroutine next to the application.
+func RunOutboxDispatcher(ctx context.Context, db *gorm.DB, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultOutboxInterval
+	}
+	for {
+		if _, err := DispatchOutboxContext(ctx, db); err != nil {
+			if errors.Is(err, ErrPluginNotRegistered) {
+				return err
+			}
+			if ctx.Err() == nil {
+				loggerFrom(db).Warn("Failed to dispatch outbox: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// dispatchOutbox delivers the pending events of the outbox
+func (p *AutoMigratePlugin) dispatchOutbox(db *gorm.DB) (int, error) {
+	p.dispatching.Lock()
+	defer p.dispatching.Unlock()
+
+	outbox := p.historyConn(db.Session(&gorm.Session{NewDB: true}))
+	var pending []MigrationOutboxEvent
+	if err := outbox.Order("id").Limit(100).Find(&pending).Error; err != nil {
+		p.Logger.Error("Failed to retrieve outbox events: %v", err)
+		return 0, fmt.Errorf("failed to retrieve outbox events: %w", err)
+	}
+
+	delivered := 0
+	for _, entry := range pending {
+		var event MigrationEvent
+		if err := json.Unmarshal([]byte(entry.Event), &event); err != nil {
+			return delivered, fmt.Errorf("failed to decode outbox event %d: %w", entry.ID, err)
+		}
+
+		var errs []error
+		for _, notifier := range p.Notifiers {
+			p.Logger.Debug("Notifying %T of migration %s", notifier, event.Version)
+			if err := notifier.Notify(contextFrom(db), event); err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify %T: %w", notifier, err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			p.Logger.Warn("Failed to deliver migration %s, attempt %d: %v", event.Version, entry.Attempts+1, err)
+			updates := map[string]interface{}{"attempts": entry.Attempts + 1, "last_error": err.Error()}
+			if updateErr := outbox.Model(&entry).Updates(updates).Error; updateErr != nil {
+				p.Logger.Error("Failed to update outbox event %d: %v", entry.ID, updateErr)
+			}
+			return delivered, fmt.Errorf("failed to deliver migration %s: %w", event.Version, err)
+		}
+
+		if err := outbox.Delete(&entry).Error; err != nil {
+			p.Logger.Error("Failed to remove delivered outbox event %d: %v", entry.ID, err)
+			return delivered, fmt.Errorf("failed to remove delivered outbox event %d: %w", entry.ID, err)
+		}
+		delivered++
+	}
+	if delivered > 0 {
+		p.Logger.Info("Delivered %d migration events from the outbox", delivered)
+	}
+	return delivered, nil
+}
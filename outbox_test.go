@@ -0,0 +1,103 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// flakyNotifier fails while down and records the versions it was notified of otherwise
+type flakyNotifier struct {
+	mu       sync.Mutex
+	down     bool
+	versions []string
+}
+
+func (n *flakyNotifier) Notify(_ context.Context, event MigrationEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.down {
+		return errors.New("connection refused")
+	}
+	n.versions = append(n.versions, event.Version)
+	return nil
+}
+
+func (n *flakyNotifier) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down = down
+}
+
+func (n *flakyNotifier) notified() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.versions...)
+}
+
+// outboxEvents returns the events waiting in the outbox of db
+func outboxEvents(t *testing.T, db *gorm.DB) []MigrationOutboxEvent {
+	t.Helper()
+	var events []MigrationOutboxEvent
+	if err := db.Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("failed to read the outbox: %v", err)
+	}
+	return events
+}
+
+func TestOutbox(t *testing.T) {
+	notifier := &flakyNotifier{down: true}
+	db, _ := openTestDB(t, WithOutbox(), WithNotifiers(notifier))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	// The events wait in the outbox while the notifier is down
+	events := outboxEvents(t, db)
+	if len(events) != 2 || events[0].Version != "1" || events[0].Attempts == 0 || events[0].LastError == "" {
+		t.Fatalf("outbox = %+v, want both events with the failed attempts", events)
+	}
+
+	notifier.setDown(false)
+	delivered, err := DispatchOutbox(db)
+	if err != nil {
+		t.Fatalf("DispatchOutbox: %v", err)
+	}
+	if delivered != 2 || len(notifier.notified()) != 2 || notifier.notified()[0] != "1" {
+		t.Errorf("delivered %d events, notified of %v, want versions 1 and 2 in order", delivered, notifier.notified())
+	}
+	if events := outboxEvents(t, db); len(events) != 0 {
+		t.Errorf("outbox = %+v, want it emptied", events)
+	}
+
+	// Once the notifier is up, events are delivered with the migration
+	migrateAs(t, db, "3", &squashedOrder{})
+	if versions := notifier.notified(); len(versions) != 3 || versions[2] != "3" {
+		t.Errorf("notified of %v, want version 3 delivered right away", versions)
+	}
+}
+
+func TestRunOutboxDispatcher(t *testing.T) {
+	notifier := &flakyNotifier{down: true}
+	db, _ := openTestDB(t, WithOutbox(), WithNotifiers(notifier))
+	migrateAs(t, db, "1", &pluginUser{})
+	notifier.setDown(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunOutboxDispatcher(ctx, db, time.Millisecond) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(notifier.notified()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("RunOutboxDispatcher = %v, want context.Canceled", err)
+	}
+	if versions := notifier.notified(); len(versions) != 1 || versions[0] != "1" {
+		t.Errorf("notified of %v, want version 1 delivered by the dispatcher", versions)
+	}
+}
@@ -0,0 +1,236 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// ErrPluginNotRegistered is returned when an operation needs the plugin to be registered on the connection
+var ErrPluginNotRegistered = errors.New("AutoMigratePlugin is not registered on this connection")
+
+// Severity rates how risky a planned statement is
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityDanger  Severity = "danger"
+)
+
+// severityRank orders severities from least to most risky
+var severityRank = map[Severity]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityDanger:  2,
+}
+
+// maxSeverity returns the riskier of two severities
+func maxSeverity(a, b Severity) Severity {
+	if severityRank[b] > severityRank[a] {
+		return b
+	}
+	return a
+}
+
+// PlannedStatement is a single DDL statement AutoMigrate would execute
+type PlannedStatement struct {
+	SQL      string   `json:"sql"`
+	Table    string   `json:"table"`
+	Severity Severity `json:"severity"`
+}
+
+// Plan describes the changes AutoMigrate would apply for a set of models
+type Plan struct {
+	Statements []PlannedStatement `json:"statements"`
+	Tables     []string           `json:"tables"`
+	Severity   Severity           `json:"severity"`
+	// Destructive lists the planned column drops, type narrowing and index drops
+	Destructive []string `json:"destructive,omitempty"`
+	// Down holds the statements reverting the plan, as far as they can be derived
+	Down []string `json:"down,omitempty"`
+}
+
+// Empty reports whether the plan contains no statements
+func (p *Plan) Empty() bool {
+	return len(p.Statements) == 0
+}
+
+// PlanContext is like Plan but runs the introspection queries with ctx
+func (p *AutoMigratePlugin) PlanContext(ctx context.Context, db *gorm.DB, models ...interface{}) (*Plan, error) {
+	return p.Plan(db.WithContext(ctx), models...)
+}
+
+// Plan computes the statements AutoMigrate would execute for the given models without
+// applying them or recording a SchemaVersion. Unlike a plain GORM DryRun session, the
+// introspection queries AutoMigrate relies on still reach the database, only statements
+// sent through Exec are intercepted.
+func (p *AutoMigratePlugin) Plan(db *gorm.DB, models ...interface{}) (*Plan, error) {
+	p.Logger.Debug("Plan method called")
+	if _, ok := pluginFrom(db); !ok {
+		return nil, ErrPluginNotRegistered
+	}
+
+	run := &migrationRun{startTime: p.now(), models: models}
+	tx := db.Session(&gorm.Session{Context: context.WithValue(contextFrom(db), runContextKey{}, run)})
+	tx.Statement.ConnPool = &planConnPool{ConnPool: tx.Statement.ConnPool}
+
+	var diffs []ColumnDiff
+	before := p.inspectModels(tx, models)
+	targets := make([]*TableSchema, len(models))
+	renames := make([][]columnRename, len(models))
+	for i, live := range before {
+		if live == nil {
+			continue
+		}
+		target, err := modelTableSchema(tx, models[i])
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = target
+		renames[i] = p.detectRenames(tx.Migrator(), live, target)
+		if live.Exists {
+			diffs = append(diffs, diffTables(tx.Migrator(), live, target)...)
+		}
+	}
+
+	enums := p.diffModelEnums(tx, models)
+	for i, model := range models {
+		if i < len(enums) {
+			if err := p.migrateEnums(tx, enums[i]); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.renameColumns(tx.Migrator(), model, renames[i]); err != nil {
+			return nil, err
+		}
+	}
+	renaming := len(run.statements)
+	if err := tx.Migrator().AutoMigrate(models...); err != nil {
+		p.Logger.Error("Failed to plan AutoMigrate: %v", err)
+		return nil, err
+	}
+	// The renamed columns weren't really renamed, drop the statements adding them again
+	planned := run.statements[:renaming]
+	for _, statement := range run.statements[renaming:] {
+		if !addsRenamedColumn(statement, targets, renames) {
+			planned = append(planned, statement)
+		}
+	}
+	run.statements = planned
+	if err := p.applyRoutines(tx, run); err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(run.statements, diffs)
+	if !plan.Empty() {
+		plan.Down = slices.Concat(run.down, generateDownStatements(tx, before, targets))
+	}
+
+	p.Logger.Info("Planned %d statements affecting %d tables", len(plan.Statements), len(plan.Tables))
+	return plan, nil
+}
+
+// newPlan builds the plan of the captured statements, diffs are the column diffs they apply
+func newPlan(statements []string, diffs []ColumnDiff) *Plan {
+	plan := &Plan{Severity: SeverityInfo}
+	seen := map[string]bool{}
+	for i, statement := range statements {
+		planned := PlannedStatement{
+			SQL:      statement,
+			Table:    statementTable(statement),
+			Severity: classifyStatementAt(statements, i),
+		}
+		plan.Statements = append(plan.Statements, planned)
+		if planned.Table != "" && !seen[planned.Table] {
+			seen[planned.Table] = true
+			plan.Tables = append(plan.Tables, planned.Table)
+		}
+		plan.Severity = maxSeverity(plan.Severity, planned.Severity)
+	}
+	plan.Destructive = destructiveChanges(statements, diffs)
+	if len(plan.Destructive) > 0 {
+		plan.Severity = SeverityDanger
+	}
+	return plan
+}
+
+// checkPlan plans AutoMigrate for the models when the pre-flight checks, the destructive
+// change policy, the policy, approvals, observers or the error reporter need it, and runs
+// the checks on the plan. It returns the plan, nil when none was needed, and the approved
+// record of the plan when RequireApproval is set.
+func (p *AutoMigratePlugin) checkPlan(db *gorm.DB, models []interface{}) (*Plan, *SchemaVersion, error) {
+	if !p.checksPlans() {
+		return nil, nil, nil
+	}
+
+	plan, err := p.Plan(db, models...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to plan AutoMigrate: %w", err)
+	}
+	return p.checkPlanned(db, plan, models)
+}
+
+// checksPlans reports whether migrations need to be planned before they are applied
+func (p *AutoMigratePlugin) checksPlans() bool {
+	return p.DestructivePolicy != DestructiveAllow || p.Policy != nil || p.RequireApproval || len(p.Preflight) > 0 || len(p.Observers) > 0 || p.ErrorReporter != nil
+}
+
+// checkPlanned runs the pre-flight checks, the destructive change policy, the policy and the
+// approval workflow on the plan of the models, returning the approved record of the plan
+func (p *AutoMigratePlugin) checkPlanned(db *gorm.DB, plan *Plan, models []interface{}) (*Plan, *SchemaVersion, error) {
+	p.observePlanned(db, plan)
+	if len(p.Preflight) > 0 && !plan.Empty() {
+		if _, err := p.runPreflight(db, plan); err != nil {
+			return plan, nil, err
+		}
+	}
+	if err := p.checkDestructive(db, plan); err != nil {
+		return plan, nil, err
+	}
+	if p.Policy != nil {
+		if err := p.checkPolicy(db, plan, models); err != nil {
+			return plan, nil, err
+		}
+	}
+	if p.RequireApproval {
+		approved, err := p.checkApproval(db, plan, models)
+		return plan, approved, err
+	}
+	return plan, nil, nil
+}
+
+// planConnPool passes queries through to the database but swallows every Exec
+type planConnPool struct {
+	gorm.ConnPool
+}
+
+// ExecContext pretends to execute the statement
+func (c *planConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+// BeginTx starts a pretend transaction so migrators relying on transactions can be planned
+func (c *planConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &planTx{planConnPool: c}, nil
+}
+
+// planTx is the pretend transaction of a planConnPool
+type planTx struct {
+	*planConnPool
+}
+
+// Commit does nothing as nothing was executed
+func (t *planTx) Commit() error {
+	return nil
+}
+
+// Rollback does nothing as nothing was executed
+func (t *planTx) Rollback() error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestPlan(t *testing.T) {
+	db, plugin := openTestDB(t)
+	plan, err := plugin.Plan(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Statements) != 1 || !strings.HasPrefix(plan.Statements[0].SQL, "CREATE TABLE `users`") {
+		t.Fatalf("planned statements = %v, want the users table created", plan.Statements)
+	}
+	if !slices.Equal(plan.Tables, []string{"users"}) || plan.Severity != SeverityInfo {
+		t.Errorf("plan = %+v, want an info plan of the users table", plan)
+	}
+	if !slices.Equal(plan.Down, []string{"DROP TABLE `users`"}) {
+		t.Errorf("down statements = %v, want the users table dropped", plan.Down)
+	}
+
+	// Planning neither migrates nor records anything
+	if db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("Plan created the users table")
+	}
+	if _, err := GetCurrentVersion(db); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("GetCurrentVersion = %v, want nothing recorded", err)
+	}
+
+	migrateAs(t, db, "1", &pluginUser{})
+	if plan, err := plugin.Plan(db, &pluginUser{}); err != nil || !plan.Empty() {
+		t.Errorf("Plan of a migrated model = %+v, %v, want an empty plan", plan, err)
+	}
+	plan, err = plugin.Plan(db, &pluginUserWithEmail{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Statements) != 1 || !strings.Contains(plan.Statements[0].SQL, "ADD `email`") || plan.Statements[0].Table != "users" {
+		t.Errorf("planned statements = %v, want the email column added to users", plan.Statements)
+	}
+	if db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("Plan added the email column")
+	}
+}
+
+func TestPlanNotRegistered(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	if _, err := NewAutoMigratePlugin().Plan(db, &pluginUser{}); !errors.Is(err, ErrPluginNotRegistered) {
+		t.Errorf("Plan on an unregistered connection = %v, want ErrPluginNotRegistered", err)
+	}
+}
+
+func TestClassifyStatement(t *testing.T) {
+	for sql, want := range map[string]Severity{
+		"CREATE TABLE `users` (`id` integer)":                 SeverityInfo,
+		"ALTER TABLE `users` ADD `email` text":                SeverityInfo,
+		"ALTER TABLE `users` RENAME COLUMN `name` TO `title`": SeverityWarning,
+		"ALTER TABLE users ALTER COLUMN name DROP NOT NULL":   SeverityWarning,
+		"DROP TRIGGER IF EXISTS users_trim_name":              SeverityWarning,
+		"ALTER TABLE `users` DROP COLUMN `email`":             SeverityDanger,
+		"DROP TABLE `users`":                                  SeverityDanger,
+	} {
+		if severity := classifyStatement(sql); severity != want {
+			t.Errorf("classifyStatement(%q) = %s, want %s", sql, severity, want)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package gorm_migrate_tracker
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,144 +15,1012 @@ import (
 
 // SchemaVersion represents a version of the database schema
 type SchemaVersion struct {
-	ID        uint      `gorm:"primaryKey"`
-	Version   string    `gorm:"uniqueIndex"`
-	AppliedAt time.Time
-	Changes   string
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Version        string    `gorm:"uniqueIndex" json:"version"`
+	Kind           string    `gorm:"default:migration" json:"kind"`
+	Status         string    `gorm:"default:success;index" json:"status"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	AppliedAt      time.Time `json:"applied_at"`
+	Changes        string    `json:"changes"`
+	Statements     string    `json:"statements"`
+	DownStatements string    `json:"down_statements"`
+	Snapshot       string    `json:"snapshot,omitempty"`
+	Checksum       string    `gorm:"index" json:"checksum,omitempty"`
+	Severity       Severity  `gorm:"index" json:"severity,omitempty"`
+	Tenant         string    `gorm:"index" json:"tenant,omitempty"`
+	// InitiatedBy identifies who triggered the migration, see ContextWithInitiatedBy
+	InitiatedBy string `gorm:"index" json:"initiated_by,omitempty"`
+	// Environment describes the host and binary that ran the migration
+	Environment Environment `gorm:"embedded;embeddedPrefix:env_" json:"environment"`
+	// Server identifies the database server and user the migration ran against
+	Server ServerInfo `gorm:"embedded;embeddedPrefix:server_" json:"server"`
+	// Labels are attached with ContextWithLabels or TagVersion
+	Labels Labels `json:"labels,omitempty"`
+	// Signature is the HMAC of the record when a SigningKey is configured, see VerifyHistory
+	Signature string `json:"signature,omitempty"`
+}
+
+// Kinds of recorded schema versions
+const (
+	KindMigration = "migration"
+	KindDrift     = "drift"
+	KindBaseline  = "baseline"
+	// KindDriftRepair marks an AutoMigrate run by RepairDrift to bring drifted objects back
+	// in line with the models
+	KindDriftRepair = "drift-repair"
+)
+
+// appliedKinds are the kinds of records describing a schema the database was brought to
+var appliedKinds = []string{KindMigration, KindBaseline, KindDriftRepair}
+
+// Statuses of recorded schema versions
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	// StatusAwaitingApproval marks planned changes recorded by RequireApproval, they are
+	// applied once approved with Approve
+	StatusAwaitingApproval = "awaiting_approval"
+	// StatusApproved marks planned changes the next AutoMigrate applies
+	StatusApproved = "approved"
+	// StatusRolledBack marks applied changes RollbackTo reverted, they are kept for the audit
+	// trail but no longer describe the schema
+	StatusRolledBack = "rolled_back"
+)
+
+// Applied reports whether the record describes a schema the database was brought to, a
+// successful migration, baseline or drift repair
+func (v SchemaVersion) Applied() bool {
+	return v.Status == StatusSuccess && slices.Contains(appliedKinds, v.Kind)
+}
+
+// runContextKey is the context key under which the state of an in-flight AutoMigrate is stored
+type runContextKey struct{}
+
+// migrationRun holds the state of a single AutoMigrate invocation
+type migrationRun struct {
+	mu          sync.Mutex
+	startTime   time.Time
+	models      []interface{}
+	before      []*TableSchema
+	statements  []string
+	version     string
+	historyRows int64
+	durations   []time.Duration
+	current     int
+	byModel     map[int][]string
+	recorded    *SchemaVersion
+	pending     *SchemaVersion
+	plan        *Plan
+	// renames holds the columns renamed before migrating the model at the same index
+	renames [][]columnRename
+	// enums holds the enum type changes applied before migrating the model at the same index
+	enums [][]EnumDiff
+	// routines holds the routines applied once the models were migrated
+	routines []RoutineChange
+	// operation is the explicit Migrator call a run records, empty for AutoMigrate
+	operation string
+	// down holds the statements reverting the routines and the operation, they precede the
+	// down statements derived from the tables
+	down []string
+	// changes is the change set of the run once the models were migrated, for the version
+	// generators deriving the version from it
+	changes *ChangeSet
+	// repairing is the drift report a RepairDrift run corrects, nil for a plain AutoMigrate
+	repairing *DriftReport
+	// waitedForLock is set when the run waited for the migration lock, another instance may
+	// have applied the same models meanwhile and a no-op run then isn't recorded again
+	waitedForLock bool
+}
+
+// beginModel attributes statements captured from now on to the model at index i
+func (r *migrationRun) beginModel(i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = i
+}
+
+// addDuration records how long the migration of the next model took
+func (r *migrationRun) addDuration(duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations = append(r.durations, duration)
+}
+
+// addStatement appends an executed DDL statement to the run
+func (r *migrationRun) addStatement(sql string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, sql)
+	if r.byModel == nil {
+		r.byModel = map[int][]string{}
+	}
+	r.byModel[r.current] = append(r.byModel[r.current], sql)
+}
+
+// runFromDB returns the migration run attached to the statement context, if any
+func runFromDB(db *gorm.DB) (*migrationRun, bool) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return nil, false
+	}
+	run, ok := db.Statement.Context.Value(runContextKey{}).(*migrationRun)
+	return run, ok
 }
 
 // AutoMigratePlugin is a GORM plugin for tracking AutoMigrate changes
 type AutoMigratePlugin struct {
-	Logger *log.Logger
+	Logger           Logger
+	VersionGenerator VersionGenerator
+	Clock            Clock
+	// LogLevel is the minimum level of the logged messages, LogLevelInfo by default so debug
+	// tracing has to be enabled with LogLevelDebug
+	LogLevel LogLevel
+	// TableName overrides the history table name, the naming strategy is used when empty
+	TableName string
+	// TableSchema places the history tables in a database schema, e.g. "ops"
+	TableSchema string
+	// SkipNoop skips recording migrations that executed no DDL statements
+	SkipNoop bool
+	// SkipUnchanged skips recording migrations that executed no DDL statements when the
+	// models' checksum matches the one of the current schema version
+	SkipUnchanged bool
+	// RecordEntries records one SchemaVersionEntry per migrated model alongside every version
+	RecordEntries bool
+	// SkipSnapshots disables storing a full schema snapshot with every version
+	SkipSnapshots bool
+	// Locker coordinates AutoMigrate across instances, no locking is done when nil
+	Locker Locker
+	// LockPolicy decides whether to wait for or skip a migration locked by another instance
+	LockPolicy LockPolicy
+	// Metrics receives measurements of every AutoMigrate run
+	Metrics MetricsRecorder
+	// Tracer starts spans around AutoMigrate runs and the models they migrate
+	Tracer Tracer
+	// Retention prunes old history records after every recorded migration
+	Retention RetentionPolicy
+	// DestructivePolicy decides whether destructive changes are applied, checked by planning
+	// every AutoMigrate before it runs
+	DestructivePolicy DestructivePolicy
+	// Atomic applies the DDL and its SchemaVersion record together: in one transaction on
+	// dialects with transactional DDL, otherwise by recording a pending version up front
+	Atomic bool
+	// Notifiers are informed of every recorded migration
+	Notifiers []Notifier
+	// Observers are informed of the plan, outcome and records of every migration
+	Observers []Observer
+	// ErrorReporter receives the details of every failed migration or failure to record one
+	ErrorReporter ErrorReporter
+	// AutoRepairDrift repairs the drift detected on Initialize for DriftModels, recorded as a
+	// drift-repair version instead of a drift one
+	AutoRepairDrift bool
+	// Preflight checks run before AutoMigrate applies any DDL, it fails with a
+	// *PreflightError reporting every check when one fails
+	Preflight []PreflightCheck
+	// Policy is evaluated against the plan of every AutoMigrate, which fails with
+	// ErrPolicyDenied when the policy rejects it
+	Policy Policy
+	// RequireApproval defers the DDL of AutoMigrate until its plan is approved with Approve,
+	// AutoMigrate records the plan and returns ErrApprovalRequired until then
+	RequireApproval bool
+	// RequiredVersion is the oldest schema version the application runs against, Initialize
+	// fails with ErrSchemaTooOld when the database is older
+	RequiredVersion string
+	// FlywayTable mirrors every recorded version into a Flyway shaped history table of this
+	// name, for Flyway reporting tools, no table is written when empty
+	FlywayTable string
+	// ServerInspector queries the database user and server version recorded with every version,
+	// DefaultServerInspector is used when nil
+	ServerInspector ServerInspector
+	// Encryptor encrypts the changes, statements and snapshot of every recorded version,
+	// which are decrypted when the history is read. QueryHistory can't match encrypted
+	// changes by model.
+	Encryptor Encryptor
+	// Compressor compresses the changes, statements and snapshot of every recorded version
+	// from CompressionThreshold bytes, they are decompressed when the history is read.
+	// QueryHistory can't match compressed changes by model.
+	Compressor Compressor
+	// SigningKey signs every recorded SchemaVersion with HMAC-SHA256, so VerifyHistory can
+	// detect modified records, nothing is signed when empty
+	SigningKey []byte
+	// InitiatedBy is recorded as the actor of migrations whose context doesn't name one
+	InitiatedBy string
+	// TenantResolver determines the tenant a migration runs against when the context doesn't
+	// name one, see ContextWithTenant
+	TenantResolver TenantResolver
+	// TenantTables keeps one history table per tenant, in the schema named after the tenant.
+	// It needs a dialect resolving schema qualified tables, such as Postgres or MySQL.
+	TenantTables bool
+	// HistoryConn stores the history on a separate database instead of the migrated one,
+	// such as an in-memory SQLite database for tests or for read-only replicas. Atomic
+	// migrations can't record their version in the migration transaction then.
+	HistoryConn *gorm.DB
+	// HistoryStore keeps the history outside of the database instead of the history table,
+	// see DocumentStore
+	HistoryStore HistoryStore
+	// DriftModels are checked for drift against the live database during Initialize
+	DriftModels []interface{}
+	// IgnoreModels are migrated by AutoMigrate without being recorded, and their tables are
+	// left out of drift detection and snapshots, like the tables of job queues or of other
+	// plugins
+	IgnoreModels []interface{}
+	// IgnoreTables are the names of tables ignored like the ones of IgnoreModels
+	IgnoreTables []string
+	// OnlyModels restricts tracking to the given models when set, the tables of every other
+	// model are ignored like the ones of IgnoreModels. It suits databases shared by services
+	// each owning their own tables.
+	OnlyModels []interface{}
+	// TrackMigratorCalls records explicit Migrator calls such as AddColumn, DropTable or
+	// CreateIndex made outside of AutoMigrate as schema versions too
+	TrackMigratorCalls bool
+	// DetectRenames pairs a column a model no longer declares with a new column of the same
+	// type and a similar name, and renames it instead of adding the new column. Columns
+	// tagged with renamedFrom, as in `gorm:"renamedFrom:old_name"`, are always renamed.
+	DetectRenames bool
+	// Views are created or replaced when their definition changes, once AutoMigrate migrated
+	// the models, see MigrateViews
+	Views []View
+	// Routines are applied within AutoMigrate once the models are migrated, whenever their
+	// statements changed since they were last recorded
+	Routines []Routine
+
+	// RecordRetry retries the insertion of history records that failed, a record that still
+	// can't be inserted is appended to the AuditFile notifiers instead
+	RecordRetry RetryPolicy
+
+	// Outbox enqueues the events of the recorded migrations in an outbox table, in the
+	// transaction recording their SchemaVersion, instead of notifying the notifiers right
+	// away. They are delivered at least once by DispatchOutbox, which AutoMigrate calls once
+	// done and RunOutboxDispatcher calls periodically. It needs the history table.
+	Outbox bool
+
+	// AppVersion is the version of the application recorded with every migration, instead of
+	// the version of its main module found in the build info
+	AppVersion string
+	// CompatibleVersions is the range of schema versions AppVersion runs against, Initialize
+	// declares it for CheckCompatibility
+	CompatibleVersions VersionRange
+
+	// DuplicateVersions decides how a record whose version is already recorded is handled,
+	// it's regenerated by default
+	DuplicateVersions DuplicateVersionPolicy
+
+	// ReadOnly registers the plugin without creating its tables, upgrading the recorded
+	// history or declaring compatible versions during Initialize, for tools that only read
+	// the history. Writes made afterwards, such as by AutoMigrate, aren't prevented.
+	ReadOnly bool
+
+	// Progress are informed of every model an AutoMigrate run migrates and of every
+	// statement it executes
+	Progress []ProgressReporter
+
+	// migrating serializes the tracked runs of the goroutines sharing the plugin, so a run
+	// doesn't diff or record the changes of another
+	migrating sync.Mutex
+	// dispatching serializes the deliveries of the outbox
+	dispatching sync.Mutex
+	// tenantTables caches the tenant history tables known to exist
+	tenantTables sync.Map
+	// optionErr holds the errors of the options that failed to apply, Initialize returns it
+	optionErr error
 }
 
-// NewAutoMigratePlugin creates a new instance of AutoMigratePlugin with a default logger
-func NewAutoMigratePlugin() *AutoMigratePlugin {
-	return &AutoMigratePlugin{
-		Logger: log.New(os.Stdout, "[AutoMigratePlugin] ", log.LstdFlags),
+// pluginName is the name the plugin is registered under
+const pluginName = "AutoMigratePlugin"
+
+// NewAutoMigratePlugin creates a new instance of AutoMigratePlugin with a default logger,
+// applying the given options on top of the defaults
+func NewAutoMigratePlugin(opts ...Option) *AutoMigratePlugin {
+	p := &AutoMigratePlugin{
+		Logger:           defaultLogger(),
+		VersionGenerator: TimestampVersionGenerator{Layout: DefaultVersionLayout},
+		Clock:            systemClock{},
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.Logger = NewLeveledLogger(p.Logger, p.LogLevel)
+	return p
 }
 
 // Name returns the name of the plugin
 func (p *AutoMigratePlugin) Name() string {
-	p.Logger.Println("Name method called")
-	return "AutoMigratePlugin"
+	p.Logger.Debug("Name method called")
+	return pluginName
+}
+
+// pluginFrom returns the AutoMigratePlugin registered on db, if any
+func pluginFrom(db *gorm.DB) (*AutoMigratePlugin, bool) {
+	if db == nil || db.Config == nil {
+		return nil, false
+	}
+	if p, ok := db.Config.Plugins[pluginName].(*AutoMigratePlugin); ok {
+		return p, true
+	}
+	// Statements on a HistoryConn carry the plugin in their context
+	if db.Statement != nil && db.Statement.Context != nil {
+		p, ok := db.Statement.Context.Value(pluginContextKey{}).(*AutoMigratePlugin)
+		return p, ok
+	}
+	return nil, false
+}
+
+// now returns the current time according to the configured clock
+func (p *AutoMigratePlugin) now() time.Time {
+	if p.Clock == nil {
+		return time.Now()
+	}
+	return p.Clock.Now()
+}
+
+// pluginContextKey is the context key under which the plugin is stored on its HistoryConn,
+// which it isn't registered on
+type pluginContextKey struct{}
+
+// historyConn returns the connection the history of db is stored on, HistoryConn when
+// configured, carrying the context of db
+func (p *AutoMigratePlugin) historyConn(db *gorm.DB) *gorm.DB {
+	if p.HistoryConn == nil {
+		return db
+	}
+	// db already is on HistoryConn, possibly in a transaction
+	if db.Statement != nil && db.Statement.Context != nil && db.Statement.Context.Value(pluginContextKey{}) == p {
+		return db
+	}
+	return p.HistoryConn.WithContext(context.WithValue(contextFrom(db), pluginContextKey{}, p))
+}
+
+// historyConn returns the connection the history of the plugin registered on db is stored on
+func historyConn(db *gorm.DB) *gorm.DB {
+	if p, ok := pluginFrom(db); ok {
+		return p.historyConn(db)
+	}
+	return db
+}
+
+// historyDB scopes db to the configured history table
+func (p *AutoMigratePlugin) historyDB(db *gorm.DB) *gorm.DB {
+	conn := p.historyConn(db)
+	if p.TableName == "" && p.TableSchema == "" && !p.TenantTables {
+		return conn
+	}
+	return conn.Table(p.historyTableName(db))
+}
+
+// historyDB scopes db to the history table of the plugin registered on db
+func historyDB(db *gorm.DB) *gorm.DB {
+	if p, ok := pluginFrom(db); ok {
+		return p.historyDB(db)
+	}
+	return db
 }
 
 // Initialize implements the GORM plugin interface
 func (p *AutoMigratePlugin) Initialize(db *gorm.DB) error {
-	p.Logger.Println("Initialize method called")
+	if p.Logger == nil {
+		p.Logger = defaultLogger()
+	}
+	p.Logger = NewLeveledLogger(p.Logger, p.LogLevel)
+	p.Logger.Debug("Initialize method called")
 
-	// Ensure the schema version table exists
-	p.Logger.Println("Attempting to create SchemaVersion table")
-	err := db.AutoMigrate(&SchemaVersion{})
-	if err != nil {
-		p.Logger.Printf("Failed to create schema version table: %v", err)
-		return fmt.Errorf("failed to create schema version table: %w", err)
+	if p.optionErr != nil {
+		p.Logger.Error("Invalid plugin options: %v", p.optionErr)
+		return fmt.Errorf("invalid plugin options: %w", p.optionErr)
 	}
-	p.Logger.Println("SchemaVersion table created or already exists")
 
-	// Register callbacks
-	p.Logger.Println("Registering before_auto_migrate callback")
-	err = db.Callback().Migrator().Register("automigrate_plugin:before_auto_migrate", p.beforeAutoMigrate)
-	if err != nil {
-		p.Logger.Printf("Failed to register before_auto_migrate callback: %v", err)
-		return fmt.Errorf("failed to register before_auto_migrate callback: %w", err)
+	if p.HistoryStore != nil {
+		p.Logger.Debug("Attempting to initialize history store")
+		if err := p.HistoryStore.Init(contextFrom(db)); err != nil {
+			p.Logger.Error("Failed to initialize history store: %v", err)
+			return fmt.Errorf("failed to initialize history store: %w", err)
+		}
+		p.Logger.Info("History store initialized")
+	}
+
+	if p.ReadOnly {
+		p.Logger.Debug("Read-only, leaving the plugin tables and the recorded history untouched")
+	} else if err := p.prepareTables(db); err != nil {
+		return err
 	}
 
-	p.Logger.Println("Registering after_auto_migrate callback")
-	err = db.Callback().Migrator().Register("automigrate_plugin:after_auto_migrate", p.afterAutoMigrate)
+	// Register the statement capture callback
+	p.Logger.Debug("Registering capture_statement callback")
+	err := db.Callback().Raw().After("gorm:raw").Register("automigrate_plugin:capture_statement", p.captureStatement)
 	if err != nil {
-		p.Logger.Printf("Failed to register after_auto_migrate callback: %v", err)
-		return fmt.Errorf("failed to register after_auto_migrate callback: %w", err)
+		p.Logger.Error("Failed to register capture_statement callback: %v", err)
+		return fmt.Errorf("failed to register capture_statement callback: %w", err)
+	}
+
+	// Wrap the dialector so AutoMigrate calls are routed through the plugin
+	p.Logger.Debug("Wrapping dialector to track AutoMigrate")
+	if _, ok := db.Dialector.(*trackingDialector); !ok {
+		db.Dialector = &trackingDialector{Dialector: db.Dialector, plugin: p}
+	}
+
+	if len(p.DriftModels) > 0 {
+		if err := p.checkDrift(db); err != nil {
+			return err
+		}
+	}
+
+	if p.RequiredVersion != "" {
+		if err := p.checkRequiredVersion(db); err != nil {
+			return err
+		}
+	}
+
+	p.Logger.Info("Initialize method completed successfully")
+	return nil
+}
+
+// prepareTables creates the tables of the plugin, upgrades the history recorded by earlier
+// releases and declares the compatible schema versions
+func (p *AutoMigratePlugin) prepareTables(db *gorm.DB) error {
+	if p.HistoryStore == nil {
+		// Ensure the schema version table exists
+		p.Logger.Debug("Attempting to create SchemaVersion table")
+		if err := untrackedMigrator(p.historyDB(db)).AutoMigrate(&SchemaVersion{}); err != nil {
+			p.Logger.Error("Failed to create schema version table: %v", err)
+			return fmt.Errorf("failed to create schema version table: %w", err)
+		}
+		p.Logger.Info("SchemaVersion table created or already exists")
+
+		// Convert change logs recorded before changes were stored as JSON
+		if _, err := upgradeChangesFormat(p.historyConn(db), p.historyTableName(db), p.Logger); err != nil {
+			return err
+		}
+	}
+
+	if p.RecordEntries {
+		p.Logger.Debug("Attempting to create SchemaVersionEntry table")
+		if err := untrackedMigrator(p.entriesDB(db)).AutoMigrate(&SchemaVersionEntry{}); err != nil {
+			p.Logger.Error("Failed to create schema version entry table: %v", err)
+			return fmt.Errorf("failed to create schema version entry table: %w", err)
+		}
+	}
+
+	if p.outboxEnabled() {
+		p.Logger.Debug("Attempting to create outbox table")
+		if err := untrackedMigrator(p.historyConn(db)).AutoMigrate(&MigrationOutboxEvent{}); err != nil {
+			p.Logger.Error("Failed to create outbox table: %v", err)
+			return fmt.Errorf("failed to create outbox table: %w", err)
+		}
 	}
 
-	p.Logger.Println("Initialize method completed successfully")
+	if p.AppVersion != "" && !p.CompatibleVersions.Empty() {
+		if err := p.declareCompatibility(db); err != nil {
+			return err
+		}
+	}
+
+	if p.FlywayTable != "" {
+		p.Logger.Debug("Attempting to create Flyway history table")
+		if err := untrackedMigrator(p.historyConn(db).Table(p.FlywayTable)).AutoMigrate(&FlywayHistory{}); err != nil {
+			p.Logger.Error("Failed to create Flyway history table: %v", err)
+			return fmt.Errorf("failed to create Flyway history table: %w", err)
+		}
+	}
 	return nil
 }
 
-// beforeAutoMigrate is called before AutoMigrate
-func (p *AutoMigratePlugin) beforeAutoMigrate(db *gorm.DB) {
-	p.Logger.Println("beforeAutoMigrate callback triggered")
-	startTime := time.Now()
-	db.InstanceSet("automigrate_plugin:start_time", startTime)
-	p.Logger.Printf("Set start time: %v", startTime)
+// beforeAutoMigrate is called before AutoMigrate and returns a session carrying the run state
+func (p *AutoMigratePlugin) beforeAutoMigrate(db *gorm.DB, models []interface{}) *gorm.DB {
+	p.Logger.Debug("beforeAutoMigrate callback triggered")
+	run := &migrationRun{
+		startTime: p.now(),
+		models:    models,
+	}
+	p.Logger.Debug("Set start time: %v", run.startTime)
+
+	// Snapshot the live tables so they can be diffed once AutoMigrate completes
+	run.before = p.inspectModels(db, models)
+	run.renames = make([][]columnRename, len(models))
+	for i, model := range models {
+		if target, err := modelTableSchema(db, model); err == nil {
+			run.renames[i] = p.detectRenames(db.Migrator(), run.before[i], target)
+		}
+	}
+	run.enums = p.diffModelEnums(db, models)
+	run.repairing, _ = contextFrom(db).Value(driftRepairContextKey{}).(*DriftReport)
+
+	return db.WithContext(context.WithValue(contextFrom(db), runContextKey{}, run))
+}
+
+// contextFrom returns the context of the statement in flight on db
+func contextFrom(db *gorm.DB) context.Context {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return context.Background()
+	}
+	return db.Statement.Context
+}
+
+// captureStatement records DDL executed through db.Exec while an AutoMigrate is in flight
+func (p *AutoMigratePlugin) captureStatement(db *gorm.DB) {
+	run, ok := runFromDB(db)
+	if !ok || db.Error != nil {
+		return
+	}
+
+	sql := strings.TrimSpace(db.Statement.SQL.String())
+	if !isDDL(sql) {
+		return
+	}
+
+	statement := db.Dialector.Explain(sql, db.Statement.Vars...)
+	p.Logger.Debug("Captured statement: %s", statement)
+	run.addStatement(statement)
+	p.reportStatement(db, run, statement)
 }
 
-// afterAutoMigrate is called after AutoMigrate
-func (p *AutoMigratePlugin) afterAutoMigrate(db *gorm.DB) {
-	p.Logger.Println("afterAutoMigrate callback triggered")
+// afterAutoMigrate is called after AutoMigrate, migrateErr is the error AutoMigrate failed with, if any
+func (p *AutoMigratePlugin) afterAutoMigrate(db *gorm.DB, migrateErr error) {
+	p.Logger.Debug("afterAutoMigrate callback triggered")
 
-	startTime, ok := db.InstanceGet("automigrate_plugin:start_time")
+	run, ok := runFromDB(db)
 	if !ok {
-		p.Logger.Println("Error: start time not found")
+		p.Logger.Error("Start time not found")
 		db.AddError(fmt.Errorf("start time not found"))
 		return
 	}
-	p.Logger.Printf("Retrieved start time: %v", startTime)
+	startTime := run.startTime
+	p.Logger.Debug("Retrieved start time: %v", startTime)
 
-	// Generate a new version
-	version := startTime.(time.Time).Format("20060102150405")
-	p.Logger.Printf("Generated version: %s", version)
+	if p.SkipNoop && migrateErr == nil && len(run.statements) == 0 {
+		p.Logger.Info("No statements executed, skipping SchemaVersion record")
+		p.discardPending(db, run)
+		return
+	}
+
+	checksum, err := modelsChecksum(db, run.models)
+	if err != nil {
+		p.Logger.Warn("Failed to compute models checksum: %v", err)
+	}
+	if (p.SkipUnchanged || run.waitedForLock) && migrateErr == nil && len(run.statements) == 0 && checksum != "" {
+		unchanged, err := p.unchangedSince(db, checksum)
+		if err != nil {
+			p.Logger.Warn("Failed to compare models checksum: %v", err)
+		}
+		if unchanged {
+			p.Logger.Info("Models unchanged since the current schema version, skipping SchemaVersion record")
+			p.discardPending(db, run)
+			return
+		}
+	}
 
 	// Track changes
-	changes := p.generateChangeLog(db)
-	p.Logger.Printf("Generated change log: %s", changes)
+	after := p.inspectModels(db, run.models)
+	for i := range after {
+		if i < len(run.renames) {
+			markRenames(after[i], run.renames[i])
+		}
+	}
+	changeSet := p.generateChangeLog(run, after, p.diffModels(db, run, after), p.diffModelIndexes(db, run, after), p.diffModelConstraints(run, after))
+	run.changes = changeSet
+
+	// Generate a new version, unless one was reserved by a pending record
+	version := run.version
+	if version == "" {
+		version, err = p.generateVersion(db, startTime)
+		if err != nil {
+			p.Logger.Error("Failed to generate version: %v", err)
+			db.AddError(fmt.Errorf("failed to generate version: %w", err))
+			return
+		}
+		p.Logger.Debug("Generated version: %s", version)
+		run.version = version
+	}
+
+	kind := KindMigration
+	if run.repairing != nil {
+		kind = KindDriftRepair
+		changeSet.Drift = run.repairing
+	}
+	severity := SeverityInfo
+	for i := range run.statements {
+		severity = maxSeverity(severity, classifyStatementAt(run.statements, i))
+	}
+	if len(changeSet.Destructive) > 0 {
+		severity = SeverityDanger
+	}
+	changes, err := encodeChangeSet(changeSet)
+	if err != nil {
+		p.Logger.Error("Failed to encode change log: %v", err)
+		db.AddError(err)
+		return
+	}
+	p.Logger.Debug("Generated change log: %s", changes)
+
+	downStatements := joinStatements(slices.Concat(run.down, generateDownStatements(db, run.before, after)))
+	p.Logger.Debug("Generated down statements: %s", downStatements)
+
+	statements := joinStatements(run.statements)
+	p.Logger.Debug("Captured %d statements", len(run.statements))
+
+	var snapshot string
+	if !p.SkipSnapshots {
+		snapshot = p.snapshot(db)
+	}
 
 	// Record the migration
+	appliedAt := p.now()
 	schemaVersion := SchemaVersion{
+		Version:        version,
+		Kind:           kind,
+		Status:         StatusSuccess,
+		AppliedAt:      appliedAt,
+		DurationMs:     appliedAt.Sub(startTime).Milliseconds(),
+		Changes:        changes,
+		Statements:     statements,
+		DownStatements: downStatements,
+		Snapshot:       snapshot,
+		Checksum:       checksum,
+		Severity:       severity,
+	}
+	if migrateErr != nil {
+		p.Logger.Error("AutoMigrate failed, recording failed migration: %v", migrateErr)
+		schemaVersion.Status = StatusFailed
+		schemaVersion.Error = migrateErr.Error()
+	}
+
+	if run.pending != nil {
+		schemaVersion.ID = run.pending.ID
+		schemaVersion.Labels = run.pending.Labels
+		if err := p.complete(db, &schemaVersion); err != nil {
+			db.AddError(err)
+			return
+		}
+		run.pending = nil
+	} else if err := p.record(db, &schemaVersion); err != nil {
+		db.AddError(err)
+		return
+	}
+	run.historyRows = 1
+	run.recorded = &schemaVersion
+	run.version = schemaVersion.Version
+
+	if p.RecordEntries {
+		if err := p.recordEntries(db, &schemaVersion, run, after); err != nil {
+			db.AddError(err)
+			return
+		}
+	}
+
+	p.applyRetention(db)
+}
+
+// record inserts a SchemaVersion into the history table
+func (p *AutoMigratePlugin) record(db *gorm.DB, schemaVersion *SchemaVersion) error {
+	p.annotate(db, schemaVersion)
+	p.sign(schemaVersion)
+	if p.TenantTables && schemaVersion.Tenant != "" {
+		if err := p.ensureTenantTable(db, p.historyTableName(db)); err != nil {
+			return err
+		}
+	}
+
+	p.Logger.Debug("Attempting to create new SchemaVersion record")
+	if err := p.insertVersion(db, schemaVersion); err != nil {
+		p.Logger.Error("Failed to record schema version: %v", err)
+		p.auditUnrecorded(db, schemaVersion, err)
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	p.Logger.Info("Successfully created new SchemaVersion record")
+	p.observeRecorded(db, schemaVersion)
+	return p.recordFlyway(db, schemaVersion)
+}
+
+// recordPending reserves the version of the run by inserting a pending SchemaVersion
+// before any DDL is executed, afterAutoMigrate completes it
+func (p *AutoMigratePlugin) recordPending(db *gorm.DB, run *migrationRun) error {
+	version, err := p.generateVersion(db, run.startTime)
+	if err != nil {
+		p.Logger.Error("Failed to generate version: %v", err)
+		return fmt.Errorf("failed to generate version: %w", err)
+	}
+	run.version = version
+
+	pending := &SchemaVersion{
 		Version:   version,
-		AppliedAt: time.Now(),
-		Changes:   changes,
+		Kind:      KindMigration,
+		Status:    StatusPending,
+		AppliedAt: run.startTime,
+	}
+	if err := p.record(db, pending); err != nil {
+		return err
+	}
+	run.pending, run.version = pending, pending.Version
+	return nil
+}
+
+// discardPending removes the pending SchemaVersion of a run that ends up not being recorded
+func (p *AutoMigratePlugin) discardPending(db *gorm.DB, run *migrationRun) {
+	if run.pending == nil {
+		return
+	}
+	if err := p.deleteVersions(db, run.pending.ID); err != nil {
+		p.Logger.Error("Failed to remove pending schema version: %v", err)
+		db.AddError(fmt.Errorf("failed to remove pending schema version: %w", err))
+	}
+	run.pending = nil
+}
+
+// annotate fills in the details describing where a SchemaVersion was recorded
+func (p *AutoMigratePlugin) annotate(db *gorm.DB, schemaVersion *SchemaVersion) {
+	if schemaVersion.Tenant == "" {
+		schemaVersion.Tenant = p.tenant(db)
+	}
+	if schemaVersion.InitiatedBy == "" {
+		schemaVersion.InitiatedBy = p.initiatedBy(db)
+	}
+	if schemaVersion.Environment == (Environment{}) {
+		schemaVersion.Environment = p.environment(db)
+	}
+	if schemaVersion.Server == (ServerInfo{}) {
+		schemaVersion.Server = p.serverInfo(db)
+	}
+	if labels := LabelsFromContext(contextFrom(db)); len(labels) > 0 {
+		merged := Labels{}
+		maps.Copy(merged, labels)
+		maps.Copy(merged, schemaVersion.Labels)
+		schemaVersion.Labels = merged
+	}
+}
+
+// complete updates a pending SchemaVersion with the outcome of the migration
+func (p *AutoMigratePlugin) complete(db *gorm.DB, schemaVersion *SchemaVersion) error {
+	p.annotate(db, schemaVersion)
+	p.sign(schemaVersion)
+	p.Logger.Debug("Attempting to complete pending SchemaVersion record %s", schemaVersion.Version)
+	err := p.withOutbox(db, schemaVersion, func(tx *gorm.DB) error {
+		return p.updateVersion(tx, schemaVersion)
+	})
+	if err != nil {
+		p.Logger.Error("Failed to complete schema version: %v", err)
+		return fmt.Errorf("failed to complete schema version: %w", err)
+	}
+	p.Logger.Info("Successfully completed SchemaVersion record")
+	p.observeRecorded(db, schemaVersion)
+	return p.recordFlyway(db, schemaVersion)
+}
+
+// generateVersion produces the version string for a migration started at startTime, unless
+// the context carries one set with ContextWithVersion
+func (p *AutoMigratePlugin) generateVersion(db *gorm.DB, startTime time.Time) (string, error) {
+	if version, ok := VersionFromContext(contextFrom(db)); ok {
+		return version, nil
+	}
+	generator := p.VersionGenerator
+	if generator == nil {
+		generator = TimestampVersionGenerator{}
+	}
+	return generator.GenerateVersion(db, startTime)
+}
+
+// inspectModels introspects the live table of every model, skipping models that can't be inspected
+func (p *AutoMigratePlugin) inspectModels(db *gorm.DB, models []interface{}) []*TableSchema {
+	tables := make([]*TableSchema, len(models))
+	for i, model := range models {
+		table, err := inspectTable(db, model)
+		if err != nil {
+			p.Logger.Warn("Failed to inspect model %s: %v", modelName(model), err)
+			continue
+		}
+		tables[i] = table
+	}
+	return tables
+}
+
+// diffModels compares the tables snapshotted before AutoMigrate with their current state
+func (p *AutoMigratePlugin) diffModels(db *gorm.DB, run *migrationRun, after []*TableSchema) [][]ColumnDiff {
+	diffs := make([][]ColumnDiff, len(run.models))
+	for i := range run.models {
+		if i >= len(run.before) || run.before[i] == nil || after[i] == nil {
+			continue
+		}
+		diffs[i] = diffTables(db.Migrator(), run.before[i], after[i])
 	}
+	return diffs
+}
+
+// diffModelIndexes compares the indexes snapshotted before AutoMigrate with the current ones,
+// taking the index types the models declare
+func (p *AutoMigratePlugin) diffModelIndexes(db *gorm.DB, run *migrationRun, after []*TableSchema) [][]IndexDiff {
+	diffs := make([][]IndexDiff, len(run.models))
+	for i, model := range run.models {
+		if i >= len(run.before) || run.before[i] == nil || after[i] == nil {
+			continue
+		}
+		diffs[i] = diffIndexes(run.before[i], after[i])
+		expected, err := modelTableSchema(db, model)
+		if err != nil {
+			continue
+		}
+		for j := range diffs[i] {
+			if index, ok := expected.index(diffs[i][j].Index); ok && diffs[i][j].Kind != IndexDropped {
+				diffs[i][j].Type = index.Type
+			}
+		}
+	}
+	return diffs
+}
 
-	p.Logger.Println("Attempting to create new SchemaVersion record")
-	if err := db.Create(&schemaVersion).Error; err != nil {
-		p.Logger.Printf("Failed to record schema version: %v", err)
-		db.AddError(fmt.Errorf("failed to record schema version: %w", err))
-	} else {
-		p.Logger.Println("Successfully created new SchemaVersion record")
+// diffModelConstraints compares the constraints snapshotted before AutoMigrate with the current ones
+func (p *AutoMigratePlugin) diffModelConstraints(run *migrationRun, after []*TableSchema) [][]ConstraintDiff {
+	diffs := make([][]ConstraintDiff, len(run.models))
+	for i := range run.models {
+		if i >= len(run.before) || run.before[i] == nil || after[i] == nil {
+			continue
+		}
+		diffs[i] = diffConstraints(run.before[i], after[i])
 	}
+	return diffs
 }
 
-// generateChangeLog creates a change log based on the migrated models
-func (p *AutoMigratePlugin) generateChangeLog(db *gorm.DB) string {
-	p.Logger.Println("generateChangeLog method called")
+// generateChangeLog creates a change set based on the migrated models and their column, index
+// and constraint diffs
+func (p *AutoMigratePlugin) generateChangeLog(run *migrationRun, after []*TableSchema, diffs [][]ColumnDiff, indexDiffs [][]IndexDiff, constraintDiffs [][]ConstraintDiff) *ChangeSet {
+	p.Logger.Debug("generateChangeLog method called")
+
+	changeSet := &ChangeSet{Operation: run.operation, Routines: run.routines}
+	if len(run.models) == 0 && run.operation != "" {
+		return changeSet
+	}
+	if len(run.models) == 0 {
+		p.Logger.Debug("No specific models found in db")
+		changeSet.Notes = append(changeSet.Notes, "No specific models found, general AutoMigrate performed")
+		return changeSet
+	}
 
-	var changes string
-	if models, ok := db.Get("gorm:auto_migrate_models"); ok {
-		p.Logger.Println("Retrieved auto_migrate_models from db")
-		modelSlice, ok := models.([]interface{})
-		if !ok {
-			p.Logger.Println("Error: models is not a slice of interface{}")
-			return "Unable to determine migrated models"
+	var columns []ColumnDiff
+	for _, diff := range diffs {
+		columns = append(columns, diff...)
+	}
+	changeSet.Destructive = destructiveChanges(run.statements, columns)
+
+	for i, model := range run.models {
+		change := ModelChange{Model: modelName(model)}
+		p.Logger.Debug("AutoMigrated model: %s", change.Model)
+		if i < len(after) && after[i] != nil {
+			change.Table = after[i].Name
+		}
+		if i < len(diffs) {
+			change.Columns = diffs[i]
 		}
-		for _, model := range modelSlice {
-			modelName := reflect.TypeOf(model).Name()
-			p.Logger.Printf("AutoMigrated model: %s", modelName)
-			changes += fmt.Sprintf("AutoMigrated %s\n", modelName)
+		if i < len(indexDiffs) {
+			change.Indexes = indexDiffs[i]
 		}
-	} else {
-		p.Logger.Println("No specific models found in db")
-		changes = "No specific models found, general AutoMigrate performed"
+		if i < len(constraintDiffs) {
+			change.Constraints = constraintDiffs[i]
+		}
+		if i < len(run.enums) {
+			change.Enums = run.enums[i]
+		}
+		if i < len(run.durations) {
+			change.DurationMs = run.durations[i].Milliseconds()
+		}
+		changeSet.Models = append(changeSet.Models, change)
+	}
+
+	p.Logger.Debug("Final change log: %s", changeSet)
+	return changeSet
+}
+
+// modelName returns the type name of a model, dereferencing pointers
+func modelName(model interface{}) string {
+	if name, ok := model.(string); ok {
+		return name
 	}
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "<nil>"
+	}
+	return t.Name()
+}
 
-	p.Logger.Printf("Final change log: %s", changes)
-	return changes
+// GetMigrationHistoryContext retrieves the history of schema changes, cancelling the query when ctx is done
+func GetMigrationHistoryContext(ctx context.Context, db *gorm.DB) ([]SchemaVersion, error) {
+	return GetMigrationHistory(db.WithContext(ctx))
 }
 
 // GetMigrationHistory retrieves the history of schema changes
 func GetMigrationHistory(db *gorm.DB) ([]SchemaVersion, error) {
-	log.Println("GetMigrationHistory function called")
+	logger := loggerFrom(db)
+	logger.Debug("GetMigrationHistory function called")
 
-	var history []SchemaVersion
-	result := db.Order("applied_at desc").Find(&history)
-	if result.Error != nil {
-		log.Printf("Failed to retrieve migration history: %v", result.Error)
-		return nil, fmt.Errorf("failed to retrieve migration history: %w", result.Error)
+	history, stored, err := registeredPlugin(db).storedHistory(db)
+	if err != nil {
+		logger.Error("Failed to retrieve migration history: %v", err)
+		return nil, err
+	}
+	if stored {
+		sortNewestFirst(history)
+	} else if err := historyDB(db).Order("applied_at desc").Find(&history).Error; err != nil {
+		logger.Error("Failed to retrieve migration history: %v", err)
+		return nil, fmt.Errorf("failed to retrieve migration history: %w", err)
 	}
 
-	log.Printf("Retrieved %d migration history records", len(history))
+	logger.Debug("Retrieved %d migration history records", len(history))
 	return history, nil
 }
 
+// GetCurrentVersionContext is like GetCurrentVersion but cancels the query when ctx is done
+func GetCurrentVersionContext(ctx context.Context, db *gorm.DB) (*SchemaVersion, error) {
+	return GetCurrentVersion(db.WithContext(ctx))
+}
+
+// GetCurrentVersion returns the highest successful migration, baseline or drift repair,
+// ordered by the VersionGenerator as in CompareVersions rather than by their strings, or
+// ErrVersionNotFound when none is recorded
+func GetCurrentVersion(db *gorm.DB) (*SchemaVersion, error) {
+	loggerFrom(db).Debug("GetCurrentVersion function called")
+	return currentVersion(db)
+}
+
+// GetLastSuccessfulMigrationContext is like GetLastSuccessfulMigration but cancels the query
+// when ctx is done
+func GetLastSuccessfulMigrationContext(ctx context.Context, db *gorm.DB) (*SchemaVersion, error) {
+	return GetLastSuccessfulMigration(db.WithContext(ctx))
+}
+
+// GetLastSuccessfulMigration returns the highest successful migration, ordered like
+// GetCurrentVersion, or ErrVersionNotFound when none is recorded
+func GetLastSuccessfulMigration(db *gorm.DB) (*SchemaVersion, error) {
+	loggerFrom(db).Debug("GetLastSuccessfulMigration function called")
+	return registeredPlugin(db).highestVersion(db, []string{KindMigration})
+}
+
+// highestVersion returns the successful record of the given kinds with the highest version,
+// the latest recorded among equal versions. Only the IDs and versions are read to compare
+// them, the winning record is loaded alone.
+func (p *AutoMigratePlugin) highestVersion(db *gorm.DB, kinds []string) (*SchemaVersion, error) {
+	history, stored, err := p.storedHistory(db)
+	if err != nil {
+		p.Logger.Error("Failed to retrieve migration history: %v", err)
+		return nil, fmt.Errorf("failed to retrieve migration history: %w", err)
+	}
+	if stored {
+		var highest *SchemaVersion
+		for i, schemaVersion := range history {
+			if schemaVersion.Status != StatusSuccess || !slices.Contains(kinds, schemaVersion.Kind) {
+				continue
+			}
+			if highest == nil || p.CompareVersions(schemaVersion.Version, highest.Version) >= 0 {
+				highest = &history[i]
+			}
+		}
+		if highest == nil {
+			return nil, ErrVersionNotFound
+		}
+		return highest, nil
+	}
+
+	var versions []struct {
+		ID      uint
+		Version string
+	}
+	err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Model(&SchemaVersion{}).Where("kind IN ? AND status = ?", kinds, StatusSuccess).
+		Order("id").Select("id", "version").Find(&versions).Error
+	if err != nil {
+		p.Logger.Error("Failed to retrieve migration history: %v", err)
+		return nil, fmt.Errorf("failed to retrieve migration history: %w", err)
+	}
+	highest := -1
+	for i, recorded := range versions {
+		if highest < 0 || p.CompareVersions(recorded.Version, versions[highest].Version) >= 0 {
+			highest = i
+		}
+	}
+	if highest < 0 {
+		return nil, ErrVersionNotFound
+	}
+
+	var schemaVersion SchemaVersion
+	if err := p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("id = ?", versions[highest].ID).Take(&schemaVersion).Error; err != nil {
+		p.Logger.Error("Failed to retrieve schema version %s: %v", versions[highest].Version, err)
+		return nil, fmt.Errorf("failed to retrieve schema version %s: %w", versions[highest].Version, err)
+	}
+	return &schemaVersion, nil
+}
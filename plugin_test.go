@@ -0,0 +1,299 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens a SQLite database in a temporary directory with a quiet plugin created
+// with the options registered on it
+func openTestDB(t *testing.T, opts ...Option) (*gorm.DB, *AutoMigratePlugin) {
+	t.Helper()
+	return openTestDBAt(t, filepath.Join(t.TempDir(), "test.db"), opts...)
+}
+
+// openTestDBAt opens the SQLite database at path with its own plugin, several instances
+// opened at the same path share the database like replicas of a deployment
+func openTestDBAt(t *testing.T, path string, opts ...Option) (*gorm.DB, *AutoMigratePlugin) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path+"?_busy_timeout=10000&_journal_mode=WAL"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	plugin := NewAutoMigratePlugin(append([]Option{WithQuiet(true)}, opts...)...)
+	if err := db.Use(plugin); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	return db, plugin
+}
+
+// mustHistory returns the recorded history of db, newest first
+func mustHistory(t *testing.T, db *gorm.DB) []SchemaVersion {
+	t.Helper()
+	history, err := GetMigrationHistory(db)
+	if err != nil {
+		t.Fatalf("GetMigrationHistory: %v", err)
+	}
+	return history
+}
+
+// mustRecorded returns the record of version, failing the test when it isn't recorded
+func mustRecorded(t *testing.T, db *gorm.DB, version string) SchemaVersion {
+	t.Helper()
+	for _, schemaVersion := range mustHistory(t, db) {
+		if schemaVersion.Version == version {
+			return schemaVersion
+		}
+	}
+	t.Fatalf("version %s is not recorded", version)
+	return SchemaVersion{}
+}
+
+// migrateAs runs AutoMigrate recording the run under version
+func migrateAs(t *testing.T, db *gorm.DB, version string, models ...interface{}) {
+	t.Helper()
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, version)).AutoMigrate(models...); err != nil {
+		t.Fatalf("AutoMigrate %s: %v", version, err)
+	}
+}
+
+type pluginUser struct {
+	ID   uint
+	Name string
+}
+
+func (pluginUser) TableName() string { return "users" }
+
+type pluginUserWithEmail struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+func (pluginUserWithEmail) TableName() string { return "users" }
+
+func TestAutoMigrateRecordsStatements(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	created := mustRecorded(t, db, "1")
+	if created.Status != StatusSuccess || created.Kind != KindMigration {
+		t.Errorf("version 1 recorded as %s %s, want a successful migration", created.Status, created.Kind)
+	}
+	if !strings.Contains(created.Statements, "CREATE TABLE `users`") {
+		t.Errorf("version 1 statements = %q, want the CREATE TABLE of users", created.Statements)
+	}
+
+	altered := mustRecorded(t, db, "2")
+	if !strings.Contains(altered.Statements, "ADD `email`") {
+		t.Errorf("version 2 statements = %q, want the email column added", altered.Statements)
+	}
+	if strings.Contains(altered.Statements, "CREATE TABLE `users`") {
+		t.Errorf("version 2 statements = %q, want only the statements of its own run", altered.Statements)
+	}
+}
+
+func TestGetCurrentVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	if _, err := GetCurrentVersion(db); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("GetCurrentVersion of an empty history = %v, want ErrVersionNotFound", err)
+	}
+
+	// Recorded out of order, the highest version wins rather than the latest recorded
+	migrateAs(t, db, "20240102000000", &pluginUser{})
+	migrateAs(t, db, "20240101000000", &pluginUserWithEmail{})
+	failed := SchemaVersion{Version: "20240103000000", Kind: KindMigration, Status: StatusFailed, AppliedAt: time.Now()}
+	if err := historyDB(db).Create(&failed).Error; err != nil {
+		t.Fatalf("failed to seed a failed version: %v", err)
+	}
+
+	current, err := GetCurrentVersion(db)
+	if err != nil {
+		t.Fatalf("GetCurrentVersion: %v", err)
+	}
+	if current.Version != "20240102000000" {
+		t.Errorf("current version = %s, want 20240102000000", current.Version)
+	}
+	if current.Statements == "" {
+		t.Error("current version was loaded without its statements")
+	}
+}
+
+func TestGetLastSuccessfulMigration(t *testing.T) {
+	db, _ := openTestDB(t)
+	if _, err := Baseline(db, "1"); err != nil {
+		t.Fatalf("Baseline: %v", err)
+	}
+	if _, err := GetLastSuccessfulMigration(db); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("GetLastSuccessfulMigration of a baseline = %v, want ErrVersionNotFound", err)
+	}
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != "1" {
+		t.Fatalf("GetCurrentVersion = %v, %v, want the baseline", current, err)
+	}
+
+	migrateAs(t, db, "2", &pluginUser{})
+	last, err := GetLastSuccessfulMigration(db)
+	if err != nil {
+		t.Fatalf("GetLastSuccessfulMigration: %v", err)
+	}
+	if last.Version != "2" {
+		t.Errorf("last successful migration = %s, want 2", last.Version)
+	}
+}
+
+type failingModel struct {
+	ID   uint
+	Name string `gorm:"check:name((("`
+}
+
+func TestAutoMigrateRecordsFailure(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateErr := db.WithContext(ContextWithVersion(db.Statement.Context, "1")).AutoMigrate(&pluginUser{}, &failingModel{})
+	if migrateErr == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	failed := mustRecorded(t, db, "1")
+	if failed.Status != StatusFailed {
+		t.Errorf("version 1 status = %s, want %s", failed.Status, StatusFailed)
+	}
+	if failed.Error != migrateErr.Error() {
+		t.Errorf("version 1 error = %q, want %q", failed.Error, migrateErr.Error())
+	}
+	// The models migrated before the failure are recorded
+	if !strings.Contains(failed.Statements, "CREATE TABLE `users`") {
+		t.Errorf("version 1 statements = %q, want the users table created", failed.Statements)
+	}
+	if _, err := GetCurrentVersion(db); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("GetCurrentVersion = %v, want a failed version not to be current", err)
+	}
+}
+
+// tickingClock advances by step every time it's read
+type tickingClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *tickingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func TestAutoMigrateRecordsDurations(t *testing.T) {
+	clock := &tickingClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), step: 10 * time.Millisecond}
+	db, _ := openTestDB(t, WithClock(clock))
+	migrateAs(t, db, "1", &pluginUser{}, &squashedOrder{})
+
+	recorded := mustRecorded(t, db, "1")
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Models) != 2 {
+		t.Fatalf("recorded changes of %d models, want 2", len(changes.Models))
+	}
+	var models int64
+	for _, model := range changes.Models {
+		if model.DurationMs <= 0 {
+			t.Errorf("model %s recorded as taking %dms, want its duration", model.Model, model.DurationMs)
+		}
+		models += model.DurationMs
+	}
+	if recorded.DurationMs < models {
+		t.Errorf("run recorded as taking %dms, want at least the %dms of its models", recorded.DurationMs, models)
+	}
+}
+
+func TestHistoryTableName(t *testing.T) {
+	db, _ := openTestDB(t, WithTableName("migration_history"), WithEntries(true))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	for _, table := range []string{"migration_history", "migration_history_entries"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s wasn't created", table)
+		}
+	}
+	if db.Migrator().HasTable(&SchemaVersion{}) {
+		t.Error("the default history table was created")
+	}
+	var count int64
+	if err := db.Table("migration_history").Where("version = ?", "1").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("migration_history holds %d records of version 1, %v, want 1", count, err)
+	}
+	mustRecorded(t, db, "1")
+	if history, err := GetTableHistory(db, "users"); err != nil || len(history) != 1 {
+		t.Errorf("GetTableHistory = %v, %v, want the entry of version 1", history, err)
+	}
+
+	// The renamed tables aren't mistaken for application tables
+	snapshot, err := TakeSnapshot(db)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	if len(snapshot.Tables) != 1 {
+		t.Errorf("snapshot = %v, want the users table alone", snapshot.Tables)
+	}
+}
+
+func TestHistoryTableSchema(t *testing.T) {
+	db, _ := openTestDB(t)
+	plugin := &AutoMigratePlugin{TableSchema: "ops", TableName: "migration_history"}
+	if table := plugin.historyTableName(db); table != "ops.migration_history" {
+		t.Errorf("history table = %s, want ops.migration_history", table)
+	}
+	if table := entriesTableFor(db, plugin.historyTableName(db)); table != "ops.migration_history_entries" {
+		t.Errorf("entry table = %s, want ops.migration_history_entries", table)
+	}
+	// The default table keeps the default entry table, qualified by the schema
+	plugin = &AutoMigratePlugin{TableSchema: "ops"}
+	if table := entriesTableFor(db, plugin.historyTableName(db)); table != "ops.schema_version_entries" {
+		t.Errorf("entry table = %s, want ops.schema_version_entries", table)
+	}
+	if !isTrackerTable(db, plugin.historyTableName(db), "schema_versions") {
+		t.Error("the unqualified history table isn't a tracker table")
+	}
+}
+
+func TestHistoryConn(t *testing.T) {
+	conn, _ := openTestDB(t)
+	db, _ := openTestDB(t, WithHistoryConn(conn), WithEntries(true))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	// The migrated database holds the users alone
+	if db.Migrator().HasTable(&SchemaVersion{}) || db.Migrator().HasTable(&SchemaVersionEntry{}) {
+		t.Error("the history tables were created on the migrated database")
+	}
+	if !db.Migrator().HasTable(&pluginUser{}) || conn.Migrator().HasTable(&pluginUser{}) {
+		t.Error("the users table wasn't created on the migrated database alone")
+	}
+	var count int64
+	if err := conn.Model(&SchemaVersion{}).Where("version = ?", "1").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("the history connection holds %d records of version 1, %v, want 1", count, err)
+	}
+	// Creating the history tables through a tracked connection isn't recorded as a migration
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("history = %v, want version 1 alone", history)
+	}
+	if history, err := GetTableHistory(db, "users"); err != nil || len(history) != 1 {
+		t.Errorf("GetTableHistory = %v, %v, want the entry of version 1", history, err)
+	}
+}
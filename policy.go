@@ -0,0 +1,172 @@
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrPolicyDenied is returned when the configured Policy rejects the planned changes
+var ErrPolicyDenied = errors.New("migration denied by policy")
+
+// PolicyInput is the document a Policy evaluates before AutoMigrate applies its plan
+type PolicyInput struct {
+	Plan        *Plan       `json:"plan"`
+	Models      []string    `json:"models"`
+	Time        time.Time   `json:"time"`
+	Tenant      string      `json:"tenant,omitempty"`
+	Labels      Labels      `json:"labels,omitempty"`
+	Environment Environment `json:"environment"`
+}
+
+// PolicyDecision is the outcome of a policy evaluation
+type PolicyDecision struct {
+	Allow bool `json:"allow"`
+	// Reasons explain a denial, such as the rules that were violated
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Policy decides whether AutoMigrate may apply the planned changes, e.g. "no column drops
+// during business hours". An evaluation error stops the migration like a denial does.
+type Policy interface {
+	Evaluate(ctx context.Context, input PolicyInput) (PolicyDecision, error)
+}
+
+// PolicyFunc adapts a function to the Policy interface
+type PolicyFunc func(ctx context.Context, input PolicyInput) (PolicyDecision, error)
+
+// Evaluate calls f(ctx, input)
+func (f PolicyFunc) Evaluate(ctx context.Context, input PolicyInput) (PolicyDecision, error) {
+	return f(ctx, input)
+}
+
+// checkPolicy evaluates the configured policy against the plan of AutoMigrate
+func (p *AutoMigratePlugin) checkPolicy(db *gorm.DB, plan *Plan, models []interface{}) error {
+	if plan.Empty() {
+		return nil
+	}
+
+	ctx := contextFrom(db)
+	input := PolicyInput{
+		Plan:        plan,
+		Time:        p.now(),
+		Tenant:      p.tenant(db),
+		Labels:      LabelsFromContext(ctx),
+		Environment: p.environment(db),
+	}
+	for _, model := range models {
+		input.Models = append(input.Models, modelName(model))
+	}
+
+	p.Logger.Debug("Evaluating policy for %d planned statements", len(plan.Statements))
+	decision, err := p.Policy.Evaluate(ctx, input)
+	if err != nil {
+		p.Logger.Error("Failed to evaluate policy: %v", err)
+		return fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+	if !decision.Allow {
+		p.Logger.Error("Policy denied AutoMigrate: %s", strings.Join(decision.Reasons, "; "))
+		if len(decision.Reasons) == 0 {
+			return ErrPolicyDenied
+		}
+		return fmt.Errorf("%w: %s", ErrPolicyDenied, strings.Join(decision.Reasons, "; "))
+	}
+	p.Logger.Info("Policy allowed AutoMigrate")
+	return nil
+}
+
+// OPAPolicy evaluates a policy served by an Open Policy Agent through its REST API. The
+// decision at Path is either a boolean, or an object with an optional allow boolean and a
+// deny list of messages, the plan is denied when allow is false or deny isn't empty.
+type OPAPolicy struct {
+	// URL is the address of the OPA server, such as http://localhost:8181
+	URL string
+	// Path is the decision path, such as gorm/migrations
+	Path string
+	// Client sends the requests, http.DefaultClient is used when nil
+	Client *http.Client
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string
+}
+
+// NewOPAPolicy creates an OPAPolicy querying the decision at path of the server at url
+func NewOPAPolicy(url, path string) *OPAPolicy {
+	return &OPAPolicy{URL: url, Path: path}
+}
+
+// Evaluate queries the decision for input
+func (o *OPAPolicy) Evaluate(ctx context.Context, input PolicyInput) (PolicyDecision, error) {
+	payload, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+
+	url := strings.TrimSuffix(o.URL, "/") + "/v1/data/" + strings.Trim(o.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range o.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return PolicyDecision{}, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to decode policy response: %w", err)
+	}
+	return DecodeOPAResult(response.Result)
+}
+
+// DecodeOPAResult converts an OPA decision document, a boolean or an object with allow and
+// deny fields, into a PolicyDecision
+func DecodeOPAResult(result []byte) (PolicyDecision, error) {
+	if len(bytes.TrimSpace(result)) == 0 {
+		return PolicyDecision{}, errors.New("policy decision is undefined")
+	}
+
+	var allow bool
+	if err := json.Unmarshal(result, &allow); err == nil {
+		return PolicyDecision{Allow: allow}, nil
+	}
+
+	var document struct {
+		Allow *bool    `json:"allow"`
+		Deny  []string `json:"deny"`
+	}
+	if err := json.Unmarshal(result, &document); err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to decode policy decision: %w", err)
+	}
+	decision := PolicyDecision{Allow: len(document.Deny) == 0, Reasons: document.Deny}
+	if document.Allow != nil && !*document.Allow {
+		decision.Allow = false
+	}
+	return decision, nil
+}
@@ -0,0 +1,87 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	// Deny column additions to the users table
+	policy := PolicyFunc(func(_ context.Context, input PolicyInput) (PolicyDecision, error) {
+		for _, statement := range input.Plan.Statements {
+			if statement.Table == "users" && strings.Contains(statement.SQL, " ADD ") {
+				return PolicyDecision{Reasons: []string{"users is frozen"}}, nil
+			}
+		}
+		return PolicyDecision{Allow: true}, nil
+	})
+	db, _ := openTestDB(t, WithPolicy(policy))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	err := db.WithContext(ContextWithVersion(db.Statement.Context, "2")).AutoMigrate(&pluginUserWithEmail{})
+	if !errors.Is(err, ErrPolicyDenied) || !strings.Contains(err.Error(), "users is frozen") {
+		t.Fatalf("AutoMigrate = %v, want the policy denial with its reason", err)
+	}
+	if db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the email column was added despite the denial")
+	}
+	if history := mustHistory(t, db); len(history) != 1 {
+		t.Errorf("history = %v, want the denied migration not recorded", history)
+	}
+}
+
+func TestOPAPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/data/gorm/migrations" {
+			t.Errorf("request path = %s, want the decision path", r.URL.Path)
+		}
+		var request struct {
+			Input PolicyInput `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode the policy input: %v", err)
+		}
+		if slices.Contains(request.Input.Models, modelName(&failingModel{})) {
+			w.Write([]byte(`{"result": {"deny": ["checks are reviewed by hand"]}}`))
+			return
+		}
+		w.Write([]byte(`{"result": true}`))
+	}))
+	defer server.Close()
+
+	db, _ := openTestDB(t, WithPolicy(NewOPAPolicy(server.URL, "/gorm/migrations/")))
+	migrateAs(t, db, "1", &pluginUser{})
+	err := db.AutoMigrate(&failingModel{})
+	if !errors.Is(err, ErrPolicyDenied) || !strings.Contains(err.Error(), "checks are reviewed by hand") {
+		t.Errorf("AutoMigrate = %v, want the denial of the OPA server", err)
+	}
+}
+
+func TestDecodeOPAResult(t *testing.T) {
+	for result, want := range map[string]PolicyDecision{
+		`true`:                                  {Allow: true},
+		`false`:                                 {},
+		`{"allow": true}`:                       {Allow: true},
+		`{"allow": false}`:                      {},
+		`{"deny": ["no drops"]}`:                {Reasons: []string{"no drops"}},
+		`{"allow": true, "deny": ["no drops"]}`: {Reasons: []string{"no drops"}},
+	} {
+		decision, err := DecodeOPAResult([]byte(result))
+		if err != nil {
+			t.Errorf("DecodeOPAResult(%s): %v", result, err)
+			continue
+		}
+		if decision.Allow != want.Allow || !slices.Equal(decision.Reasons, want.Reasons) {
+			t.Errorf("DecodeOPAResult(%s) = %+v, want %+v", result, decision, want)
+		}
+	}
+	if _, err := DecodeOPAResult(nil); err == nil {
+		t.Error("DecodeOPAResult of an undefined decision succeeded")
+	}
+}
@@ -0,0 +1,303 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrPreflightFailed is returned when a pre-flight check fails before AutoMigrate runs its DDL
+var ErrPreflightFailed = errors.New("pre-flight checks failed")
+
+// ErrPreflightSkipped is returned by a PreflightCheck that doesn't apply to the connection,
+// such as a check the dialect doesn't support
+var ErrPreflightSkipped = errors.New("pre-flight check skipped")
+
+// PreflightCheck verifies the database is fit for the planned changes before any DDL runs
+type PreflightCheck interface {
+	Name() string
+	Check(ctx context.Context, db *gorm.DB, plan *Plan) error
+}
+
+// preflightFunc is a PreflightCheck implemented by a function
+type preflightFunc struct {
+	name  string
+	check func(ctx context.Context, db *gorm.DB, plan *Plan) error
+}
+
+// Name returns the name of the check
+func (c preflightFunc) Name() string {
+	return c.name
+}
+
+// Check runs the check function
+func (c preflightFunc) Check(ctx context.Context, db *gorm.DB, plan *Plan) error {
+	return c.check(ctx, db, plan)
+}
+
+// NewPreflightCheck creates a PreflightCheck from a function, for custom checks
+func NewPreflightCheck(name string, check func(ctx context.Context, db *gorm.DB, plan *Plan) error) PreflightCheck {
+	return preflightFunc{name: name, check: check}
+}
+
+// PreflightResult is the outcome of a single check
+type PreflightResult struct {
+	Name       string `json:"name"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// PreflightReport is the outcome of every configured check
+type PreflightReport struct {
+	Results []PreflightResult `json:"results"`
+}
+
+// Failed returns the results of the checks that failed
+func (r *PreflightReport) Failed() []PreflightResult {
+	var failed []PreflightResult
+	for _, result := range r.Results {
+		if result.Error != "" {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// String renders the report as one check per line
+func (r *PreflightReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		switch {
+		case result.Skipped:
+			fmt.Fprintf(&b, "skipped %s\n", result.Name)
+		case result.Error != "":
+			fmt.Fprintf(&b, "failed %s: %s\n", result.Name, result.Error)
+		default:
+			fmt.Fprintf(&b, "passed %s in %dms\n", result.Name, result.DurationMs)
+		}
+	}
+	return b.String()
+}
+
+// PreflightError is returned with the report when pre-flight checks fail, it matches
+// ErrPreflightFailed with errors.Is
+type PreflightError struct {
+	Report *PreflightReport
+}
+
+// Error lists the failed checks
+func (e *PreflightError) Error() string {
+	failed := e.Report.Failed()
+	messages := make([]string, len(failed))
+	for i, result := range failed {
+		messages[i] = result.Name + ": " + result.Error
+	}
+	return fmt.Sprintf("%s: %s", ErrPreflightFailed, strings.Join(messages, "; "))
+}
+
+// Is reports whether target is ErrPreflightFailed
+func (e *PreflightError) Is(target error) bool {
+	return target == ErrPreflightFailed
+}
+
+// RunPreflight plans AutoMigrate for the models and runs the configured pre-flight checks,
+// returning the report of every check and a *PreflightError when any failed
+func (p *AutoMigratePlugin) RunPreflight(db *gorm.DB, models ...interface{}) (*PreflightReport, error) {
+	plan, err := p.Plan(db, models...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan AutoMigrate: %w", err)
+	}
+	return p.runPreflight(db, plan)
+}
+
+// runPreflight runs every configured check against the plan, all checks run even when one fails
+func (p *AutoMigratePlugin) runPreflight(db *gorm.DB, plan *Plan) (*PreflightReport, error) {
+	p.Logger.Debug("Running %d pre-flight checks", len(p.Preflight))
+	ctx := contextFrom(db)
+	report := &PreflightReport{}
+	for _, check := range p.Preflight {
+		start := p.now()
+		err := check.Check(ctx, db.Session(&gorm.Session{NewDB: true}), plan)
+		result := PreflightResult{Name: check.Name(), DurationMs: p.now().Sub(start).Milliseconds()}
+		switch {
+		case errors.Is(err, ErrPreflightSkipped):
+			result.Skipped = true
+			p.Logger.Debug("Pre-flight check %s skipped", result.Name)
+		case err != nil:
+			result.Error = err.Error()
+			p.Logger.Error("Pre-flight check %s failed: %v", result.Name, err)
+		default:
+			p.Logger.Debug("Pre-flight check %s passed", result.Name)
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if len(report.Failed()) > 0 {
+		return report, &PreflightError{Report: report}
+	}
+	p.Logger.Info("Pre-flight checks passed")
+	return report, nil
+}
+
+// DefaultPreflightChecks returns the standard checks: connectivity, privileges, transactions
+// running for over five minutes and replication lagging over thirty seconds
+func DefaultPreflightChecks() []PreflightCheck {
+	return []PreflightCheck{
+		ConnectivityCheck(),
+		PrivilegesCheck(),
+		LongTransactionsCheck(5 * time.Minute),
+		ReplicationLagCheck(30 * time.Second),
+	}
+}
+
+// ConnectivityCheck pings the database
+func ConnectivityCheck() PreflightCheck {
+	return NewPreflightCheck("connectivity", func(ctx context.Context, db *gorm.DB, _ *Plan) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("failed to access database handle: %w", err)
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// PrivilegesCheck verifies the user may create objects, on Postgres in the current schema,
+// on MySQL in its grants and on SQLite that the connection isn't read only
+func PrivilegesCheck() PreflightCheck {
+	return NewPreflightCheck("privileges", func(ctx context.Context, db *gorm.DB, _ *Plan) error {
+		var query string
+		switch db.Dialector.Name() {
+		case "postgres":
+			query = "SELECT has_schema_privilege(current_schema(), 'CREATE')"
+		case "mysql":
+			return mysqlCanCreate(ctx, db)
+		case "sqlite":
+			query = "SELECT NOT query_only FROM pragma_query_only"
+		default:
+			return ErrPreflightSkipped
+		}
+
+		var allowed bool
+		if err := db.WithContext(ctx).Raw(query).Row().Scan(&allowed); err != nil {
+			return fmt.Errorf("failed to query privileges: %w", err)
+		}
+		if !allowed {
+			return errors.New("the database user may not create objects")
+		}
+		return nil
+	})
+}
+
+// mysqlCanCreate looks for the CREATE privilege in the grants of the current user
+func mysqlCanCreate(ctx context.Context, db *gorm.DB) error {
+	rows, err := db.WithContext(ctx).Raw("SHOW GRANTS").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query privileges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return fmt.Errorf("failed to query privileges: %w", err)
+		}
+		privileges, _, _ := strings.Cut(strings.ToUpper(grant), " ON ")
+		if strings.Contains(privileges, "ALL PRIVILEGES") || strings.Contains(privileges, "CREATE,") || strings.HasSuffix(privileges, "CREATE") {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query privileges: %w", err)
+	}
+	return errors.New("the database user may not create objects")
+}
+
+// LongTransactionsCheck fails when other transactions have been running for longer than
+// threshold, as DDL would queue behind their locks, on Postgres and MySQL
+func LongTransactionsCheck(threshold time.Duration) PreflightCheck {
+	return NewPreflightCheck("long transactions", func(ctx context.Context, db *gorm.DB, _ *Plan) error {
+		var query string
+		switch db.Dialector.Name() {
+		case "postgres":
+			query = "SELECT COUNT(*) FROM pg_stat_activity WHERE pid <> pg_backend_pid() AND state <> 'idle' AND xact_start < now() - make_interval(secs => ?)"
+		case "mysql":
+			query = "SELECT COUNT(*) FROM information_schema.innodb_trx WHERE trx_started < NOW() - INTERVAL ? SECOND"
+		default:
+			return ErrPreflightSkipped
+		}
+
+		var count int64
+		if err := db.WithContext(ctx).Raw(query, int64(threshold.Seconds())).Row().Scan(&count); err != nil {
+			return fmt.Errorf("failed to query running transactions: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%d transactions running for over %s", count, threshold)
+		}
+		return nil
+	})
+}
+
+// ReplicationLagCheck fails when a replica lags behind the primary by more than maxLag, on
+// Postgres primaries
+func ReplicationLagCheck(maxLag time.Duration) PreflightCheck {
+	return NewPreflightCheck("replication lag", func(ctx context.Context, db *gorm.DB, _ *Plan) error {
+		if db.Dialector.Name() != "postgres" {
+			return ErrPreflightSkipped
+		}
+
+		var seconds float64
+		query := "SELECT COALESCE(MAX(EXTRACT(EPOCH FROM replay_lag)), 0) FROM pg_stat_replication"
+		if err := db.WithContext(ctx).Raw(query).Row().Scan(&seconds); err != nil {
+			return fmt.Errorf("failed to query replication lag: %w", err)
+		}
+		if lag := time.Duration(seconds * float64(time.Second)); lag > maxLag {
+			return fmt.Errorf("replication lag of %s exceeds %s", lag.Round(time.Millisecond), maxLag)
+		}
+		return nil
+	})
+}
+
+// TableSizeCheck fails when a table the plan alters holds more than maxRows rows, as
+// rewriting it could lock it for long. Row counts are estimated on Postgres and MySQL.
+func TableSizeCheck(maxRows int64) PreflightCheck {
+	return NewPreflightCheck("table size", func(ctx context.Context, db *gorm.DB, plan *Plan) error {
+		var oversized []string
+		for _, table := range plan.Tables {
+			if !db.Migrator().HasTable(table) {
+				continue
+			}
+			rows, err := estimateRows(db.WithContext(ctx), table)
+			if err != nil {
+				return fmt.Errorf("failed to count rows of %s: %w", table, err)
+			}
+			if rows > maxRows {
+				oversized = append(oversized, fmt.Sprintf("%s has about %d rows", table, rows))
+			}
+		}
+		if len(oversized) > 0 {
+			return fmt.Errorf("tables over %d rows: %s", maxRows, strings.Join(oversized, ", "))
+		}
+		return nil
+	})
+}
+
+// estimateRows returns the planner's row estimate of a table, counting rows on dialects
+// without one
+func estimateRows(db *gorm.DB, table string) (int64, error) {
+	var rows int64
+	var err error
+	switch db.Dialector.Name() {
+	case "postgres":
+		err = db.Raw("SELECT COALESCE(reltuples, 0)::bigint FROM pg_class WHERE oid = to_regclass(?)", table).Row().Scan(&rows)
+	case "mysql":
+		err = db.Raw("SELECT COALESCE(table_rows, 0) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Row().Scan(&rows)
+	default:
+		err = db.Table(table).Count(&rows).Error
+	}
+	return rows, err
+}
@@ -0,0 +1,49 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPreflightChecks(t *testing.T) {
+	db, plugin := openTestDB(t, WithPreflight(DefaultPreflightChecks()...), WithPreflight(TableSizeCheck(2)))
+	migrateAs(t, db, "1", &pluginUser{})
+	for _, name := range []string{"Ada", "Grace", "Linus"} {
+		if err := db.Create(&pluginUser{Name: name}).Error; err != nil {
+			t.Fatalf("failed to seed a user: %v", err)
+		}
+	}
+
+	report, err := plugin.RunPreflight(db, &pluginUserWithEmail{})
+	var preflightErr *PreflightError
+	if !errors.Is(err, ErrPreflightFailed) || !errors.As(err, &preflightErr) {
+		t.Fatalf("RunPreflight = %v, want a PreflightError", err)
+	}
+	results := map[string]PreflightResult{}
+	for _, result := range report.Results {
+		results[result.Name] = result
+	}
+	// Every check runs, the ones SQLite doesn't support are skipped
+	if len(results) != 5 || results["connectivity"].Error != "" || results["privileges"].Error != "" {
+		t.Errorf("results = %v, want every check run and the SQLite ones passed", report.Results)
+	}
+	if !results["long transactions"].Skipped || !results["replication lag"].Skipped {
+		t.Errorf("results = %v, want the Postgres and MySQL checks skipped", report.Results)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "table size" {
+		t.Errorf("failed checks = %v, want the table size alone", failed)
+	}
+
+	// A failed check aborts AutoMigrate before its DDL runs
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "2")).AutoMigrate(&pluginUserWithEmail{}); !errors.Is(err, ErrPreflightFailed) {
+		t.Fatalf("AutoMigrate = %v, want ErrPreflightFailed", err)
+	}
+	if db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the email column was added despite the failed check")
+	}
+
+	// Models that don't change aren't checked
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Errorf("AutoMigrate of an unchanged model = %v, want the checks skipped", err)
+	}
+}
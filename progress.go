@@ -0,0 +1,163 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Progress is the state of an AutoMigrate run as it migrates its models one at a time
+type Progress struct {
+	Model string
+	Table string
+	// Index is the position of Model among the models of the run, from 1 up to Total
+	Index int
+	Total int
+	// Statements is the number of DDL statements the run executed so far
+	Statements int
+	// PlannedStatements is the number of statements the run was planned to execute, zero
+	// when it wasn't planned, see Plan
+	PlannedStatements int
+	// Elapsed is the time since the run started
+	Elapsed time.Duration
+}
+
+// String renders the progress as a single human readable line
+func (p Progress) String() string {
+	statements := fmt.Sprintf("%d statements", p.Statements)
+	if p.PlannedStatements > 0 {
+		statements = fmt.Sprintf("%d/%d statements", p.Statements, p.PlannedStatements)
+	}
+	return fmt.Sprintf("%d/%d tables, %s (%s), %s in %s", p.Index, p.Total, p.Table, p.Model, statements, p.Elapsed.Round(time.Millisecond))
+}
+
+// ProgressReporter is informed of the progress of an AutoMigrate run model by model, so long
+// migrations can be surfaced in startup logs or UIs. It's called synchronously, slow
+// reporters slow the migration down. Embed ProgressFuncs to implement only some of the
+// methods.
+type ProgressReporter interface {
+	// OnModelStarted is called before a model is migrated
+	OnModelStarted(ctx context.Context, progress Progress)
+	// OnStatement is called after every DDL statement executed for a model, the statements
+	// applying the routines are reported with the last model once it's done
+	OnStatement(ctx context.Context, progress Progress, statement string)
+	// OnModelDone is called once a model is migrated, err is the error it failed with
+	OnModelDone(ctx context.Context, progress Progress, err error)
+}
+
+// ProgressFuncs implements ProgressReporter with optional functions, nil functions are skipped
+type ProgressFuncs struct {
+	ModelStarted func(ctx context.Context, progress Progress)
+	Statement    func(ctx context.Context, progress Progress, statement string)
+	ModelDone    func(ctx context.Context, progress Progress, err error)
+}
+
+// OnModelStarted calls f.ModelStarted
+func (f ProgressFuncs) OnModelStarted(ctx context.Context, progress Progress) {
+	if f.ModelStarted != nil {
+		f.ModelStarted(ctx, progress)
+	}
+}
+
+// OnStatement calls f.Statement
+func (f ProgressFuncs) OnStatement(ctx context.Context, progress Progress, statement string) {
+	if f.Statement != nil {
+		f.Statement(ctx, progress, statement)
+	}
+}
+
+// OnModelDone calls f.ModelDone
+func (f ProgressFuncs) OnModelDone(ctx context.Context, progress Progress, err error) {
+	if f.ModelDone != nil {
+		f.ModelDone(ctx, progress, err)
+	}
+}
+
+// ProgressLogger is a ProgressReporter logging every model as it's migrated, and every
+// statement at the debug level
+type ProgressLogger struct {
+	Logger Logger
+}
+
+var _ ProgressReporter = (*ProgressLogger)(nil)
+
+// NewProgressLogger creates a ProgressLogger writing to logger
+func NewProgressLogger(logger Logger) *ProgressLogger {
+	return &ProgressLogger{Logger: logger}
+}
+
+// OnModelStarted logs the model about to be migrated
+func (l *ProgressLogger) OnModelStarted(_ context.Context, progress Progress) {
+	l.Logger.Info("Migrating %d/%d tables: %s", progress.Index, progress.Total, progress.Table)
+}
+
+// OnStatement logs the executed statement
+func (l *ProgressLogger) OnStatement(_ context.Context, progress Progress, statement string) {
+	l.Logger.Debug("Executed statement %d for %s: %s", progress.Statements, progress.Table, statement)
+}
+
+// OnModelDone logs the outcome of the migration of the model
+func (l *ProgressLogger) OnModelDone(_ context.Context, progress Progress, err error) {
+	if err != nil {
+		l.Logger.Error("Failed to migrate %s: %v", progress, err)
+		return
+	}
+	l.Logger.Info("Migrated %s", progress)
+}
+
+// progress returns the progress of the run at the model at index i
+func (p *AutoMigratePlugin) progress(db *gorm.DB, run *migrationRun, i int) Progress {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	progress := Progress{
+		Index:      i + 1,
+		Total:      len(run.models),
+		Statements: len(run.statements),
+		Elapsed:    p.now().Sub(run.startTime),
+	}
+	if i < len(run.models) {
+		progress.Model, progress.Table = modelName(run.models[i]), tableOf(db, run.models[i])
+	}
+	if run.plan != nil {
+		progress.PlannedStatements = len(run.plan.Statements)
+	}
+	return progress
+}
+
+// reportModelStarted informs the progress reporters that the model at index i is migrated
+func (p *AutoMigratePlugin) reportModelStarted(db *gorm.DB, run *migrationRun, i int) {
+	if len(p.Progress) == 0 {
+		return
+	}
+	progress := p.progress(db, run, i)
+	for _, reporter := range p.Progress {
+		reporter.OnModelStarted(contextFrom(db), progress)
+	}
+}
+
+// reportStatement informs the progress reporters of a statement executed by the run
+func (p *AutoMigratePlugin) reportStatement(db *gorm.DB, run *migrationRun, statement string) {
+	if len(p.Progress) == 0 {
+		return
+	}
+	run.mu.Lock()
+	current := run.current
+	run.mu.Unlock()
+	progress := p.progress(db, run, current)
+	for _, reporter := range p.Progress {
+		reporter.OnStatement(contextFrom(db), progress, statement)
+	}
+}
+
+// reportModelDone informs the progress reporters that the model at index i was migrated
+func (p *AutoMigratePlugin) reportModelDone(db *gorm.DB, run *migrationRun, i int, err error) {
+	if len(p.Progress) == 0 {
+		return
+	}
+	progress := p.progress(db, run, i)
+	for _, reporter := range p.Progress {
+		reporter.OnModelDone(contextFrom(db), progress, err)
+	}
+}
@@ -0,0 +1,41 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var calls []string
+	reporter := ProgressFuncs{
+		ModelStarted: func(_ context.Context, progress Progress) {
+			calls = append(calls, fmt.Sprintf("started %d/%d %s", progress.Index, progress.Total, progress.Table))
+		},
+		Statement: func(_ context.Context, progress Progress, _ string) {
+			calls = append(calls, fmt.Sprintf("statement %d of %s", progress.Statements, progress.Table))
+		},
+		ModelDone: func(_ context.Context, progress Progress, err error) {
+			calls = append(calls, fmt.Sprintf("done %s, %v", progress.Table, err))
+		},
+	}
+	db, _ := openTestDB(t, WithProgress(reporter))
+	migrateAs(t, db, "1", &pluginUser{}, &squashedOrder{})
+
+	orders := tableOf(db, &squashedOrder{})
+	want := []string{
+		"started 1/2 users", "statement 1 of users", "done users, <nil>",
+		"started 2/2 " + orders, "statement 2 of " + orders, "done " + orders + ", <nil>",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("progress calls =\n%q\nwant\n%q", calls, want)
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	progress := Progress{Model: "main.User", Table: "users", Index: 1, Total: 3, Statements: 2, PlannedStatements: 5}
+	if got, want := progress.String(), "1/3 tables, users (main.User), 2/5 statements in 0s"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy bounds how much migration history is kept
+type RetentionPolicy struct {
+	// KeepLast keeps only the newest records, zero keeps all of them
+	KeepLast int
+	// MaxAge prunes records applied longer ago, zero keeps all of them
+	MaxAge time.Duration
+	// ArchiveTable receives pruned records before they are deleted, they are discarded when empty
+	ArchiveTable string
+}
+
+// enabled reports whether the policy prunes anything
+func (r RetentionPolicy) enabled() bool {
+	return r.KeepLast > 0 || r.MaxAge > 0
+}
+
+// Prune deletes all but the newest keepLast history records, returning the number of
+// pruned records. Only finished records are pruned, and the current version and the last
+// recorded change of every view and routine are always kept.
+func Prune(db *gorm.DB, keepLast int) (int64, error) {
+	return registeredPlugin(db).pruneKeepLast(db, keepLast)
+}
+
+// PruneOlderThan deletes the history records applied more than age ago, returning the number
+// of pruned records. The records Prune keeps are kept as well.
+func PruneOlderThan(db *gorm.DB, age time.Duration) (int64, error) {
+	p := registeredPlugin(db)
+	return p.pruneOlderThan(db, p.now().Add(-age))
+}
+
+// registeredPlugin returns the plugin registered on db, or an unconfigured one using its logger
+func registeredPlugin(db *gorm.DB) *AutoMigratePlugin {
+	if p, ok := pluginFrom(db); ok {
+		return p
+	}
+	return &AutoMigratePlugin{Logger: loggerFrom(db)}
+}
+
+// applyRetention prunes the history according to the configured retention policy, failures
+// are logged and never fail the migration
+func (p *AutoMigratePlugin) applyRetention(db *gorm.DB) {
+	if !p.Retention.enabled() {
+		return
+	}
+	db = db.Session(&gorm.Session{NewDB: true})
+
+	if p.Retention.KeepLast > 0 {
+		if _, err := p.pruneKeepLast(db, p.Retention.KeepLast); err != nil {
+			p.Logger.Warn("Failed to apply history retention: %v", err)
+			return
+		}
+	}
+	if p.Retention.MaxAge > 0 {
+		if _, err := p.pruneOlderThan(db, p.now().Add(-p.Retention.MaxAge)); err != nil {
+			p.Logger.Warn("Failed to apply history retention: %v", err)
+		}
+	}
+}
+
+// pruneKeepLast prunes every record except the newest keepLast
+func (p *AutoMigratePlugin) pruneKeepLast(db *gorm.DB, keepLast int) (int64, error) {
+	p.Logger.Debug("Pruning history, keeping the last %d records", keepLast)
+	if keepLast < 0 {
+		return 0, fmt.Errorf("keepLast must not be negative, got %d", keepLast)
+	}
+
+	history, stored, err := p.storedHistory(db)
+	ids := versionIDs(history)
+	if stored {
+		slices.Reverse(ids)
+	} else {
+		err = p.historyDB(db).Model(&SchemaVersion{}).Order("id desc").Pluck("id", &ids).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve history to prune: %v", err)
+		return 0, fmt.Errorf("failed to retrieve history to prune: %w", err)
+	}
+	if len(ids) <= keepLast {
+		return 0, nil
+	}
+	return p.prune(db, ids[keepLast:])
+}
+
+// pruneOlderThan prunes every record applied before cutoff
+func (p *AutoMigratePlugin) pruneOlderThan(db *gorm.DB, cutoff time.Time) (int64, error) {
+	p.Logger.Debug("Pruning history applied before %v", cutoff)
+
+	history, stored, err := p.storedHistory(db)
+	ids := versionIDs(filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+		return schemaVersion.AppliedAt.Before(cutoff)
+	}))
+	if !stored {
+		err = p.historyDB(db).Model(&SchemaVersion{}).Where("applied_at < ?", cutoff).Pluck("id", &ids).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve history to prune: %v", err)
+		return 0, fmt.Errorf("failed to retrieve history to prune: %w", err)
+	}
+	return p.prune(db, ids)
+}
+
+// finishedStatuses are the statuses of the records retention may prune, pending and planned
+// records are still used by the run or the approval holding them
+var finishedStatuses = []string{StatusSuccess, StatusFailed, StatusRolledBack}
+
+// prune archives, if configured, and deletes the given history records together with their
+// entries, sparing the records retainedVersions returns
+func (p *AutoMigratePlugin) prune(db *gorm.DB, ids []uint) (int64, error) {
+	retained, err := p.retainedVersions(db.Session(&gorm.Session{NewDB: true}))
+	if err != nil {
+		return 0, err
+	}
+
+	var prunable []uint
+	for _, id := range ids {
+		if !retained[id] {
+			prunable = append(prunable, id)
+		}
+	}
+	if len(prunable) == 0 {
+		p.Logger.Debug("Nothing to prune")
+		return 0, nil
+	}
+
+	var pruned int64
+	err = p.historyConn(db).Transaction(func(tx *gorm.DB) error {
+		if p.Retention.ArchiveTable != "" {
+			if err := p.archive(tx, prunable); err != nil {
+				return err
+			}
+		}
+		if p.RecordEntries {
+			if err := p.entriesDB(tx.Session(&gorm.Session{NewDB: true})).Where("schema_version_id IN ?", prunable).Delete(&SchemaVersionEntry{}).Error; err != nil {
+				return fmt.Errorf("failed to delete schema version entries: %w", err)
+			}
+		}
+		if err := p.deleteVersions(tx, prunable...); err != nil {
+			return err
+		}
+		pruned = int64(len(prunable))
+		return nil
+	})
+	if err != nil {
+		p.Logger.Error("Failed to prune history: %v", err)
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+
+	p.Logger.Info("Pruned %d history records", pruned)
+	return pruned, nil
+}
+
+// retainedVersions returns the IDs of the records retention never prunes: the current
+// version, the records that aren't finished and the records holding the last recorded change
+// of a view or routine, which MigrateViews and AutoMigrate compare the definitions against
+func (p *AutoMigratePlugin) retainedVersions(db *gorm.DB) (map[uint]bool, error) {
+	retained := map[uint]bool{}
+	current, err := currentVersion(db)
+	if err != nil && !errors.Is(err, ErrVersionNotFound) {
+		p.Logger.Error("Failed to retrieve the current schema version: %v", err)
+		return nil, err
+	}
+	if current != nil {
+		retained[current.ID] = true
+	}
+
+	history, stored, err := p.storedHistory(db)
+	unfinished := versionIDs(filterVersions(slices.Clone(history), func(schemaVersion *SchemaVersion) bool {
+		return !slices.Contains(finishedStatuses, schemaVersion.Status)
+	}))
+	definitions := filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+		return schemaVersion.Status == StatusSuccess && (schemaVersion.Kind == KindView || schemaVersion.Applied())
+	})
+	if !stored && err == nil {
+		err = p.historyDB(db).Model(&SchemaVersion{}).Where("status NOT IN ?", finishedStatuses).Pluck("id", &unfinished).Error
+	}
+	if !stored && err == nil {
+		kinds := append([]string{KindView}, appliedKinds...)
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("kind IN ? AND status = ?", kinds, StatusSuccess).Order("id").Find(&definitions).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve history to retain: %v", err)
+		return nil, fmt.Errorf("failed to retrieve history to retain: %w", err)
+	}
+	for _, id := range unfinished {
+		retained[id] = true
+	}
+
+	views, routines := map[string]uint{}, map[string]uint{}
+	for _, schemaVersion := range definitions {
+		changes, err := schemaVersion.ParseChanges()
+		if err != nil {
+			return nil, err
+		}
+		if schemaVersion.Kind == KindView {
+			for _, change := range changes.Views {
+				views[change.Name] = schemaVersion.ID
+			}
+			continue
+		}
+		for _, change := range changes.Routines {
+			routines[change.Name] = schemaVersion.ID
+		}
+	}
+	for _, id := range views {
+		retained[id] = true
+	}
+	for _, id := range routines {
+		retained[id] = true
+	}
+	return retained, nil
+}
+
+// archive copies the given history records into the archive table
+func (p *AutoMigratePlugin) archive(db *gorm.DB, ids []uint) error {
+	table := p.Retention.ArchiveTable
+	if err := untrackedMigrator(db.Table(table)).AutoMigrate(&SchemaVersion{}); err != nil {
+		return fmt.Errorf("failed to create archive table %s: %w", table, err)
+	}
+
+	history, stored, err := p.storedHistory(db)
+	if stored {
+		history = filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+			return slices.Contains(ids, schemaVersion.ID)
+		})
+	} else {
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("id IN ?", ids).Find(&history).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve history to archive: %w", err)
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Table(table).CreateInBatches(history, 100).Error; err != nil {
+		return fmt.Errorf("failed to archive history to %s: %w", table, err)
+	}
+	p.Logger.Debug("Archived %d history records to %s", len(history), table)
+	return nil
+}
@@ -0,0 +1,143 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedVersions records the versions in order, as successful migrations unless they say
+// otherwise, applied a day apart until a day ago
+func seedVersions(t *testing.T, db *gorm.DB, versions ...SchemaVersion) {
+	t.Helper()
+	for i := range versions {
+		if versions[i].Kind == "" {
+			versions[i].Kind = KindMigration
+		}
+		if versions[i].Status == "" {
+			versions[i].Status = StatusSuccess
+		}
+		if versions[i].Changes == "" {
+			versions[i].Changes = "{}"
+		}
+		versions[i].AppliedAt = time.Now().AddDate(0, 0, i-len(versions))
+		if err := historyDB(db).Create(&versions[i]).Error; err != nil {
+			t.Fatalf("failed to seed version %s: %v", versions[i].Version, err)
+		}
+	}
+}
+
+// recordedVersionsOf returns the sorted versions recorded on db
+func recordedVersionsOf(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var versions []string
+	for _, schemaVersion := range mustHistory(t, db) {
+		versions = append(versions, schemaVersion.Version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+func TestPrune(t *testing.T) {
+	db, _ := openTestDB(t)
+	seedVersions(t, db,
+		SchemaVersion{Version: "1"},
+		SchemaVersion{Version: "2", Status: StatusFailed},
+		SchemaVersion{Version: "3"},
+		SchemaVersion{Version: "4"},
+		SchemaVersion{Version: "5"},
+	)
+
+	pruned, err := Prune(db, 2)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if pruned != 3 {
+		t.Errorf("pruned %d records, want 3", pruned)
+	}
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, []string{"4", "5"}) {
+		t.Errorf("versions after pruning = %v, want [4 5]", versions)
+	}
+
+	if _, err := Prune(db, -1); err == nil {
+		t.Error("Prune with a negative count succeeded")
+	}
+}
+
+func TestPruneRetainsCurrentAndUnfinished(t *testing.T) {
+	db, _ := openTestDB(t)
+	views, err := encodeChangeSet(&ChangeSet{Views: []ViewChange{{Name: "active_users", Kind: ViewCreated}}})
+	if err != nil {
+		t.Fatalf("encodeChangeSet: %v", err)
+	}
+	replacedViews, err := encodeChangeSet(&ChangeSet{Views: []ViewChange{{Name: "active_users", Kind: ViewReplaced}}})
+	if err != nil {
+		t.Fatalf("encodeChangeSet: %v", err)
+	}
+	routines, err := encodeChangeSet(&ChangeSet{Routines: []RoutineChange{{Name: "touch_updated_at", Kind: RoutineCreated}}})
+	if err != nil {
+		t.Fatalf("encodeChangeSet: %v", err)
+	}
+	seedVersions(t, db,
+		// The highest version was recorded first
+		SchemaVersion{Version: "9"},
+		SchemaVersion{Version: "1", Status: StatusAwaitingApproval},
+		SchemaVersion{Version: "2", Status: StatusPending},
+		SchemaVersion{Version: "view-1", Kind: KindView, Changes: views},
+		SchemaVersion{Version: "view-2", Kind: KindView, Changes: replacedViews},
+		SchemaVersion{Version: "3", Changes: routines},
+		SchemaVersion{Version: "4", Status: StatusRolledBack},
+		SchemaVersion{Version: "5"},
+	)
+
+	if _, err := Prune(db, 1); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	// The current version, the unfinished records and the last change of the view and the
+	// routine are kept
+	want := []string{"1", "2", "3", "5", "9", "view-2"}
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, want) {
+		t.Errorf("versions after pruning = %v, want %v", versions, want)
+	}
+}
+
+func TestPruneOlderThan(t *testing.T) {
+	db, _ := openTestDB(t)
+	seedVersions(t, db,
+		SchemaVersion{Version: "1"},
+		SchemaVersion{Version: "2"},
+		SchemaVersion{Version: "3"},
+	)
+
+	// Seeded 3, 2 and 1 days ago
+	pruned, err := PruneOlderThan(db, 36*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneOlderThan: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d records, want 2", pruned)
+	}
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, []string{"3"}) {
+		t.Errorf("versions after pruning = %v, want [3]", versions)
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	db, _ := openTestDB(t, WithRetention(RetentionPolicy{KeepLast: 2, ArchiveTable: "schema_versions_archive"}))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	migrateAs(t, db, "3", &squashedOrder{})
+
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, []string{"2", "3"}) {
+		t.Errorf("versions after AutoMigrate = %v, want the last 2", versions)
+	}
+	var archived []SchemaVersion
+	if err := db.Table("schema_versions_archive").Find(&archived).Error; err != nil {
+		t.Fatalf("failed to read the archive: %v", err)
+	}
+	if len(archived) != 1 || archived[0].Version != "1" || archived[0].Statements == "" {
+		t.Errorf("archived %v, want version 1 with its statements", archived)
+	}
+}
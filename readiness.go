@@ -0,0 +1,115 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaTooOld is returned by Initialize when the database is older than RequiredVersion
+var ErrSchemaTooOld = errors.New("schema version is older than required")
+
+// checkRequiredVersion fails when the current schema version is older than RequiredVersion
+func (p *AutoMigratePlugin) checkRequiredVersion(db *gorm.DB) error {
+	p.Logger.Debug("Checking schema version against required version %s", p.RequiredVersion)
+	current, err := p.currentVersion(db.Session(&gorm.Session{NewDB: true}))
+	if errors.Is(err, ErrVersionNotFound) {
+		p.Logger.Error("No schema version recorded, %s required", p.RequiredVersion)
+		return fmt.Errorf("%w: no schema version recorded, %s required", ErrSchemaTooOld, p.RequiredVersion)
+	}
+	if err != nil {
+		p.Logger.Error("Failed to check required schema version: %v", err)
+		return err
+	}
+	if p.CompareVersions(current.Version, p.RequiredVersion) < 0 {
+		p.Logger.Error("Schema version %s is older than required version %s", current.Version, p.RequiredVersion)
+		return fmt.Errorf("%w: database is at %s, %s required", ErrSchemaTooOld, current.Version, p.RequiredVersion)
+	}
+	p.Logger.Info("Schema version %s satisfies required version %s", current.Version, p.RequiredVersion)
+	return nil
+}
+
+// ReadinessResponse is the JSON document served by ReadinessHandler
+type ReadinessResponse struct {
+	Ready           bool   `json:"ready"`
+	CurrentVersion  string `json:"current_version,omitempty"`
+	RequiredVersion string `json:"required_version"`
+	Error           string `json:"error,omitempty"`
+}
+
+// schemaAtLeast reports whether the current schema version is at or above minVersion, and
+// returns the current version
+func schemaAtLeast(db *gorm.DB, minVersion string) (bool, string, error) {
+	current, err := currentVersion(db)
+	if errors.Is(err, ErrVersionNotFound) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return registeredPlugin(db).CompareVersions(current.Version, minVersion) >= 0, current.Version, nil
+}
+
+// WaitForVersion blocks until the current schema version is at or above minVersion, polling
+// every DefaultPollInterval, or until ctx is done
+func WaitForVersion(ctx context.Context, db *gorm.DB, minVersion string) error {
+	logger := loggerFrom(db)
+	logger.Debug("WaitForVersion function called for %s", minVersion)
+
+	db = db.WithContext(ctx)
+	ticker := time.NewTicker(DefaultPollInterval)
+	defer ticker.Stop()
+	for {
+		ready, current, err := schemaAtLeast(db, minVersion)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to check schema version: %v", err)
+		}
+		if ready {
+			logger.Info("Schema version %s reached required version %s", current, minVersion)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// readinessHandler reports whether the schema reached the required version
+type readinessHandler struct {
+	db         *gorm.DB
+	minVersion string
+}
+
+// ReadinessHandler returns an http.Handler for Kubernetes readiness probes, answering
+// 503 Service Unavailable until the current schema version is at or above minVersion
+func ReadinessHandler(db *gorm.DB, minVersion string) http.Handler {
+	return &readinessHandler{db: db, minVersion: minVersion}
+}
+
+// ServeHTTP implements http.Handler
+func (h *readinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ready, current, err := schemaAtLeast(h.db.WithContext(r.Context()), h.minVersion)
+	response := ReadinessResponse{Ready: ready, CurrentVersion: current, RequiredVersion: h.minVersion}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
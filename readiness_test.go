@@ -0,0 +1,104 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestWaitForVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "2", &pluginUser{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := WaitForVersion(ctx, db, "1"); err != nil {
+		t.Errorf("WaitForVersion of a reached version = %v, want nil", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := WaitForVersion(ctx, db, "3"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForVersion of a pending version = %v, want the deadline exceeded", err)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	db, _ := openTestDB(t)
+	server := httptest.NewServer(ReadinessHandler(db, "2"))
+	defer server.Close()
+	probe := func() (int, ReadinessResponse) {
+		t.Helper()
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		var response ReadinessResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode the readiness response: %v", err)
+		}
+		return resp.StatusCode, response
+	}
+
+	if status, response := probe(); status != http.StatusServiceUnavailable || response.Ready || response.CurrentVersion != "" {
+		t.Errorf("probe of an empty history = %d %+v, want 503", status, response)
+	}
+	migrateAs(t, db, "1", &pluginUser{})
+	if status, response := probe(); status != http.StatusServiceUnavailable || response.CurrentVersion != "1" {
+		t.Errorf("probe at version 1 = %d %+v, want 503", status, response)
+	}
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	if status, response := probe(); status != http.StatusOK || !response.Ready || response.RequiredVersion != "2" {
+		t.Errorf("probe at version 2 = %d %+v, want 200", status, response)
+	}
+
+	resp, err := http.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestRequiredVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, _ := openTestDBAt(t, path)
+
+	open := func(required string) error {
+		t.Helper()
+		conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			t.Fatalf("failed to open SQLite database: %v", err)
+		}
+		t.Cleanup(func() {
+			if sqlDB, err := conn.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		return conn.Use(NewAutoMigratePlugin(WithQuiet(true), WithRequiredVersion(required)))
+	}
+
+	if err := open("2"); !errors.Is(err, ErrSchemaTooOld) {
+		t.Errorf("Initialize with an empty history = %v, want ErrSchemaTooOld", err)
+	}
+	migrateAs(t, db, "1", &pluginUser{})
+	if err := open("2"); !errors.Is(err, ErrSchemaTooOld) {
+		t.Errorf("Initialize at version 1 = %v, want ErrSchemaTooOld", err)
+	}
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	if err := open("2"); err != nil {
+		t.Errorf("Initialize at the required version = %v, want nil", err)
+	}
+}
@@ -0,0 +1,164 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ReconstructSchema returns the schema as it existed at a version. The snapshot recorded with
+// the version is returned when there is one, otherwise the change sets recorded since the
+// closest earlier snapshot, or since the first version, are replayed onto it. Replayed change
+// sets only describe columns, so indexes and primary keys added since the snapshot are missing.
+func ReconstructSchema(db *gorm.DB, atVersion string) (*SchemaSnapshot, error) {
+	logger := loggerFrom(db)
+	logger.Debug("ReconstructSchema function called for %s", atVersion)
+
+	target, err := findVersion(db, atVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var base SchemaVersion
+	history, stored, err := registeredPlugin(db).storedHistory(db)
+	if stored {
+		for _, schemaVersion := range history {
+			if schemaVersion.ID <= target.ID && schemaVersion.Applied() && schemaVersion.Snapshot != "" {
+				base = schemaVersion
+			}
+		}
+	} else {
+		err = historyDB(db).Where("id <= ? AND kind IN ? AND status = ? AND snapshot <> ''", target.ID, appliedKinds, StatusSuccess).
+			Order("id desc").Limit(1).Find(&base).Error
+	}
+	if err != nil {
+		logger.Error("Failed to retrieve the snapshot preceding %s: %v", atVersion, err)
+		return nil, fmt.Errorf("failed to retrieve the snapshot preceding %s: %w", atVersion, err)
+	}
+
+	snapshot := &SchemaSnapshot{}
+	if base.ID != 0 {
+		if snapshot, err = base.ParseSnapshot(); err != nil {
+			return nil, err
+		}
+		if base.ID == target.ID {
+			return snapshot, nil
+		}
+		logger.Debug("Replaying change sets onto the snapshot of %s", base.Version)
+	}
+
+	var replayed []SchemaVersion
+	if stored {
+		replayed = filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+			return schemaVersion.ID > base.ID && schemaVersion.ID <= target.ID && schemaVersion.Applied()
+		})
+	} else {
+		err = historyDB(db).Where("id > ? AND id <= ? AND kind IN ? AND status = ?", base.ID, target.ID, appliedKinds, StatusSuccess).
+			Order("id").Find(&replayed).Error
+	}
+	if err != nil {
+		logger.Error("Failed to retrieve versions up to %s: %v", atVersion, err)
+		return nil, fmt.Errorf("failed to retrieve versions up to %s: %w", atVersion, err)
+	}
+	for _, schemaVersion := range replayed {
+		changes, err := schemaVersion.ParseChanges()
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range changes.Models {
+			for _, column := range model.Columns {
+				snapshot.apply(column)
+			}
+		}
+	}
+
+	logger.Info("Reconstructed %d tables at %s from %d change sets", len(snapshot.Tables), atVersion, len(replayed))
+	return snapshot, nil
+}
+
+// apply replays a column change onto the snapshot, creating the table on its first column
+func (s *SchemaSnapshot) apply(diff ColumnDiff) {
+	table, ok := s.Table(diff.Table)
+	if !ok {
+		if diff.Kind != ColumnAdded {
+			return
+		}
+		s.Tables = append(s.Tables, TableSchema{Name: diff.Table, Exists: true})
+		table = &s.Tables[len(s.Tables)-1]
+	}
+
+	name := diff.Column
+	if diff.Kind == ColumnRenamed {
+		name = diff.OldName
+	}
+	for i := range table.Columns {
+		if !strings.EqualFold(table.Columns[i].Name, name) {
+			continue
+		}
+		switch diff.Kind {
+		case ColumnRenamed:
+			table.Columns[i].Name = diff.Column
+		case ColumnDropped:
+			table.Columns = append(table.Columns[:i], table.Columns[i+1:]...)
+		case ColumnTypeChanged:
+			table.Columns[i].Type = diff.NewType
+		case ColumnNullableChanged:
+			table.Columns[i].Nullable = diff.NewNullable
+		}
+		return
+	}
+	if diff.Kind == ColumnAdded {
+		table.Columns = append(table.Columns, ColumnSchema{Name: diff.Column, Type: diff.NewType, Nullable: diff.NewNullable})
+	}
+}
+
+// CreateStatements renders the DDL creating every table and index of the snapshot, e.g. to
+// rebuild a reconstructed schema in a scratch database
+func (s *SchemaSnapshot) CreateStatements(db *gorm.DB) []string {
+	var statements []string
+	for _, table := range s.Tables {
+		definitions := make([]string, 0, len(table.Columns)+1)
+		var primaryKey []string
+		for _, column := range table.Columns {
+			definition := quote(db, column.Name) + " " + column.Type
+			if !column.Nullable && !column.PrimaryKey {
+				definition += " NOT NULL"
+			}
+			definitions = append(definitions, definition)
+			if column.PrimaryKey {
+				primaryKey = append(primaryKey, quote(db, column.Name))
+			}
+		}
+		if len(primaryKey) > 0 {
+			definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ",")))
+		}
+		statements = append(statements, fmt.Sprintf("CREATE TABLE %s (%s)", quote(db, table.Name), strings.Join(definitions, ",")))
+		for _, index := range table.Indexes {
+			statements = append(statements, createIndexSQL(db, table.Name, index))
+		}
+	}
+	return statements
+}
+
+// RebuildSchema creates every table and index of the snapshot on db, a scratch database, in
+// a single transaction where the dialect supports transactional DDL
+func RebuildSchema(db *gorm.DB, snapshot *SchemaSnapshot) error {
+	logger := loggerFrom(db)
+	logger.Debug("RebuildSchema function called for %d tables", len(snapshot.Tables))
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range snapshot.CreateStatements(tx) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to apply %q: %w", statement, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to rebuild schema: %v", err)
+		return err
+	}
+	logger.Info("Rebuilt %d tables", len(snapshot.Tables))
+	return nil
+}
@@ -0,0 +1,73 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReconstructSchema(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{}, &squashedOrder{})
+
+	schema, err := ReconstructSchema(db, "1")
+	if err != nil {
+		t.Fatalf("ReconstructSchema: %v", err)
+	}
+	if len(schema.Tables) != 1 || !slices.Equal(tableColumns(t, schema, "users"), []string{"id", "name"}) {
+		t.Errorf("schema at 1 = %v, want the users table without its email", schema.Tables)
+	}
+
+	if _, err := ReconstructSchema(db, "3"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("ReconstructSchema at an unknown version = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestReconstructSchemaReplaysChanges(t *testing.T) {
+	db, _ := openTestDB(t, WithSnapshots(false))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{}, &squashedOrder{})
+
+	// Without snapshots the change sets are replayed from the first version
+	schema, err := ReconstructSchema(db, "2")
+	if err != nil {
+		t.Fatalf("ReconstructSchema: %v", err)
+	}
+	if len(schema.Tables) != 2 {
+		t.Fatalf("schema at 2 = %v, want the users and orders tables", schema.Tables)
+	}
+	if columns := tableColumns(t, schema, "users"); !slices.Equal(columns, []string{"id", "name", "email"}) {
+		t.Errorf("users columns at 2 = %v, want id, name and email", columns)
+	}
+	if _, ok := schema.Table("squashed_orders"); !ok {
+		t.Error("the orders table created by 2 wasn't replayed")
+	}
+}
+
+func TestRebuildSchema(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	schema, err := ReconstructSchema(db, "1")
+	if err != nil {
+		t.Fatalf("ReconstructSchema: %v", err)
+	}
+	scratch, _ := openTestDB(t)
+	if err := RebuildSchema(scratch, schema); err != nil {
+		t.Fatalf("RebuildSchema: %v", err)
+	}
+	if !scratch.Migrator().HasColumn(&pluginUser{}, "name") || scratch.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the rebuilt users table doesn't match version 1")
+	}
+
+	// A rebuilt schema is the schema that was snapshotted
+	rebuilt, err := TakeSnapshot(scratch)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	if diffs := diffTables(scratch.Migrator(), &schema.Tables[0], &rebuilt.Tables[0]); len(diffs) != 0 {
+		t.Errorf("the rebuilt users table differs from the snapshot: %v", diffs)
+	}
+}
@@ -0,0 +1,200 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// renamedFromTag is the gorm tag setting naming the column a field was renamed from, as in
+// `gorm:"renamedFrom:user_name"`. AutoMigrate renames the column instead of adding a new one.
+const renamedFromTag = "RENAMEDFROM"
+
+// columnRename is a live column renamed to match its model
+type columnRename struct {
+	from string
+	to   string
+}
+
+// renamedColumn returns the old column a new column was renamed from, if it is still present
+// in the old table and gone from the new one
+func renamedColumn(old, new *TableSchema, column ColumnSchema) (ColumnSchema, bool) {
+	if column.RenamedFrom == "" {
+		return ColumnSchema{}, false
+	}
+	if _, ok := new.column(column.RenamedFrom); ok {
+		return ColumnSchema{}, false
+	}
+	return old.column(column.RenamedFrom)
+}
+
+// detectRenames pairs the live columns the model no longer declares with the model columns
+// missing from the live table, by the renamedFrom tag setting or, with DetectRenames, by a
+// similar name and the same type. Every pair is marked as renamed on target.
+func (p *AutoMigratePlugin) detectRenames(migrator gorm.Migrator, live, target *TableSchema) []columnRename {
+	if live == nil || target == nil || !live.Exists {
+		return nil
+	}
+
+	var dropped []ColumnSchema
+	for _, column := range live.Columns {
+		if _, ok := target.column(column.Name); !ok {
+			dropped = append(dropped, column)
+		}
+	}
+	var added []int
+	for i, column := range target.Columns {
+		if _, ok := live.column(column.Name); !ok {
+			added = append(added, i)
+		}
+	}
+
+	var renames []columnRename
+	claimed := map[string]bool{}
+	var unmatched []int
+	for _, i := range added {
+		if previous, ok := renamedColumn(live, target, target.Columns[i]); ok && !claimed[previous.Name] {
+			claimed[previous.Name] = true
+			target.Columns[i].RenamedFrom = previous.Name
+			renames = append(renames, columnRename{from: previous.Name, to: target.Columns[i].Name})
+			continue
+		}
+		target.Columns[i].RenamedFrom = ""
+		unmatched = append(unmatched, i)
+	}
+	if !p.DetectRenames {
+		return renames
+	}
+
+	// Only pair columns having a single candidate either way, ambiguous matches are left as
+	// dropped and added
+	candidates := func(column ColumnSchema, columns []ColumnSchema) []ColumnSchema {
+		var matches []ColumnSchema
+		for _, candidate := range columns {
+			if !claimed[candidate.Name] && !candidate.PrimaryKey && !column.PrimaryKey &&
+				sameType(migrator, candidate.Type, column.Type) && similarNames(candidate.Name, column.Name) {
+				matches = append(matches, candidate)
+			}
+		}
+		return matches
+	}
+	var addedColumns []ColumnSchema
+	for _, i := range unmatched {
+		addedColumns = append(addedColumns, target.Columns[i])
+	}
+	for _, i := range unmatched {
+		column := target.Columns[i]
+		matches := candidates(column, dropped)
+		if len(matches) != 1 || len(candidates(matches[0], addedColumns)) != 1 {
+			continue
+		}
+		claimed[matches[0].Name] = true
+		target.Columns[i].RenamedFrom = matches[0].Name
+		renames = append(renames, columnRename{from: matches[0].Name, to: column.Name})
+	}
+	return renames
+}
+
+// markRenames marks the renamed columns of a table inspected after AutoMigrate
+func markRenames(table *TableSchema, renames []columnRename) {
+	if table == nil {
+		return
+	}
+	for _, rename := range renames {
+		for i := range table.Columns {
+			if strings.EqualFold(table.Columns[i].Name, rename.to) {
+				table.Columns[i].RenamedFrom = rename.from
+			}
+		}
+	}
+}
+
+// renameColumns renames the live columns of a model before AutoMigrate, so it doesn't add
+// them again under their new name
+func (p *AutoMigratePlugin) renameColumns(migrator gorm.Migrator, model interface{}, renames []columnRename) error {
+	for _, rename := range renames {
+		p.Logger.Info("Renaming column %s of %s to %s", rename.from, modelName(model), rename.to)
+		if err := migrator.RenameColumn(model, rename.from, rename.to); err != nil {
+			p.Logger.Error("Failed to rename column %s of %s: %v", rename.from, modelName(model), err)
+			return fmt.Errorf("failed to rename column %s of %s: %w", rename.from, modelName(model), err)
+		}
+	}
+	return nil
+}
+
+// similarNames reports whether two column names likely name the same data, ignoring case and
+// underscores: one contains the other or they are a few edits apart
+func similarNames(a, b string) bool {
+	a = strings.ReplaceAll(strings.ToLower(a), "_", "")
+	b = strings.ReplaceAll(strings.ToLower(b), "_", "")
+	if a == "" || b == "" {
+		return false
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+	return editDistance(a, b)*3 <= max(len(a), len(b))
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// isAddColumn reports whether a statement adds the given column, as AutoMigrate does for a
+// column it doesn't know was renamed
+func isAddColumn(sql, column string) bool {
+	fields := strings.Fields(sql)
+	for i := 0; i+1 < len(fields); i++ {
+		if !strings.EqualFold(fields[i], "ADD") {
+			continue
+		}
+		name := fields[i+1]
+		if strings.EqualFold(name, "COLUMN") && i+2 < len(fields) {
+			name = fields[i+2]
+		}
+		return strings.HasPrefix(strings.ToUpper(fields[0]), "ALTER") && strings.EqualFold(unquoteIdentifier(name), column)
+	}
+	return false
+}
+
+// renameColumnSQL renders the statement renaming a column back, for down statements
+func renameColumnSQL(db *gorm.DB, table, from, to string) string {
+	if db.Dialector.Name() == "sqlserver" {
+		return fmt.Sprintf("EXEC sp_rename '%s.%s', '%s', 'COLUMN'", table, from, to)
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", quote(db, table), quote(db, from), quote(db, to))
+}
+
+// addsRenamedColumn reports whether a statement adds one of the columns renamed on the tables
+func addsRenamedColumn(sql string, tables []*TableSchema, renames [][]columnRename) bool {
+	table := statementTable(sql)
+	for i, renamed := range renames {
+		if tables[i] == nil || !strings.EqualFold(tables[i].Name, table) {
+			continue
+		}
+		for _, rename := range renamed {
+			if isAddColumn(sql, rename.to) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+type taggedRenameUser struct {
+	ID       uint
+	FullName string `gorm:"renamedFrom:name"`
+}
+
+func (taggedRenameUser) TableName() string { return "users" }
+
+type fullNameUser struct {
+	ID       uint
+	FullName string
+}
+
+func (fullNameUser) TableName() string { return "users" }
+
+func TestAutoMigrateRenamesTaggedColumns(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	if err := db.Create(&pluginUser{Name: "Ada"}).Error; err != nil {
+		t.Fatalf("failed to seed a user: %v", err)
+	}
+	migrateAs(t, db, "2", &taggedRenameUser{})
+
+	if db.Migrator().HasColumn(&pluginUser{}, "name") {
+		t.Error("the name column is still present")
+	}
+	var user taggedRenameUser
+	if err := db.First(&user).Error; err != nil || user.FullName != "Ada" {
+		t.Errorf("user = %+v, %v, want the name kept in full_name", user, err)
+	}
+
+	recorded := mustRecorded(t, db, "2")
+	if !strings.Contains(recorded.Statements, "RENAME COLUMN `name` TO `full_name`") || strings.Contains(recorded.Statements, "ADD `full_name`") {
+		t.Errorf("version 2 statements = %q, want the column renamed rather than added", recorded.Statements)
+	}
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	columns := changes.Models[0].Columns
+	if len(columns) != 1 || columns[0].Kind != ColumnRenamed || columns[0].OldName != "name" || columns[0].Column != "full_name" {
+		t.Errorf("recorded column changes = %v, want name renamed to full_name", columns)
+	}
+}
+
+func TestRenameDetection(t *testing.T) {
+	db, plugin := openTestDB(t, WithRenameDetection())
+	migrateAs(t, db, "1", &pluginUser{})
+
+	plan, err := plugin.Plan(db, &fullNameUser{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plan.Statements) != 1 || !strings.Contains(plan.Statements[0].SQL, "RENAME COLUMN `name` TO `full_name`") {
+		t.Errorf("planned statements = %v, want name renamed to full_name", plan.Statements)
+	}
+
+	// Without detection the column is dropped and added
+	plugin.DetectRenames = false
+	plan, err = plugin.Plan(db, &fullNameUser{})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	for _, statement := range plan.Statements {
+		if strings.Contains(statement.SQL, "RENAME") {
+			t.Errorf("planned %q without rename detection", statement.SQL)
+		}
+	}
+}
+
+func TestSimilarNames(t *testing.T) {
+	for _, test := range []struct {
+		a, b    string
+		similar bool
+	}{
+		{"name", "full_name", true},
+		{"user_name", "username", true},
+		{"adress", "address", true},
+		{"email", "age", false},
+	} {
+		if similar := similarNames(test.a, test.b); similar != test.similar {
+			t.Errorf("similarNames(%s, %s) = %t, want %t", test.a, test.b, similar, test.similar)
+		}
+	}
+}
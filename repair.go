@@ -0,0 +1,122 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// driftRepairContextKey is the context key under which RepairDrift passes the drift report
+// it corrects to the AutoMigrate it runs
+type driftRepairContextKey struct{}
+
+// RepairDrift detects drift between the live schema and the given models and runs
+// AutoMigrate for the drifted models only, recording it as a drift-repair version that
+// carries the drift report. Missing tables, columns and indexes are created, unmanaged
+// objects are left in place as they may belong to another application. It returns the
+// recorded version, or nil when no repairable drift was found.
+func RepairDrift(db *gorm.DB, models ...interface{}) (*SchemaVersion, error) {
+	p, ok := pluginFrom(db)
+	if !ok {
+		return nil, ErrPluginNotRegistered
+	}
+	p.Logger.Debug("RepairDrift function called for %d models", len(models))
+
+	report, err := detectDrift(db, p.Logger, p.historyTableName(db), models)
+	if err != nil {
+		p.Logger.Error("Failed to detect drift: %v", err)
+		return nil, fmt.Errorf("failed to detect drift: %w", err)
+	}
+	return p.repairDrift(db, report, models)
+}
+
+// PlanDriftRepair computes the statements RepairDrift would execute without applying them
+func (p *AutoMigratePlugin) PlanDriftRepair(db *gorm.DB, models ...interface{}) (*Plan, error) {
+	p.Logger.Debug("PlanDriftRepair method called")
+	report, err := detectDrift(db, p.Logger, p.historyTableName(db), models)
+	if err != nil {
+		p.Logger.Error("Failed to detect drift: %v", err)
+		return nil, fmt.Errorf("failed to detect drift: %w", err)
+	}
+
+	drifted, err := driftedModels(db, report, models)
+	if err != nil {
+		return nil, err
+	}
+	if len(drifted) == 0 {
+		return &Plan{Severity: SeverityInfo}, nil
+	}
+	return p.Plan(db, drifted...)
+}
+
+// repairDrift runs AutoMigrate for the models report found drifted
+func (p *AutoMigratePlugin) repairDrift(db *gorm.DB, report *DriftReport, models []interface{}) (*SchemaVersion, error) {
+	drifted, err := driftedModels(db, report, models)
+	if err != nil {
+		return nil, err
+	}
+	if unmanaged := len(report.UnmanagedTables) + len(report.UnmanagedColumns) + len(report.UnmanagedIndexes); unmanaged > 0 {
+		p.Logger.Warn("Leaving %d unmanaged schema objects in place", unmanaged)
+	}
+	if len(drifted) == 0 {
+		p.Logger.Info("No repairable drift found")
+		return nil, nil
+	}
+
+	p.Logger.Info("Repairing drift of %d models", len(drifted))
+	ctx := context.WithValue(contextFrom(db), driftRepairContextKey{}, report)
+	if err := db.WithContext(ctx).Migrator().AutoMigrate(drifted...); err != nil {
+		p.Logger.Error("Failed to repair drift: %v", err)
+		return nil, fmt.Errorf("failed to repair drift: %w", err)
+	}
+
+	var repaired SchemaVersion
+	history, stored, err := p.storedHistory(db)
+	if stored {
+		for _, schemaVersion := range history {
+			if schemaVersion.Kind == KindDriftRepair {
+				repaired = schemaVersion
+			}
+		}
+	} else {
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("kind = ?", KindDriftRepair).Order("id desc").Limit(1).Find(&repaired).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve drift repair version: %v", err)
+		return nil, fmt.Errorf("failed to retrieve drift repair version: %w", err)
+	}
+	if repaired.ID == 0 {
+		return nil, nil
+	}
+	p.Logger.Info("Repaired drift in schema version %s", repaired.Version)
+	return &repaired, nil
+}
+
+// driftedModels returns the models whose table is missing or lacks columns or indexes
+func driftedModels(db *gorm.DB, report *DriftReport, models []interface{}) ([]interface{}, error) {
+	drifted := map[string]bool{}
+	for _, table := range report.MissingTables {
+		drifted[table] = true
+	}
+	for _, objects := range [][]string{report.MissingColumns, report.MissingIndexes} {
+		for _, object := range objects {
+			if i := strings.LastIndex(object, "."); i >= 0 {
+				drifted[object[:i]] = true
+			}
+		}
+	}
+
+	var result []interface{}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %s: %w", modelName(model), err)
+		}
+		if drifted[stmt.Table] {
+			result = append(result, model)
+		}
+	}
+	return result, nil
+}
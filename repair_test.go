@@ -0,0 +1,69 @@
+package gorm_migrate_tracker
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRepairDrift(t *testing.T) {
+	db := driftedDatabase(t, filepath.Join(t.TempDir(), "test.db"))
+
+	plan, err := registeredPlugin(db).PlanDriftRepair(db, &pluginUser{}, &squashedOrder{})
+	if err != nil {
+		t.Fatalf("PlanDriftRepair: %v", err)
+	}
+	if len(plan.Statements) == 0 || db.Migrator().HasTable(&squashedOrder{}) {
+		t.Fatalf("plan = %v, want the repair planned without applying it", plan.Statements)
+	}
+
+	repaired, err := RepairDrift(db, &pluginUser{}, &squashedOrder{})
+	if err != nil {
+		t.Fatalf("RepairDrift: %v", err)
+	}
+	if repaired == nil || repaired.Kind != KindDriftRepair {
+		t.Fatalf("RepairDrift = %v, want a drift-repair version", repaired)
+	}
+	if !db.Migrator().HasTable(&squashedOrder{}) || !db.Migrator().HasColumn(&pluginUser{}, "name") {
+		t.Error("the missing table and column weren't created")
+	}
+	// Unmanaged objects may belong to another application
+	if !db.Migrator().HasTable("audit_log") || !db.Migrator().HasColumn(&pluginUser{}, "legacy") {
+		t.Error("the unmanaged objects were dropped")
+	}
+
+	recorded := mustRecorded(t, db, repaired.Version)
+	changes, err := recorded.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if changes.Drift == nil || !slices.Equal(changes.Drift.MissingTables, []string{"squashed_orders"}) {
+		t.Errorf("recorded drift = %+v, want the report that was repaired", changes.Drift)
+	}
+	if !strings.HasPrefix(changes.Summary(), "Repaired drift of ") {
+		t.Errorf("summary = %q, want the repair described", changes.Summary())
+	}
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != repaired.Version {
+		t.Errorf("GetCurrentVersion = %v, %v, want the repair", current, err)
+	}
+
+	// Nothing left to repair
+	if repaired, err := RepairDrift(db, &pluginUser{}, &squashedOrder{}); repaired != nil || err != nil {
+		t.Errorf("RepairDrift of a repaired schema = %v, %v, want nothing recorded", repaired, err)
+	}
+}
+
+func TestDriftRepairOnInitialize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	driftedDatabase(t, path)
+
+	db, _ := openTestDBAt(t, path, WithDriftCheck(&pluginUser{}, &squashedOrder{}), WithDriftRepair(true))
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Kind != KindDriftRepair {
+		t.Fatalf("history = %v, want the drift repaired", history)
+	}
+	if !db.Migrator().HasTable(&squashedOrder{}) {
+		t.Error("the missing table wasn't created")
+	}
+}
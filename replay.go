@@ -0,0 +1,91 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrDatabaseNotEmpty is returned by Replay when the database already holds tables
+var ErrDatabaseNotEmpty = errors.New("database is not empty")
+
+// ReplayContext is like Replay but cancels the statements when ctx is done
+func ReplayContext(ctx context.Context, db *gorm.DB, uptoVersion string) (int, error) {
+	return Replay(db.WithContext(ctx), uptoVersion)
+}
+
+// Replay executes the statements captured in the migration history, in the order they were
+// recorded, onto an empty database, reproducing the schema at uptoVersion, or at the latest
+// version when it's empty. The history is read from the plugin registered on db, so it can
+// come from the database being reproduced through HistoryConn or HistoryStore. Only the
+// successful migrations, drift repairs and views are replayed, and baselines only when Squash
+// consolidated statements into them, as Baseline doesn't capture the tables it adopts. It
+// returns how many versions were replayed.
+func Replay(db *gorm.DB, uptoVersion string) (int, error) {
+	p := registeredPlugin(db)
+	p.Logger.Debug("Replay function called for %s", uptoVersion)
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tables: %w", err)
+	}
+	for _, table := range tables {
+		if !isTrackerTable(db, p.historyTableName(db), table) && !isInternalTable(table) {
+			return 0, fmt.Errorf("%w: table %s exists", ErrDatabaseNotEmpty, table)
+		}
+	}
+
+	history, err := p.replayedVersions(db, uptoVersion)
+	if err != nil {
+		p.Logger.Error("Failed to retrieve versions to replay: %v", err)
+		return 0, err
+	}
+	for i, schemaVersion := range history {
+		if schemaVersion.Kind == KindBaseline && schemaVersion.Statements == "" {
+			p.Logger.Warn("Baseline %s captured no statements, the tables it adopted are missing", schemaVersion.Version)
+			continue
+		}
+		statements := splitStatements(schemaVersion.Statements)
+		p.Logger.Info("Replaying version %s (%d statements)", schemaVersion.Version, len(statements))
+		for _, statement := range statements {
+			if err := db.Session(&gorm.Session{NewDB: true}).Exec(statement).Error; err != nil {
+				p.Logger.Error("Failed to replay version %s: %v", schemaVersion.Version, err)
+				return i, fmt.Errorf("failed to replay version %s: %w", schemaVersion.Version, err)
+			}
+		}
+	}
+
+	p.Logger.Info("Replayed %d versions", len(history))
+	return len(history), nil
+}
+
+// replayedVersions returns the successful records up to a version, oldest first
+func (p *AutoMigratePlugin) replayedVersions(db *gorm.DB, uptoVersion string) ([]SchemaVersion, error) {
+	var upto uint
+	if uptoVersion != "" {
+		target, err := findVersion(db, uptoVersion)
+		if err != nil {
+			return nil, err
+		}
+		upto = target.ID
+	}
+
+	kinds := append([]string{KindView}, appliedKinds...)
+	history, stored, err := p.storedHistory(db)
+	if !stored && err == nil {
+		query := p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("kind IN ? AND status = ?", kinds, StatusSuccess)
+		if upto != 0 {
+			query = query.Where("id <= ?", upto)
+		}
+		err = query.Order("id").Find(&history).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve migration history: %w", err)
+	}
+	return filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+		replayed := schemaVersion.Applied() || (schemaVersion.Kind == KindView && schemaVersion.Status == StatusSuccess)
+		return replayed && (upto == 0 || schemaVersion.ID <= upto)
+	}), nil
+}
@@ -0,0 +1,45 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	source, _ := openTestDB(t)
+	migrateAs(t, source, "1", &pluginUser{})
+	migrateAs(t, source, "2", &squashedOrder{})
+	migrateAs(t, source, "3", &pluginUserWithEmail{})
+	if err := source.WithContext(ContextWithVersion(source.Statement.Context, "4")).AutoMigrate(&failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+
+	if _, err := Replay(source, ""); !errors.Is(err, ErrDatabaseNotEmpty) {
+		t.Fatalf("Replay onto the migrated database = %v, want ErrDatabaseNotEmpty", err)
+	}
+
+	// The empty database reads the history of the source
+	db, _ := openTestDB(t, WithHistoryConn(source))
+	replayed, err := Replay(db, "2")
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed != 2 {
+		t.Errorf("replayed %d versions, want 2", replayed)
+	}
+	if !db.Migrator().HasTable(&pluginUser{}) || !db.Migrator().HasTable(&squashedOrder{}) {
+		t.Error("the tables of versions 1 and 2 weren't created")
+	}
+	if db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the email column of version 3 was replayed")
+	}
+
+	// The failed version isn't replayed
+	db, _ = openTestDB(t, WithHistoryConn(source))
+	if replayed, err := Replay(db, ""); err != nil || replayed != 3 {
+		t.Fatalf("Replay = %d, %v, want the 3 successful versions", replayed, err)
+	}
+	if !db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") || db.Migrator().HasTable(&failingModel{}) {
+		t.Error("the schema doesn't match the latest successful version")
+	}
+}
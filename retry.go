@@ -0,0 +1,62 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RetryPolicy retries the insertion of history records that failed, such as on a deadlock
+// or a dropped connection right after the DDL was applied
+type RetryPolicy struct {
+	// Retries is the number of additional attempts made after a failed insertion
+	Retries int
+	// Backoff is the delay before the first retry, doubled for every following one
+	Backoff time.Duration
+	// MaxBackoff caps the delay between two attempts, it is uncapped when zero
+	MaxBackoff time.Duration
+}
+
+// retry calls insert until it succeeds, the retries run out, the error is permanent or
+// the context of db is done
+func (r RetryPolicy) retry(db *gorm.DB, logger Logger, insert func() error) error {
+	ctx := contextFrom(db)
+	backoff := r.Backoff
+	var err error
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		if attempt > 0 {
+			logger.Warn("Retrying history record in %s after failure: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+				backoff = r.MaxBackoff
+			}
+		}
+
+		if err = insert(); err == nil || !retryable(db, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryable reports whether an insertion may succeed when attempted again, duplicate keys
+// and cancelled contexts won't
+func retryable(db *gorm.DB, err error) bool {
+	err = translateError(db, err)
+	return !errors.Is(err, gorm.ErrDuplicatedKey) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// translateError translates a driver error to the gorm errors when the dialector supports it
+func translateError(db *gorm.DB, err error) error {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		return translator.Translate(err)
+	}
+	return err
+}
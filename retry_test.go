@@ -0,0 +1,100 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// failHistoryInserts makes the next n insertions into the history table fail like a
+// dropped connection
+func failHistoryInserts(t *testing.T, db *gorm.DB, n int32) *atomic.Int32 {
+	t.Helper()
+	remaining := &atomic.Int32{}
+	remaining.Store(n)
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_history", func(tx *gorm.DB) {
+		if tx.Statement.Table == "schema_versions" && remaining.Add(-1) >= 0 {
+			tx.AddError(errors.New("driver: bad connection"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to register the failing callback: %v", err)
+	}
+	return remaining
+}
+
+func TestRetryPolicy(t *testing.T) {
+	db, plugin := openTestDB(t)
+	policy := RetryPolicy{Retries: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	attempts := 0
+	err := policy.retry(db, plugin.Logger, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("deadlock detected")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("retry = %v after %d attempts, want success on the third", err, attempts)
+	}
+
+	// Permanent errors aren't retried
+	attempts = 0
+	err = policy.retry(db, plugin.Logger, func() error {
+		attempts++
+		return gorm.ErrDuplicatedKey
+	})
+	if !errors.Is(err, gorm.ErrDuplicatedKey) || attempts != 1 {
+		t.Errorf("retry of a duplicate key = %v after %d attempts, want a single attempt", err, attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	err = policy.retry(db.WithContext(ctx), plugin.Logger, func() error {
+		attempts++
+		return errors.New("deadlock detected")
+	})
+	if !errors.Is(err, context.Canceled) || attempts != 1 {
+		t.Errorf("retry with a cancelled context = %v after %d attempts, want it to stop", err, attempts)
+	}
+}
+
+func TestRecordRetry(t *testing.T) {
+	db, _ := openTestDB(t, WithRecordRetry(RetryPolicy{Retries: 2, Backoff: time.Millisecond}))
+	failHistoryInserts(t, db, 2)
+	migrateAs(t, db, "1", &pluginUser{})
+
+	if recorded := mustRecorded(t, db, "1"); recorded.Status != StatusSuccess {
+		t.Errorf("version 1 status = %s, want it recorded after retrying", recorded.Status)
+	}
+}
+
+func TestRecordRetryFallsBackToAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrations.jsonl")
+	db, _ := openTestDB(t, WithRecordRetry(RetryPolicy{Retries: 1, Backoff: time.Millisecond}), WithAuditFile(path))
+	failHistoryInserts(t, db, 2)
+	if err := db.WithContext(ContextWithVersion(db.Statement.Context, "1")).AutoMigrate(&pluginUser{}); err == nil {
+		t.Fatal("AutoMigrate succeeded without recording its version")
+	}
+
+	if history := mustHistory(t, db); len(history) != 0 {
+		t.Fatalf("history = %v, want nothing recorded", history)
+	}
+	// The audit file keeps the record along with the notification of the migration
+	var kept *MigrationEvent
+	for _, event := range auditEvents(t, path) {
+		if event.RecordError != "" {
+			kept = &event
+		}
+	}
+	if kept == nil || kept.Version != "1" || len(kept.Statements) == 0 {
+		t.Errorf("audit file kept %+v, want version 1 with its statements", kept)
+	}
+}
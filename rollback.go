@@ -0,0 +1,183 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionNotFound is returned when a requested schema version has not been recorded
+var ErrVersionNotFound = errors.New("schema version not found")
+
+// generateDownStatements builds the DDL that reverts the tables from their after state back to their before state
+func generateDownStatements(db *gorm.DB, before, after []*TableSchema) []string {
+	var statements []string
+	for i := len(after) - 1; i >= 0; i-- {
+		if i >= len(before) || before[i] == nil || after[i] == nil || !after[i].Exists {
+			continue
+		}
+		old, new := before[i], after[i]
+
+		if !old.Exists {
+			statements = append(statements, fmt.Sprintf("DROP TABLE %s", quote(db, new.Name)))
+			continue
+		}
+
+		for _, index := range new.Indexes {
+			if _, ok := old.index(index.Name); !ok {
+				statements = append(statements, dropIndexSQL(db, new.Name, index.Name))
+			}
+		}
+
+		for _, diff := range diffTables(db.Migrator(), old, new) {
+			switch diff.Kind {
+			case ColumnAdded:
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quote(db, diff.Table), quote(db, diff.Column)))
+			case ColumnRenamed:
+				statements = append(statements, renameColumnSQL(db, diff.Table, diff.Column, diff.OldName))
+			default:
+				// Reverting column definitions is dialect specific, leave a note for the operator
+				statements = append(statements, fmt.Sprintf("-- manual rollback required: %s", diff))
+			}
+		}
+
+		for _, index := range old.Indexes {
+			if _, ok := new.index(index.Name); !ok {
+				statements = append(statements, createIndexSQL(db, old.Name, index))
+			}
+		}
+	}
+	return statements
+}
+
+// quote quotes an identifier using the dialector of the connection
+func quote(db *gorm.DB, name string) string {
+	return db.Statement.Quote(clause.Table{Name: name})
+}
+
+// dropIndexSQL renders a DROP INDEX statement for the connected dialect
+func dropIndexSQL(db *gorm.DB, table, index string) string {
+	switch db.Dialector.Name() {
+	case "mysql", "sqlserver":
+		return fmt.Sprintf("DROP INDEX %s ON %s", quote(db, index), quote(db, table))
+	default:
+		return fmt.Sprintf("DROP INDEX %s", quote(db, index))
+	}
+}
+
+// createIndexSQL renders a CREATE INDEX statement restoring the given index
+func createIndexSQL(db *gorm.DB, table string, index IndexSchema) string {
+	columns := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		columns[i] = quote(db, column)
+	}
+
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, quote(db, index.Name), quote(db, table), strings.Join(columns, ","))
+}
+
+// splitStatements parses statements serialized by joinStatements
+func splitStatements(statements string) []string {
+	var result []string
+	for _, statement := range strings.Split(statements, ";\n") {
+		statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+		if statement != "" {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+// RollbackToContext is like RollbackTo but stops before reverting the next version once ctx
+// is done, a version interrupted midway is left in place by its transaction
+func RollbackToContext(ctx context.Context, db *gorm.DB, version string) error {
+	return RollbackTo(db.WithContext(ctx), version)
+}
+
+// RollbackTo reverts every migration applied after the given version by applying
+// their down statements in reverse order, marking the reverted history records with
+// StatusRolledBack. It holds the migration lock of the Locker while reverting, so it
+// doesn't race AutoMigrate on another instance.
+func RollbackTo(db *gorm.DB, version string) error {
+	logger := loggerFrom(db)
+	logger.Debug("RollbackTo function called for version %s", version)
+	p := registeredPlugin(db)
+	p.migrating.Lock()
+	defer p.migrating.Unlock()
+	if p.Locker != nil {
+		lock, _, err := p.acquireLock(db)
+		if err != nil {
+			logger.Error("Not rolling back to %s without the migration lock: %v", version, err)
+			return err
+		}
+		defer p.releaseLock(db, lock)
+	}
+
+	target, err := findVersion(db, version)
+	if err != nil {
+		if errors.Is(err, ErrVersionNotFound) {
+			logger.Warn("Schema version %s not found", version)
+		} else {
+			logger.Error("Failed to retrieve schema version %s: %v", version, err)
+		}
+		return err
+	}
+	if !target.Applied() {
+		logger.Error("Schema version %s wasn't applied, its status is %s", version, target.Status)
+		return fmt.Errorf("cannot roll back to version %s with status %s", version, target.Status)
+	}
+
+	history, stored, err := p.storedHistory(db)
+	if !stored && err == nil {
+		err = historyDB(db).Where("id > ?", target.ID).Order("id").Find(&history).Error
+	}
+	if err != nil {
+		logger.Error("Failed to retrieve migrations after %s: %v", version, err)
+		return fmt.Errorf("failed to retrieve migrations after %s: %w", version, err)
+	}
+	// Only the records whose DDL was applied are reverted, the failed, planned and observed
+	// ones are left in the history
+	newer := filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+		applied := schemaVersion.Applied() || (schemaVersion.Kind == KindView && schemaVersion.Status == StatusSuccess)
+		return applied && schemaVersion.ID > target.ID
+	})
+	slices.Reverse(newer)
+
+	for i := range newer {
+		schemaVersion := &newer[i]
+		if err := contextFrom(db).Err(); err != nil {
+			logger.Warn("Rollback to %s cancelled: %v", version, err)
+			return err
+		}
+		logger.Info("Rolling back schema version %s", schemaVersion.Version)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range splitStatements(schemaVersion.DownStatements) {
+				if strings.HasPrefix(statement, "--") {
+					logger.Warn("Skipping note in down statements: %s", statement)
+					continue
+				}
+				if err := tx.Exec(statement).Error; err != nil {
+					return fmt.Errorf("failed to apply down statement %q: %w", statement, err)
+				}
+			}
+			schemaVersion.Status = StatusRolledBack
+			p.sign(schemaVersion)
+			return p.updateVersion(tx, schemaVersion, "status", "signature")
+		})
+		if err != nil {
+			logger.Error("Failed to roll back schema version %s: %v", schemaVersion.Version, err)
+			return fmt.Errorf("failed to roll back schema version %s: %w", schemaVersion.Version, err)
+		}
+	}
+
+	logger.Info("Rolled back %d schema versions", len(newer))
+	return nil
+}
@@ -0,0 +1,84 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAutoMigrateRecordsDownStatements(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	if down := mustRecorded(t, db, "1").DownStatements; down != "DROP TABLE `users`;" {
+		t.Errorf("version 1 down statements = %q, want the table dropped", down)
+	}
+	if down := mustRecorded(t, db, "2").DownStatements; down != "ALTER TABLE `users` DROP COLUMN `email`;" {
+		t.Errorf("version 2 down statements = %q, want the email column dropped", down)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := []string{"CREATE TABLE `a` (`id` integer)", "CREATE INDEX `idx_a` ON `a`(`id`)"}
+	if got := splitStatements(joinStatements(statements)); !slices.Equal(got, statements) {
+		t.Errorf("splitStatements(joinStatements(%q)) = %q", statements, got)
+	}
+	if got := splitStatements(""); len(got) != 0 {
+		t.Errorf("splitStatements of no statements = %q", got)
+	}
+}
+
+func TestRollbackTo(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	if err := RollbackTo(db, "1"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the email column added by version 2 wasn't dropped")
+	}
+	if !db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("the table created by version 1 was dropped")
+	}
+
+	// The reverted record is kept for the audit trail but is no longer current
+	if status := mustRecorded(t, db, "2").Status; status != StatusRolledBack {
+		t.Errorf("version 2 status = %s, want %s", status, StatusRolledBack)
+	}
+	current, err := GetCurrentVersion(db)
+	if err != nil {
+		t.Fatalf("GetCurrentVersion: %v", err)
+	}
+	if current.Version != "1" {
+		t.Errorf("current version = %s, want 1", current.Version)
+	}
+
+	// The reverted change can be applied again
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+	if !db.Migrator().HasColumn(&pluginUserWithEmail{}, "email") {
+		t.Error("the email column wasn't added again")
+	}
+}
+
+func TestRollbackToUnappliedVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	failed := SchemaVersion{Version: "2", Kind: KindMigration, Status: StatusFailed, AppliedAt: time.Now()}
+	if err := historyDB(db).Create(&failed).Error; err != nil {
+		t.Fatalf("failed to seed version 2: %v", err)
+	}
+
+	if err := RollbackTo(db, "2"); err == nil {
+		t.Error("RollbackTo a failed version succeeded")
+	}
+	if err := RollbackTo(db, "3"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("RollbackTo an unknown version = %v, want ErrVersionNotFound", err)
+	}
+	if !db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("a refused rollback dropped the table of version 1")
+	}
+}
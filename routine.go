@@ -0,0 +1,129 @@
+package gorm_migrate_tracker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Types of routines
+const (
+	RoutineFunction  = "function"
+	RoutineTrigger   = "trigger"
+	RoutineProcedure = "procedure"
+)
+
+// Routine is a named block of SQL, such as a stored function or a trigger, AutoMigrate applies
+// after the models whenever it changed since it was last recorded. Its statements must be
+// idempotent, as in CREATE OR REPLACE FUNCTION or DROP TRIGGER IF EXISTS followed by
+// CREATE TRIGGER.
+type Routine struct {
+	Name string
+	// Type describes the routine, such as RoutineFunction or RoutineTrigger
+	Type string
+	// Statements are executed in order, each as a single statement
+	Statements []string
+	// DropStatements remove the routine, they are recorded as the down statements of a
+	// routine applied for the first time
+	DropStatements []string
+}
+
+// checksum hashes the statements of the routine
+func (r Routine) checksum() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", r.Type, r.Name)
+	for _, statement := range r.Statements {
+		b.WriteString(strings.TrimSpace(statement) + "\n")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// RoutineChange is a routine applied by AutoMigrate because it's new or its statements changed
+type RoutineChange struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type,omitempty"`
+	Kind       ChangeKind `json:"kind"`
+	Checksum   string     `json:"checksum"`
+	Statements []string   `json:"statements"`
+}
+
+// String renders the change as a single human readable line
+func (c RoutineChange) String() string {
+	routine := c.Type
+	if routine == "" {
+		routine = "routine"
+	}
+	return fmt.Sprintf("%s %s %s (checksum %.12s)", routine, c.Name, c.Kind, c.Checksum)
+}
+
+// applyRoutines executes the routines that are new or changed since they were last recorded,
+// after the models of a run were migrated, and keeps the changes for its change set
+func (p *AutoMigratePlugin) applyRoutines(db *gorm.DB, run *migrationRun) error {
+	if len(p.Routines) == 0 {
+		return nil
+	}
+
+	recorded, err := p.recordedRoutines(db)
+	if err != nil {
+		p.Logger.Error("Failed to retrieve recorded routines: %v", err)
+		return err
+	}
+	run.beginModel(-1)
+	for _, routine := range p.Routines {
+		checksum := routine.checksum()
+		previous, ok := recorded[routine.Name]
+		if ok && previous.Checksum == checksum {
+			continue
+		}
+		change := RoutineChange{Name: routine.Name, Type: routine.Type, Kind: RoutineCreated, Checksum: checksum, Statements: routine.Statements}
+		down := routine.DropStatements
+		if ok {
+			change.Kind, down = RoutineReplaced, previous.Statements
+		}
+
+		p.Logger.Info("Applying %s %s", routine.Type, routine.Name)
+		for _, statement := range routine.Statements {
+			if err := db.Exec(statement).Error; err != nil {
+				p.Logger.Error("Failed to apply %s %s: %v", routine.Type, routine.Name, err)
+				return fmt.Errorf("failed to apply %s %s: %w", routine.Type, routine.Name, err)
+			}
+		}
+		run.routines = append(run.routines, change)
+		run.down = slices.Concat(down, run.down)
+	}
+	return nil
+}
+
+// recordedRoutines returns the last successfully recorded change of every routine
+func (p *AutoMigratePlugin) recordedRoutines(db *gorm.DB) (map[string]RoutineChange, error) {
+	history, stored, err := p.storedHistory(db)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		err := p.historyDB(db).Where("kind IN ? AND status = ?", appliedKinds, StatusSuccess).Order("id").Find(&history).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve routine history: %w", err)
+		}
+	}
+
+	routines := map[string]RoutineChange{}
+	for _, schemaVersion := range history {
+		if !schemaVersion.Applied() {
+			continue
+		}
+		changes, err := schemaVersion.ParseChanges()
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes.Routines {
+			routines[change.Name] = change
+		}
+	}
+	return routines, nil
+}
@@ -0,0 +1,60 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoMigrateAppliesRoutines(t *testing.T) {
+	trigger := Routine{
+		Name: "users_trim_name",
+		Type: RoutineTrigger,
+		Statements: []string{
+			"DROP TRIGGER IF EXISTS users_trim_name",
+			"CREATE TRIGGER users_trim_name AFTER INSERT ON users BEGIN UPDATE users SET name = trim(name) WHERE id = NEW.id; END",
+		},
+		DropStatements: []string{"DROP TRIGGER IF EXISTS users_trim_name"},
+	}
+	db, plugin := openTestDB(t, WithRoutines(trigger))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	if err := db.Create(&pluginUser{Name: "  Ada  "}).Error; err != nil {
+		t.Fatalf("failed to create a user: %v", err)
+	}
+	var user pluginUser
+	if err := db.First(&user).Error; err != nil || user.Name != "Ada" {
+		t.Errorf("user = %+v, %v, want the name trimmed by the trigger", user, err)
+	}
+
+	created := mustRecorded(t, db, "1")
+	changes, err := created.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Routines) != 1 || changes.Routines[0].Kind != RoutineCreated || changes.Routines[0].Checksum != trigger.checksum() {
+		t.Errorf("routine changes = %v, want users_trim_name created", changes.Routines)
+	}
+	if !strings.Contains(created.DownStatements, "DROP TRIGGER IF EXISTS users_trim_name") {
+		t.Errorf("down statements = %q, want the trigger dropped", created.DownStatements)
+	}
+
+	// An unchanged routine isn't applied again
+	migrateAs(t, db, "2", &pluginUser{})
+	if changes, err := mustRecorded(t, db, "2").ParseChanges(); err != nil || len(changes.Routines) != 0 {
+		t.Errorf("routine changes of version 2 = %v, %v, want none", changes.Routines, err)
+	}
+
+	plugin.Routines[0].Statements = []string{trigger.Statements[0], strings.Replace(trigger.Statements[1], "trim(name)", "upper(trim(name))", 1)}
+	migrateAs(t, db, "3", &pluginUser{})
+	replaced := mustRecorded(t, db, "3")
+	changes, err = replaced.ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Routines) != 1 || changes.Routines[0].Kind != RoutineReplaced {
+		t.Errorf("routine changes of version 3 = %v, want users_trim_name replaced", changes.Routines)
+	}
+	if !strings.Contains(replaced.DownStatements, "SET name = trim(name)") {
+		t.Errorf("down statements = %q, want the previous trigger restored", replaced.DownStatements)
+	}
+}
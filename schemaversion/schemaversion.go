@@ -0,0 +1,140 @@
+// Package schemaversion embeds the schema a binary was built against and checks it against
+// the database at startup, catching a new binary deployed against a schema that wasn't
+// migrated yet. The expected schema is either injected with -ldflags, see LDFlags, or
+// written to a Go file of the application by Generate, usually from the database CI
+// migrated, as in
+//
+//	migrate-tracer -dsn ci.db -package app embed-version schema_version.go
+package schemaversion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"text/template"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/gorm"
+)
+
+// ErrSchemaMismatch is returned by Check when no migration of the expected models is recorded
+var ErrSchemaMismatch = errors.New("schema doesn't match the models the binary was built with")
+
+// Version and Checksum hold the expected schema injected at build time with
+//
+//	go build -ldflags "-X github.com/leodahal4/go-migrate-tracer/schemaversion.Version=... -X github.com/leodahal4/go-migrate-tracer/schemaversion.Checksum=..."
+var (
+	Version  string
+	Checksum string
+)
+
+// Expected is the schema a binary expects the database to be at
+type Expected struct {
+	// Version is the oldest schema version the binary runs against
+	Version string
+	// Checksum is the checksum AutoMigrate recorded for the models of the binary, it's
+	// independent of the versions, which usually differ between environments
+	Checksum string
+}
+
+// Embedded returns the expected schema injected with -ldflags
+func Embedded() Expected {
+	return Expected{Version: Version, Checksum: Checksum}
+}
+
+// FromDatabase returns the current schema version of a database and its checksum as the
+// expected schema
+func FromDatabase(db *gorm.DB) (Expected, error) {
+	current, err := tracker.GetCurrentVersion(db)
+	if err != nil {
+		return Expected{}, err
+	}
+	return Expected{Version: current.Version, Checksum: current.Checksum}, nil
+}
+
+// LDFlags renders the -X flags injecting the expected schema into Version and Checksum
+func LDFlags(expected Expected) string {
+	const pkg = "github.com/leodahal4/go-migrate-tracer/schemaversion"
+	return fmt.Sprintf("-X %s.Version=%s -X %s.Checksum=%s", pkg, expected.Version, pkg, expected.Checksum)
+}
+
+// file is the Go file written by Generate
+var file = template.Must(template.New("schemaversion").Parse(`// Code generated by migrate-tracer embed-version. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/leodahal4/go-migrate-tracer/schemaversion"
+
+// ExpectedSchema is the schema this binary was built against, see schemaversion.Check
+var ExpectedSchema = schemaversion.Expected{
+	Version:  {{printf "%q" .Expected.Version}},
+	Checksum: {{printf "%q" .Expected.Checksum}},
+}
+`))
+
+// Generate writes a Go file of package pkg declaring the expected schema as ExpectedSchema
+func Generate(w io.Writer, pkg string, expected Expected) error {
+	var b bytes.Buffer
+	if err := file.Execute(&b, map[string]interface{}{"Package": pkg, "Expected": expected}); err != nil {
+		return fmt.Errorf("failed to render schema version file: %w", err)
+	}
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format schema version file: %w", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+// WriteFile writes the file rendered by Generate to path
+func WriteFile(path, pkg string, expected Expected) error {
+	var b bytes.Buffer
+	if err := Generate(&b, pkg, expected); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// CheckContext is like Check but cancels the queries when ctx is done
+func CheckContext(ctx context.Context, db *gorm.DB, expected Expected) error {
+	return Check(db.WithContext(ctx), expected)
+}
+
+// Check fails when the database isn't at the expected schema. With a checksum, a successful
+// migration of the models the binary was built with must be recorded, otherwise the current
+// version must be at or above the expected one, see tracker.ErrSchemaTooOld. Nothing is
+// checked when nothing is expected, such as a development build without -ldflags.
+func Check(db *gorm.DB, expected Expected) error {
+	if expected == (Expected{}) {
+		return nil
+	}
+	current, err := tracker.GetCurrentVersion(db)
+	if errors.Is(err, tracker.ErrVersionNotFound) {
+		return fmt.Errorf("%w: no schema version recorded", ErrSchemaMismatch)
+	}
+	if err != nil {
+		return err
+	}
+
+	if expected.Checksum != "" {
+		history, err := tracker.GetMigrationHistory(db)
+		if err != nil {
+			return err
+		}
+		for _, schemaVersion := range history {
+			if schemaVersion.Applied() && schemaVersion.Checksum == expected.Checksum {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: no migration with checksum %.12s recorded, database is at %s", ErrSchemaMismatch, expected.Checksum, current.Version)
+	}
+
+	if tracker.CompareRecordedVersions(db, current.Version, expected.Version) < 0 {
+		return fmt.Errorf("%w: database is at %s, %s expected", tracker.ErrSchemaTooOld, current.Version, expected.Version)
+	}
+	return nil
+}
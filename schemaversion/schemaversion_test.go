@@ -0,0 +1,124 @@
+package schemaversion
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tracker "github.com/leodahal4/go-migrate-tracer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID   uint
+	Name string
+}
+
+type userWithEmail struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+func (userWithEmail) TableName() string { return "users" }
+
+// openTestDB opens a SQLite database in a temporary directory with the plugin registered
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.Use(tracker.NewAutoMigratePlugin(tracker.WithQuiet(true))); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	return db
+}
+
+// migrateAs runs AutoMigrate recording the run under version
+func migrateAs(t *testing.T, db *gorm.DB, version string, models ...interface{}) {
+	t.Helper()
+	if err := db.WithContext(tracker.ContextWithVersion(db.Statement.Context, version)).AutoMigrate(models...); err != nil {
+		t.Fatalf("AutoMigrate %s: %v", version, err)
+	}
+}
+
+func TestFromDatabase(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := FromDatabase(db); !errors.Is(err, tracker.ErrVersionNotFound) {
+		t.Fatalf("FromDatabase of an empty history = %v, want ErrVersionNotFound", err)
+	}
+
+	migrateAs(t, db, "1", &user{})
+	expected, err := FromDatabase(db)
+	if err != nil {
+		t.Fatalf("FromDatabase: %v", err)
+	}
+	if expected.Version != "1" || expected.Checksum == "" {
+		t.Errorf("expected = %+v, want version 1 with its checksum", expected)
+	}
+}
+
+func TestLDFlags(t *testing.T) {
+	flags := LDFlags(Expected{Version: "20240101000000", Checksum: "abc"})
+	want := "-X github.com/leodahal4/go-migrate-tracer/schemaversion.Version=20240101000000 -X github.com/leodahal4/go-migrate-tracer/schemaversion.Checksum=abc"
+	if flags != want {
+		t.Errorf("LDFlags = %q, want %q", flags, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	var b bytes.Buffer
+	if err := Generate(&b, "app", Expected{Version: "1", Checksum: "abc"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	source := b.String()
+	for _, want := range []string{"package app\n", `Version:  "1",`, `Checksum: "abc",`} {
+		if !strings.Contains(source, want) {
+			t.Errorf("generated file lacks %q:\n%s", want, source)
+		}
+	}
+
+	if err := Generate(&b, "not a package", Expected{}); err == nil {
+		t.Error("Generate of an invalid package name succeeded")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := openTestDB(t)
+	if err := Check(db, Expected{}); err != nil {
+		t.Errorf("Check without an expected schema = %v, want nil", err)
+	}
+	if err := Check(db, Expected{Version: "1"}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("Check of an empty history = %v, want ErrSchemaMismatch", err)
+	}
+
+	migrateAs(t, db, "1", &user{})
+	built, err := FromDatabase(db)
+	if err != nil {
+		t.Fatalf("FromDatabase: %v", err)
+	}
+	migrateAs(t, db, "2", &userWithEmail{})
+
+	// A database migrated past the binary's models still runs it, by version or checksum
+	for _, expected := range []Expected{{Version: "1"}, {Version: "2"}, built} {
+		if err := Check(db, expected); err != nil {
+			t.Errorf("Check(%+v) = %v, want nil", expected, err)
+		}
+	}
+	if err := Check(db, Expected{Version: "3"}); !errors.Is(err, tracker.ErrSchemaTooOld) {
+		t.Errorf("Check of a newer version = %v, want ErrSchemaTooOld", err)
+	}
+	if err := Check(db, Expected{Version: "1", Checksum: "unknown"}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("Check of an unknown checksum = %v, want ErrSchemaMismatch", err)
+	}
+}
@@ -0,0 +1,278 @@
+package gorm_migrate_tracker
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultSemverInitial is the version of the first migration recorded by a SemverVersionGenerator
+const DefaultSemverInitial = "1.0.0"
+
+// VersionBump is the part of a semantic version a migration increments
+type VersionBump int
+
+// Parts of a semantic version, from the least to the most significant
+const (
+	// BumpNone marks a migration that changed nothing, such as a no-op AutoMigrate or a drift
+	// record, which doesn't use up a version
+	BumpNone VersionBump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// String returns the name of the bumped part
+func (b VersionBump) String() string {
+	switch b {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	case BumpPatch:
+		return "patch"
+	default:
+		return "none"
+	}
+}
+
+// Bump classifies the change set: destructive changes other than index drops bump the major
+// version, added or altered tables, columns, constraints, enum types, views and routines
+// the minor one, changes limited to indexes, baselines and other explicit Migrator calls the
+// patch one, and an empty change set nothing
+func (c *ChangeSet) Bump() VersionBump {
+	bump := BumpNone
+	if c.Operation != "" || c.Baseline {
+		bump = BumpPatch
+	}
+	if len(c.Views) > 0 || len(c.Routines) > 0 {
+		bump = BumpMinor
+	}
+	for _, model := range c.Models {
+		for _, column := range model.Columns {
+			if column.Kind == ColumnDropped {
+				return BumpMajor
+			}
+		}
+		if len(model.Columns) > 0 || len(model.Constraints) > 0 || len(model.Enums) > 0 {
+			bump = BumpMinor
+		}
+		if len(model.Indexes) > 0 {
+			bump = max(bump, BumpPatch)
+		}
+	}
+	return max(bump, destructiveBump(c.Destructive))
+}
+
+// Bump classifies the plan like ChangeSet.Bump, from its statements
+func (p *Plan) Bump() VersionBump {
+	bump := destructiveBump(p.Destructive)
+	if bump == BumpMajor {
+		return bump
+	}
+	for _, statement := range p.Statements {
+		if !isIndexStatement(statement.SQL) {
+			return BumpMinor
+		}
+		bump = BumpPatch
+	}
+	return bump
+}
+
+// destructiveBump returns BumpMajor unless the destructive changes only drop indexes, which
+// lose no data and bump the patch version
+func destructiveBump(destructive []string) VersionBump {
+	bump := BumpNone
+	for _, change := range destructive {
+		if !isIndexStatement(change) {
+			return BumpMajor
+		}
+		bump = BumpPatch
+	}
+	return bump
+}
+
+// isIndexStatement reports whether a DDL statement only creates, drops or renames an index
+func isIndexStatement(sql string) bool {
+	upper := strings.Join(strings.Fields(strings.ToUpper(sql)), " ")
+	for _, prefix := range []string{"CREATE INDEX", "CREATE UNIQUE INDEX", "DROP INDEX", "ALTER INDEX"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(upper, "ALTER TABLE") && strings.Contains(upper, " RENAME INDEX ")
+}
+
+// planContextKey is the context key under which the plan a version is generated for is stored
+type planContextKey struct{}
+
+// SemverVersionGenerator generates semantic versions, incrementing the latest recorded one
+// according to the Bump of the migration: major for destructive changes, minor for added
+// tables and columns, patch for index only changes. Migrations that change nothing are
+// recorded under the latest version with a build number, as in 1.4.2+1, so the next
+// migration still bumps 1.4.2.
+type SemverVersionGenerator struct {
+	// Prefix precedes the versions, such as "v"
+	Prefix string
+	// Initial is the version of the first migration, DefaultSemverInitial when empty
+	Initial string
+}
+
+var _ VersionComparer = SemverVersionGenerator{}
+
+// GenerateVersion bumps the latest version of the history for the migration in flight on db
+func (g SemverVersionGenerator) GenerateVersion(db *gorm.DB, _ time.Time) (string, error) {
+	versions, err := recordedVersions(db)
+	if err != nil {
+		return "", err
+	}
+	var latest [3]int
+	found := false
+	for _, version := range versions {
+		if parsed, _, ok := g.parse(version); ok && (!found || compareSemver(parsed, latest) > 0) {
+			latest, found = parsed, true
+		}
+	}
+	if !found {
+		initial := g.Initial
+		if initial == "" {
+			initial = DefaultSemverInitial
+		}
+		return g.Prefix + strings.TrimPrefix(initial, g.Prefix), nil
+	}
+
+	bump := migrationBump(db)
+	loggerFrom(db).Debug("Bumping %s version of %d.%d.%d", bump, latest[0], latest[1], latest[2])
+	switch bump {
+	case BumpNone:
+		// Take the next free build number of the latest version
+		build := 0
+		for _, version := range versions {
+			if parsed, n, ok := g.parse(version); ok && parsed == latest {
+				build = max(build, n)
+			}
+		}
+		return fmt.Sprintf("%s%d.%d.%d+%d", g.Prefix, latest[0], latest[1], latest[2], build+1), nil
+	case BumpMajor:
+		latest = [3]int{latest[0] + 1, 0, 0}
+	case BumpMinor:
+		latest = [3]int{latest[0], latest[1] + 1, 0}
+	default:
+		latest[2]++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", g.Prefix, latest[0], latest[1], latest[2]), nil
+}
+
+// CompareVersions orders semantic versions numerically, then by build number, falling back to
+// CompareVersions for versions that don't parse
+func (g SemverVersionGenerator) CompareVersions(a, b string) int {
+	left, leftBuild, leftOk := g.parse(a)
+	right, rightBuild, rightOk := g.parse(b)
+	if !leftOk || !rightOk {
+		return CompareVersions(a, b)
+	}
+	if c := compareSemver(left, right); c != 0 {
+		return c
+	}
+	return cmp.Compare(leftBuild, rightBuild)
+}
+
+// parse splits a version of the generator into its major, minor and patch numbers and its
+// build number, zero when it has none
+func (g SemverVersionGenerator) parse(version string) ([3]int, int, bool) {
+	var parsed [3]int
+	version, build, hasBuild := strings.Cut(strings.TrimPrefix(version, g.Prefix), "+")
+	buildNumber := 0
+	if hasBuild {
+		n, err := strconv.Atoi(build)
+		if err != nil || n < 0 {
+			return parsed, 0, false
+		}
+		buildNumber = n
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return parsed, 0, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, 0, false
+		}
+		parsed[i] = n
+	}
+	return parsed, buildNumber, true
+}
+
+// compareSemver orders two parsed semantic versions
+func compareSemver(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// migrationBump classifies the migration in flight on db by its change set once it was
+// applied, or by its plan when the version is generated beforehand. It defaults to a patch
+// when neither is known, as for views.
+func migrationBump(db *gorm.DB) VersionBump {
+	if plan, ok := contextFrom(db).Value(planContextKey{}).(*Plan); ok {
+		return plan.Bump()
+	}
+	run, ok := runFromDB(db)
+	if !ok {
+		return BumpPatch
+	}
+	switch {
+	case run.changes != nil:
+		return run.changes.Bump()
+	case run.plan != nil:
+		return run.plan.Bump()
+	}
+	p, ok := pluginFrom(db)
+	if !ok {
+		return BumpPatch
+	}
+	plan, err := p.Plan(db, run.models...)
+	if err != nil {
+		p.Logger.Warn("Failed to plan the migration to bump its version: %v", err)
+		return BumpPatch
+	}
+	run.plan = plan
+	return plan.Bump()
+}
+
+// recordedVersions lists the versions of every record of the history of the plugin registered on db
+func recordedVersions(db *gorm.DB) ([]string, error) {
+	p := registeredPlugin(db)
+	history, stored, err := p.storedHistory(db)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	if stored {
+		for _, schemaVersion := range history {
+			versions = append(versions, schemaVersion.Version)
+		}
+		return versions, nil
+	}
+	if err := p.historyDB(db.Session(&gorm.Session{NewDB: true})).Model(&SchemaVersion{}).Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve schema versions: %w", err)
+	}
+	return versions, nil
+}
+
+// withPlan returns a session generating versions for the given plan
+func withPlan(db *gorm.DB, plan *Plan) *gorm.DB {
+	return db.WithContext(context.WithValue(contextFrom(db), planContextKey{}, plan))
+}
@@ -0,0 +1,119 @@
+package gorm_migrate_tracker
+
+import "testing"
+
+type semverBook struct {
+	ID    uint
+	Title string
+}
+
+type semverBookWithAuthor struct {
+	ID     uint
+	Title  string
+	Author string
+}
+
+func (semverBookWithAuthor) TableName() string { return "semver_books" }
+
+type semverBookIndexed struct {
+	ID     uint
+	Title  string
+	Author string `gorm:"index"`
+}
+
+func (semverBookIndexed) TableName() string { return "semver_books" }
+
+func TestChangeSetBump(t *testing.T) {
+	tests := []struct {
+		name      string
+		changeSet ChangeSet
+		want      VersionBump
+	}{
+		{"empty", ChangeSet{}, BumpNone},
+		{"operation", ChangeSet{Operation: "RenameIndex(a, b)"}, BumpPatch},
+		{"index", ChangeSet{Models: []ModelChange{{Indexes: []IndexDiff{{Kind: IndexCreated}}}}}, BumpPatch},
+		{"column added", ChangeSet{Models: []ModelChange{{Columns: []ColumnDiff{{Kind: ColumnAdded}}}}}, BumpMinor},
+		{"view", ChangeSet{Views: []ViewChange{{}}}, BumpMinor},
+		{"column dropped", ChangeSet{Models: []ModelChange{{Columns: []ColumnDiff{{Kind: ColumnAdded}, {Kind: ColumnDropped}}}}}, BumpMajor},
+		{"index dropped", ChangeSet{Destructive: []string{"DROP INDEX `idx_books_title`"}}, BumpPatch},
+		{"table dropped", ChangeSet{Destructive: []string{"DROP TABLE `books`"}}, BumpMajor},
+	}
+	for _, tt := range tests {
+		if got := tt.changeSet.Bump(); got != tt.want {
+			t.Errorf("Bump of %s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBump(t *testing.T) {
+	tests := []struct {
+		name string
+		plan *Plan
+		want VersionBump
+	}{
+		{"empty", newPlan(nil, nil), BumpNone},
+		{"index", newPlan([]string{"CREATE INDEX `idx_books_title` ON `books`(`title`)"}, nil), BumpPatch},
+		{"table", newPlan([]string{"CREATE INDEX `idx_a` ON `a`(`b`)", "CREATE TABLE `books` (`id` integer)"}, nil), BumpMinor},
+		{"column dropped", newPlan([]string{"ALTER TABLE `books` DROP COLUMN `title`"}, nil), BumpMajor},
+	}
+	for _, tt := range tests {
+		if got := tt.plan.Bump(); got != tt.want {
+			t.Errorf("Bump of %s plan = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSemverVersionGenerator(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(SemverVersionGenerator{Prefix: "v"}))
+	steps := []struct {
+		model interface{}
+		want  string
+	}{
+		{&semverBook{}, "v1.0.0"},
+		{&semverBookWithAuthor{}, "v1.1.0"},
+		{&semverBookWithAuthor{}, "v1.1.0+1"},
+		{&semverBookWithAuthor{}, "v1.1.0+2"},
+		{&semverBookIndexed{}, "v1.1.1"},
+	}
+	for _, step := range steps {
+		if err := db.AutoMigrate(step.model); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+		mustRecorded(t, db, step.want)
+	}
+
+	current, err := GetCurrentVersion(db)
+	if err != nil {
+		t.Fatalf("GetCurrentVersion: %v", err)
+	}
+	if current.Version != "v1.1.1" {
+		t.Errorf("current version = %s, want v1.1.1", current.Version)
+	}
+}
+
+func TestSemverVersionGeneratorInitial(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(SemverVersionGenerator{Initial: "0.1.0"}))
+	if err := db.AutoMigrate(&semverBook{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	mustRecorded(t, db, "0.1.0")
+}
+
+func TestSemverVersionGeneratorCompareVersions(t *testing.T) {
+	generator := SemverVersionGenerator{Prefix: "v"}
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.9.0", "v1.10.0", -1},
+		{"v1.1.0+1", "v1.1.0", 1},
+		{"v1.1.0+9", "v1.1.1", -1},
+		{"v2.0.0", "v2.0.0", 0},
+		{"v1.0", "v1.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := generator.CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
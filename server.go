@@ -0,0 +1,70 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ServerInfo identifies the database server and user a migration ran against
+type ServerInfo struct {
+	User    string `json:"user,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// ServerInspector queries the dialect specific details of a connection
+type ServerInspector interface {
+	Inspect(ctx context.Context, db *gorm.DB) (ServerInfo, error)
+}
+
+// QueryServerInspector inspects a connection with a query returning the user and the server
+// version as its two columns
+type QueryServerInspector struct {
+	Query string
+}
+
+// Inspect runs the query on db
+func (i QueryServerInspector) Inspect(ctx context.Context, db *gorm.DB) (ServerInfo, error) {
+	var user, version string
+	row := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Raw(i.Query).Row()
+	if err := row.Scan(&user, &version); err != nil {
+		return ServerInfo{}, fmt.Errorf("failed to inspect database server: %w", err)
+	}
+	return ServerInfo{User: user, Version: version}, nil
+}
+
+// DefaultServerInspector returns the inspector for the connected dialect, nil when the dialect
+// is not known
+func DefaultServerInspector(db *gorm.DB) ServerInspector {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return QueryServerInspector{Query: "SELECT current_user, version()"}
+	case "mysql":
+		return QueryServerInspector{Query: "SELECT CURRENT_USER(), VERSION()"}
+	case "sqlserver":
+		return QueryServerInspector{Query: "SELECT SUSER_SNAME(), @@VERSION"}
+	case "sqlite":
+		return QueryServerInspector{Query: "SELECT '', 'SQLite ' || sqlite_version()"}
+	default:
+		return nil
+	}
+}
+
+// serverInfo inspects the server of db with the configured or default inspector, failures
+// are logged and leave the details empty
+func (p *AutoMigratePlugin) serverInfo(db *gorm.DB) ServerInfo {
+	inspector := p.ServerInspector
+	if inspector == nil {
+		inspector = DefaultServerInspector(db)
+	}
+	if inspector == nil {
+		return ServerInfo{}
+	}
+
+	info, err := inspector.Inspect(contextFrom(db), db)
+	if err != nil {
+		p.Logger.Warn("Failed to inspect database server: %v", err)
+	}
+	return info
+}
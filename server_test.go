@@ -0,0 +1,47 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// staticInspector reports the same server details for every connection
+type staticInspector struct {
+	info ServerInfo
+	err  error
+}
+
+func (i staticInspector) Inspect(context.Context, *gorm.DB) (ServerInfo, error) {
+	return i.info, i.err
+}
+
+func TestRecordedServerInfo(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+
+	// SQLite has no users, only its version is known
+	server := mustRecorded(t, db, "1").Server
+	if server.User != "" || !strings.HasPrefix(server.Version, "SQLite 3.") {
+		t.Errorf("server = %+v, want the SQLite version", server)
+	}
+}
+
+func TestServerInspector(t *testing.T) {
+	inspector := staticInspector{info: ServerInfo{User: "migrator", Version: "PostgreSQL 16.2"}}
+	db, _ := openTestDB(t, WithServerInspector(inspector))
+	migrateAs(t, db, "1", &pluginUser{})
+	if server := mustRecorded(t, db, "1").Server; server != inspector.info {
+		t.Errorf("server = %+v, want %+v", server, inspector.info)
+	}
+
+	// A failing inspector doesn't fail the migration
+	db, _ = openTestDB(t, WithServerInspector(staticInspector{err: errors.New("permission denied")}))
+	migrateAs(t, db, "1", &pluginUser{})
+	if server := mustRecorded(t, db, "1").Server; server != (ServerInfo{}) {
+		t.Errorf("server = %+v, want it left empty", server)
+	}
+}
@@ -0,0 +1,106 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Shard is a named database handle, with dbresolver pass a handle bound to the sources
+type Shard struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// ShardSet migrates and reports on several databases sharing a logical schema version. The
+// plugin has to be registered on every shard.
+type ShardSet struct {
+	Shards []Shard
+}
+
+// NewShardSet creates a ShardSet from the given shards
+func NewShardSet(shards ...Shard) *ShardSet {
+	return &ShardSet{Shards: shards}
+}
+
+// AutoMigrate migrates every shard in order, recording the migration under the same version
+// on all of them. It stops at the first failing shard.
+func (s *ShardSet) AutoMigrate(ctx context.Context, version string, models ...interface{}) error {
+	if version == "" {
+		return errors.New("a shared version is required to migrate a shard set")
+	}
+
+	ctx = ContextWithVersion(ctx, version)
+	for _, shard := range s.Shards {
+		logger := loggerFrom(shard.DB)
+		logger.Info("Migrating shard %s to version %s", shard.Name, version)
+		if err := shard.DB.WithContext(ctx).AutoMigrate(models...); err != nil {
+			logger.Error("Failed to migrate shard %s: %v", shard.Name, err)
+			return fmt.Errorf("failed to migrate shard %s: %w", shard.Name, err)
+		}
+	}
+	return nil
+}
+
+// ShardStatus is the schema version a shard is at
+type ShardStatus struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version,omitempty"`
+	AppliedAt time.Time `json:"applied_at,omitempty"`
+	// Lagging reports whether the shard is behind the shard at the highest version
+	Lagging bool   `json:"lagging"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Status reports the current schema version of every shard, marking the shards that are not
+// at the highest version any of them is at, ordered by CompareVersions. Unreachable shards are reported with
+// their error and counted as lagging.
+func (s *ShardSet) Status(ctx context.Context) []ShardStatus {
+	statuses := make([]ShardStatus, len(s.Shards))
+	var target ShardStatus
+	for i, shard := range s.Shards {
+		statuses[i].Name = shard.Name
+		current, err := currentVersion(shard.DB.WithContext(ctx))
+		switch {
+		case errors.Is(err, ErrVersionNotFound):
+		case err != nil:
+			statuses[i].Error = err.Error()
+		default:
+			statuses[i].Version = current.Version
+			statuses[i].AppliedAt = current.AppliedAt
+			if target.Version == "" || CompareRecordedVersions(shard.DB, current.Version, target.Version) > 0 {
+				target = statuses[i]
+			}
+		}
+	}
+
+	for i := range statuses {
+		statuses[i].Lagging = statuses[i].Error != "" || statuses[i].Version != target.Version
+	}
+	return statuses
+}
+
+// Lagging returns the shards that are not at the highest schema version
+func (s *ShardSet) Lagging(ctx context.Context) []ShardStatus {
+	var lagging []ShardStatus
+	for _, status := range s.Status(ctx) {
+		if status.Lagging {
+			lagging = append(lagging, status)
+		}
+	}
+	return lagging
+}
+
+// currentVersion returns the current version recorded on db, see GetCurrentVersion
+func currentVersion(db *gorm.DB) (*SchemaVersion, error) {
+	return registeredPlugin(db).currentVersion(db)
+}
+
+// currentVersion returns the highest successful migration, baseline or drift repair recorded
+// by the plugin, ordered by CompareVersions, the version the database is at
+func (p *AutoMigratePlugin) currentVersion(db *gorm.DB) (*SchemaVersion, error) {
+	return p.highestVersion(db, appliedKinds)
+}
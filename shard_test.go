@@ -0,0 +1,46 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestShardSet(t *testing.T) {
+	eu, _ := openTestDB(t)
+	us, _ := openTestDB(t)
+	shards := NewShardSet(Shard{Name: "eu", DB: eu}, Shard{Name: "us", DB: us})
+	ctx := context.Background()
+
+	if err := shards.AutoMigrate(ctx, "", &pluginUser{}); err == nil {
+		t.Fatal("AutoMigrate without a shared version succeeded")
+	}
+	if err := shards.AutoMigrate(ctx, "1", &pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	for _, db := range []*gorm.DB{eu, us} {
+		mustRecorded(t, db, "1")
+	}
+	if lagging := shards.Lagging(ctx); len(lagging) != 0 {
+		t.Errorf("lagging shards = %v, want none", lagging)
+	}
+
+	migrateAs(t, eu, "2", &pluginUserWithEmail{})
+	statuses := shards.Status(ctx)
+	want := []ShardStatus{{Name: "eu", Version: "2"}, {Name: "us", Version: "1", Lagging: true}}
+	if !slices.EqualFunc(statuses, want, func(a, b ShardStatus) bool {
+		return a.Name == b.Name && a.Version == b.Version && a.Lagging == b.Lagging && a.Error == ""
+	}) {
+		t.Errorf("shard statuses = %+v, want us lagging behind eu", statuses)
+	}
+
+	// A failing shard stops the migration of the following ones
+	if err := shards.AutoMigrate(ctx, "3", &failingModel{}); err == nil {
+		t.Fatal("AutoMigrate of an invalid model succeeded")
+	}
+	if history := mustHistory(t, us); len(history) != 1 {
+		t.Errorf("history of us = %v, want the migration stopped at eu", history)
+	}
+}
@@ -0,0 +1,165 @@
+package gorm_migrate_tracker
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNoSigningKey is returned by VerifyHistory when the plugin has no signing key
+	ErrNoSigningKey = errors.New("no history signing key configured")
+	// ErrHistoryTampered is matched by the error VerifyHistory returns for modified records
+	ErrHistoryTampered = errors.New("migration history was tampered with")
+)
+
+// TamperedVersion is a history record whose signature doesn't verify
+type TamperedVersion struct {
+	ID      uint   `json:"id"`
+	Version string `json:"version"`
+	// Reason is either "unsigned" or "signature mismatch"
+	Reason string `json:"reason"`
+}
+
+// TamperedHistoryError is returned by VerifyHistory with the records failing verification, it
+// matches ErrHistoryTampered with errors.Is
+type TamperedHistoryError struct {
+	Versions []TamperedVersion
+}
+
+// Error lists the tampered versions
+func (e *TamperedHistoryError) Error() string {
+	versions := make([]string, len(e.Versions))
+	for i, tampered := range e.Versions {
+		versions[i] = tampered.Version + " (" + tampered.Reason + ")"
+	}
+	return fmt.Sprintf("%s: %s", ErrHistoryTampered, strings.Join(versions, ", "))
+}
+
+// Is reports whether target is ErrHistoryTampered
+func (e *TamperedHistoryError) Is(target error) bool {
+	return target == ErrHistoryTampered
+}
+
+// signatureV2Prefix marks the signatures covering every persisted column, signatures without
+// it cover the fields signed by the first format only
+const signatureV2Prefix = "v2:"
+
+// sign sets the signature of a SchemaVersion when a signing key is configured
+func (p *AutoMigratePlugin) sign(schemaVersion *SchemaVersion) {
+	if len(p.SigningKey) == 0 {
+		return
+	}
+	schemaVersion.Signature = signature(p.SigningKey, schemaVersion)
+}
+
+// signature computes the HMAC-SHA256 of every persisted column of a SchemaVersion but its ID,
+// which isn't assigned yet when it's signed. The applied at time is signed with second
+// precision, which every dialect stores.
+func signature(key []byte, schemaVersion *SchemaVersion) string {
+	labels := schemaVersion.Labels
+	if len(labels) == 0 {
+		// Empty labels are stored as NULL and read back as nil
+		labels = nil
+	}
+	fields, _ := json.Marshal([]interface{}{
+		schemaVersion.Version,
+		schemaVersion.Kind,
+		schemaVersion.Status,
+		schemaVersion.Error,
+		schemaVersion.DurationMs,
+		schemaVersion.AppliedAt.Unix(),
+		schemaVersion.Changes,
+		schemaVersion.Statements,
+		schemaVersion.DownStatements,
+		schemaVersion.Snapshot,
+		schemaVersion.Checksum,
+		schemaVersion.Severity,
+		schemaVersion.Tenant,
+		schemaVersion.InitiatedBy,
+		schemaVersion.Environment,
+		schemaVersion.Server,
+		labels,
+	})
+	return signatureV2Prefix + hmacHex(key, fields)
+}
+
+// signatureV1 computes the signatures recorded before every column was signed, so the
+// records signed then still verify
+func signatureV1(key []byte, schemaVersion *SchemaVersion) string {
+	fields, _ := json.Marshal([]interface{}{
+		schemaVersion.Version,
+		schemaVersion.Kind,
+		schemaVersion.Status,
+		schemaVersion.AppliedAt.Unix(),
+		schemaVersion.Changes,
+		schemaVersion.Statements,
+		schemaVersion.DownStatements,
+		schemaVersion.Checksum,
+	})
+	return hmacHex(key, fields)
+}
+
+// hmacHex returns the hex encoded HMAC-SHA256 of data
+func hmacHex(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature reports whether the signature of a SchemaVersion matches its fields, in the
+// format it was signed with
+func verifySignature(key []byte, schemaVersion *SchemaVersion) bool {
+	expected := signatureV1(key, schemaVersion)
+	if strings.HasPrefix(schemaVersion.Signature, signatureV2Prefix) {
+		expected = signature(key, schemaVersion)
+	}
+	return hmac.Equal([]byte(schemaVersion.Signature), []byte(expected))
+}
+
+// VerifyHistory checks the signature of every history record against the signing key of the
+// plugin, returning a *TamperedHistoryError listing the records that were modified. Every
+// unsigned record is reported, including those recorded before the signing key was configured,
+// since a stripped signature can't be told apart from one that was never set.
+func VerifyHistory(db *gorm.DB) error {
+	logger := loggerFrom(db)
+	logger.Debug("VerifyHistory function called")
+
+	p := registeredPlugin(db)
+	if len(p.SigningKey) == 0 {
+		return ErrNoSigningKey
+	}
+
+	history, stored, err := p.storedHistory(db)
+	if !stored {
+		err = historyDB(db).Order("id").Find(&history).Error
+	}
+	if err != nil {
+		logger.Error("Failed to retrieve migration history: %v", err)
+		return fmt.Errorf("failed to retrieve migration history: %w", err)
+	}
+
+	var tampered []TamperedVersion
+	for i := range history {
+		schemaVersion := &history[i]
+		switch {
+		case schemaVersion.Signature == "":
+			tampered = append(tampered, TamperedVersion{ID: schemaVersion.ID, Version: schemaVersion.Version, Reason: "unsigned"})
+		case !verifySignature(p.SigningKey, schemaVersion):
+			tampered = append(tampered, TamperedVersion{ID: schemaVersion.ID, Version: schemaVersion.Version, Reason: "signature mismatch"})
+		}
+	}
+
+	if len(tampered) > 0 {
+		logger.Error("Migration history failed verification for %d versions", len(tampered))
+		return &TamperedHistoryError{Versions: tampered}
+	}
+	logger.Info("Verified the signatures of %d versions", len(history))
+	return nil
+}
@@ -0,0 +1,129 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testSigningKey = []byte("test-signing-key")
+
+// tamperedVersions returns the records err reports as tampered, failing the test unless it's
+// a *TamperedHistoryError
+func tamperedVersions(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if !errors.Is(err, ErrHistoryTampered) {
+		t.Fatalf("VerifyHistory = %v, want ErrHistoryTampered", err)
+	}
+	var tamperedErr *TamperedHistoryError
+	if !errors.As(err, &tamperedErr) {
+		t.Fatalf("VerifyHistory = %T, want a *TamperedHistoryError", err)
+	}
+	reasons := map[string]string{}
+	for _, tampered := range tamperedErr.Versions {
+		reasons[tampered.Version] = tampered.Reason
+	}
+	return reasons
+}
+
+func TestVerifyHistory(t *testing.T) {
+	db, _ := openTestDB(t, WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	if err := VerifyHistory(db); err != nil {
+		t.Fatalf("VerifyHistory of an untouched history: %v", err)
+	}
+
+	// Rolling back re-signs the records it marks
+	if err := RollbackTo(db, "1"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Fatalf("VerifyHistory after a rollback: %v", err)
+	}
+}
+
+func TestVerifyHistoryTampered(t *testing.T) {
+	db, _ := openTestDB(t, WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+
+	// A signed column and one only the second signature format covers are both caught
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", "1").Update("statements", "DROP TABLE `users`").Error; err != nil {
+		t.Fatalf("failed to tamper with version 1: %v", err)
+	}
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", "2").Update("initiated_by", "someone-else").Error; err != nil {
+		t.Fatalf("failed to tamper with version 2: %v", err)
+	}
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", "3").Update("signature", "").Error; err != nil {
+		t.Fatalf("failed to strip the signature of version 3: %v", err)
+	}
+
+	reasons := tamperedVersions(t, VerifyHistory(db))
+	want := map[string]string{"1": "signature mismatch", "2": "signature mismatch", "3": "unsigned"}
+	if len(reasons) != len(want) {
+		t.Errorf("tampered versions = %v, want %v", reasons, want)
+	}
+	for version, reason := range want {
+		if reasons[version] != reason {
+			t.Errorf("version %s reported as %q, want %q", version, reasons[version], reason)
+		}
+	}
+}
+
+func TestVerifyHistorySignatureV1(t *testing.T) {
+	db, _ := openTestDB(t, WithSigningKey(testSigningKey))
+	legacy := SchemaVersion{
+		Version:     "1",
+		Kind:        KindMigration,
+		Status:      StatusSuccess,
+		AppliedAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Changes:     "{}",
+		Statements:  "CREATE TABLE `users` (`id` integer);",
+		InitiatedBy: "ci",
+	}
+	legacy.Signature = signatureV1(testSigningKey, &legacy)
+	if err := historyDB(db).Create(&legacy).Error; err != nil {
+		t.Fatalf("failed to seed version 1: %v", err)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Fatalf("VerifyHistory of a record signed in the first format: %v", err)
+	}
+
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", "1").Update("changes", `{"notes":["edited"]}`).Error; err != nil {
+		t.Fatalf("failed to tamper with version 1: %v", err)
+	}
+	if reasons := tamperedVersions(t, VerifyHistory(db)); reasons["1"] != "signature mismatch" {
+		t.Errorf("tampered versions = %v, want version 1 mismatching", reasons)
+	}
+}
+
+func TestVerifyHistoryWithoutKey(t *testing.T) {
+	db, _ := openTestDB(t)
+	if err := VerifyHistory(db); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("VerifyHistory without a key = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestSignatureFormats(t *testing.T) {
+	schemaVersion := &SchemaVersion{Version: "1", Status: StatusSuccess, AppliedAt: time.Unix(1700000000, 0)}
+	schemaVersion.Signature = signature(testSigningKey, schemaVersion)
+	if !verifySignature(testSigningKey, schemaVersion) {
+		t.Error("a second format signature doesn't verify")
+	}
+	if verifySignature([]byte("another-key"), schemaVersion) {
+		t.Error("a signature verifies with another key")
+	}
+
+	// The applied at time is signed with second precision
+	schemaVersion.AppliedAt = schemaVersion.AppliedAt.Add(500 * time.Millisecond)
+	if !verifySignature(testSigningKey, schemaVersion) {
+		t.Error("a signature doesn't verify once the applied at time lost its fraction of a second")
+	}
+
+	schemaVersion.Signature = signatureV1(testSigningKey, schemaVersion)
+	if !verifySignature(testSigningKey, schemaVersion) {
+		t.Error("a first format signature doesn't verify")
+	}
+}
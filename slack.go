@@ -0,0 +1,134 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackPostMessageURL is the Slack Web API method used when posting with a bot token
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// SlackNotifier posts a summary of every migration to a Slack channel, either through an
+// incoming webhook or with a bot token
+type SlackNotifier struct {
+	// WebhookURL is an incoming webhook URL, used when Token is empty
+	WebhookURL string
+	// Token is a bot token allowed to post to Channel
+	Token   string
+	Channel string
+	// Client sends the requests, http.DefaultClient is used when nil
+	Client  *http.Client
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+}
+
+// NewSlackWebhookNotifier creates a SlackNotifier posting through an incoming webhook
+func NewSlackWebhookNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		Timeout:    DefaultWebhookTimeout,
+		Retries:    DefaultWebhookRetries,
+		Backoff:    DefaultWebhookBackoff,
+	}
+}
+
+// NewSlackBotNotifier creates a SlackNotifier posting to channel with a bot token
+func NewSlackBotNotifier(token, channel string) *SlackNotifier {
+	return &SlackNotifier{
+		Token:   token,
+		Channel: channel,
+		Timeout: DefaultWebhookTimeout,
+		Retries: DefaultWebhookRetries,
+		Backoff: DefaultWebhookBackoff,
+	}
+}
+
+// slackMessage is the payload accepted by incoming webhooks and chat.postMessage
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Notify posts the formatted event to Slack
+func (s *SlackNotifier) Notify(ctx context.Context, event MigrationEvent) error {
+	payload, err := json.Marshal(slackMessage{Channel: s.Channel, Text: FormatSlackMessage(event)})
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+
+	webhook := &WebhookNotifier{
+		Client:  s.Client,
+		Timeout: s.Timeout,
+		Retries: s.Retries,
+		Backoff: s.Backoff,
+	}
+	url := s.WebhookURL
+	if s.Token != "" {
+		url = slackPostMessageURL
+		webhook.Headers = map[string]string{"Authorization": "Bearer " + s.Token}
+		webhook.validate = checkSlackResponse
+	}
+	if url == "" {
+		return errors.New("slack notifier requires a webhook URL or a bot token")
+	}
+
+	if err := webhook.deliver(ctx, url, payload); err != nil {
+		return fmt.Errorf("failed to post slack message: %w", err)
+	}
+	return nil
+}
+
+// checkSlackResponse reports errors returned by the Slack Web API with a 200 status
+func checkSlackResponse(body []byte) error {
+	var response struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to decode slack response: %w", err)
+	}
+	if !response.OK {
+		return fmt.Errorf("slack API error: %s", response.Error)
+	}
+	return nil
+}
+
+// FormatSlackMessage renders an event as Slack mrkdwn, highlighting dangerous statements
+func FormatSlackMessage(event MigrationEvent) string {
+	var b strings.Builder
+	if event.Status == StatusFailed {
+		fmt.Fprintf(&b, ":x: *Migration %s failed* after %dms\n", event.Version, event.DurationMs)
+		fmt.Fprintf(&b, "> %s\n", event.Error)
+	} else {
+		fmt.Fprintf(&b, ":white_check_mark: *Migration %s applied* in %dms\n", event.Version, event.DurationMs)
+	}
+
+	if event.Changes != nil && len(event.Changes.Models) > 0 {
+		models := make([]string, len(event.Changes.Models))
+		for i, model := range event.Changes.Models {
+			models[i] = fmt.Sprintf("`%s`", model.Model)
+		}
+		fmt.Fprintf(&b, "*Models:* %s\n", strings.Join(models, ", "))
+	}
+
+	var dangerous []string
+	for _, statement := range event.Statements {
+		if classifyStatement(statement) == SeverityDanger {
+			dangerous = append(dangerous, statement)
+		}
+	}
+	fmt.Fprintf(&b, "*Statements:* %d", len(event.Statements))
+	if len(dangerous) > 0 {
+		fmt.Fprintf(&b, "\n:warning: *%d dangerous operations:*", len(dangerous))
+		for _, statement := range dangerous {
+			fmt.Fprintf(&b, "\n```%s```", statement)
+		}
+	}
+	return b.String()
+}
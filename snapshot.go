@@ -0,0 +1,135 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// SchemaSnapshot is the full definition of every table in the database at a point in time
+type SchemaSnapshot struct {
+	Tables []TableSchema `json:"tables"`
+}
+
+// Table returns the named table of the snapshot, if present
+func (s *SchemaSnapshot) Table(name string) (*TableSchema, bool) {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i], true
+		}
+	}
+	return nil, false
+}
+
+// TakeSnapshotContext is like TakeSnapshot but cancels the introspection queries when ctx is done
+func TakeSnapshotContext(ctx context.Context, db *gorm.DB) (*SchemaSnapshot, error) {
+	return TakeSnapshot(db.WithContext(ctx))
+}
+
+// TakeSnapshot introspects every table of the database, except the tracker's own tables and
+// the ignored ones
+func TakeSnapshot(db *gorm.DB) (*SchemaSnapshot, error) {
+	return takeSnapshot(db, historyTableName(db))
+}
+
+// takeSnapshot introspects every table of the database, skipping the history table
+func takeSnapshot(db *gorm.DB, historyTable string) (*SchemaSnapshot, error) {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list database tables: %w", err)
+	}
+	sort.Strings(tables)
+
+	snapshot := &SchemaSnapshot{}
+	for _, name := range tables {
+		if isTrackerTable(db, historyTable, name) || isInternalTable(name) || isIgnoredTable(db, name) {
+			continue
+		}
+		table, err := inspectTable(db, name)
+		if err != nil {
+			return nil, err
+		}
+		table.Exists = true
+		snapshot.Tables = append(snapshot.Tables, *table)
+	}
+	return snapshot, nil
+}
+
+// ParseSnapshot decodes the schema snapshot stored with a version, returning nil when
+// the version was recorded without one
+func (v SchemaVersion) ParseSnapshot() (*SchemaSnapshot, error) {
+	if v.Snapshot == "" {
+		return nil, nil
+	}
+	snapshot := &SchemaSnapshot{}
+	if err := json.Unmarshal([]byte(v.Snapshot), snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot of version %s: %w", v.Version, err)
+	}
+	for i := range snapshot.Tables {
+		snapshot.Tables[i].Exists = true
+	}
+	return snapshot, nil
+}
+
+// snapshot serializes the current schema, returning an empty string when it can't be taken
+func (p *AutoMigratePlugin) snapshot(db *gorm.DB) string {
+	snapshot, err := takeSnapshot(db, p.historyTableName(db))
+	if err != nil {
+		p.Logger.Warn("Failed to take schema snapshot: %v", err)
+		return ""
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		p.Logger.Warn("Failed to encode schema snapshot: %v", err)
+		return ""
+	}
+	p.Logger.Debug("Took schema snapshot of %d tables", len(snapshot.Tables))
+	return string(data)
+}
+
+// versionSnapshot returns the snapshot recorded with a version, or a snapshot of the live
+// schema when version is empty
+func versionSnapshot(db *gorm.DB, version string) (*SchemaSnapshot, error) {
+	if version == "" {
+		snapshot, err := TakeSnapshot(db)
+		if err != nil {
+			loggerFrom(db).Error("Failed to take schema snapshot: %v", err)
+			return nil, err
+		}
+		return snapshot, nil
+	}
+
+	schemaVersion, err := findVersion(db, version)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := schemaVersion.ParseSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("schema version %s was recorded without a snapshot", version)
+	}
+	return snapshot, nil
+}
+
+// ModelsSnapshot builds the snapshot of the schema the given models define, without
+// querying the database
+func ModelsSnapshot(db *gorm.DB, models ...interface{}) (*SchemaSnapshot, error) {
+	snapshot := &SchemaSnapshot{}
+	for _, model := range models {
+		table, err := modelTableSchema(db, model)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Tables = append(snapshot.Tables, *table)
+	}
+	sort.Slice(snapshot.Tables, func(i, j int) bool {
+		return snapshot.Tables[i].Name < snapshot.Tables[j].Name
+	})
+	return snapshot, nil
+}
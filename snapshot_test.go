@@ -0,0 +1,84 @@
+package gorm_migrate_tracker
+
+import "testing"
+
+// tableColumns returns the column names of the named table of the snapshot
+func tableColumns(t *testing.T, snapshot *SchemaSnapshot, name string) []string {
+	t.Helper()
+	table, ok := snapshot.Table(name)
+	if !ok {
+		t.Fatalf("the snapshot has no %s table", name)
+	}
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.Name
+	}
+	return columns
+}
+
+func TestSnapshotPerVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{}, &squashedOrder{})
+
+	first, err := mustRecorded(t, db, "1").ParseSnapshot()
+	if err != nil {
+		t.Fatalf("ParseSnapshot: %v", err)
+	}
+	if len(first.Tables) != 1 {
+		t.Fatalf("snapshot of 1 holds %d tables, want only users", len(first.Tables))
+	}
+	if columns := tableColumns(t, first, "users"); len(columns) != 2 {
+		t.Errorf("users columns at 1 = %v, want id and name", columns)
+	}
+	if !first.Tables[0].Exists {
+		t.Error("a parsed snapshot table doesn't exist")
+	}
+
+	// Every version snapshots the whole schema, not only the tables it migrated
+	second, err := mustRecorded(t, db, "2").ParseSnapshot()
+	if err != nil {
+		t.Fatalf("ParseSnapshot: %v", err)
+	}
+	if len(second.Tables) != 2 || second.Tables[0].Name != "squashed_orders" || second.Tables[1].Name != "users" {
+		t.Fatalf("snapshot of 2 = %v, want the orders and users tables sorted", second.Tables)
+	}
+	if columns := tableColumns(t, second, "users"); len(columns) != 3 {
+		t.Errorf("users columns at 2 = %v, want the email column added", columns)
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	db, _ := openTestDB(t, WithEntries(true))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	snapshot, err := TakeSnapshot(db)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	// The tracker's own tables are left out
+	if len(snapshot.Tables) != 1 || snapshot.Tables[0].Name != "users" {
+		t.Errorf("snapshot = %v, want the users table alone", snapshot.Tables)
+	}
+
+	models, err := ModelsSnapshot(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("ModelsSnapshot: %v", err)
+	}
+	if diffs := diffTables(db.Migrator(), &snapshot.Tables[0], &models.Tables[0]); len(diffs) != 0 {
+		t.Errorf("the live users table differs from its model: %v", diffs)
+	}
+}
+
+func TestWithoutSnapshots(t *testing.T) {
+	db, _ := openTestDB(t, WithSnapshots(false))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	recorded := mustRecorded(t, db, "1")
+	if recorded.Snapshot != "" {
+		t.Errorf("snapshot = %q, want none recorded", recorded.Snapshot)
+	}
+	if snapshot, err := recorded.ParseSnapshot(); snapshot != nil || err != nil {
+		t.Errorf("ParseSnapshot without a snapshot = %v, %v, want nil", snapshot, err)
+	}
+}
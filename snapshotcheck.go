@@ -0,0 +1,101 @@
+package gorm_migrate_tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// ErrSnapshotMismatch is matched by the error CheckSnapshot returns when the schema diverges
+// from the committed snapshot
+var ErrSnapshotMismatch = errors.New("schema diverges from the committed snapshot")
+
+// SnapshotMismatchError is returned by CheckSnapshot with the differences found
+type SnapshotMismatchError struct {
+	Diff *VersionDiff
+}
+
+// Error implements the error interface
+func (e *SnapshotMismatchError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrSnapshotMismatch, e.Diff)
+}
+
+// Is matches ErrSnapshotMismatch
+func (e *SnapshotMismatchError) Is(target error) bool {
+	return target == ErrSnapshotMismatch
+}
+
+// ExportSnapshot writes the schema the given models define as an indented JSON snapshot, or
+// the live schema when no models are given, to be committed and checked with CheckSnapshot
+func ExportSnapshot(db *gorm.DB, w io.Writer, models ...interface{}) error {
+	logger := loggerFrom(db)
+	logger.Debug("ExportSnapshot function called")
+
+	snapshot, err := currentSnapshot(db, models)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		logger.Error("Failed to write schema snapshot: %v", err)
+		return fmt.Errorf("failed to write schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot decodes a snapshot written by ExportSnapshot
+func ReadSnapshot(r io.Reader) (*SchemaSnapshot, error) {
+	var snapshot SchemaSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode schema snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// ReadSnapshotFile decodes the snapshot file at path
+func ReadSnapshotFile(path string) (*SchemaSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema snapshot: %w", err)
+	}
+	defer file.Close()
+	return ReadSnapshot(file)
+}
+
+// CheckSnapshot compares a committed snapshot with the schema AutoMigrate would produce for
+// the given models, or with the live schema when no models are given. It returns the diff
+// along with a *SnapshotMismatchError when they differ, e.g. to fail a CI job on model changes
+// whose snapshot wasn't regenerated.
+func CheckSnapshot(db *gorm.DB, committed *SchemaSnapshot, models ...interface{}) (*VersionDiff, error) {
+	logger := loggerFrom(db)
+	logger.Debug("CheckSnapshot function called")
+
+	current, err := currentSnapshot(db, models)
+	if err != nil {
+		return nil, err
+	}
+	diff := &VersionDiff{From: "committed", To: "models"}
+	if len(models) == 0 {
+		diff.To = "live"
+	}
+	diffSnapshots(db.Migrator(), committed, current, diff)
+	if !diff.Empty() {
+		logger.Warn("Schema diverges from the committed snapshot")
+		return diff, &SnapshotMismatchError{Diff: diff}
+	}
+	logger.Info("Schema matches the committed snapshot")
+	return diff, nil
+}
+
+// currentSnapshot builds the snapshot of the models, or of the live schema without models
+func currentSnapshot(db *gorm.DB, models []interface{}) (*SchemaSnapshot, error) {
+	if len(models) > 0 {
+		return ModelsSnapshot(db, models...)
+	}
+	return TakeSnapshot(db)
+}
@@ -0,0 +1,48 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSnapshot(t *testing.T) {
+	db, _ := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "schema.json")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create the snapshot file: %v", err)
+	}
+	if err := ExportSnapshot(db, file, &pluginUser{}, &squashedOrder{}); err != nil {
+		t.Fatalf("ExportSnapshot: %v", err)
+	}
+	file.Close()
+	committed, err := ReadSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshotFile: %v", err)
+	}
+
+	if diff, err := CheckSnapshot(db, committed, &pluginUser{}, &squashedOrder{}); err != nil || !diff.Empty() {
+		t.Errorf("CheckSnapshot of the same models = %v, %v, want no changes", diff, err)
+	}
+
+	// A model changed without regenerating the snapshot fails the check
+	diff, err := CheckSnapshot(db, committed, &pluginUserWithEmail{}, &squashedOrder{})
+	var mismatch *SnapshotMismatchError
+	if !errors.Is(err, ErrSnapshotMismatch) || !errors.As(err, &mismatch) {
+		t.Fatalf("CheckSnapshot of a changed model = %v, want a SnapshotMismatchError", err)
+	}
+	if len(diff.Columns) != 1 || diff.Columns[0].Column != "email" || mismatch.Diff != diff {
+		t.Errorf("diff = %s, want the email column added", diff)
+	}
+
+	// Without models the live schema is checked
+	if _, err := CheckSnapshot(db, committed); !errors.Is(err, ErrSnapshotMismatch) {
+		t.Errorf("CheckSnapshot of an empty database = %v, want ErrSnapshotMismatch", err)
+	}
+	migrateAs(t, db, "1", &pluginUser{}, &squashedOrder{})
+	if diff, err := CheckSnapshot(db, committed); err != nil {
+		t.Errorf("CheckSnapshot of the migrated database = %s, %v, want no changes", diff, err)
+	}
+}
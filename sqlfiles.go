@@ -0,0 +1,126 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// golangMigrateTimestamp is the layout of timestamp versions in golang-migrate file names
+const golangMigrateTimestamp = "20060102150405"
+
+// WriteMigrationFiles plans AutoMigrate for the given models and writes the statements as
+// the next up and down files of dir in the golang-migrate layout, such as
+// 000004_add_users.up.sql, instead of applying them. Files are numbered after the highest
+// version already in dir, timestamps are used when dir already holds timestamp versions.
+// Nothing is written when the plan is empty, the returned paths are empty then.
+func (p *AutoMigratePlugin) WriteMigrationFiles(db *gorm.DB, dir, name string, models ...interface{}) (up, down string, err error) {
+	p.Logger.Debug("WriteMigrationFiles method called for %s", dir)
+
+	plan, err := p.Plan(db, models...)
+	if err != nil {
+		return "", "", err
+	}
+	if plan.Empty() {
+		p.Logger.Info("No changes planned, no migration files written")
+		return "", "", nil
+	}
+
+	version, err := p.nextFileVersion(dir)
+	if err != nil {
+		return "", "", err
+	}
+	if name == "" {
+		name = strings.Join(plan.Tables, "_")
+	}
+	prefix := filepath.Join(dir, version+"_"+fileTitle(name))
+
+	statements := make([]string, len(plan.Statements))
+	for i, statement := range plan.Statements {
+		statements[i] = statement.SQL
+	}
+	up, down = prefix+".up.sql", prefix+".down.sql"
+	if err := writeSQLFile(up, statements); err != nil {
+		p.Logger.Error("Failed to write migration file %s: %v", up, err)
+		return "", "", err
+	}
+	if err := writeSQLFile(down, plan.Down); err != nil {
+		p.Logger.Error("Failed to write migration file %s: %v", down, err)
+		return "", "", err
+	}
+
+	p.Logger.Info("Wrote %d statements to %s", len(statements), up)
+	return up, down, nil
+}
+
+// nextFileVersion returns the version following the highest golang-migrate version in dir
+func (p *AutoMigratePlugin) nextFileVersion(dir string) (string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	var highest uint64
+	width := 6
+	for _, file := range files {
+		match := golangMigrateFile.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if version >= highest {
+			highest, width = version, len(match[1])
+		}
+	}
+
+	if width == len(golangMigrateTimestamp) && highest > 0 {
+		next := p.now().UTC().Format(golangMigrateTimestamp)
+		if current := strconv.FormatUint(highest, 10); next <= current {
+			next = strconv.FormatUint(highest+1, 10)
+		}
+		return next, nil
+	}
+	return fmt.Sprintf("%0*d", width, highest+1), nil
+}
+
+// fileTitle turns a name into the title part of a migration file name
+func fileTitle(name string) string {
+	title := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, name)
+	return strings.Trim(title, "_")
+}
+
+// writeSQLFile writes statements to path, one per line
+func writeSQLFile(path string, statements []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	var b strings.Builder
+	for _, statement := range statements {
+		if strings.HasPrefix(statement, "--") {
+			fmt.Fprintf(&b, "%s\n", statement)
+			continue
+		}
+		fmt.Fprintf(&b, "%s;\n", statement)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
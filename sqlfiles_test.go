@@ -0,0 +1,56 @@
+package gorm_migrate_tracker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMigrationFiles(t *testing.T) {
+	db, plugin := openTestDB(t)
+	dir := filepath.Join(t.TempDir(), "migrations")
+
+	up, down, err := plugin.WriteMigrationFiles(db, dir, "Create Users", &pluginUser{})
+	if err != nil {
+		t.Fatalf("WriteMigrationFiles: %v", err)
+	}
+	if up != filepath.Join(dir, "000001_create_users.up.sql") || down != filepath.Join(dir, "000001_create_users.down.sql") {
+		t.Errorf("files = %s, %s, want version 000001", up, down)
+	}
+	content, err := os.ReadFile(up)
+	if err != nil || !strings.HasPrefix(string(content), "CREATE TABLE `users`") || !strings.HasSuffix(string(content), ");\n") {
+		t.Errorf("up file = %q, %v, want the CREATE TABLE statement", content, err)
+	}
+	if content, err := os.ReadFile(down); err != nil || string(content) != "DROP TABLE `users`;\n" {
+		t.Errorf("down file = %q, %v, want the users table dropped", content, err)
+	}
+	// Nothing is applied
+	if db.Migrator().HasTable(&pluginUser{}) {
+		t.Error("WriteMigrationFiles created the users table")
+	}
+
+	migrateAs(t, db, "1", &pluginUser{})
+	if up, _, err := plugin.WriteMigrationFiles(db, dir, "", &pluginUserWithEmail{}); err != nil || filepath.Base(up) != "000002_users.up.sql" {
+		t.Errorf("WriteMigrationFiles = %s, %v, want the next version named after the table", up, err)
+	}
+	if up, down, err := plugin.WriteMigrationFiles(db, dir, "", &pluginUser{}); err != nil || up != "" || down != "" {
+		t.Errorf("WriteMigrationFiles of an unchanged model = %s, %s, %v, want nothing written", up, down, err)
+	}
+}
+
+func TestNextFileVersionTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "20240101000000_init.up.sql"), nil, 0o644); err != nil {
+		t.Fatalf("failed to seed a migration file: %v", err)
+	}
+	plugin := NewAutoMigratePlugin(WithClock(&tickingClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}))
+	if version, err := plugin.nextFileVersion(dir); err != nil || version != "20240301120000" {
+		t.Errorf("nextFileVersion = %s, %v, want the current timestamp", version, err)
+	}
+	plugin = NewAutoMigratePlugin(WithClock(&tickingClock{now: time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)}))
+	if version, err := plugin.nextFileVersion(dir); err != nil || version != "20240101000001" {
+		t.Errorf("nextFileVersion with a clock behind = %s, %v, want the highest version incremented", version, err)
+	}
+}
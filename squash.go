@@ -0,0 +1,159 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// SquashContext is like Squash but cancels the queries when ctx is done
+func SquashContext(ctx context.Context, db *gorm.DB, uptoVersion string) (*SchemaVersion, error) {
+	return Squash(db.WithContext(ctx), uptoVersion)
+}
+
+// Squash collapses the history up to a version into a single baseline record, keeping the
+// version and ID of uptoVersion so the records after it stay in order. The baseline holds the
+// snapshot of the schema at the version and the statements of the squashed migrations, so
+// Replay still reproduces it, while failed, rolled back and drift records are dropped. Views,
+// whose records MigrateViews compares against, and records awaiting approval or completion
+// are kept. It holds the migration lock of the Locker, so another instance doesn't record or
+// prune versions while the history is rewritten.
+func Squash(db *gorm.DB, uptoVersion string) (*SchemaVersion, error) {
+	p := registeredPlugin(db)
+	p.Logger.Debug("Squash function called for %s", uptoVersion)
+	p.migrating.Lock()
+	defer p.migrating.Unlock()
+	if p.Locker != nil {
+		lock, _, err := p.acquireLock(db)
+		if err != nil {
+			p.Logger.Error("Not squashing up to %s without the migration lock: %v", uptoVersion, err)
+			return nil, err
+		}
+		defer p.releaseLock(db, lock)
+	}
+
+	target, err := findVersion(db, uptoVersion)
+	if err != nil {
+		return nil, err
+	}
+	if !target.Applied() {
+		return nil, fmt.Errorf("cannot squash up to version %s with status %s", uptoVersion, target.Status)
+	}
+
+	history, stored, err := p.storedHistory(db)
+	if !stored && err == nil {
+		err = p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("id <= ?", target.ID).Order("id").Find(&history).Error
+	}
+	if err != nil {
+		p.Logger.Error("Failed to retrieve history to squash: %v", err)
+		return nil, fmt.Errorf("failed to retrieve history to squash: %w", err)
+	}
+	squashed := filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+		finished := slices.Contains(finishedStatuses, schemaVersion.Status)
+		return schemaVersion.ID <= target.ID && schemaVersion.Kind != KindView && finished
+	})
+
+	baseline, err := p.squashedBaseline(db, target, squashed)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for _, schemaVersion := range squashed {
+		if schemaVersion.ID != target.ID {
+			ids = append(ids, schemaVersion.ID)
+		}
+	}
+
+	err = p.historyConn(db).Transaction(func(tx *gorm.DB) error {
+		if p.Retention.ArchiveTable != "" {
+			if err := p.archive(tx, versionIDs(squashed)); err != nil {
+				return err
+			}
+		}
+		if p.RecordEntries && len(ids) > 0 {
+			if err := p.entriesDB(tx.Session(&gorm.Session{NewDB: true})).Where("schema_version_id IN ?", ids).Delete(&SchemaVersionEntry{}).Error; err != nil {
+				return fmt.Errorf("failed to delete schema version entries: %w", err)
+			}
+		}
+		if err := p.deleteVersions(tx, ids...); err != nil {
+			return err
+		}
+		return p.updateVersion(tx, baseline)
+	})
+	if err != nil {
+		p.Logger.Error("Failed to squash history: %v", err)
+		return nil, fmt.Errorf("failed to squash history: %w", err)
+	}
+
+	p.Logger.Info("Squashed %d history records into baseline %s", len(squashed), baseline.Version)
+	return baseline, nil
+}
+
+// squashedBaseline consolidates the squashed records into a baseline replacing target
+func (p *AutoMigratePlugin) squashedBaseline(db *gorm.DB, target *SchemaVersion, squashed []SchemaVersion) (*SchemaVersion, error) {
+	changeSet := &ChangeSet{Baseline: true}
+	routines := map[string]int{}
+	seen := map[string]bool{}
+	var statements, downStatements []string
+	var durationMs int64
+	for _, schemaVersion := range squashed {
+		if !schemaVersion.Applied() {
+			continue
+		}
+		changes, err := schemaVersion.ParseChanges()
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range changes.Models {
+			if !seen[model.Model] {
+				seen[model.Model] = true
+				changeSet.Models = append(changeSet.Models, ModelChange{Model: model.Model, Table: model.Table})
+			}
+		}
+		// Only the last change of a routine is kept, it's what recordedRoutines compares against
+		for _, routine := range changes.Routines {
+			if i, ok := routines[routine.Name]; ok {
+				changeSet.Routines[i] = routine
+				continue
+			}
+			routines[routine.Name] = len(changeSet.Routines)
+			changeSet.Routines = append(changeSet.Routines, routine)
+		}
+		statements = append(statements, splitStatements(schemaVersion.Statements)...)
+		downStatements = slices.Concat(splitStatements(schemaVersion.DownStatements), downStatements)
+		durationMs += schemaVersion.DurationMs
+	}
+	changeSet.Notes = []string{fmt.Sprintf("squashed %d versions from %s to %s", len(squashed), squashed[0].Version, target.Version)}
+	changes, err := encodeChangeSet(changeSet)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := target.Snapshot
+	if snapshot == "" {
+		reconstructed, err := ReconstructSchema(db, target.Version)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(reconstructed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema snapshot: %w", err)
+		}
+		snapshot = string(data)
+	}
+
+	baseline := *target
+	baseline.Kind = KindBaseline
+	baseline.Severity = SeverityInfo
+	baseline.Error = ""
+	baseline.DurationMs = durationMs
+	baseline.Changes = changes
+	baseline.Statements = joinStatements(statements)
+	baseline.DownStatements = joinStatements(downStatements)
+	baseline.Snapshot = snapshot
+	p.sign(&baseline)
+	return &baseline, nil
+}
@@ -0,0 +1,125 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type squashedOrder struct {
+	ID    uint
+	Total int
+}
+
+func TestSquash(t *testing.T) {
+	db, _ := openTestDB(t, WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+	failed := SchemaVersion{Version: "2", Kind: KindMigration, Status: StatusFailed, AppliedAt: time.Now(), Changes: "{}"}
+	if err := historyDB(db).Create(&failed).Error; err != nil {
+		t.Fatalf("failed to seed version 2: %v", err)
+	}
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+	migrateAs(t, db, "4", &squashedOrder{})
+
+	baseline, err := Squash(db, "3")
+	if err != nil {
+		t.Fatalf("Squash: %v", err)
+	}
+	if baseline.Version != "3" || baseline.Kind != KindBaseline {
+		t.Errorf("baseline = %s %s, want version 3 as a baseline", baseline.Version, baseline.Kind)
+	}
+
+	history := mustHistory(t, db)
+	versions := make([]string, len(history))
+	for i, schemaVersion := range history {
+		versions[i] = schemaVersion.Version
+	}
+	if len(versions) != 2 || !strings.Contains(strings.Join(versions, ","), "3") || !strings.Contains(strings.Join(versions, ","), "4") {
+		t.Fatalf("versions after squashing = %v, want the baseline 3 and version 4", versions)
+	}
+
+	// The baseline keeps the statements of the squashed migrations, oldest first
+	squashed := mustRecorded(t, db, "3")
+	create, add := strings.Index(squashed.Statements, "CREATE TABLE `users`"), strings.Index(squashed.Statements, "ADD `email`")
+	if create < 0 || add < create {
+		t.Errorf("baseline statements = %q, want the table created then the email column added", squashed.Statements)
+	}
+	if squashed.Snapshot == "" {
+		t.Error("the baseline has no snapshot")
+	}
+
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != "4" {
+		t.Errorf("GetCurrentVersion = %v, %v, want version 4", current, err)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory after squashing: %v", err)
+	}
+}
+
+func TestSquashRolledBack(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	if err := RollbackTo(db, "1"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	migrateAs(t, db, "3", &squashedOrder{})
+
+	if _, err := Squash(db, "3"); err != nil {
+		t.Fatalf("Squash: %v", err)
+	}
+	history := mustHistory(t, db)
+	if len(history) != 1 || history[0].Version != "3" {
+		t.Fatalf("history after squashing = %v, want the baseline 3 alone", history)
+	}
+	// The reverted migration isn't part of the schema the baseline describes
+	if strings.Contains(history[0].Statements, "`email`") {
+		t.Errorf("baseline statements = %q, want the rolled back migration left out", history[0].Statements)
+	}
+}
+
+func TestSquashUnappliedVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	failed := SchemaVersion{Version: "2", Kind: KindMigration, Status: StatusFailed, AppliedAt: time.Now(), Changes: "{}"}
+	if err := historyDB(db).Create(&failed).Error; err != nil {
+		t.Fatalf("failed to seed version 2: %v", err)
+	}
+
+	if _, err := Squash(db, "2"); err == nil {
+		t.Error("Squash up to a failed version succeeded")
+	}
+	if _, err := Squash(db, "3"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Squash up to an unknown version = %v, want ErrVersionNotFound", err)
+	}
+	if history := mustHistory(t, db); len(history) != 2 {
+		t.Errorf("a refused squash left %d records, want 2", len(history))
+	}
+}
+
+func TestSquashHoldsLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	holder, _ := openTestDBAt(t, path)
+	db, _ := openTestDBAt(t, path, WithLocker(&TableLocker{Name: DefaultLockName}, LockSkip))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+
+	lock, err := (&TableLocker{Name: DefaultLockName}).Acquire(context.Background(), holder, false)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := Squash(db, "2"); !errors.Is(err, ErrLockNotAcquired) {
+		t.Errorf("Squash while another instance holds the lock = %v, want ErrLockNotAcquired", err)
+	}
+	if history := mustHistory(t, db); len(history) != 2 {
+		t.Errorf("Squash without the lock left %d records, want 2", len(history))
+	}
+
+	lock.Release(context.Background())
+	if _, err := Squash(db, "2"); err != nil {
+		t.Errorf("Squash once the lock is released: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package gorm_migrate_tracker
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// DefaultStatsDPrefix prefixes the metric names of a StatsDRecorder without a prefix
+const DefaultStatsDPrefix = "gorm.automigrate"
+
+// StatsDRecorder is a MetricsRecorder sending the migration count, failures, duration and
+// statement count of every run to a StatsD server over UDP. Like StatsD clients do, send
+// errors are dropped.
+type StatsDRecorder struct {
+	// Addr is the host:port of the StatsD server
+	Addr string
+	// Prefix is prepended to every metric name, DefaultStatsDPrefix when empty
+	Prefix string
+	// Tags are attached to every metric in the DogStatsD format, next to the version and
+	// status, they are only sent when DogStatsD is set
+	Tags []string
+	// DogStatsD sends tags with every metric, which plain StatsD servers reject
+	DogStatsD bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+var _ MetricsRecorder = (*StatsDRecorder)(nil)
+
+// NewStatsDRecorder creates a StatsDRecorder sending plain StatsD metrics to addr
+func NewStatsDRecorder(addr, prefix string) *StatsDRecorder {
+	return &StatsDRecorder{Addr: addr, Prefix: prefix}
+}
+
+// NewDogStatsDRecorder creates a StatsDRecorder sending tagged DogStatsD metrics to addr
+func NewDogStatsDRecorder(addr, prefix string, tags ...string) *StatsDRecorder {
+	return &StatsDRecorder{Addr: addr, Prefix: prefix, Tags: tags, DogStatsD: true}
+}
+
+// ObserveMigration sends the measurements of an AutoMigrate run in a single datagram
+func (s *StatsDRecorder) ObserveMigration(metrics MigrationMetrics) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = DefaultStatsDPrefix
+	}
+	var suffix string
+	if s.DogStatsD {
+		status := StatusSuccess
+		if metrics.Err != nil {
+			status = StatusFailed
+		}
+		tags := append(append([]string(nil), s.Tags...), "migration_version:"+metrics.Version, "status:"+status)
+		suffix = "|#" + strings.Join(tags, ",")
+	}
+
+	lines := []string{fmt.Sprintf("%s.migrations:1|c%s", prefix, suffix)}
+	if metrics.Err != nil {
+		lines = append(lines, fmt.Sprintf("%s.failures:1|c%s", prefix, suffix))
+	}
+	lines = append(lines,
+		fmt.Sprintf("%s.duration:%d|ms%s", prefix, metrics.Duration.Milliseconds(), suffix),
+		fmt.Sprintf("%s.statements:%d|c%s", prefix, metrics.Statements, suffix),
+	)
+	s.send(strings.Join(lines, "\n"))
+}
+
+// send writes a datagram, dialing the server on first use
+func (s *StatsDRecorder) send(datagram string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.Addr)
+		if err != nil {
+			return
+		}
+		s.conn = conn
+	}
+	s.conn.Write([]byte(datagram))
+}
+
+// Close closes the connection to the server
+func (s *StatsDRecorder) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
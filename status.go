@@ -0,0 +1,141 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ModelStatus tells whether a model is fully applied, with the statements AutoMigrate would
+// execute for it otherwise
+type ModelStatus struct {
+	Model      string             `json:"model"`
+	Table      string             `json:"table"`
+	Statements []PlannedStatement `json:"statements,omitempty"`
+	Severity   Severity           `json:"severity,omitempty"`
+}
+
+// StatusReport lists the models that are applied, those AutoMigrate would change if it ran
+// now and the tables no model manages
+type StatusReport struct {
+	// SchemaVersion is the version the database is at, empty when none is recorded
+	SchemaVersion string        `json:"schema_version,omitempty"`
+	Applied       []ModelStatus `json:"applied"`
+	Pending       []ModelStatus `json:"pending"`
+	// Routines are the statements applying the routines that changed
+	Routines []PlannedStatement `json:"routines,omitempty"`
+	// UnmanagedTables are the tables of the database without a model, leaving out the tables
+	// of the plugin and the ignored ones
+	UnmanagedTables []string `json:"unmanaged_tables,omitempty"`
+}
+
+// UpToDate reports whether AutoMigrate has nothing left to apply
+func (r *StatusReport) UpToDate() bool {
+	return len(r.Pending) == 0 && len(r.Routines) == 0
+}
+
+// String renders the report as a human readable summary
+func (r *StatusReport) String() string {
+	var b strings.Builder
+	version := r.SchemaVersion
+	if version == "" {
+		version = "none"
+	}
+	fmt.Fprintf(&b, "Schema version: %s\n", version)
+	fmt.Fprintf(&b, "Applied: %d models, pending: %d models, unmanaged: %d tables\n", len(r.Applied), len(r.Pending), len(r.UnmanagedTables))
+	for _, model := range r.Applied {
+		fmt.Fprintf(&b, "  = %s (%s)\n", model.Model, model.Table)
+	}
+	for _, model := range r.Pending {
+		fmt.Fprintf(&b, "  ~ %s (%s), %d statements, %s\n", model.Model, model.Table, len(model.Statements), model.Severity)
+		for _, statement := range model.Statements {
+			fmt.Fprintf(&b, "      %s\n", statement.SQL)
+		}
+	}
+	if len(r.Routines) > 0 {
+		fmt.Fprintf(&b, "  ~ routines, %d statements\n", len(r.Routines))
+	}
+	for _, table := range r.UnmanagedTables {
+		fmt.Fprintf(&b, "  ? table %s\n", table)
+	}
+	return b.String()
+}
+
+// StatusContext is like Status but cancels the queries when ctx is done
+func StatusContext(ctx context.Context, db *gorm.DB, models ...interface{}) (*StatusReport, error) {
+	return Status(db.WithContext(ctx), models...)
+}
+
+// Status plans AutoMigrate for every model, the DriftModels of the plugin when none are
+// given, without applying anything, and reports which models are applied and which would
+// change, along with the tables of the database no model manages
+func Status(db *gorm.DB, models ...interface{}) (*StatusReport, error) {
+	p, ok := pluginFrom(db)
+	if !ok {
+		return nil, ErrPluginNotRegistered
+	}
+	p.Logger.Debug("Status function called for %d models", len(models))
+	if len(models) == 0 {
+		models = p.DriftModels
+	}
+
+	report := &StatusReport{Applied: []ModelStatus{}, Pending: []ModelStatus{}}
+	current, err := p.currentVersion(db)
+	if err != nil && !errors.Is(err, ErrVersionNotFound) {
+		p.Logger.Error("Failed to retrieve the current schema version: %v", err)
+		return nil, err
+	}
+	if current != nil {
+		report.SchemaVersion = current.Version
+	}
+
+	// Every plan ends with the statements of the changed routines, they're planned once on
+	// their own and left out of the plans of the models
+	routines, err := p.Plan(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan routines: %w", err)
+	}
+	report.Routines = routines.Statements
+
+	managed := map[string]bool{}
+	for _, model := range models {
+		status := ModelStatus{Model: modelName(model), Table: tableOf(db, model)}
+		managed[status.Table] = true
+		plan, err := p.Plan(db, model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan %s: %w", status.Model, err)
+		}
+		status.Statements = plan.Statements[:max(len(plan.Statements)-len(routines.Statements), 0)]
+		if len(status.Statements) == 0 {
+			report.Applied = append(report.Applied, ModelStatus{Model: status.Model, Table: status.Table})
+			continue
+		}
+		status.Severity = SeverityInfo
+		for _, statement := range status.Statements {
+			status.Severity = maxSeverity(status.Severity, statement.Severity)
+		}
+		if len(plan.Destructive) > 0 {
+			status.Severity = SeverityDanger
+		}
+		report.Pending = append(report.Pending, status)
+	}
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		p.Logger.Error("Failed to list database tables: %v", err)
+		return nil, fmt.Errorf("failed to list database tables: %w", err)
+	}
+	for _, table := range tables {
+		if !managed[table] && !isTrackerTable(db, p.historyTableName(db), table) && !isInternalTable(table) && !p.ignoresTable(db, table) {
+			report.UnmanagedTables = append(report.UnmanagedTables, table)
+		}
+	}
+	sort.Strings(report.UnmanagedTables)
+
+	p.Logger.Info("Status: %d models applied, %d pending, %d unmanaged tables", len(report.Applied), len(report.Pending), len(report.UnmanagedTables))
+	return report, nil
+}
@@ -0,0 +1,53 @@
+package gorm_migrate_tracker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	db, _ := openTestDB(t, WithIgnoreTables("sessions"))
+	migrateAs(t, db, "1", &pluginUser{}, &jobQueue{})
+	if err := db.Exec("CREATE TABLE sessions (id integer)").Error; err != nil {
+		t.Fatalf("failed to create the sessions table: %v", err)
+	}
+
+	report, err := Status(db, &pluginUserWithEmail{}, &squashedOrder{}, &jobQueue{})
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if report.SchemaVersion != "1" || report.UpToDate() {
+		t.Errorf("report = %+v, want pending changes at version 1", report)
+	}
+	if len(report.Applied) != 1 || report.Applied[0].Table != tableOf(db, &jobQueue{}) {
+		t.Errorf("applied models = %v, want the job queue alone", report.Applied)
+	}
+	var pending []string
+	for _, model := range report.Pending {
+		pending = append(pending, model.Table)
+	}
+	if !slices.Equal(pending, []string{"users", tableOf(db, &squashedOrder{})}) {
+		t.Errorf("pending tables = %v, want users and orders", pending)
+	}
+	if statements := report.Pending[0].Statements; len(statements) != 1 || !strings.Contains(statements[0].SQL, "ADD `email`") {
+		t.Errorf("pending statements of users = %v, want the email column added", statements)
+	}
+	// Neither the tracker tables nor the ignored ones are unmanaged
+	if len(report.UnmanagedTables) != 0 {
+		t.Errorf("unmanaged tables = %v, want none", report.UnmanagedTables)
+	}
+
+	// Status applies nothing
+	if db.Migrator().HasTable(&squashedOrder{}) || len(mustHistory(t, db)) != 1 {
+		t.Error("Status migrated the pending models")
+	}
+
+	report, err = Status(db, &pluginUser{})
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !report.UpToDate() || !slices.Equal(report.UnmanagedTables, []string{tableOf(db, &jobQueue{})}) {
+		t.Errorf("report = %+v, want users applied and the job queue unmanaged", report)
+	}
+}
@@ -0,0 +1,292 @@
+package gorm_migrate_tracker
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// HistoryStore persists the schema versions recorded by the plugin outside of the history
+// table, such as in a file, an object store or a key-value store, so the audit trail doesn't
+// live in the migrated database. Versions reach the store compressed, encrypted and signed as
+// configured. Entries, the Flyway history, locks and leases are still kept in the database.
+type HistoryStore interface {
+	// Init prepares the store when the plugin is initialized
+	Init(ctx context.Context) error
+	// Create stores a new version and assigns its ID, failing when its version is taken
+	Create(ctx context.Context, schemaVersion *SchemaVersion) error
+	// Update replaces the stored version with the ID of schemaVersion
+	Update(ctx context.Context, schemaVersion *SchemaVersion) error
+	// Delete removes the versions with the given IDs
+	Delete(ctx context.Context, ids ...uint) error
+	// List returns every stored version
+	List(ctx context.Context) ([]SchemaVersion, error)
+}
+
+// createVersion inserts a new SchemaVersion into the HistoryStore, or the history table
+func (p *AutoMigratePlugin) createVersion(db *gorm.DB, schemaVersion *SchemaVersion) error {
+	if p.HistoryStore == nil {
+		return p.historyDB(db.Session(&gorm.Session{NewDB: true})).Create(schemaVersion).Error
+	}
+	encoded := *schemaVersion
+	if err := encoded.BeforeSave(p.hookDB(db)); err != nil {
+		return err
+	}
+	if err := p.HistoryStore.Create(contextFrom(db), &encoded); err != nil {
+		return err
+	}
+	schemaVersion.ID = encoded.ID
+	return nil
+}
+
+// updateVersion saves a recorded SchemaVersion, only the given columns of history table rows
+// are updated when there are any
+func (p *AutoMigratePlugin) updateVersion(db *gorm.DB, schemaVersion *SchemaVersion, columns ...string) error {
+	if p.HistoryStore == nil {
+		history := p.historyDB(db.Session(&gorm.Session{NewDB: true}))
+		if len(columns) > 0 {
+			return history.Model(schemaVersion).Select(columns).Updates(schemaVersion).Error
+		}
+		return history.Save(schemaVersion).Error
+	}
+	encoded := *schemaVersion
+	if err := encoded.BeforeSave(p.hookDB(db)); err != nil {
+		return err
+	}
+	return p.HistoryStore.Update(contextFrom(db), &encoded)
+}
+
+// deleteVersions removes recorded versions by ID
+func (p *AutoMigratePlugin) deleteVersions(db *gorm.DB, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if p.HistoryStore == nil {
+		return p.historyDB(db.Session(&gorm.Session{NewDB: true})).Where("id IN ?", ids).Delete(&SchemaVersion{}).Error
+	}
+	return p.HistoryStore.Delete(contextFrom(db), ids...)
+}
+
+// storedHistory returns the decoded history of the HistoryStore ordered by ID, limited to the
+// tenant of db with TenantTables. ok is false when the history is kept in the table.
+func (p *AutoMigratePlugin) storedHistory(db *gorm.DB) (history []SchemaVersion, ok bool, err error) {
+	if p.HistoryStore == nil {
+		return nil, false, nil
+	}
+	history, err = p.HistoryStore.List(contextFrom(db))
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to retrieve migration history: %w", err)
+	}
+	if p.TenantTables {
+		tenant := p.tenant(db)
+		history = filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+			return schemaVersion.Tenant == tenant
+		})
+	}
+	for i := range history {
+		if err := history[i].decode(p.hookDB(db)); err != nil {
+			return nil, true, err
+		}
+	}
+	slices.SortFunc(history, func(a, b SchemaVersion) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return history, true, nil
+}
+
+// sortNewestFirst orders a stored history like the history table is queried, most recently
+// applied first
+func sortNewestFirst(history []SchemaVersion) {
+	slices.SortStableFunc(history, func(a, b SchemaVersion) int {
+		return b.AppliedAt.Compare(a.AppliedAt)
+	})
+}
+
+// versionIDs returns the IDs of the versions of history
+func versionIDs(history []SchemaVersion) []uint {
+	ids := make([]uint, len(history))
+	for i, schemaVersion := range history {
+		ids[i] = schemaVersion.ID
+	}
+	return ids
+}
+
+// hookDB carries p in the context of db for the SchemaVersion hooks encoding the versions of
+// the HistoryStore, which also run while the plugin is being registered
+func (p *AutoMigratePlugin) hookDB(db *gorm.DB) *gorm.DB {
+	return db.WithContext(context.WithValue(contextFrom(db), pluginContextKey{}, p))
+}
+
+// filterVersions returns the versions of history keep reports true for, reusing its storage
+func filterVersions(history []SchemaVersion, keep func(*SchemaVersion) bool) []SchemaVersion {
+	return slices.DeleteFunc(history, func(schemaVersion SchemaVersion) bool {
+		return !keep(&schemaVersion)
+	})
+}
+
+// Document is the blob a DocumentStore keeps the history in
+type Document interface {
+	// Read returns the content of the document, nil when it doesn't exist yet
+	Read(ctx context.Context) ([]byte, error)
+	// Write replaces the content of the document
+	Write(ctx context.Context, data []byte) error
+}
+
+// DocumentStore is a HistoryStore keeping the whole history as a JSON array in a Document,
+// such as a file or an object in an object store. Access is serialized within the process,
+// writes of other processes sharing the document may be lost.
+type DocumentStore struct {
+	Document Document
+
+	mu sync.Mutex
+}
+
+var _ HistoryStore = (*DocumentStore)(nil)
+
+// NewDocumentStore creates a DocumentStore keeping the history in document
+func NewDocumentStore(document Document) *DocumentStore {
+	return &DocumentStore{Document: document}
+}
+
+// NewJSONFileStore creates a DocumentStore keeping the history in the JSON file at path
+func NewJSONFileStore(path string) *DocumentStore {
+	return NewDocumentStore(fileDocument(path))
+}
+
+// Init creates the document when it doesn't exist
+func (s *DocumentStore) Init(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.Document.Read(ctx)
+	if err != nil || data != nil {
+		return err
+	}
+	return s.write(ctx, []SchemaVersion{})
+}
+
+// Create appends schemaVersion with the next ID
+func (s *DocumentStore) Create(ctx context.Context, schemaVersion *SchemaVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	var id uint
+	for _, stored := range history {
+		if stored.Version == schemaVersion.Version {
+			return fmt.Errorf("schema version %s is already recorded", schemaVersion.Version)
+		}
+		id = max(id, stored.ID)
+	}
+	schemaVersion.ID = id + 1
+	return s.write(ctx, append(history, *schemaVersion))
+}
+
+// Update replaces the version with the ID of schemaVersion
+func (s *DocumentStore) Update(ctx context.Context, schemaVersion *SchemaVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(history, func(stored SchemaVersion) bool {
+		return stored.ID == schemaVersion.ID
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrVersionNotFound, schemaVersion.Version)
+	}
+	history[i] = *schemaVersion
+	return s.write(ctx, history)
+}
+
+// Delete removes the versions with the given IDs
+func (s *DocumentStore) Delete(ctx context.Context, ids ...uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	return s.write(ctx, slices.DeleteFunc(history, func(stored SchemaVersion) bool {
+		return slices.Contains(ids, stored.ID)
+	}))
+}
+
+// List returns every version of the document
+func (s *DocumentStore) List(ctx context.Context) ([]SchemaVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(ctx)
+}
+
+// read decodes the history of the document
+func (s *DocumentStore) read(ctx context.Context) ([]SchemaVersion, error) {
+	data, err := s.Document.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history document: %w", err)
+	}
+	var history []SchemaVersion
+	if data == nil {
+		return history, nil
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode history document: %w", err)
+	}
+	return history, nil
+}
+
+// write encodes history into the document
+func (s *DocumentStore) write(ctx context.Context, history []SchemaVersion) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history document: %w", err)
+	}
+	if err := s.Document.Write(ctx, data); err != nil {
+		return fmt.Errorf("failed to write history document: %w", err)
+	}
+	return nil
+}
+
+// fileDocument is a Document stored in the file at its path
+type fileDocument string
+
+// Read returns the content of the file, nil when it doesn't exist
+func (f fileDocument) Read(_ context.Context) ([]byte, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Write replaces the file through a temporary file, so readers never see a partial history
+func (f fileDocument) Write(_ context.Context, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(string(f)), filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
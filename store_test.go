@@ -0,0 +1,104 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// storedDocument decodes the history kept in the JSON file at path
+func storedDocument(t *testing.T, path string) []SchemaVersion {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the history file: %v", err)
+	}
+	var history []SchemaVersion
+	if err := json.Unmarshal(data, &history); err != nil {
+		t.Fatalf("failed to decode the history file: %v", err)
+	}
+	return history
+}
+
+func TestJSONFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	db, _ := openTestDB(t, WithHistoryStore(NewJSONFileStore(path)))
+	if stored := storedDocument(t, path); len(stored) != 0 {
+		t.Fatalf("a new store holds %v, want an empty history", stored)
+	}
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &pluginUserWithEmail{})
+	migrateAs(t, db, "3", &squashedOrder{})
+
+	if db.Migrator().HasTable(&SchemaVersion{}) {
+		t.Error("the history table was created alongside the store")
+	}
+	stored := storedDocument(t, path)
+	if len(stored) != 3 || stored[0].ID != 1 || stored[2].ID != 3 {
+		t.Fatalf("the history file holds %v, want versions 1 to 3 numbered in order", stored)
+	}
+	if history := mustHistory(t, db); history[0].Version != "3" {
+		t.Errorf("newest version = %s, want 3", history[0].Version)
+	}
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != "3" {
+		t.Errorf("GetCurrentVersion = %v, %v, want version 3", current, err)
+	}
+
+	// Updating and deleting go through the store
+	if err := RollbackTo(db, "2"); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if recorded := mustRecorded(t, db, "3"); recorded.Status != StatusRolledBack {
+		t.Errorf("version 3 status = %s, want %s", recorded.Status, StatusRolledBack)
+	}
+	if _, err := Prune(db, 1); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	// The last record and the current version are kept
+	if versions := recordedVersionsOf(t, db); !slices.Equal(versions, []string{"2", "3"}) {
+		t.Errorf("versions after pruning = %v, want [2 3]", versions)
+	}
+}
+
+func TestJSONFileStoreEncrypted(t *testing.T) {
+	encryptor, err := NewAESEncryptor(testEncryptionKey)
+	if err != nil {
+		t.Fatalf("NewAESEncryptor: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "history.json")
+	db, _ := openTestDB(t, WithHistoryStore(NewJSONFileStore(path)), WithEncryptor(encryptor), WithSigningKey(testSigningKey))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	// Versions reach the store encrypted and signed
+	stored := storedDocument(t, path)
+	if len(stored) != 1 || !strings.HasPrefix(stored[0].Statements, encryptedPrefix) || stored[0].Signature == "" {
+		t.Fatalf("the history file holds %v, want version 1 encrypted and signed", stored)
+	}
+	if recorded := mustRecorded(t, db, "1"); !strings.Contains(recorded.Statements, "CREATE TABLE `users`") {
+		t.Errorf("statements read back as %q, want them decrypted", recorded.Statements)
+	}
+	if err := VerifyHistory(db); err != nil {
+		t.Errorf("VerifyHistory: %v", err)
+	}
+}
+
+func TestDocumentStoreDuplicateVersion(t *testing.T) {
+	store := NewJSONFileStore(filepath.Join(t.TempDir(), "history.json"))
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := store.Create(ctx, &SchemaVersion{Version: "1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := store.Create(ctx, &SchemaVersion{Version: "1"}); err == nil {
+		t.Error("Create of a recorded version succeeded")
+	}
+	if err := store.Update(ctx, &SchemaVersion{ID: 2, Version: "2"}); err == nil {
+		t.Error("Update of an unknown version succeeded")
+	}
+}
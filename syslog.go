@@ -0,0 +1,106 @@
+//go:build !windows && !plan9
+
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/syslog"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultJournaldSocket is the socket journald receives native protocol messages on
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// syslogLogger adapts a *syslog.Writer to the Logger interface
+type syslogLogger struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogLogger returns a Logger writing to syslog with the priority of every level, e.g.
+// for a writer created with syslog.New(syslog.LOG_DAEMON, "migrate")
+func NewSyslogLogger(writer *syslog.Writer) Logger {
+	return &syslogLogger{writer: writer}
+}
+
+// Debug logs a debug level message
+func (l *syslogLogger) Debug(format string, args ...interface{}) {
+	l.writer.Debug(fmt.Sprintf(format, args...))
+}
+
+// Info logs a info level message
+func (l *syslogLogger) Info(format string, args ...interface{}) {
+	l.writer.Info(fmt.Sprintf(format, args...))
+}
+
+// Warn logs a warn level message
+func (l *syslogLogger) Warn(format string, args ...interface{}) {
+	l.writer.Warning(fmt.Sprintf(format, args...))
+}
+
+// Error logs a error level message
+func (l *syslogLogger) Error(format string, args ...interface{}) {
+	l.writer.Err(fmt.Sprintf(format, args...))
+}
+
+// journaldLogger writes to journald using its native protocol
+type journaldLogger struct {
+	conn       net.Conn
+	identifier string
+}
+
+// NewJournaldLogger returns a Logger sending messages to the local journald, tagged with
+// identifier as their SYSLOG_IDENTIFIER and with the priority of every level
+func NewJournaldLogger(identifier string) (Logger, error) {
+	conn, err := net.Dial("unixgram", DefaultJournaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journaldLogger{conn: conn, identifier: identifier}, nil
+}
+
+// send writes a message with its priority and identifier, dropping it when journald is gone
+func (l *journaldLogger) send(priority syslog.Priority, format string, args ...interface{}) {
+	var message bytes.Buffer
+	journaldField(&message, "MESSAGE", fmt.Sprintf(format, args...))
+	journaldField(&message, "PRIORITY", strconv.Itoa(int(priority)))
+	if l.identifier != "" {
+		journaldField(&message, "SYSLOG_IDENTIFIER", l.identifier)
+	}
+	l.conn.Write(message.Bytes())
+}
+
+// journaldField appends a field to a native protocol message, values spanning several lines
+// are length prefixed
+func journaldField(message *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(message, "%s=%s\n", name, value)
+		return
+	}
+	message.WriteString(name + "\n")
+	binary.Write(message, binary.LittleEndian, uint64(len(value)))
+	message.WriteString(value + "\n")
+}
+
+// Debug logs a debug level message
+func (l *journaldLogger) Debug(format string, args ...interface{}) {
+	l.send(syslog.LOG_DEBUG, format, args...)
+}
+
+// Info logs a info level message
+func (l *journaldLogger) Info(format string, args ...interface{}) {
+	l.send(syslog.LOG_INFO, format, args...)
+}
+
+// Warn logs a warn level message
+func (l *journaldLogger) Warn(format string, args ...interface{}) {
+	l.send(syslog.LOG_WARNING, format, args...)
+}
+
+// Error logs a error level message
+func (l *journaldLogger) Error(format string, args ...interface{}) {
+	l.send(syslog.LOG_ERR, format, args...)
+}
@@ -0,0 +1,121 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// tenantContextKey is the context key under which the tenant of a migration is stored
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a context recording migrations run with it against tenant,
+// e.g. db.WithContext(ContextWithTenant(ctx, "acme")).AutoMigrate(&User{})
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with ContextWithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// TenantResolver determines the tenant a migration runs against when the context doesn't name one
+type TenantResolver func(db *gorm.DB) (string, error)
+
+// CurrentSchemaTenant resolves the tenant to the current schema of the connection, the
+// first schema of the search_path on Postgres and the selected database on MySQL
+func CurrentSchemaTenant(db *gorm.DB) (string, error) {
+	var query string
+	switch db.Dialector.Name() {
+	case "postgres":
+		query = "SELECT current_schema()"
+	case "mysql":
+		query = "SELECT DATABASE()"
+	case "sqlserver":
+		query = "SELECT SCHEMA_NAME()"
+	default:
+		return "", nil
+	}
+
+	var schema string
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw(query).Scan(&schema).Error; err != nil {
+		return "", fmt.Errorf("failed to resolve current schema: %w", err)
+	}
+	return schema, nil
+}
+
+// TablePrefixTenant resolves the tenant to the table prefix of the naming strategy
+func TablePrefixTenant(db *gorm.DB) (string, error) {
+	if prefixed, ok := db.NamingStrategy.(interface{ TableName(string) string }); ok {
+		// The prefix is whatever the strategy prepends to an empty table name, without its separator
+		return strings.TrimRight(prefixed.TableName(""), "._"), nil
+	}
+	return "", nil
+}
+
+// tenant returns the tenant a statement on db runs against, empty when unknown
+func (p *AutoMigratePlugin) tenant(db *gorm.DB) string {
+	if tenant, ok := TenantFromContext(contextFrom(db)); ok {
+		return tenant
+	}
+	if p.TenantResolver == nil {
+		return ""
+	}
+
+	tenant, err := p.TenantResolver(db)
+	if err != nil {
+		p.Logger.Warn("Failed to resolve tenant: %v", err)
+		return ""
+	}
+	return tenant
+}
+
+// ensureTenantTable creates the history table in the schema of the tenant on first use
+func (p *AutoMigratePlugin) ensureTenantTable(db *gorm.DB, table string) error {
+	if _, ok := p.tenantTables.Load(table); ok {
+		return nil
+	}
+
+	p.Logger.Debug("Attempting to create SchemaVersion table %s", table)
+	if err := untrackedMigrator(p.historyConn(db.Session(&gorm.Session{NewDB: true})).Table(table)).AutoMigrate(&SchemaVersion{}); err != nil {
+		p.Logger.Error("Failed to create schema version table %s: %v", table, err)
+		return fmt.Errorf("failed to create schema version table %s: %w", table, err)
+	}
+	p.tenantTables.Store(table, true)
+	return nil
+}
+
+// GetMigrationHistoryForTenant retrieves the history of schema changes applied to a tenant
+func GetMigrationHistoryForTenant(db *gorm.DB, tenant string) ([]SchemaVersion, error) {
+	logger := loggerFrom(db)
+	logger.Debug("GetMigrationHistoryForTenant function called for %s", tenant)
+
+	db = db.WithContext(ContextWithTenant(contextFrom(db), tenant))
+	history, stored, err := registeredPlugin(db).storedHistory(db)
+	if err != nil {
+		logger.Error("Failed to retrieve migration history of tenant %s: %v", tenant, err)
+		return nil, err
+	}
+	if stored {
+		history = filterVersions(history, func(schemaVersion *SchemaVersion) bool {
+			return schemaVersion.Tenant == tenant
+		})
+		sortNewestFirst(history)
+		return history, nil
+	}
+
+	query := historyDB(db)
+	if p, ok := pluginFrom(db); !ok || !p.TenantTables {
+		query = query.Where("tenant = ?", tenant)
+	}
+
+	if err := query.Order("applied_at desc").Find(&history).Error; err != nil {
+		logger.Error("Failed to retrieve migration history of tenant %s: %v", tenant, err)
+		return nil, fmt.Errorf("failed to retrieve migration history of tenant %s: %w", tenant, err)
+	}
+	return history, nil
+}
@@ -0,0 +1,74 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// tenantAs returns db running against tenant as version
+func tenantAs(db *gorm.DB, tenant, version string) *gorm.DB {
+	return db.WithContext(ContextWithVersion(ContextWithTenant(context.Background(), tenant), version))
+}
+
+func TestGetMigrationHistoryForTenant(t *testing.T) {
+	db, _ := openTestDB(t)
+	if err := tenantAs(db, "acme", "1").AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := tenantAs(db, "globex", "2").AutoMigrate(&squashedOrder{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+
+	if recorded := mustRecorded(t, db, "1"); recorded.Tenant != "acme" {
+		t.Errorf("tenant of version 1 = %q, want acme", recorded.Tenant)
+	}
+	history, err := GetMigrationHistoryForTenant(db, "acme")
+	if err != nil {
+		t.Fatalf("GetMigrationHistoryForTenant: %v", err)
+	}
+	if len(history) != 1 || history[0].Version != "1" {
+		t.Errorf("history of acme = %v, want version 1 alone", history)
+	}
+	// Migrations without a tenant belong to the empty one
+	if history, err := GetMigrationHistoryForTenant(db, ""); err != nil || len(history) != 1 || history[0].Version != "3" {
+		t.Errorf("history without a tenant = %v, %v, want version 3 alone", history, err)
+	}
+}
+
+func TestTenantResolver(t *testing.T) {
+	db, _ := openTestDB(t, WithTenantResolver(func(*gorm.DB) (string, error) { return "initech", nil }))
+	migrateAs(t, db, "1", &pluginUser{})
+	if err := tenantAs(db, "acme", "2").AutoMigrate(&squashedOrder{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	if recorded := mustRecorded(t, db, "1"); recorded.Tenant != "initech" {
+		t.Errorf("tenant of version 1 = %q, want the resolved initech", recorded.Tenant)
+	}
+	// The context takes precedence over the resolver
+	if recorded := mustRecorded(t, db, "2"); recorded.Tenant != "acme" {
+		t.Errorf("tenant of version 2 = %q, want acme from the context", recorded.Tenant)
+	}
+}
+
+func TestTablePrefixTenant(t *testing.T) {
+	db := &gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{TablePrefix: "acme_"}}}
+	if tenant, err := TablePrefixTenant(db); err != nil || tenant != "acme" {
+		t.Errorf("TablePrefixTenant = %q, %v, want acme", tenant, err)
+	}
+}
+
+func TestTenantTables(t *testing.T) {
+	db, _ := openTestDB(t)
+	plugin := &AutoMigratePlugin{TenantTables: true}
+	if table := plugin.historyTableName(tenantAs(db, "acme", "1")); table != "acme.schema_versions" {
+		t.Errorf("history table of acme = %s, want acme.schema_versions", table)
+	}
+	if table := plugin.historyTableName(db); table != "schema_versions" {
+		t.Errorf("history table without a tenant = %s, want schema_versions", table)
+	}
+}
@@ -0,0 +1,78 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Span is a unit of traced migration work
+type Span interface {
+	// SetAttribute attaches a key/value attribute to the span
+	SetAttribute(key string, value interface{})
+	// End finishes the span, marking it failed when err is not nil
+	End(err error)
+}
+
+// Tracer starts spans around AutoMigrate invocations and the models they migrate,
+// see the contrib/otel module for an OpenTelemetry implementation
+type Tracer interface {
+	StartMigration(ctx context.Context) (context.Context, Span)
+	StartModel(ctx context.Context, model string) (context.Context, Span)
+}
+
+// Span attribute keys set by the plugin
+const (
+	AttributeVersion     = "migration.version"
+	AttributeModels      = "migration.models"
+	AttributeStatements  = "migration.statements"
+	AttributeHistoryRows = "migration.history_rows"
+	AttributeModel       = "migration.model"
+)
+
+// noopTracer is used when no tracer is configured
+type noopTracer struct{}
+
+// StartMigration returns ctx unchanged and a span that does nothing
+func (noopTracer) StartMigration(ctx context.Context) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// StartModel returns ctx unchanged and a span that does nothing
+func (noopTracer) StartModel(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// noopSpan is a span that records nothing
+type noopSpan struct{}
+
+// SetAttribute does nothing
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+// End does nothing
+func (noopSpan) End(error) {}
+
+// tracer returns the configured tracer or a no-op one
+func (p *AutoMigratePlugin) tracer() Tracer {
+	if p.Tracer == nil {
+		return noopTracer{}
+	}
+	return p.Tracer
+}
+
+// endMigrationSpan annotates the migration span with the outcome of the run and ends it
+func (p *AutoMigratePlugin) endMigrationSpan(db *gorm.DB, span Span, err error) {
+	if run, ok := runFromDB(db); ok {
+		run.mu.Lock()
+		models := make([]string, len(run.models))
+		for i, model := range run.models {
+			models[i] = modelName(model)
+		}
+		span.SetAttribute(AttributeVersion, run.version)
+		span.SetAttribute(AttributeModels, models)
+		span.SetAttribute(AttributeStatements, len(run.statements))
+		span.SetAttribute(AttributeHistoryRows, run.historyRows)
+		run.mu.Unlock()
+	}
+	span.End(err)
+}
@@ -0,0 +1,391 @@
+package gorm_migrate_tracker
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultVersionLayout is the time layout used by the default version generator
+const DefaultVersionLayout = "20060102150405"
+
+// VersionGenerator produces the version string recorded for a migration
+type VersionGenerator interface {
+	GenerateVersion(db *gorm.DB, startTime time.Time) (string, error)
+}
+
+// VersionGeneratorFunc adapts a function to the VersionGenerator interface
+type VersionGeneratorFunc func(db *gorm.DB, startTime time.Time) (string, error)
+
+// GenerateVersion calls f(db, startTime)
+func (f VersionGeneratorFunc) GenerateVersion(db *gorm.DB, startTime time.Time) (string, error) {
+	return f(db, startTime)
+}
+
+// TimestampVersionGenerator generates versions by formatting the migration start time
+type TimestampVersionGenerator struct {
+	Layout string
+}
+
+// GenerateVersion formats the start time using the configured layout
+func (g TimestampVersionGenerator) GenerateVersion(_ *gorm.DB, startTime time.Time) (string, error) {
+	layout := g.Layout
+	if layout == "" {
+		layout = DefaultVersionLayout
+	}
+	return startTime.Format(layout), nil
+}
+
+// VersionData is what a TemplateVersionGenerator executes its template with
+type VersionData struct {
+	// Timestamp is the start time of the migration formatted with the layout of the generator
+	Timestamp string
+	// Time is the start time of the migration
+	Time time.Time
+	// GitSHA is the VCS revision the binary was built from, ShortSHA its first 7 characters
+	GitSHA   string
+	ShortSHA string
+	// AppVersion is the version of the main module of the binary
+	AppVersion string
+	Hostname   string
+	// Seq is one more than the highest sequence number of the recorded versions matching the
+	// template, or than the number of records in the history when it's higher, so it keeps
+	// increasing after the history is pruned or squashed
+	Seq int
+}
+
+// TemplateVersionGenerator generates versions by executing a text/template with VersionData,
+// such as "{{.Timestamp}}-{{.ShortSHA}}-{{.Seq}}", to follow an existing release naming
+// convention
+type TemplateVersionGenerator struct {
+	Template *template.Template
+	// Layout formats the Timestamp, DefaultVersionLayout when empty
+	Layout string
+}
+
+// NewTemplateVersionGenerator parses format as the template of a TemplateVersionGenerator
+func NewTemplateVersionGenerator(format string) (*TemplateVersionGenerator, error) {
+	tmpl, err := template.New("version").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version template: %w", err)
+	}
+	return &TemplateVersionGenerator{Template: tmpl}, nil
+}
+
+// GenerateVersion executes the template for a migration started at startTime
+func (g *TemplateVersionGenerator) GenerateVersion(db *gorm.DB, startTime time.Time) (string, error) {
+	layout := g.Layout
+	if layout == "" {
+		layout = DefaultVersionLayout
+	}
+	env := currentEnvironment()
+	data := VersionData{
+		Timestamp:  startTime.Format(layout),
+		Time:       startTime,
+		GitSHA:     env.GitCommit,
+		ShortSHA:   env.GitCommit,
+		AppVersion: env.AppVersion,
+		Hostname:   env.Hostname,
+	}
+	if len(data.ShortSHA) > 7 {
+		data.ShortSHA = data.ShortSHA[:7]
+	}
+	seq, err := g.highestSeq(db)
+	if err != nil {
+		return "", err
+	}
+	data.Seq = seq + 1
+
+	var b strings.Builder
+	if err := g.Template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute version template: %w", err)
+	}
+	version := strings.TrimSpace(b.String())
+	if version == "" {
+		return "", errors.New("version template produced an empty version")
+	}
+	return version, nil
+}
+
+// highestSeq returns the highest sequence number of the recorded versions the template
+// produced, or the number of records when it's higher, such as for a template whose Seq
+// can't be told apart from its other fields
+func (g *TemplateVersionGenerator) highestSeq(db *gorm.DB) (int, error) {
+	recorded, err := countVersions(db)
+	if err != nil {
+		return 0, err
+	}
+	highest := int(recorded)
+	pattern := g.seqPattern()
+	if pattern == nil {
+		return highest, nil
+	}
+	versions, err := recordedVersions(db)
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		match := pattern.FindStringSubmatch(version)
+		for _, digits := range match[min(1, len(match)):] {
+			if n, err := strconv.Atoi(digits); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+	return highest, nil
+}
+
+// seqFieldPattern matches a reference to the Seq field in a template action
+var seqFieldPattern = regexp.MustCompile(`\.Seq\b`)
+
+// seqPattern returns a regular expression matching the versions produced by the template,
+// capturing the output of the actions printing Seq. It's nil when the template doesn't print
+// Seq at its top level, or prints it next to another action without text separating them.
+func (g *TemplateVersionGenerator) seqPattern() *regexp.Regexp {
+	if g.Template == nil || g.Template.Tree == nil || g.Template.Tree.Root == nil {
+		return nil
+	}
+	var b strings.Builder
+	var seq bool
+	// previous is the kind of the last pattern written: "text", "seq" or "any"
+	previous := "text"
+	b.WriteString("^")
+	for _, node := range g.Template.Tree.Root.Nodes {
+		current := "any"
+		switch node := node.(type) {
+		case *parse.TextNode:
+			current = "text"
+		case *parse.ActionNode:
+			if node.Pipe != nil && len(node.Pipe.Decl) == 0 && seqFieldPattern.MatchString(node.String()) {
+				current = "seq"
+			}
+		}
+		if current != "text" && previous != "text" && (current == "seq" || previous == "seq") {
+			return nil
+		}
+		switch current {
+		case "text":
+			b.WriteString(regexp.QuoteMeta(string(node.(*parse.TextNode).Text)))
+		case "seq":
+			b.WriteString(`(\d+)`)
+			seq = true
+		default:
+			b.WriteString(".*?")
+		}
+		previous = current
+	}
+	b.WriteString("$")
+	if !seq {
+		return nil
+	}
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil
+	}
+	return pattern
+}
+
+// countVersions counts the records in the history of the plugin registered on db
+func countVersions(db *gorm.DB) (int64, error) {
+	p := registeredPlugin(db)
+	history, stored, err := p.storedHistory(db)
+	if err != nil {
+		return 0, err
+	}
+	if stored {
+		return int64(len(history)), nil
+	}
+	var count int64
+	if err := p.historyDB(db.Session(&gorm.Session{NewDB: true})).Model(&SchemaVersion{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count schema versions: %w", err)
+	}
+	return count, nil
+}
+
+// versionContextKey is the context key under which a fixed version is stored
+type versionContextKey struct{}
+
+// ContextWithVersion returns a context recording migrations run with it under the given
+// version instead of a generated one, e.g. to share a logical version across shards
+func ContextWithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionContextKey{}, version)
+}
+
+// VersionFromContext returns the version set with ContextWithVersion
+func VersionFromContext(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(versionContextKey{}).(string)
+	return version, ok && version != ""
+}
+
+// VersionComparer is implemented by VersionGenerators whose versions don't order naturally
+type VersionComparer interface {
+	// CompareVersions returns -1, 0 or +1 as a is older than, equal to or newer than b
+	CompareVersions(a, b string) int
+}
+
+// CompareVersions orders versions in the layout of the generator, falling back to
+// CompareVersions for versions that don't parse
+func (g TimestampVersionGenerator) CompareVersions(a, b string) int {
+	layout := g.Layout
+	if layout == "" {
+		layout = DefaultVersionLayout
+	}
+	left, leftErr := time.Parse(layout, a)
+	right, rightErr := time.Parse(layout, b)
+	if leftErr != nil || rightErr != nil {
+		return CompareVersions(a, b)
+	}
+	return left.Compare(right)
+}
+
+// CompareVersions orders two versions, returning -1, 0 or +1. Versions are compared segment by
+// segment, splitting on dots, dashes and underscores, numeric segments numerically, so
+// timestamps, sequences and dotted versions order as expected.
+func CompareVersions(a, b string) int {
+	split := func(r rune) bool { return r == '.' || r == '-' || r == '_' }
+	left, right := strings.FieldsFunc(strings.TrimPrefix(a, "v"), split), strings.FieldsFunc(strings.TrimPrefix(b, "v"), split)
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		// A missing numeric segment counts as zero, so 1.0 equals 1
+		if l == "" && isNumeric(r) {
+			l = "0"
+		}
+		if r == "" && isNumeric(l) {
+			r = "0"
+		}
+		if c := compareSegments(l, r); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareSegments orders two version segments, numerically when both are numbers
+func compareSegments(a, b string) int {
+	if isNumeric(a) && isNumeric(b) {
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumeric reports whether s is a non-empty string of digits
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareVersions orders two versions using the VersionGenerator when it implements
+// VersionComparer, and the package level CompareVersions otherwise
+func (p *AutoMigratePlugin) CompareVersions(a, b string) int {
+	if comparer, ok := p.VersionGenerator.(VersionComparer); ok {
+		return comparer.CompareVersions(a, b)
+	}
+	return CompareVersions(a, b)
+}
+
+// CompareRecordedVersions compares two versions like the plugin registered on db does, see
+// AutoMigratePlugin.CompareVersions
+func CompareRecordedVersions(db *gorm.DB, a, b string) int {
+	return registeredPlugin(db).CompareVersions(a, b)
+}
+
+// LatestVersion returns the version the database is at, the highest successful migration,
+// baseline or drift repair as GetCurrentVersion returns it, or ErrVersionNotFound when none
+// is recorded
+func LatestVersion(db *gorm.DB) (*SchemaVersion, error) {
+	loggerFrom(db).Debug("LatestVersion function called")
+	return currentVersion(db)
+}
+
+// IsApplied reports whether version is recorded as successfully applied
+func IsApplied(db *gorm.DB, version string) (bool, error) {
+	loggerFrom(db).Debug("IsApplied function called for %s", version)
+	schemaVersion, err := findVersion(db, version)
+	if errors.Is(err, ErrVersionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return schemaVersion.Applied(), nil
+}
+
+// SequenceVersionGenerator numbers migrations with an integer sequence, such as V1, V2 and
+// so on in the Flyway style, one more than the highest number recorded in the history.
+// Instances migrating the same database should hold a lock, see WithLocking, so they don't
+// compute the same number.
+type SequenceVersionGenerator struct {
+	// Prefix precedes the numbers, such as "V"
+	Prefix string
+}
+
+var _ VersionComparer = (*SequenceVersionGenerator)(nil)
+
+// NewSequenceVersionGenerator creates a SequenceVersionGenerator numbering versions after prefix
+func NewSequenceVersionGenerator(prefix string) *SequenceVersionGenerator {
+	return &SequenceVersionGenerator{Prefix: prefix}
+}
+
+// GenerateVersion returns the number following the highest one of the history
+func (g *SequenceVersionGenerator) GenerateVersion(db *gorm.DB, _ time.Time) (string, error) {
+	versions, err := recordedVersions(db)
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	for _, version := range versions {
+		if n, ok := g.parse(version); ok && n >= next {
+			next = n + 1
+		}
+	}
+	return g.Prefix + strconv.Itoa(next), nil
+}
+
+// CompareVersions orders versions by their number, falling back to CompareVersions for
+// versions that don't parse
+func (g *SequenceVersionGenerator) CompareVersions(a, b string) int {
+	left, leftOk := g.parse(a)
+	right, rightOk := g.parse(b)
+	if !leftOk || !rightOk {
+		return CompareVersions(a, b)
+	}
+	return cmp.Compare(left, right)
+}
+
+// parse returns the number of a version of the generator
+func (g *SequenceVersionGenerator) parse(version string) (int, bool) {
+	digits, ok := strings.CutPrefix(version, g.Prefix)
+	if !ok || !isNumeric(digits) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
@@ -0,0 +1,232 @@
+package gorm_migrate_tracker
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type versionedProduct struct {
+	ID   uint
+	Name string
+}
+
+type versionedProductWithPrice struct {
+	ID    uint
+	Name  string
+	Price int
+}
+
+func (versionedProductWithPrice) TableName() string { return "versioned_products" }
+
+type versionedProductWithSKU struct {
+	ID    uint
+	Name  string
+	Price int
+	SKU   string
+}
+
+func (versionedProductWithSKU) TableName() string { return "versioned_products" }
+
+func TestTimestampVersionGenerator(t *testing.T) {
+	startTime := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	version, err := TimestampVersionGenerator{}.GenerateVersion(nil, startTime)
+	if err != nil {
+		t.Fatalf("GenerateVersion: %v", err)
+	}
+	if version != "20240301123045" {
+		t.Errorf("version = %s, want 20240301123045", version)
+	}
+
+	version, err = TimestampVersionGenerator{Layout: "2006.01.02"}.GenerateVersion(nil, startTime)
+	if err != nil {
+		t.Fatalf("GenerateVersion: %v", err)
+	}
+	if version != "2024.03.01" {
+		t.Errorf("version = %s, want 2024.03.01", version)
+	}
+}
+
+func TestVersionGeneratorFunc(t *testing.T) {
+	generator := VersionGeneratorFunc(func(db *gorm.DB, startTime time.Time) (string, error) {
+		return "custom-" + startTime.Format("2006"), nil
+	})
+	clock := ClockFunc(func() time.Time { return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) })
+	db, _ := openTestDB(t, WithVersionGenerator(generator), WithClock(clock))
+	if err := db.AutoMigrate(&versionedProduct{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	mustRecorded(t, db, "custom-2024")
+}
+
+func TestContextWithVersion(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "release-1", &versionedProduct{})
+	mustRecorded(t, db, "release-1")
+}
+
+func TestTemplateVersionGenerator(t *testing.T) {
+	generator, err := NewTemplateVersionGenerator("R{{.Seq}}-{{.Time.Format \"2006\"}}")
+	if err != nil {
+		t.Fatalf("NewTemplateVersionGenerator: %v", err)
+	}
+	clock := ClockFunc(func() time.Time { return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) })
+	db, _ := openTestDB(t, WithVersionGenerator(generator), WithClock(clock))
+
+	if err := db.AutoMigrate(&versionedProduct{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := db.AutoMigrate(&versionedProductWithPrice{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	mustRecorded(t, db, "R1-2024")
+	mustRecorded(t, db, "R2-2024")
+
+	// Seq keeps increasing once the history is pruned
+	if err := historyDB(db).Where("version = ?", "R1-2024").Delete(&SchemaVersion{}).Error; err != nil {
+		t.Fatalf("failed to prune R1-2024: %v", err)
+	}
+	if err := db.AutoMigrate(&versionedProductWithSKU{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	mustRecorded(t, db, "R3-2024")
+}
+
+func TestTemplateVersionGeneratorSeqPattern(t *testing.T) {
+	tests := []struct {
+		format  string
+		version string
+		// seq is the captured sequence number, empty when the pattern is nil or doesn't match
+		seq string
+	}{
+		{"R{{.Seq}}", "R12", "12"},
+		{"R{{.Seq}}", "X12", ""},
+		{"{{.Timestamp}}-{{.Seq}}", "20240301000000-7", "7"},
+		{"v{{.AppVersion}}.{{.Seq}}-rel", "v1.2.3.4-rel", "4"},
+		// Seq can't be told apart from an adjacent action or isn't printed at all
+		{"{{.Timestamp}}{{.Seq}}", "202403010000007", ""},
+		{"{{.Timestamp}}-{{.ShortSHA}}", "20240301000000-abc1234", ""},
+	}
+	for _, tt := range tests {
+		generator, err := NewTemplateVersionGenerator(tt.format)
+		if err != nil {
+			t.Fatalf("NewTemplateVersionGenerator(%q): %v", tt.format, err)
+		}
+		seq := ""
+		if pattern := generator.seqPattern(); pattern != nil {
+			if match := pattern.FindStringSubmatch(tt.version); len(match) == 2 {
+				seq = match[1]
+			}
+		}
+		if seq != tt.seq {
+			t.Errorf("Seq of %s with template %q = %q, want %q", tt.version, tt.format, seq, tt.seq)
+		}
+	}
+}
+
+func TestNewTemplateVersionGeneratorInvalid(t *testing.T) {
+	if _, err := NewTemplateVersionGenerator("{{.Seq"); err == nil {
+		t.Error("NewTemplateVersionGenerator of an unterminated action succeeded")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1", 0},
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1.10.0", "1.9.0", 1},
+		{"v1.2", "1.2.0", 0},
+		{"2024-01-02", "2024-01-10", -1},
+		{"20240102000000", "20240101235959", 1},
+		{"1.0.0-rc_2", "1.0.0-rc_10", -1},
+		{"001", "1", 0},
+		{"a", "b", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestTimestampVersionGeneratorCompareVersions(t *testing.T) {
+	generator := TimestampVersionGenerator{Layout: "02.01.2006"}
+	if got := generator.CompareVersions("02.01.2024", "01.02.2024"); got != -1 {
+		t.Errorf("CompareVersions in the layout = %d, want the 2nd of January first", got)
+	}
+	if got := generator.CompareVersions("2", "10"); got != -1 {
+		t.Errorf("CompareVersions out of the layout = %d, want CompareVersions ordering", got)
+	}
+}
+
+func TestCompareRecordedVersions(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(TimestampVersionGenerator{Layout: "02.01.2006"}))
+	if got := CompareRecordedVersions(db, "31.12.2023", "01.01.2024"); got != -1 {
+		t.Errorf("CompareRecordedVersions = %d, want the generator ordering", got)
+	}
+
+	// The current version follows the same ordering
+	migrateAs(t, db, "01.01.2024", &versionedProduct{})
+	migrateAs(t, db, "31.12.2023", &versionedProductWithPrice{})
+	current, err := LatestVersion(db)
+	if err != nil {
+		t.Fatalf("LatestVersion: %v", err)
+	}
+	if current.Version != "01.01.2024" {
+		t.Errorf("LatestVersion = %s, want 01.01.2024", current.Version)
+	}
+}
+
+func TestSequenceVersionGenerator(t *testing.T) {
+	db, _ := openTestDB(t, WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	for _, model := range []interface{}{&versionedProduct{}, &versionedProductWithPrice{}} {
+		if err := db.AutoMigrate(model); err != nil {
+			t.Fatalf("AutoMigrate: %v", err)
+		}
+	}
+	mustRecorded(t, db, "V1")
+	mustRecorded(t, db, "V2")
+
+	// The next number follows the highest recorded one, not the number of records
+	if err := historyDB(db).Model(&SchemaVersion{}).Where("version = ?", "V2").Update("version", "V9").Error; err != nil {
+		t.Fatalf("failed to renumber V2: %v", err)
+	}
+	if err := db.AutoMigrate(&versionedProductWithSKU{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	mustRecorded(t, db, "V10")
+
+	current, err := GetCurrentVersion(db)
+	if err != nil {
+		t.Fatalf("GetCurrentVersion: %v", err)
+	}
+	if current.Version != "V10" {
+		t.Errorf("current version = %s, want V10 ordered after V9", current.Version)
+	}
+}
+
+func TestSequenceVersionGeneratorCompareVersions(t *testing.T) {
+	generator := NewSequenceVersionGenerator("V")
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"V9", "V10", -1},
+		{"V10", "V10", 0},
+		{"V2", "V1", 1},
+		{"V1", "W1", -1},
+	}
+	for _, tt := range tests {
+		if got := generator.CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// VersionDiff is the difference between the schemas of two recorded versions
+type VersionDiff struct {
+	From           string       `json:"from"`
+	To             string       `json:"to"`
+	AddedTables    []string     `json:"added_tables,omitempty"`
+	DroppedTables  []string     `json:"dropped_tables,omitempty"`
+	Columns        []ColumnDiff `json:"columns,omitempty"`
+	AddedIndexes   []string     `json:"added_indexes,omitempty"`
+	DroppedIndexes []string     `json:"dropped_indexes,omitempty"`
+	// Changesets holds the change logs recorded between the versions when either
+	// version was recorded without a snapshot
+	Changesets []VersionChanges `json:"changesets,omitempty"`
+}
+
+// VersionChanges is the change set recorded with a version
+type VersionChanges struct {
+	Version string     `json:"version"`
+	Changes *ChangeSet `json:"changes"`
+}
+
+// Empty reports whether the diff contains no changes
+func (d *VersionDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.DroppedTables) == 0 && len(d.Columns) == 0 &&
+		len(d.AddedIndexes) == 0 && len(d.DroppedIndexes) == 0 && len(d.Changesets) == 0
+}
+
+// String renders the diff as a human readable report
+func (d *VersionDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes from %s to %s:\n", d.From, d.To)
+	if d.Empty() {
+		b.WriteString("  no changes\n")
+	}
+	for _, table := range d.AddedTables {
+		fmt.Fprintf(&b, "  + table %s\n", table)
+	}
+	for _, table := range d.DroppedTables {
+		fmt.Fprintf(&b, "  - table %s\n", table)
+	}
+	for _, column := range d.Columns {
+		fmt.Fprintf(&b, "  ~ %s\n", column)
+	}
+	for _, index := range d.AddedIndexes {
+		fmt.Fprintf(&b, "  + index %s\n", index)
+	}
+	for _, index := range d.DroppedIndexes {
+		fmt.Fprintf(&b, "  - index %s\n", index)
+	}
+	for _, changeset := range d.Changesets {
+		fmt.Fprintf(&b, "  %s:\n", changeset.Version)
+		for _, line := range strings.Split(strings.TrimSpace(changeset.Changes.String()), "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// DiffVersionsContext is like DiffVersions but cancels the queries when ctx is done
+func DiffVersionsContext(ctx context.Context, db *gorm.DB, fromVersion, toVersion string) (*VersionDiff, error) {
+	return DiffVersions(db.WithContext(ctx), fromVersion, toVersion)
+}
+
+// DiffVersions compares the schemas recorded with two versions. The stored snapshots are
+// compared when both versions have one, otherwise the change logs recorded after
+// fromVersion up to and including toVersion are returned.
+func DiffVersions(db *gorm.DB, fromVersion, toVersion string) (*VersionDiff, error) {
+	logger := loggerFrom(db)
+	logger.Debug("DiffVersions function called for %s..%s", fromVersion, toVersion)
+
+	from, err := findVersion(db, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := findVersion(db, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &VersionDiff{From: fromVersion, To: toVersion}
+	fromSnapshot, err := from.ParseSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	toSnapshot, err := to.ParseSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if fromSnapshot == nil || toSnapshot == nil {
+		logger.Debug("Snapshot missing, falling back to recorded change logs")
+		low, high := from.ID, to.ID
+		if low > high {
+			low, high = high, low
+		}
+		between, stored, err := registeredPlugin(db).storedHistory(db)
+		if stored {
+			between = filterVersions(between, func(schemaVersion *SchemaVersion) bool {
+				return schemaVersion.ID > low && schemaVersion.ID <= high
+			})
+		} else {
+			err = historyDB(db).Where("id > ? AND id <= ?", low, high).Order("id").Find(&between).Error
+		}
+		if err != nil {
+			logger.Error("Failed to retrieve versions between %s and %s: %v", fromVersion, toVersion, err)
+			return nil, fmt.Errorf("failed to retrieve versions between %s and %s: %w", fromVersion, toVersion, err)
+		}
+		for _, schemaVersion := range between {
+			changes, err := schemaVersion.ParseChanges()
+			if err != nil {
+				return nil, err
+			}
+			diff.Changesets = append(diff.Changesets, VersionChanges{Version: schemaVersion.Version, Changes: changes})
+		}
+		return diff, nil
+	}
+
+	diffSnapshots(db.Migrator(), fromSnapshot, toSnapshot, diff)
+	return diff, nil
+}
+
+// diffSnapshots fills diff with the differences between two snapshots
+func diffSnapshots(migrator gorm.Migrator, from, to *SchemaSnapshot, diff *VersionDiff) {
+	for i := range to.Tables {
+		newTable := &to.Tables[i]
+		oldTable, ok := from.Table(newTable.Name)
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, newTable.Name)
+			continue
+		}
+
+		diff.Columns = append(diff.Columns, diffTables(migrator, oldTable, newTable)...)
+		for _, index := range newTable.Indexes {
+			if _, ok := oldTable.index(index.Name); !ok {
+				diff.AddedIndexes = append(diff.AddedIndexes, newTable.Name+"."+index.Name)
+			}
+		}
+		for _, index := range oldTable.Indexes {
+			if _, ok := newTable.index(index.Name); !ok {
+				diff.DroppedIndexes = append(diff.DroppedIndexes, newTable.Name+"."+index.Name)
+			}
+		}
+	}
+
+	for _, table := range from.Tables {
+		if _, ok := to.Table(table.Name); !ok {
+			diff.DroppedTables = append(diff.DroppedTables, table.Name)
+		}
+	}
+}
+
+// findVersion loads a recorded version, returning ErrVersionNotFound when it doesn't exist
+func findVersion(db *gorm.DB, version string) (*SchemaVersion, error) {
+	var schemaVersion SchemaVersion
+	history, stored, err := registeredPlugin(db).storedHistory(db)
+	if stored {
+		for _, recorded := range history {
+			if recorded.Version == version {
+				schemaVersion = recorded
+			}
+		}
+	} else {
+		err = historyDB(db).Where("version = ?", version).Limit(1).Find(&schemaVersion).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve schema version %s: %w", version, err)
+	}
+	if schemaVersion.ID == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, version)
+	}
+	return &schemaVersion, nil
+}
@@ -0,0 +1,67 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffVersions(t *testing.T) {
+	db, _ := openTestDB(t)
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &indexedUser{}, &squashedOrder{})
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+
+	diff, err := DiffVersions(db, "1", "3")
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	if !slices.Equal(diff.AddedTables, []string{tableOf(db, &squashedOrder{})}) || len(diff.DroppedTables) != 0 {
+		t.Errorf("table changes = +%v -%v, want the orders table added", diff.AddedTables, diff.DroppedTables)
+	}
+	if len(diff.Columns) != 1 || diff.Columns[0].Kind != ColumnAdded || diff.Columns[0].Column != "email" {
+		t.Errorf("column changes = %v, want the email column added", diff.Columns)
+	}
+	if !slices.Equal(diff.AddedIndexes, []string{"users.idx_users_name"}) {
+		t.Errorf("added indexes = %v, want idx_users_name", diff.AddedIndexes)
+	}
+	if !strings.Contains(diff.String(), "~ users.email added") {
+		t.Errorf("diff = %q, want the email column listed", diff.String())
+	}
+
+	// Going back reverses the diff
+	diff, err = DiffVersions(db, "3", "1")
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	if len(diff.AddedTables) != 0 || len(diff.DroppedTables) != 1 || len(diff.Columns) != 1 || diff.Columns[0].Kind != ColumnDropped {
+		t.Errorf("diff = %s, want the orders table and the email column dropped", diff)
+	}
+
+	if diff, err := DiffVersions(db, "3", "3"); err != nil || !diff.Empty() {
+		t.Errorf("DiffVersions of a version with itself = %v, %v, want no changes", diff, err)
+	}
+	if _, err := DiffVersions(db, "1", "4"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("DiffVersions to an unknown version = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestDiffVersionsWithoutSnapshots(t *testing.T) {
+	db, _ := openTestDB(t, WithSnapshots(false))
+	migrateAs(t, db, "1", &pluginUser{})
+	migrateAs(t, db, "2", &squashedOrder{})
+	migrateAs(t, db, "3", &pluginUserWithEmail{})
+
+	diff, err := DiffVersions(db, "1", "3")
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	var versions []string
+	for _, changeset := range diff.Changesets {
+		versions = append(versions, changeset.Version)
+	}
+	if !slices.Equal(versions, []string{"2", "3"}) {
+		t.Errorf("change logs of versions %v, want 2 and 3", versions)
+	}
+}
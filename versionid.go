@@ -0,0 +1,139 @@
+package gorm_migrate_tracker
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrEntropyExhausted is returned when more versions are generated within a millisecond than
+// the random bits of an identifier can tell apart
+var ErrEntropyExhausted = errors.New("version entropy exhausted within the millisecond")
+
+// monotonicEntropy hands out the random bits of the identifiers generated within a
+// millisecond, incrementing them when several are generated within the same one or when
+// the clock goes back, so that identifiers keep increasing
+type monotonicEntropy struct {
+	mu      sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// next returns the millisecond and the random bits of a new identifier for time t
+func (m *monotonicEntropy) next(t time.Time) (uint64, [10]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ms := uint64(t.UnixMilli())
+	if m.ms != 0 && ms <= m.ms {
+		for i := len(m.entropy) - 1; i >= 0; i-- {
+			m.entropy[i]++
+			if m.entropy[i] != 0 {
+				return m.ms, m.entropy, nil
+			}
+		}
+		return 0, m.entropy, ErrEntropyExhausted
+	}
+
+	if _, err := rand.Read(m.entropy[:]); err != nil {
+		return 0, m.entropy, fmt.Errorf("failed to read random bits: %w", err)
+	}
+	// Leave room for 2^55 increments before they carry into the bits UUIDv7 reserves
+	m.entropy[3] &= 0x7f
+	m.ms = ms
+	return ms, m.entropy, nil
+}
+
+// crockford is the alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDVersionGenerator generates ULIDs, 26 character identifiers ordered by the millisecond
+// a migration started at and unique across replicas thanks to their 80 random bits.
+// Versions generated within the same millisecond by a generator keep increasing.
+type ULIDVersionGenerator struct {
+	entropy monotonicEntropy
+}
+
+var _ VersionComparer = (*ULIDVersionGenerator)(nil)
+
+// NewULIDVersionGenerator creates a ULIDVersionGenerator
+func NewULIDVersionGenerator() *ULIDVersionGenerator {
+	return &ULIDVersionGenerator{}
+}
+
+// GenerateVersion returns a ULID for a migration started at startTime
+func (g *ULIDVersionGenerator) GenerateVersion(_ *gorm.DB, startTime time.Time) (string, error) {
+	ms, entropy, err := g.entropy.next(startTime)
+	if err != nil {
+		return "", err
+	}
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], ms<<16)
+	copy(id[6:], entropy[:])
+
+	// 128 bits as 26 base32 characters, the first one holding the 3 top bits
+	var b strings.Builder
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		shift := uint(5 * i)
+		var digit uint64
+		switch {
+		case shift >= 64:
+			digit = hi >> (shift - 64)
+		case shift > 59:
+			digit = lo>>shift | hi<<(64-shift)
+		default:
+			digit = lo >> shift
+		}
+		b.WriteByte(crockford[digit&0x1f])
+	}
+	return b.String(), nil
+}
+
+// CompareVersions orders ULIDs, which sort lexicographically
+func (g *ULIDVersionGenerator) CompareVersions(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// UUIDv7VersionGenerator generates UUIDv7 identifiers, ordered by the millisecond a migration
+// started at and unique across replicas thanks to their 74 random bits. Versions generated
+// within the same millisecond by a generator keep increasing.
+type UUIDv7VersionGenerator struct {
+	entropy monotonicEntropy
+}
+
+var _ VersionComparer = (*UUIDv7VersionGenerator)(nil)
+
+// NewUUIDv7VersionGenerator creates a UUIDv7VersionGenerator
+func NewUUIDv7VersionGenerator() *UUIDv7VersionGenerator {
+	return &UUIDv7VersionGenerator{}
+}
+
+// GenerateVersion returns a UUIDv7 for a migration started at startTime
+func (g *UUIDv7VersionGenerator) GenerateVersion(_ *gorm.DB, startTime time.Time) (string, error) {
+	ms, entropy, err := g.entropy.next(startTime)
+	if err != nil {
+		return "", err
+	}
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], ms<<16)
+	id[6] = 0x70 | entropy[0]&0x0f
+	id[7] = entropy[1]
+	id[8] = 0x80 | entropy[2]&0x3f
+	copy(id[9:], entropy[3:])
+
+	encoded := hex.EncodeToString(id[:])
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:], nil
+}
+
+// CompareVersions orders UUIDv7s, which sort lexicographically
+func (g *UUIDv7VersionGenerator) CompareVersions(a, b string) int {
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,114 @@
+package gorm_migrate_tracker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// idStartTime is a millisecond precise start time of the identifiers under test
+var idStartTime = time.Date(2024, 3, 1, 12, 30, 45, 123_000_000, time.UTC)
+
+func TestULIDVersionGenerator(t *testing.T) {
+	version, err := NewULIDVersionGenerator().GenerateVersion(nil, idStartTime)
+	if err != nil {
+		t.Fatalf("GenerateVersion: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`).MatchString(version) {
+		t.Fatalf("version %s isn't a ULID", version)
+	}
+
+	// The first 10 characters encode the millisecond
+	var ms uint64
+	for _, c := range version[:10] {
+		ms = ms<<5 | uint64(strings.IndexRune(crockford, c))
+	}
+	if ms != uint64(idStartTime.UnixMilli()) {
+		t.Errorf("ULID %s encodes %d, want %d", version, ms, idStartTime.UnixMilli())
+	}
+}
+
+func TestUUIDv7VersionGenerator(t *testing.T) {
+	version, err := NewUUIDv7VersionGenerator().GenerateVersion(nil, idStartTime)
+	if err != nil {
+		t.Fatalf("GenerateVersion: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(version) {
+		t.Fatalf("version %s isn't a UUIDv7", version)
+	}
+
+	// The first 48 bits are the millisecond
+	if prefix := fmt.Sprintf("%012x", idStartTime.UnixMilli()); strings.ReplaceAll(version, "-", "")[:12] != prefix {
+		t.Errorf("UUIDv7 %s doesn't start with the millisecond %s", version, prefix)
+	}
+}
+
+func TestVersionIDsIncrease(t *testing.T) {
+	generators := map[string]interface {
+		VersionGenerator
+		VersionComparer
+	}{
+		"ULID":   NewULIDVersionGenerator(),
+		"UUIDv7": NewUUIDv7VersionGenerator(),
+	}
+	for name, generator := range generators {
+		t.Run(name, func(t *testing.T) {
+			// Within the same millisecond, then with the clock going back
+			startTimes := []time.Time{idStartTime, idStartTime, idStartTime, idStartTime.Add(-time.Second), idStartTime.Add(time.Second)}
+			var previous string
+			for _, startTime := range startTimes {
+				version, err := generator.GenerateVersion(nil, startTime)
+				if err != nil {
+					t.Fatalf("GenerateVersion: %v", err)
+				}
+				if previous != "" && generator.CompareVersions(previous, version) >= 0 {
+					t.Errorf("version %s doesn't follow %s", version, previous)
+				}
+				previous = version
+			}
+		})
+	}
+}
+
+func TestVersionIDsConcurrent(t *testing.T) {
+	generator := NewULIDVersionGenerator()
+	versions := make([]string, 64)
+	var wg sync.WaitGroup
+	for i := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			version, err := generator.GenerateVersion(nil, idStartTime)
+			if err != nil {
+				t.Errorf("GenerateVersion: %v", err)
+			}
+			versions[i] = version
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, version := range versions {
+		if seen[version] {
+			t.Errorf("version %s generated twice", version)
+		}
+		seen[version] = true
+	}
+}
+
+func TestMonotonicEntropyExhausted(t *testing.T) {
+	var entropy monotonicEntropy
+	if _, _, err := entropy.next(idStartTime); err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	for i := range entropy.entropy {
+		entropy.entropy[i] = 0xff
+	}
+	if _, _, err := entropy.next(idStartTime); !errors.Is(err, ErrEntropyExhausted) {
+		t.Errorf("next once the random bits overflow = %v, want ErrEntropyExhausted", err)
+	}
+}
@@ -0,0 +1,223 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// KindView marks the records of views created or replaced by MigrateViews, they don't
+// change the current schema version
+const KindView = "view"
+
+// View is the definition of a view kept up to date by MigrateViews
+type View struct {
+	Name string
+	// Definition is the query the view selects, without the CREATE VIEW clause
+	Definition string
+	// Materialized creates a Postgres materialized view, it is dropped and created again when
+	// its definition changes
+	Materialized bool
+}
+
+// checksum hashes the definition of the view
+func (v View) checksum() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("materialized=%t\n%s", v.Materialized, strings.TrimSpace(v.Definition))))
+	return hex.EncodeToString(sum[:])
+}
+
+// ViewChange is a view created or replaced because its definition changed
+type ViewChange struct {
+	Name         string     `json:"name"`
+	Kind         ChangeKind `json:"kind"`
+	Materialized bool       `json:"materialized,omitempty"`
+	Checksum     string     `json:"checksum"`
+	Definition   string     `json:"definition"`
+}
+
+// String renders the change as a single human readable line
+func (c ViewChange) String() string {
+	kind := "view"
+	if c.Materialized {
+		kind = "materialized view"
+	}
+	return fmt.Sprintf("%s %s %s (checksum %.12s)", kind, c.Name, c.Kind, c.Checksum)
+}
+
+// MigrateViewsContext is like MigrateViews but cancels the queries when ctx is done
+func MigrateViewsContext(ctx context.Context, db *gorm.DB) (*SchemaVersion, error) {
+	return MigrateViews(db.WithContext(ctx))
+}
+
+// MigrateViews creates the views registered with WithViews that don't exist yet and replaces
+// those whose definition changed since they were last recorded, recording the changes as a
+// SchemaVersion of kind KindView. It returns nil when every view is up to date. AutoMigrate
+// calls it once the models are migrated.
+func MigrateViews(db *gorm.DB) (*SchemaVersion, error) {
+	p := registeredPlugin(db)
+	p.Logger.Debug("MigrateViews function called")
+	p.migrating.Lock()
+	defer p.migrating.Unlock()
+	return p.migrateViews(db)
+}
+
+// migrateViews applies the changed views and records them
+func (p *AutoMigratePlugin) migrateViews(db *gorm.DB) (*SchemaVersion, error) {
+	if len(p.Views) == 0 {
+		return nil, nil
+	}
+
+	recorded, err := p.recordedViews(db)
+	if err != nil {
+		p.Logger.Error("Failed to retrieve recorded views: %v", err)
+		return nil, err
+	}
+
+	var changes []ViewChange
+	var statements, downStatements []string
+	for _, view := range p.Views {
+		checksum := view.checksum()
+		previous, ok := recorded[view.Name]
+		if ok && previous.Checksum == checksum {
+			continue
+		}
+		change := ViewChange{Name: view.Name, Kind: ViewCreated, Materialized: view.Materialized, Checksum: checksum, Definition: view.Definition}
+		if ok {
+			change.Kind = ViewReplaced
+		}
+		up, err := viewStatements(db, view)
+		if err != nil {
+			return nil, err
+		}
+		if ok && previous.Materialized != view.Materialized {
+			up = append([]string{dropViewSQL(db, View{Name: previous.Name, Materialized: previous.Materialized})}, up...)
+		}
+		statements = append(statements, up...)
+		if ok {
+			down, _ := viewStatements(db, View{Name: previous.Name, Definition: previous.Definition, Materialized: previous.Materialized})
+			if previous.Materialized != view.Materialized {
+				down = append([]string{dropViewSQL(db, view)}, down...)
+			}
+			downStatements = append(down, downStatements...)
+		} else {
+			downStatements = append([]string{dropViewSQL(db, view)}, downStatements...)
+		}
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		p.Logger.Debug("Views are up to date")
+		return nil, nil
+	}
+
+	startTime := p.now()
+	var execErr error
+	for _, statement := range statements {
+		p.Logger.Debug("Executing view statement: %s", statement)
+		if execErr = db.Session(&gorm.Session{NewDB: true}).Exec(statement).Error; execErr != nil {
+			p.Logger.Error("Failed to migrate views: %v", execErr)
+			break
+		}
+	}
+
+	version, err := p.generateVersion(db, startTime)
+	if err != nil {
+		p.Logger.Error("Failed to generate version: %v", err)
+		return nil, fmt.Errorf("failed to generate version: %w", err)
+	}
+	encoded, err := encodeChangeSet(&ChangeSet{Views: changes})
+	if err != nil {
+		return nil, err
+	}
+	schemaVersion := &SchemaVersion{
+		Version:        version,
+		Kind:           KindView,
+		Status:         StatusSuccess,
+		DurationMs:     p.now().Sub(startTime).Milliseconds(),
+		AppliedAt:      p.now(),
+		Changes:        encoded,
+		Statements:     joinStatements(statements),
+		DownStatements: joinStatements(downStatements),
+		Severity:       SeverityInfo,
+	}
+	// Replacing a view may break the queries relying on its columns
+	for _, change := range changes {
+		if change.Kind == ViewReplaced {
+			schemaVersion.Severity = SeverityWarning
+		}
+	}
+	if execErr != nil {
+		schemaVersion.Status = StatusFailed
+		schemaVersion.Error = execErr.Error()
+	}
+	if err := p.record(db, schemaVersion); err != nil {
+		return nil, err
+	}
+	if execErr != nil {
+		return schemaVersion, fmt.Errorf("failed to migrate views: %w", execErr)
+	}
+	p.Logger.Info("Migrated %d views at version %s", len(changes), version)
+	return schemaVersion, nil
+}
+
+// recordedViews returns the last successfully recorded change of every view
+func (p *AutoMigratePlugin) recordedViews(db *gorm.DB) (map[string]ViewChange, error) {
+	history, stored, err := p.storedHistory(db)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		if err := p.historyDB(db).Where("kind = ? AND status = ?", KindView, StatusSuccess).Order("id").Find(&history).Error; err != nil {
+			return nil, fmt.Errorf("failed to retrieve view history: %w", err)
+		}
+	}
+
+	views := map[string]ViewChange{}
+	for _, schemaVersion := range history {
+		if schemaVersion.Kind != KindView || schemaVersion.Status != StatusSuccess {
+			continue
+		}
+		changes, err := schemaVersion.ParseChanges()
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes.Views {
+			views[change.Name] = change
+		}
+	}
+	return views, nil
+}
+
+// viewStatements renders the statements creating or replacing a view on the connected dialect
+func viewStatements(db *gorm.DB, view View) ([]string, error) {
+	name, definition := quote(db, view.Name), strings.TrimSpace(view.Definition)
+	if view.Materialized {
+		if db.Dialector.Name() != "postgres" {
+			return nil, fmt.Errorf("materialized view %s needs Postgres", view.Name)
+		}
+		return []string{
+			dropViewSQL(db, view),
+			fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS %s", name, definition),
+		}, nil
+	}
+
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return []string{dropViewSQL(db, view), fmt.Sprintf("CREATE VIEW %s AS %s", name, definition)}, nil
+	case "sqlserver":
+		return []string{fmt.Sprintf("CREATE OR ALTER VIEW %s AS %s", name, definition)}, nil
+	default:
+		return []string{fmt.Sprintf("CREATE OR REPLACE VIEW %s AS %s", name, definition)}, nil
+	}
+}
+
+// dropViewSQL renders the statement dropping a view
+func dropViewSQL(db *gorm.DB, view View) string {
+	if view.Materialized {
+		return fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", quote(db, view.Name))
+	}
+	return fmt.Sprintf("DROP VIEW IF EXISTS %s", quote(db, view.Name))
+}
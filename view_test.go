@@ -0,0 +1,56 @@
+package gorm_migrate_tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateViews(t *testing.T) {
+	view := View{Name: "named_users", Definition: "SELECT id, name FROM users WHERE name <> ''"}
+	db, plugin := openTestDB(t, WithViews(view), WithVersionGenerator(NewSequenceVersionGenerator("V")))
+	if err := db.AutoMigrate(&pluginUser{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	var views int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'view' AND name = ?", "named_users").Scan(&views).Error; err != nil || views != 1 {
+		t.Fatalf("found %d named_users views, %v, want the view created", views, err)
+	}
+	history := mustHistory(t, db)
+	if len(history) != 2 || history[0].Kind != KindView || history[0].Severity != SeverityInfo {
+		t.Fatalf("history = %v, want the view recorded after the migration", history)
+	}
+	changes, err := history[0].ParseChanges()
+	if err != nil {
+		t.Fatalf("ParseChanges: %v", err)
+	}
+	if len(changes.Views) != 1 || changes.Views[0].Kind != ViewCreated || changes.Views[0].Checksum != view.checksum() {
+		t.Errorf("view changes = %v, want named_users created", changes.Views)
+	}
+	if !strings.Contains(history[0].DownStatements, "DROP VIEW IF EXISTS `named_users`") {
+		t.Errorf("down statements = %q, want the view dropped", history[0].DownStatements)
+	}
+	// A view record doesn't change the current version
+	if current, err := GetCurrentVersion(db); err != nil || current.Version != history[1].Version {
+		t.Errorf("GetCurrentVersion = %v, %v, want the migration", current, err)
+	}
+
+	// Unchanged views aren't recorded again
+	if schemaVersion, err := MigrateViews(db); err != nil || schemaVersion != nil {
+		t.Fatalf("MigrateViews of unchanged views = %v, %v, want nothing recorded", schemaVersion, err)
+	}
+
+	plugin.Views[0].Definition = "SELECT id FROM users"
+	replaced, err := MigrateViews(db)
+	if err != nil || replaced == nil {
+		t.Fatalf("MigrateViews = %v, %v, want the view replaced", replaced, err)
+	}
+	if replaced.Severity != SeverityWarning {
+		t.Errorf("severity of a replaced view = %s, want %s", replaced.Severity, SeverityWarning)
+	}
+	if !strings.Contains(replaced.DownStatements, view.Definition) {
+		t.Errorf("down statements = %q, want the previous definition restored", replaced.DownStatements)
+	}
+	if columns, err := db.Migrator().ColumnTypes("named_users"); err != nil || len(columns) != 1 {
+		t.Errorf("view has %d columns, %v, want the new definition", len(columns), err)
+	}
+}
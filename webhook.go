@@ -0,0 +1,127 @@
+package gorm_migrate_tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Defaults used by NewWebhookNotifier
+const (
+	DefaultWebhookTimeout = 10 * time.Second
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = time.Second
+)
+
+// WebhookNotifier POSTs every MigrationEvent as JSON to one or more URLs
+type WebhookNotifier struct {
+	URLs []string
+	// Client sends the requests, http.DefaultClient is used when nil
+	Client *http.Client
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration
+	// Retries is the number of additional attempts made after a failed delivery
+	Retries int
+	// Backoff is the delay before the first retry, doubled for every following one
+	Backoff time.Duration
+
+	// validate inspects the body of successful responses, for APIs reporting errors with a 2xx status
+	validate func(body []byte) error
+}
+
+// NewWebhookNotifier creates a WebhookNotifier for the given URLs with default timeouts and retries
+func NewWebhookNotifier(urls ...string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URLs:    urls,
+		Timeout: DefaultWebhookTimeout,
+		Retries: DefaultWebhookRetries,
+		Backoff: DefaultWebhookBackoff,
+	}
+}
+
+// Notify delivers the event to every URL, returning the errors of the URLs that could not be reached
+func (w *WebhookNotifier) Notify(ctx context.Context, event MigrationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode migration event: %w", err)
+	}
+
+	var errs []error
+	for _, url := range w.URLs {
+		if err := w.deliver(ctx, url, payload); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deliver webhook to %s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver posts payload to url, retrying failed attempts with exponential backoff
+func (w *WebhookNotifier) deliver(ctx context.Context, url string, payload []byte) error {
+	backoff := w.Backoff
+	var err error
+	for attempt := 0; attempt <= w.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = w.post(ctx, url, payload); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// post makes a single delivery attempt
+func (w *WebhookNotifier) post(ctx context.Context, url string, payload []byte) error {
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if w.validate == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return w.validate(body)
+}
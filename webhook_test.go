@@ -0,0 +1,69 @@
+package gorm_migrate_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var received []MigrationEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request headers = %v, want the JSON content type and the configured token", r.Header)
+		}
+		// The first delivery fails and is retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var event MigrationEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode the webhook payload: %v", err)
+		}
+		received = append(received, event)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookNotifier(server.URL)
+	webhook.Headers = map[string]string{"Authorization": "Bearer secret"}
+	webhook.Backoff = time.Millisecond
+	db, _ := openTestDB(t, WithNotifiers(webhook))
+	migrateAs(t, db, "1", &pluginUser{})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 || len(received) != 1 {
+		t.Fatalf("received %d events in %d attempts, want the event delivered on the retry", len(received), attempts)
+	}
+	if received[0].Version != "1" || received[0].Status != StatusSuccess || len(received[0].Statements) != 1 {
+		t.Errorf("event = %+v, want the successful migration of version 1", received[0])
+	}
+}
+
+func TestWebhookNotifierGivesUp(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookNotifier(server.URL)
+	webhook.Retries, webhook.Backoff = 2, time.Millisecond
+	if err := webhook.Notify(context.Background(), MigrationEvent{Version: "1"}); err == nil {
+		t.Error("Notify to a failing server succeeded")
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want the first one and 2 retries", attempts)
+	}
+}